@@ -2,16 +2,30 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/context-maximiser/code-graph/pkg/api"
+	"github.com/context-maximiser/code-graph/pkg/benchmark"
+	"github.com/context-maximiser/code-graph/pkg/models"
 	"github.com/context-maximiser/code-graph/pkg/neo4j"
 	"github.com/context-maximiser/code-graph/pkg/schema"
 	"github.com/context-maximiser/code-graph/pkg/indexer/static"
 	"github.com/context-maximiser/code-graph/pkg/indexer/documents"
+	"github.com/context-maximiser/code-graph/pkg/logging"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/context-maximiser/code-graph/pkg/similarity"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -52,15 +66,32 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVar(&neo4jURI, "neo4j-uri", "bolt://localhost:7687", "Neo4j connection URI")
 	rootCmd.PersistentFlags().StringVar(&neo4jUser, "neo4j-user", "neo4j", "Neo4j username")
-	rootCmd.PersistentFlags().StringVar(&neo4jPass, "neo4j-password", "password123", "Neo4j password")
+	rootCmd.PersistentFlags().StringVar(&neo4jPass, "neo4j-password", "password123", "Neo4j password (visible in process listings, prefer --neo4j-password-file or NEO4J_PASSWORD)")
+	rootCmd.PersistentFlags().String("neo4j-password-file", "", "File containing the Neo4j password, e.g. a mounted secret")
 	rootCmd.PersistentFlags().StringVar(&neo4jDB, "neo4j-database", "neo4j", "Neo4j database name")
+	rootCmd.PersistentFlags().Bool("neo4j-encrypted", false, "Connect to Neo4j over TLS even with a bolt:// or neo4j:// URI")
+	rootCmd.PersistentFlags().String("neo4j-trust-strategy", "", "Server certificates trusted over TLS: system, custom or all (default system, custom with --neo4j-ca-cert)")
+	rootCmd.PersistentFlags().String("neo4j-ca-cert", "", "PEM file of the CA certificates trusted over TLS")
+	rootCmd.PersistentFlags().Int("neo4j-max-retries", neo4j.DefaultMaxRetries, "Times a transaction failing with a transient Neo4j error is retried (negative disables)")
+	rootCmd.PersistentFlags().Duration("query-timeout", 0, "Abort Neo4j queries running longer than this, e.g. 30s (0 = no timeout)")
+	rootCmd.PersistentFlags().String("output", "text", "Output format of query and status commands: text or json")
+	rootCmd.PersistentFlags().String("redact", "", "Node properties omitted from output, e.g. embedding,Function.filePath (default embedding, none disables)")
 
 	// Bind flags to viper
 	viper.BindPFlag("neo4j.uri", rootCmd.PersistentFlags().Lookup("neo4j-uri"))
 	viper.BindPFlag("neo4j.username", rootCmd.PersistentFlags().Lookup("neo4j-user"))
 	viper.BindPFlag("neo4j.password", rootCmd.PersistentFlags().Lookup("neo4j-password"))
+	viper.BindPFlag("neo4j.password_file", rootCmd.PersistentFlags().Lookup("neo4j-password-file"))
 	viper.BindPFlag("neo4j.database", rootCmd.PersistentFlags().Lookup("neo4j-database"))
+	viper.BindPFlag("neo4j.encrypted", rootCmd.PersistentFlags().Lookup("neo4j-encrypted"))
+	viper.BindPFlag("neo4j.trust_strategy", rootCmd.PersistentFlags().Lookup("neo4j-trust-strategy"))
+	viper.BindPFlag("neo4j.ca_cert", rootCmd.PersistentFlags().Lookup("neo4j-ca-cert"))
+	viper.BindPFlag("neo4j.max_retries", rootCmd.PersistentFlags().Lookup("neo4j-max-retries"))
+	viper.BindPFlag("neo4j.query_timeout", rootCmd.PersistentFlags().Lookup("query-timeout"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	viper.BindPFlag("redact", rootCmd.PersistentFlags().Lookup("redact"))
+	bindNeo4jEnv(viper.GetViper())
 
 	// Add subcommands
 	rootCmd.AddCommand(statusCmd)
@@ -68,9 +99,13 @@ func init() {
 	rootCmd.AddCommand(indexCmd)
 	rootCmd.AddCommand(queryCmd)
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(benchmarkCmd)
 }
 
 func initConfig() {
+	// Logs go to stderr so command output on stdout stays parseable
+	slog.SetDefault(logging.New(os.Stderr, verbose))
+
 	if cfgFile != "" {
 		// Use config file from the flag
 		viper.SetConfigFile(cfgFile)
@@ -97,8 +132,17 @@ func initConfig() {
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check Neo4j connection status",
-	Long:  "Check if the Neo4j database is accessible and return connection information",
+	Long: `Check if the Neo4j database is accessible and report its contents: node
+counts by label, relationship counts by type, missing constraints and indexes,
+and whether the vector and full-text indexes are online. With --check the
+command fails unless the schema is ready, for use as a readiness probe.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		check, _ := cmd.Flags().GetBool("check")
+		asJSON, err := jsonOutput()
+		if err != nil {
+			return err
+		}
+
 		client, err := createNeo4jClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Neo4j client: %w", err)
@@ -106,28 +150,101 @@ var statusCmd = &cobra.Command{
 		defer client.Close(context.Background())
 
 		ctx := context.Background()
-		info, err := client.GetDatabaseInfo(ctx)
+		diagnostics, err := schema.NewSchemaManager(client).Diagnose(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to get database info: %w", err)
+			return fmt.Errorf("failed to get database status: %w", err)
 		}
 
-		fmt.Println("Neo4j Connection Status: ✓ Connected")
-		fmt.Printf("Database: %s\n", neo4jDB)
-		fmt.Printf("URI: %s\n", neo4jURI)
-		if name, ok := info["name"]; ok {
-			fmt.Printf("Name: %s\n", name)
-		}
-		if versions, ok := info["versions"]; ok {
-			fmt.Printf("Version: %s\n", versions)
+		if asJSON {
+			err = printJSON(struct {
+				URI      string `json:"uri"`
+				Database string `json:"database"`
+				*schema.Diagnostics
+			}{neo4jURI, neo4jDB, diagnostics})
+		} else {
+			printStatus(diagnostics)
 		}
-		if edition, ok := info["edition"]; ok {
-			fmt.Printf("Edition: %s\n", edition)
+		if err != nil {
+			return err
 		}
 
+		if check && !diagnostics.Schema.Ready {
+			return errors.New("schema is not ready")
+		}
 		return nil
 	},
 }
 
+// printStatus prints the diagnostics of the status command as text
+func printStatus(diagnostics *schema.Diagnostics) {
+	fmt.Println("Neo4j Connection Status: ✓ Connected")
+	fmt.Printf("Database: %s\n", neo4jDB)
+	fmt.Printf("URI: %s\n", neo4jURI)
+	if name, ok := diagnostics.Server["name"]; ok {
+		fmt.Printf("Name: %s\n", name)
+	}
+	if versions, ok := diagnostics.Server["versions"]; ok {
+		fmt.Printf("Version: %s\n", versions)
+	}
+	if edition, ok := diagnostics.Server["edition"]; ok {
+		fmt.Printf("Edition: %s\n", edition)
+	}
+	if caps := diagnostics.Capabilities; caps != nil {
+		switch caps.FulltextDDL {
+		case schema.FulltextCreateIndex:
+			fmt.Println("Full-text indexes: CREATE FULLTEXT INDEX")
+		case schema.FulltextProcedure:
+			fmt.Println("Full-text indexes: db.index.fulltext.createNodeIndex (legacy)")
+		default:
+			fmt.Println("Full-text indexes: not supported")
+		}
+	}
+
+	printCounts := func(title string, counts map[string]int64) {
+		fmt.Printf("\n%s:\n", title)
+		if len(counts) == 0 {
+			fmt.Println("  (none)")
+			return
+		}
+		keys := make([]string, 0, len(counts))
+		for key := range counts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("  %-24s %d\n", key, counts[key])
+		}
+	}
+	printCounts("Nodes", diagnostics.Nodes)
+	printCounts("Relationships", diagnostics.Relationships)
+
+	status := diagnostics.Schema
+	fmt.Printf("\nSchema version: %d (latest %d)\n", status.Version, status.LatestVersion)
+	if len(status.MissingConstraints) > 0 {
+		fmt.Printf("Missing constraints: %s\n", strings.Join(status.MissingConstraints, ", "))
+	}
+	if len(status.MissingIndexes) > 0 {
+		fmt.Printf("Missing indexes: %s\n", strings.Join(status.MissingIndexes, ", "))
+	}
+	for _, group := range []struct {
+		kind    string
+		indexes []schema.IndexState
+	}{{"Vector", status.VectorIndexes}, {"Full-text", status.FulltextIndexes}} {
+		for _, index := range group.indexes {
+			queryable := "not queryable"
+			if index.Queryable {
+				queryable = "queryable"
+			}
+			fmt.Printf("%s index %s: %s (%.0f%%, %s)\n", group.kind, index.Name, index.State, index.PopulationPercent, queryable)
+		}
+	}
+	if status.Ready {
+		fmt.Println("Schema: ✓ Ready")
+	} else {
+		fmt.Println("Schema: ✗ Not ready")
+	}
+}
+
 // schemaCmd manages Neo4j schema (constraints and indexes)
 var schemaCmd = &cobra.Command{
 	Use:   "schema",
@@ -146,7 +263,10 @@ var schemaCreateCmd = &cobra.Command{
 		}
 		defer client.Close(context.Background())
 
+		vectorDimensions, _ := cmd.Flags().GetInt("vector-dimensions")
+
 		schemaManager := schema.NewSchemaManager(client)
+		schemaManager.SetVectorDimensions(vectorDimensions)
 		
 		fmt.Println("Creating Neo4j schema...")
 		ctx := context.Background()
@@ -171,14 +291,92 @@ var schemaDropCmd = &cobra.Command{
 		defer client.Close(context.Background())
 
 		schemaManager := schema.NewSchemaManager(client)
-		
-		fmt.Println("Dropping Neo4j schema...")
 		ctx := context.Background()
-		if err := schemaManager.DropSchema(ctx); err != nil {
+
+		plan, err := schemaManager.PlanDrop(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list schema: %w", err)
+		}
+
+		if plan.Total() == 0 {
+			fmt.Println("Nothing to drop")
+			return nil
+		}
+
+		fmt.Printf("The following will be dropped from database '%s':\n", viper.GetString("neo4j.database"))
+		fmt.Printf("Constraints (%d):\n", len(plan.Constraints))
+		for _, name := range plan.Constraints {
+			fmt.Printf("  - %s\n", name)
+		}
+		fmt.Printf("Indexes (%d):\n", len(plan.Indexes))
+		for _, name := range plan.Indexes {
+			fmt.Printf("  - %s\n", name)
+		}
+
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+		if err := schema.ConfirmDrop(plan, os.Stdin, os.Stdout, isTerminal(os.Stdin), assumeYes); err != nil {
+			if errors.Is(err, schema.ErrDropNotConfirmed) {
+				return fmt.Errorf("%w (pass --yes to drop without prompting)", err)
+			}
+			return err
+		}
+
+		fmt.Println("Dropping Neo4j schema...")
+		dropped, err := schemaManager.DropSchemaWithSummary(ctx)
+		if err != nil {
 			return fmt.Errorf("failed to drop schema: %w", err)
 		}
 
-		fmt.Println("✓ Schema dropped successfully")
+		fmt.Printf("✓ Schema dropped successfully (%d constraints, %d indexes)\n",
+			len(dropped.Constraints), len(dropped.Indexes))
+		return nil
+	},
+}
+
+var schemaMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Long:  "Bring the Neo4j schema to the latest version, or to the version given with --to, and record it in the database",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		target, _ := cmd.Flags().GetInt("to")
+		vectorDimensions, _ := cmd.Flags().GetInt("vector-dimensions")
+		if target < 0 {
+			target = schema.LatestVersion()
+		}
+
+		schemaManager := schema.NewSchemaManager(client)
+		schemaManager.SetVectorDimensions(vectorDimensions)
+
+		ctx := context.Background()
+		current, err := schemaManager.CurrentVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if current == target {
+			fmt.Printf("✓ Schema is up to date (version %d)\n", current)
+			return nil
+		}
+
+		fmt.Printf("Migrating schema from version %d to %d...\n", current, target)
+		steps, err := schemaManager.MigrateTo(ctx, target)
+		for _, step := range steps {
+			verb := "Applied"
+			if target < current {
+				verb = "Reverted"
+			}
+			fmt.Printf("  %s %d: %s\n", verb, step.Version, step.Description)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+
+		fmt.Printf("✓ Schema migrated to version %d\n", target)
 		return nil
 	},
 }
@@ -204,6 +402,10 @@ var schemaInfoCmd = &cobra.Command{
 
 		fmt.Println("Schema Information:")
 		fmt.Println("==================")
+
+		if version, ok := info["version"].(int); ok {
+			fmt.Printf("\nVersion: %d (latest %d)\n", version, schema.LatestVersion())
+		}
 		
 		if constraints, ok := info["constraints"].([]map[string]any); ok {
 			fmt.Printf("\nConstraints (%d):\n", len(constraints))
@@ -248,6 +450,24 @@ var indexProjectCmd = &cobra.Command{
 		serviceName, _ := cmd.Flags().GetString("service")
 		version, _ := cmd.Flags().GetString("version")
 		repoURL, _ := cmd.Flags().GetString("repo-url")
+		followSymlinks, _ := cmd.Flags().GetBool("follow-symlinks")
+		includeStdlib, _ := cmd.Flags().GetBool("include-stdlib-interfaces")
+		warmCache, _ := cmd.Flags().GetBool("warm-cache")
+		goos, _ := cmd.Flags().GetString("goos")
+		goarch, _ := cmd.Flags().GetString("goarch")
+		tags, _ := cmd.Flags().GetStringSlice("tags")
+		apiCalls, _ := cmd.Flags().GetBool("api-calls")
+		httpClients, _ := cmd.Flags().GetStringArray("http-client")
+		routes, _ := cmd.Flags().GetBool("routes")
+		routeSpecs, _ := cmd.Flags().GetStringArray("route-pattern")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		showTimings, _ := cmd.Flags().GetBool("timings")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		skipDirs, _ := cmd.Flags().GetStringArray("skip-dir")
+		includeTests, _ := cmd.Flags().GetBool("include-tests")
+		exportedOnly, _ := cmd.Flags().GetBool("exported-only")
+		languages, _ := cmd.Flags().GetStringSlice("languages")
+		since, _ := cmd.Flags().GetString("since")
 
 		if serviceName == "" {
 			serviceName = "context-maximiser" // Default service name
@@ -256,6 +476,27 @@ var indexProjectCmd = &cobra.Command{
 			version = "v1.0.0"
 		}
 
+		// configureWalk applies the flags selecting the files to index
+		configureWalk := func(indexer *static.StaticIndexer) error {
+			indexer.SetFollowSymlinks(followSymlinks)
+			indexer.SetBuildContext(goos, goarch, tags)
+			indexer.SetSkipDirs(append(static.DefaultSkipDirs(), skipDirs...))
+			indexer.SetIncludeTests(includeTests)
+			return indexer.SetLanguages(languages)
+		}
+
+		if dryRun {
+			planner := static.NewStaticIndexer(nil, serviceName, version, repoURL)
+			if err := configureWalk(planner); err != nil {
+				return err
+			}
+			plan, err := planner.PlanProject(projectPath)
+			if err != nil {
+				return fmt.Errorf("failed to plan indexing: %w", err)
+			}
+			return printIndexPlan(plan)
+		}
+
 		client, err := createNeo4jClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Neo4j client: %w", err)
@@ -263,22 +504,143 @@ var indexProjectCmd = &cobra.Command{
 		defer client.Close(context.Background())
 
 		indexer := static.NewStaticIndexer(client, serviceName, version, repoURL)
+		if err := configureWalk(indexer); err != nil {
+			return err
+		}
+		indexer.SetIncludeStdlibInterfaces(includeStdlib)
+		indexer.SetExportedOnly(exportedOnly)
+		indexer.SetConcurrency(concurrency)
+		if apiCalls || len(httpClients) > 0 {
+			patterns := static.DefaultHTTPClientPatterns()
+			for _, spec := range httpClients {
+				pattern, err := static.ParseHTTPClientPattern(spec)
+				if err != nil {
+					return err
+				}
+				patterns = append(patterns, pattern)
+			}
+			indexer.SetHTTPClientPatterns(patterns)
+		}
+		if routes || len(routeSpecs) > 0 {
+			patterns := static.DefaultRoutePatterns()
+			for _, spec := range routeSpecs {
+				pattern, err := static.ParseRoutePattern(spec)
+				if err != nil {
+					return err
+				}
+				patterns = append(patterns, pattern)
+			}
+			indexer.SetRoutePatterns(patterns)
+		}
 		
-		fmt.Printf("Indexing project at %s using AST parsing...\n", projectPath)
 		ctx := context.Background()
+		if warmCache {
+			modules, symbols, err := indexer.WarmCaches(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to warm caches: %w", err)
+			}
+			fmt.Printf("Loaded %d modules and %d symbols from the existing graph\n", modules, symbols)
+		}
+
+		if since != "" {
+			fmt.Printf("Indexing files of %s changed since %s...\n", projectPath, since)
+			changes, err := indexer.IndexChanges(ctx, projectPath, since)
+			if err != nil {
+				return fmt.Errorf("failed to index changes: %w", err)
+			}
+			fmt.Printf("✓ Reindexed %d changed files, moved %d renamed files and removed %d deleted files (detected by %s)\n",
+				len(changes.Changed), len(changes.Renamed), len(changes.Deleted), changes.Method)
+			return nil
+		}
+
+		fmt.Printf("Indexing project at %s using AST parsing...\n", projectPath)
 		if err := indexer.IndexProject(ctx, projectPath); err != nil {
 			return fmt.Errorf("failed to index project: %w", err)
 		}
 
 		fmt.Println("✓ Project indexed successfully")
+		if showTimings {
+			printIndexTimings(indexer.Timings())
+		}
 		return nil
 	},
 }
 
+// indexPlanSample is how many of the planned files a dry run lists
+const indexPlanSample = 10
+
+// printIndexPlan prints the files an index run would process and what it
+// would skip
+func printIndexPlan(plan *static.IndexPlan) error {
+	asJSON, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(plan)
+	}
+
+	fmt.Printf("Would index %d files\n", len(plan.Files))
+	for _, file := range plan.Files[:min(len(plan.Files), indexPlanSample)] {
+		fmt.Printf("  %s\n", file)
+	}
+	if len(plan.Files) > indexPlanSample {
+		fmt.Printf("  ... and %d more\n", len(plan.Files)-indexPlanSample)
+	}
+
+	if len(plan.SkippedDirs) > 0 {
+		fmt.Printf("\nSkipped directories (%d):\n", len(plan.SkippedDirs))
+		for _, dir := range plan.SkippedDirs {
+			fmt.Printf("  %s\n", dir)
+		}
+	}
+	if len(plan.TestFiles) > 0 {
+		fmt.Printf("\nSkipped test files: %d\n", len(plan.TestFiles))
+	}
+	if len(plan.Excluded) > 0 {
+		fmt.Printf("\nExcluded by build constraints (%d):\n", len(plan.Excluded))
+		for _, file := range plan.Excluded {
+			fmt.Printf("  %s\n", file)
+		}
+	}
+	return nil
+}
+
+// printIndexTimings prints how long each phase of indexing took and its share
+// of the total, followed by the write time of the files phase
+func printIndexTimings(timings static.IndexTimings) {
+	phases := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"walk", timings.Walk},
+		{"load packages", timings.Load},
+		{"index files", timings.Files},
+		{"link", timings.Link},
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PHASE\tDURATION\tSHARE")
+	for _, phase := range phases {
+		share := 0.0
+		if timings.Total > 0 {
+			share = float64(phase.duration) / float64(timings.Total) * 100
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.1f%%\n", phase.name, phase.duration.Round(time.Millisecond), share)
+	}
+	fmt.Fprintf(w, "total\t%s\t\n", timings.Total.Round(time.Millisecond))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "WRITES (ALL WORKERS)\tDURATION\t")
+	fmt.Fprintf(w, "nodes\t%s\t\n", timings.Nodes.Round(time.Millisecond))
+	fmt.Fprintf(w, "relationships\t%s\t\n", timings.Relationships.Round(time.Millisecond))
+	w.Flush()
+}
+
 var indexSCIPCmd = &cobra.Command{
 	Use:   "scip [path]",
-	Short: "Index a Go project using SCIP",
-	Long:  "Index a Go project using the SCIP (Source Code Intelligence Protocol) indexer for more accurate code intelligence",
+	Short: "Index a project using SCIP",
+	Long:  "Index a Go, TypeScript, or Python project using its SCIP (Source Code Intelligence Protocol) indexer for more accurate code intelligence",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectPath := "."
@@ -289,6 +651,10 @@ var indexSCIPCmd = &cobra.Command{
 		serviceName, _ := cmd.Flags().GetString("service")
 		version, _ := cmd.Flags().GetString("version")
 		repoURL, _ := cmd.Flags().GetString("repo-url")
+		referenceRoles, _ := cmd.Flags().GetString("reference-roles")
+		debug, _ := cmd.Flags().GetBool("debug")
+		language, _ := cmd.Flags().GetString("language")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 		if serviceName == "" {
 			serviceName = "context-maximiser" // Default service name
@@ -297,6 +663,23 @@ var indexSCIPCmd = &cobra.Command{
 			version = "v1.0.0"
 		}
 
+		if dryRun {
+			planner := static.NewSCIPIndexer(nil, serviceName, version, repoURL)
+			if err := planner.SetLanguage(language); err != nil {
+				return fmt.Errorf("invalid --language: %w", err)
+			}
+			files, err := planner.PlanProject(projectPath)
+			if err != nil {
+				return fmt.Errorf("failed to plan indexing: %w", err)
+			}
+			return printIndexPlan(&static.IndexPlan{Files: files})
+		}
+
+		roleFilter, err := static.ParseReferenceRoles(referenceRoles)
+		if err != nil {
+			return fmt.Errorf("invalid --reference-roles: %w", err)
+		}
+
 		client, err := createNeo4jClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Neo4j client: %w", err)
@@ -304,6 +687,11 @@ var indexSCIPCmd = &cobra.Command{
 		defer client.Close(context.Background())
 
 		scipIndexer := static.NewSCIPIndexer(client, serviceName, version, repoURL)
+		scipIndexer.SetReferenceRoles(roleFilter)
+		scipIndexer.SetDebug(debug)
+		if err := scipIndexer.SetLanguage(language); err != nil {
+			return fmt.Errorf("invalid --language: %w", err)
+		}
 		
 		// Validate environment
 		if err := scipIndexer.ValidateEnvironment(); err != nil {
@@ -321,6 +709,73 @@ var indexSCIPCmd = &cobra.Command{
 	},
 }
 
+// indexCompareCmd compares the AST and SCIP indexers on one project
+var indexCompareCmd = &cobra.Command{
+	Use:   "compare [path]",
+	Short: "Compare AST and SCIP indexing results",
+	Long:  "Index a Go project with both the AST and SCIP indexers, without writing to the graph, and report definitions missing from either side, differing byte offsets and differing signatures",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectPath := "."
+		if len(args) > 0 {
+			projectPath = args[0]
+		}
+
+		useAST, _ := cmd.Flags().GetBool("ast")
+		useSCIP, _ := cmd.Flags().GetBool("scip")
+		serviceName, _ := cmd.Flags().GetString("service")
+		version, _ := cmd.Flags().GetString("version")
+		format, _ := cmd.Flags().GetString("format")
+
+		if !useAST || !useSCIP {
+			return fmt.Errorf("compare needs both --ast and --scip")
+		}
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid --format %q: use text or json", format)
+		}
+		if serviceName == "" {
+			serviceName = "context-maximiser" // Default service name
+		}
+
+		scipIndexer := static.NewSCIPIndexer(nil, serviceName, version, "")
+		if err := scipIndexer.ValidateEnvironment(); err != nil {
+			return fmt.Errorf("environment validation failed: %w", err)
+		}
+
+		astDefs, err := static.NewStaticIndexer(nil, serviceName, version, "").CollectDefinitions(projectPath)
+		if err != nil {
+			return fmt.Errorf("failed to collect AST definitions: %w", err)
+		}
+		scipDefs, err := scipIndexer.CollectDefinitions(projectPath)
+		if err != nil {
+			return fmt.Errorf("failed to collect SCIP definitions: %w", err)
+		}
+
+		comparison := static.CompareDefinitions(astDefs, scipDefs)
+
+		if format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(comparison)
+		}
+
+		fmt.Printf("AST definitions: %d, SCIP definitions: %d, matching: %d\n",
+			comparison.ASTCount, comparison.SCIPCount, comparison.Matched)
+		if len(comparison.Discrepancies) == 0 {
+			fmt.Println("✓ No discrepancies found")
+			return nil
+		}
+
+		fmt.Printf("\nDiscrepancies (%d):\n", len(comparison.Discrepancies))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TYPE\tFILE\tNAME\tAST\tSCIP")
+		for _, d := range comparison.Discrepancies {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Type, d.FilePath, d.Name, d.AST, d.SCIP)
+		}
+		return w.Flush()
+	},
+}
+
 // indexDocsCmd handles indexing documents  
 var indexDocsCmd = &cobra.Command{
 	Use:   "docs [path]",
@@ -336,7 +791,29 @@ var indexDocsCmd = &cobra.Command{
 		}
 		defer client.Close(context.Background())
 
+		chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+		extractorName, _ := cmd.Flags().GetString("extractor")
+		llmEndpoint, _ := cmd.Flags().GetString("llm-endpoint")
+		llmModel, _ := cmd.Flags().GetString("llm-model")
+
 		indexer := documents.NewDocumentIndexer(client)
+		indexer.SetChunkSize(chunkSize)
+
+		switch extractorName {
+		case "rules":
+		case "llm":
+			apiKey := os.Getenv("CODEGRAPH_LLM_API_KEY")
+			if apiKey == "" {
+				apiKey = os.Getenv("OPENAI_API_KEY")
+			}
+			if apiKey == "" {
+				fmt.Println("Warning: no CODEGRAPH_LLM_API_KEY or OPENAI_API_KEY set, using rule-based feature extraction")
+			} else {
+				indexer.SetFeatureExtractor(documents.NewLLMExtractor(llmEndpoint, apiKey, llmModel))
+			}
+		default:
+			return fmt.Errorf("unknown extractor %q (use llm or rules)", extractorName)
+		}
 		ctx := context.Background()
 
 		// Check if path is a file or directory
@@ -369,9 +846,15 @@ var indexDocsCmd = &cobra.Command{
 			if featureCount, ok := stats["featureCount"]; ok {
 				fmt.Printf("  Features extracted: %v\n", featureCount)  
 			}
+			if chunkCount, ok := stats["chunkCount"]; ok {
+				fmt.Printf("  Chunks: %v\n", chunkCount)
+			}
 			if symbolCount, ok := stats["mentionedSymbolCount"]; ok {
 				fmt.Printf("  Code symbols linked: %v\n", symbolCount)
 			}
+			if codeCount, ok := stats["mentionedCodeCount"]; ok {
+				fmt.Printf("  Functions, methods and classes linked: %v\n", codeCount)
+			}
 		}
 
 		fmt.Println("✓ Documents indexed successfully")
@@ -379,6 +862,63 @@ var indexDocsCmd = &cobra.Command{
 	},
 }
 
+// indexCleanCmd purges a service from the graph
+var indexCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove a service's nodes from the graph",
+	Long:  "Delete the Service node, everything it contains and symbols left without definitions or references",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serviceName, _ := cmd.Flags().GetString("service")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if serviceName == "" {
+			return fmt.Errorf("--service is required")
+		}
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		indexer := static.NewStaticIndexer(client, serviceName, "", "")
+		ctx := context.Background()
+
+		counts, err := indexer.CleanService(ctx, dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to clean service: %w", err)
+		}
+
+		if len(counts) == 0 {
+			fmt.Printf("No nodes found for service %s\n", serviceName)
+			return nil
+		}
+
+		labels := make([]string, 0, len(counts))
+		total := 0
+		for label, count := range counts {
+			labels = append(labels, label)
+			total += count
+		}
+		sort.Strings(labels)
+
+		if dryRun {
+			fmt.Printf("Would delete %d nodes for service %s:\n", total, serviceName)
+		} else {
+			fmt.Printf("Deleted %d nodes for service %s:\n", total, serviceName)
+		}
+		for _, label := range labels {
+			fmt.Printf("  %s: %d\n", label, counts[label])
+		}
+
+		if !dryRun {
+			fmt.Println("✓ Service cleaned successfully")
+		}
+		return nil
+	},
+}
+
 // queryCmd handles querying the graph
 var queryCmd = &cobra.Command{
 	Use:   "query",
@@ -393,6 +933,14 @@ var querySearchCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		searchTerm := args[0]
+		asJSON, err := jsonOutput()
+		if err != nil {
+			return err
+		}
+		redactConfig, err := redaction()
+		if err != nil {
+			return err
+		}
 		
 		client, err := createNeo4jClient()
 		if err != nil {
@@ -400,27 +948,57 @@ var querySearchCmd = &cobra.Command{
 		}
 		defer client.Close(context.Background())
 
-		queryBuilder := neo4j.NewQueryBuilder(client)
+		version, _ := cmd.Flags().GetString("version")
+		queryBuilder := neo4j.NewQueryBuilder(client).WithVersion(version)
 		
 		// Get limit from flags, 0 means no limit
 		limit, _ := cmd.Flags().GetInt("limit")
+
+		var opts []neo4j.SearchOption
+		fulltext, _ := cmd.Flags().GetBool("fulltext")
+		fuzzy, _ := cmd.Flags().GetBool("fuzzy")
+		if fulltext || fuzzy {
+			opts = append(opts, neo4j.UseFulltextIndex())
+		}
+		if fuzzy {
+			opts = append(opts, neo4j.FuzzyFallback())
+		}
+		boostSpecs, _ := cmd.Flags().GetStringArray("boost")
+		for _, spec := range boostSpecs {
+			index, field, weight, err := neo4j.ParseFieldBoost(spec)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, neo4j.BoostFields(index, map[string]float64{field: weight}))
+		}
 		
 		ctx := context.Background()
 		results, err := queryBuilder.SearchNodes(ctx, searchTerm, 
-			[]string{"Function", "Method", "Class", "Variable", "File", "Symbol", "Document", "Feature"}, limit)
+			[]string{"Function", "Method", "Class", "Variable", "File", "Symbol", "Document", "Feature"}, limit, opts...)
 		if err != nil {
 			return fmt.Errorf("failed to search: %w", err)
 		}
 
+		if asJSON {
+			matches := query.SearchResultsFromRecords(results)
+			query.RedactSearchResults(matches, redactConfig)
+			return printJSON(query.SearchResponse{Query: searchTerm, Results: matches, Count: len(matches), Limit: limit})
+		}
+
 		fmt.Printf("Search results for '%s':\n", searchTerm)
 		fmt.Println("========================")
+		if len(results) > 0 {
+			if isFuzzy, _ := results[0].AsMap()["fuzzy"].(bool); isFuzzy {
+				fmt.Println("No exact matches; showing fuzzy matches")
+			}
+		}
 		
 		for _, record := range results {
 			recordMap := record.AsMap()
 			if nodeObj, ok := recordMap["n"]; ok {
 				// Handle Neo4j Node object
 				if node, ok := nodeObj.(dbtype.Node); ok {
-					props := node.Props
+					props := redactConfig.Redact(node.Labels, node.Props)
 					if labels, ok := recordMap["nodeLabels"].([]interface{}); ok {
 						// Handle different node types
 						var displayName string
@@ -488,51 +1066,550 @@ var querySearchCmd = &cobra.Command{
 	},
 }
 
-var querySourceCmd = &cobra.Command{
-	Use:   "source [function_name]",
-	Short: "Get source code for a function",
-	Long:  "Retrieve the exact source code for a function or method using stored location metadata",
-	Args:  cobra.ExactArgs(1),
+// queryNewSinceCmd lists declarations added since a point in time
+var queryNewSinceCmd = &cobra.Command{
+	Use:   "new-since [timestamp]",
+	Short: "List symbols created since a time",
+	Long:  "List functions, types and variables first indexed at or after a Unix timestamp or RFC 3339 time, or since the last index run of a service",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		functionName := args[0]
-		
+		sinceLastRun, _ := cmd.Flags().GetBool("since-last-run")
+		serviceName, _ := cmd.Flags().GetString("service")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		if sinceLastRun == (len(args) == 1) {
+			return fmt.Errorf("provide either a timestamp or --since-last-run")
+		}
+
 		client, err := createNeo4jClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Neo4j client: %w", err)
 		}
 		defer client.Close(context.Background())
 
-		queryBuilder := neo4j.NewQueryBuilder(client)
-		
+		version, _ := cmd.Flags().GetString("version")
+		queryBuilder := neo4j.NewQueryBuilder(client).WithVersion(version)
 		ctx := context.Background()
-		sourceCode, err := queryBuilder.GetFunctionSourceCode(ctx, functionName)
+
+		var since int64
+		if sinceLastRun {
+			run, err := queryBuilder.GetLastIndexRun(ctx, serviceName)
+			if err != nil {
+				return err
+			}
+			since = run.StartedAt
+		} else {
+			since, err = parseTimestamp(args[0])
+			if err != nil {
+				return err
+			}
+		}
+
+		// The service of the last index run also scopes the results
+		if !sinceLastRun && !cmd.Flags().Changed("service") {
+			serviceName = ""
+		}
+		results, err := queryBuilder.FindNodesCreatedSince(ctx, serviceName, version, since, limit)
 		if err != nil {
-			return fmt.Errorf("failed to get source code: %w", err)
+			return fmt.Errorf("failed to query new symbols: %w", err)
 		}
 
-		fmt.Printf("Source code for function '%s':\n", functionName)
-		fmt.Println("=" + strings.Repeat("=", len(functionName)+25))
-		fmt.Println(sourceCode)
-		fmt.Println("=" + strings.Repeat("=", len(functionName)+25))
-		
+		fmt.Printf("Symbols created since %s:\n", time.Unix(since, 0).UTC().Format(time.RFC3339))
+		fmt.Println("========================")
+
+		for _, record := range results {
+			recordMap := record.AsMap()
+			node, ok := recordMap["n"].(dbtype.Node)
+			if !ok {
+				continue
+			}
+			labels, _ := recordMap["nodeLabels"].([]interface{})
+			if len(labels) == 0 {
+				continue
+			}
+
+			fmt.Printf("- %v (%v)\n", node.Props["name"], labels[0])
+			if filePath, ok := node.Props["filePath"]; ok {
+				fmt.Printf("  File: %v\n", filePath)
+			}
+			if createdAt, ok := node.Props["createdAt"].(int64); ok {
+				fmt.Printf("  Created: %s\n", time.Unix(createdAt, 0).UTC().Format(time.RFC3339))
+			}
+		}
+
+		fmt.Printf("\nFound %d new symbols\n", len(results))
 		return nil
 	},
 }
 
-// serverCmd starts the API server
-var serverCmd = &cobra.Command{
-	Use:   "server",
-	Short: "Start the API server",
-	Long:  "Start the REST API server for querying the code graph",
+// queryComplexityCmd reports the most complex functions and methods
+var queryComplexityCmd = &cobra.Command{
+	Use:   "complexity",
+	Short: "Report function complexity",
+	Long:  "List functions and methods ordered by complexity score, with cyclomatic complexity, size, parameter and call counts",
+	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		port, _ := cmd.Flags().GetInt("port")
-		
-		fmt.Printf("Starting API server on port %d...\n", port)
-		fmt.Println("API server functionality not yet implemented")
-		
-		// Set up signal handling for graceful shutdown
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		serviceName, _ := cmd.Flags().GetString("service")
+		filePath, _ := cmd.Flags().GetString("file")
+		threshold, _ := cmd.Flags().GetInt("threshold")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		analysis := query.NewAdvancedQueryServiceWithBuilder(neo4j.NewQueryBuilder(client).WithVersion(version))
+
+		ctx := context.Background()
+		result, err := analysis.AnalyzeComplexity(ctx, query.ComplexityAnalysisRequest{
+			ServiceName:             serviceName,
+			FilePath:                filePath,
+			HighComplexityThreshold: threshold,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to analyze complexity: %w", err)
+		}
+
+		functions := result.Functions
+		if limit > 0 && len(functions) > limit {
+			functions = functions[:limit]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SCORE\tCYCLOMATIC\tLINES\tPARAMS\tCALLS\tNAME\tFILE")
+		for _, fn := range functions {
+			fmt.Fprintf(w, "%.2f\t%d\t%d\t%d\t%d\t%s (%s)\t%s\n",
+				fn.ComplexityScore, fn.CyclomaticComplexity, fn.LinesOfCode,
+				fn.ParameterCount, fn.CallCount, fn.Name, fn.Type, fn.FilePath)
+		}
+		w.Flush()
+
+		summary := result.Summary
+		fmt.Printf("\nFunctions: %d  Average complexity: %.2f  Max complexity: %d  Above threshold: %d\n",
+			summary.TotalFunctions, summary.AverageComplexity, summary.MaxComplexity, summary.HighComplexityCount)
+		return nil
+	},
+}
+
+var queryUncheckedErrorsCmd = &cobra.Command{
+	Use:   "unchecked-errors",
+	Short: "Find functions that ignore returned errors",
+	Long:  "List functions and methods that call error-returning functions without checking the error, either discarding the result or assigning the error to _",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serviceName, _ := cmd.Flags().GetString("service")
+		filePath, _ := cmd.Flags().GetString("file")
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		analysis := query.NewAdvancedQueryServiceWithBuilder(neo4j.NewQueryBuilder(client).WithVersion(version))
+
+		ctx := context.Background()
+		result, err := analysis.FindUncheckedErrors(ctx, query.UncheckedErrorRequest{
+			ServiceName: serviceName,
+			FilePath:    filePath,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find unchecked errors: %w", err)
+		}
+
+		if len(result.Functions) == 0 {
+			fmt.Println("No unchecked errors found")
+			return nil
+		}
+
+		for _, fn := range result.Functions {
+			fmt.Printf("%s (%s) %s:%d\n", fn.Name, fn.Type, fn.FilePath, fn.Line)
+			for _, call := range fn.Calls {
+				fmt.Printf("  %s\n", call)
+			}
+		}
+		fmt.Printf("\nFunctions: %d  Unchecked calls: %d\n", len(result.Functions), result.CallCount)
+		return nil
+	},
+}
+
+// queryDuplicatesCmd groups near-duplicate definitions
+var queryDuplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "Find duplicate definitions",
+	Long:  "Group functions, methods, classes and interfaces whose name and signature are near-duplicates under a similarity metric",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serviceName, _ := cmd.Flags().GetString("service")
+		filePath, _ := cmd.Flags().GetString("file")
+		metric, _ := cmd.Flags().GetString("metric")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		asJSON, err := jsonOutput()
+		if err != nil {
+			return err
+		}
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		analysis := query.NewAdvancedQueryServiceWithBuilder(neo4j.NewQueryBuilder(client).WithVersion(version))
+
+		ctx := context.Background()
+		result, err := analysis.FindDuplicateDefinitions(ctx, query.DuplicateDefinitionRequest{
+			ServiceName: serviceName,
+			FilePath:    filePath,
+			Metric:      metric,
+			Threshold:   threshold,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find duplicate definitions: %w", err)
+		}
+
+		if asJSON {
+			return printJSON(result)
+		}
+
+		if len(result.Groups) == 0 {
+			fmt.Println("No duplicate definitions found")
+			return nil
+		}
+
+		for _, group := range result.Groups {
+			fmt.Printf("%s (%s)\n", group[0].Name, group[0].Type)
+			for _, definition := range group {
+				fmt.Printf("  %s:%d %s\n", definition.FilePath, definition.Line, definition.Signature)
+			}
+		}
+		fmt.Printf("\nDuplicate groups: %d (%s, threshold %.2f)\n", len(result.Groups), result.Metric, result.Threshold)
+		return nil
+	},
+}
+
+// queryDependenciesCmd lists the packages a service depends on
+var queryDependenciesCmd = &cobra.Command{
+	Use:   "dependencies",
+	Short: "List the packages a service depends on",
+	Long:  "List the external packages and services a service imports or calls, with the functions calling each and the number of calls",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serviceName, _ := cmd.Flags().GetString("service")
+		includeInternal, _ := cmd.Flags().GetBool("include-internal")
+		includeStdlib, _ := cmd.Flags().GetBool("include-stdlib")
+		if serviceName == "" {
+			return fmt.Errorf("--service is required")
+		}
+		asJSON, err := jsonOutput()
+		if err != nil {
+			return err
+		}
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		analysis := query.NewAdvancedQueryServiceWithBuilder(neo4j.NewQueryBuilder(client).WithVersion(version))
+
+		ctx := context.Background()
+		result, err := analysis.AnalyzeDependencies(ctx, query.DependencyAnalysisRequest{
+			ServiceName:     serviceName,
+			IncludeInternal: includeInternal,
+			IncludeStdlib:   includeStdlib,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to analyze dependencies: %w", err)
+		}
+
+		if asJSON {
+			return printJSON(result)
+		}
+
+		if len(result.Dependencies) == 0 {
+			fmt.Printf("No dependencies found for service '%s'\n", serviceName)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CALLS\tPACKAGE\tCALLING FUNCTIONS\tIMPORTED BY")
+		for _, dep := range result.Dependencies {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", dep.CallCount, dep.ServiceName,
+				strings.Join(dep.CallingFunctions, ", "), strings.Join(dep.ImportedBy, ", "))
+		}
+		w.Flush()
+
+		fmt.Printf("\nDependencies: %d\n", result.DependencyCount)
+		return nil
+	},
+}
+
+// queryCallGraphCmd prints the call graph around a function
+var queryCallGraphCmd = &cobra.Command{
+	Use:   "callgraph [function]",
+	Short: "Show the call graph of a function",
+	Long:  "Traverse CALLS relationships from a function, identified by name, signature or symbol, and print the graph as JSON or Graphviz DOT",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		depth, _ := cmd.Flags().GetInt("depth")
+		direction, _ := cmd.Flags().GetString("direction")
+		format, _ := cmd.Flags().GetString("format")
+
+		if format != "json" && format != "dot" {
+			return fmt.Errorf("invalid format %q: expected json or dot", format)
+		}
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		analysis := query.NewAdvancedQueryServiceWithBuilder(neo4j.NewQueryBuilder(client).WithVersion(version))
+
+		ctx := context.Background()
+		graph, err := analysis.BuildCallGraph(ctx, query.CallGraphRequest{
+			RootFunction: args[0],
+			MaxDepth:     depth,
+			Direction:    direction,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to build call graph: %w", err)
+		}
+
+		if format == "dot" {
+			fmt.Print(graph.DOT())
+			return nil
+		}
+
+		output, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode call graph: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	},
+}
+
+var querySourceCmd = &cobra.Command{
+	Use:   "source [function_name]",
+	Short: "Get source code for a function",
+	Long:  "Retrieve the exact source code for a function or method using stored location metadata",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		functionName := args[0]
+		asJSON, err := jsonOutput()
+		if err != nil {
+			return err
+		}
+		
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		maxFileSize, _ := cmd.Flags().GetInt64("max-file-size")
+		readTimeout, _ := cmd.Flags().GetDuration("read-timeout")
+		queryBuilder := neo4j.NewQueryBuilder(client).WithVersion(version).
+			WithSourceReadLimits(neo4j.SourceReadLimits{MaxFileSize: maxFileSize, Timeout: readTimeout})
+		
+		ctx := context.Background()
+		source, err := queryBuilder.GetFunctionSource(ctx, functionName)
+		if err != nil {
+			return fmt.Errorf("failed to get source code: %w", err)
+		}
+
+		if asJSON {
+			return printJSON(source)
+		}
+
+		fmt.Printf("Source code for function '%s':\n", functionName)
+		fmt.Println("=" + strings.Repeat("=", len(functionName)+25))
+		fmt.Println(source.Source)
+		fmt.Println("=" + strings.Repeat("=", len(functionName)+25))
+		
+		return nil
+	},
+}
+
+var queryReferencesCmd = &cobra.Command{
+	Use:   "references [symbol]",
+	Short: "Find references to a symbol",
+	Long:  "List the usages of a symbol given its name or full SCIP symbol, with the file, line, column, and source line of each",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		asJSON, err := jsonOutput()
+		if err != nil {
+			return err
+		}
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		queryBuilder := neo4j.NewQueryBuilder(client).WithVersion(version)
+
+		ctx := context.Background()
+		symbols, err := queryBuilder.ResolveSymbol(ctx, name)
+		if err != nil {
+			return err
+		}
+		if len(symbols) == 0 {
+			return fmt.Errorf("no symbol named '%s' found", name)
+		}
+		if len(symbols) > 1 {
+			fmt.Fprintf(os.Stderr, "'%s' matches %d symbols:\n", name, len(symbols))
+			for _, symbol := range symbols {
+				fmt.Fprintf(os.Stderr, "  %s\n", symbol)
+			}
+			return fmt.Errorf("'%s' is ambiguous; pass one of the full symbols above", name)
+		}
+		symbol := symbols[0]
+
+		page := neo4j.ReferencePage{}
+		page.Limit, _ = cmd.Flags().GetInt("limit")
+		page.FilePrefix, _ = cmd.Flags().GetString("file-prefix")
+		references, total, err := queryBuilder.FindReferencesPage(ctx, symbol, page)
+		if err != nil {
+			return fmt.Errorf("failed to find references: %w", err)
+		}
+		queryBuilder.AddReferenceContext(ctx, references)
+
+		if asJSON {
+			if references == nil {
+				references = []*models.SymbolReference{}
+			}
+			return printJSON(api.ReferencesResponse{
+				Symbol:     symbol,
+				References: references,
+				Count:      len(references),
+				Total:      total,
+				Limit:      page.Limit,
+			})
+		}
+
+		fmt.Printf("References to '%s':\n", symbol)
+		fmt.Println("=" + strings.Repeat("=", len(symbol)+17))
+		if len(references) == 0 {
+			fmt.Println("No references found")
+			return nil
+		}
+		for _, ref := range references {
+			fmt.Printf("%s:%d:%d\n", ref.FilePath, ref.StartLine, ref.StartColumn)
+			if ref.Context != "" {
+				fmt.Printf("    %s\n", ref.Context)
+			}
+		}
+		if len(references) < total {
+			fmt.Printf("\nShowing %d of %d references\n", len(references), total)
+		}
+		return nil
+	},
+}
+
+var queryOutlineCmd = &cobra.Command{
+	Use:   "outline [file]",
+	Short: "Show the structural outline of a file",
+	Long:  "List the functions, types, and variables declared in a file along with the relationships between them",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filePath := args[0]
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		version, _ := cmd.Flags().GetString("version")
+		serviceName, _ := cmd.Flags().GetString("service")
+		queryBuilder := neo4j.NewQueryBuilder(client).WithVersion(version)
+
+		ctx := context.Background()
+		outline, err := queryBuilder.GetFileContents(ctx, serviceName, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to get file outline: %w", err)
+		}
+
+		fmt.Printf("Outline for '%s':\n", filePath)
+		fmt.Println("=" + strings.Repeat("=", len(filePath)+14))
+
+		names := make(map[string]string)
+		for _, entry := range outline.Entries {
+			names[entry.ID] = entry.Name
+		}
+
+		var printEntries func(parentID string, indent string)
+		printEntries = func(parentID string, indent string) {
+			for _, entry := range outline.Children(parentID) {
+				fmt.Printf("%s- %s (%s) lines %d-%d\n", indent, entry.Name, entry.Kind, entry.StartLine, entry.EndLine)
+				if entry.Signature != "" && entry.Signature != entry.Name {
+					fmt.Printf("%s  Signature: %s\n", indent, entry.Signature)
+				}
+				printEntries(entry.ID, indent+"  ")
+			}
+		}
+		printEntries("", "")
+
+		if len(outline.Relationships) > 0 {
+			fmt.Println("\nRelationships:")
+			for _, rel := range outline.Relationships {
+				fmt.Printf("  %s -[%s]-> %s\n", names[rel.FromID], rel.Type, names[rel.ToID])
+			}
+		}
+
+		return nil
+	},
+}
+
+// serverCmd starts the API server
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Start the API server",
+	Long:  "Start the REST API server for querying the code graph. Endpoints: /healthz, /search?q=, /source/{function}, /references/{symbol} and /analyze/{function}",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		port, _ := cmd.Flags().GetInt("port")
+		readTimeout, _ := cmd.Flags().GetDuration("read-timeout")
+		writeTimeout, _ := cmd.Flags().GetDuration("write-timeout")
+		version, _ := cmd.Flags().GetString("version")
+		redactConfig, err := redaction()
+		if err != nil {
+			return err
+		}
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		server := &http.Server{
+			Addr:         fmt.Sprintf(":%d", port),
+			Handler:      api.NewServer(neo4j.NewQueryBuilder(client).WithVersion(version)).WithRedaction(redactConfig).Handler(),
+			ReadTimeout:  readTimeout,
+			WriteTimeout: writeTimeout,
+		}
+
+		fmt.Printf("Starting API server on port %d...\n", port)
+		
+		// Set up signal handling for graceful shutdown
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
 		// Handle shutdown signals
 		sigChan := make(chan os.Signal, 1)
@@ -544,42 +1621,217 @@ var serverCmd = &cobra.Command{
 			cancel()
 		}()
 
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- server.ListenAndServe()
+		}()
+
 		// Wait for shutdown signal
-		<-ctx.Done()
+		select {
+		case err := <-serveErr:
+			return fmt.Errorf("API server failed: %w", err)
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down API server: %w", err)
+		}
 		return nil
 	},
 }
 
+// benchmarkCmd groups the benchmarks run against an indexed graph
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark",
+	Short: "Benchmark queries against the graph",
+	Long:  "Measure the latency and quality of queries against an indexed graph",
+}
+
+// benchmarkSearchCmd compares the search modes on a query set
+var benchmarkSearchCmd = &cobra.Command{
+	Use:   "search [queries-file]",
+	Short: "Compare search modes on a query set",
+	Long:  "Run every query of a file in each search mode and report latency percentiles and recall@k per mode. The file has one query per line, optionally followed by a tab and the name of the node expected among the top k results; blank lines and lines starting with # are skipped.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modes, _ := cmd.Flags().GetStringSlice("modes")
+		types, _ := cmd.Flags().GetStringSlice("types")
+		k, _ := cmd.Flags().GetInt("k")
+		runs, _ := cmd.Flags().GetInt("runs")
+		version, _ := cmd.Flags().GetString("version")
+		asJSON, err := jsonOutput()
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open queries file: %w", err)
+		}
+		defer file.Close()
+		queries, err := benchmark.LoadSearchQueries(file)
+		if err != nil {
+			return fmt.Errorf("failed to load queries from %s: %w", args[0], err)
+		}
+
+		client, err := createNeo4jClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Neo4j client: %w", err)
+		}
+		defer client.Close(context.Background())
+
+		queryBuilder := neo4j.NewQueryBuilder(client).WithVersion(version)
+		results, err := benchmark.RunSearch(context.Background(), queryBuilder, queries, benchmark.SearchConfig{
+			Modes: modes,
+			Types: types,
+			K:     k,
+			Runs:  runs,
+		})
+		if err != nil {
+			return fmt.Errorf("search benchmark failed: %w", err)
+		}
+
+		if asJSON {
+			return printJSON(results)
+		}
+		return benchmark.PrintComparison(os.Stdout, results, k)
+	},
+}
+
 func init() {
+	// Flags for status
+	statusCmd.Flags().Bool("check", false, "Exit with an error unless the schema is ready (readiness probe)")
+
 	// Schema subcommands
 	schemaCmd.AddCommand(schemaCreateCmd)
 	schemaCmd.AddCommand(schemaDropCmd)
 	schemaCmd.AddCommand(schemaInfoCmd)
+	schemaCmd.AddCommand(schemaMigrateCmd)
+
+	// Flags for schema drop
+	schemaDropCmd.Flags().BoolP("yes", "y", false, "Drop without asking for confirmation")
+	schemaCreateCmd.Flags().Int("vector-dimensions", schema.DefaultVectorDimensions, "Embedding size of the vector indexes (e.g. 768 or 1536)")
+
+	// Flags for schema migrate
+	schemaMigrateCmd.Flags().Int("to", -1, "Target schema version; lower than the current version reverts migrations (default: latest)")
+	schemaMigrateCmd.Flags().Int("vector-dimensions", schema.DefaultVectorDimensions, "Embedding size of the vector indexes (e.g. 768 or 1536)")
 
 	// Index subcommands
 	indexCmd.AddCommand(indexProjectCmd)
 	indexCmd.AddCommand(indexSCIPCmd)
+	indexCmd.AddCommand(indexCompareCmd)
 	indexCmd.AddCommand(indexDocsCmd)
+	indexCmd.AddCommand(indexCleanCmd)
 	
 	// Flags for project command
 	indexProjectCmd.Flags().StringP("service", "s", "", "Service name")
 	indexProjectCmd.Flags().StringP("version", "", "v1.0.0", "Service version")
 	indexProjectCmd.Flags().StringP("repo-url", "r", "", "Repository URL")
+	indexProjectCmd.Flags().Bool("follow-symlinks", false, "Follow symlinked files and directories (loops are detected)")
+	indexProjectCmd.Flags().Bool("include-stdlib-interfaces", false, "Link structs to standard library interfaces they implement")
+	indexProjectCmd.Flags().Bool("warm-cache", false, "Load known modules and symbols of the service from the graph before indexing")
+	indexProjectCmd.Flags().String("goos", "", "Only index files built for this GOOS (default: every file, or the host OS when --goarch or --tags is set)")
+	indexProjectCmd.Flags().String("goarch", "", "Only index files built for this GOARCH (default: every file, or the host architecture when --goos or --tags is set)")
+	indexProjectCmd.Flags().StringSlice("tags", nil, "Build tags to satisfy when selecting files, e.g. integration,netgo")
+	indexProjectCmd.Flags().Bool("api-calls", false, "Link functions to the external HTTP endpoints they call (CALLS_API)")
+	indexProjectCmd.Flags().StringArray("http-client", nil, "Additional HTTP client call as callee:METHOD:urlArg, METHOD may be $N to read it from argument N (implies --api-calls)")
+	indexProjectCmd.Flags().Bool("routes", false, "Create APIRoute nodes for handlers registered with net/http, gorilla/mux, chi or gin (EXPOSES_API)")
+	indexProjectCmd.Flags().StringArray("route-pattern", nil, "Additional route registration as callee:METHOD:pathArg, METHOD may be $N to read it from argument N or * for any (implies --routes)")
+	indexProjectCmd.Flags().Int("concurrency", 1, "Number of files to parse and index in parallel")
+	indexProjectCmd.Flags().StringArray("skip-dir", nil, "Additional directory name to skip, e.g. generated (repeatable; vendor, .git, node_modules and other defaults are always skipped)")
+	indexProjectCmd.Flags().Bool("include-tests", false, "Also index _test.go files")
+	indexProjectCmd.Flags().Bool("exported-only", false, "Only index exported declarations; exported methods of unexported types are skipped")
+	indexProjectCmd.Flags().StringSlice("languages", []string{"go"}, "Languages to index, e.g. go,typescript,javascript (languages other than Go require a cgo build)")
+	indexProjectCmd.Flags().Bool("dry-run", false, "List the files that would be indexed and the directories skipped, without connecting to Neo4j")
+	indexProjectCmd.Flags().String("since", "", "Only reindex files changed since this git ref and remove deleted ones, e.g. origin/main (compares file hashes outside a git repository)")
+	indexProjectCmd.Flags().Bool("timings", false, "Print how long each indexing phase took, and the write time of all workers")
 	
 	// Flags for SCIP command
 	indexSCIPCmd.Flags().StringP("service", "s", "", "Service name")
 	indexSCIPCmd.Flags().StringP("version", "", "v1.0.0", "Service version")
 	indexSCIPCmd.Flags().StringP("repo-url", "r", "", "Repository URL")
+	indexSCIPCmd.Flags().String("reference-roles", "", "SCIP roles that create reference edges: import, read, write, generated, test, forward, reference (plain uses) or all; prefix with - to exclude, e.g. all,-import (default: every occurrence)")
+	indexSCIPCmd.Flags().String("language", "go", "Language of the project, selecting its SCIP indexer: go, typescript or python")
+	indexSCIPCmd.Flags().Bool("dry-run", false, "Run the SCIP indexer and list the documents it found, without connecting to Neo4j")
+	indexSCIPCmd.Flags().Bool("debug", false, "Print a summary of the SCIP index before writing it to the graph")
+
+	// Flags for compare command
+	indexCompareCmd.Flags().Bool("ast", true, "Include the AST indexer in the comparison")
+	indexCompareCmd.Flags().Bool("scip", true, "Include the SCIP indexer in the comparison")
+	indexCompareCmd.Flags().StringP("service", "s", "", "Service name passed to scip-go as the module name")
+	indexCompareCmd.Flags().StringP("version", "", "v1.0.0", "Service version")
+	indexCompareCmd.Flags().StringP("format", "o", "text", "Output format: text or json")
+
+	// Flags for docs command
+	indexDocsCmd.Flags().Int("chunk-size", 1000, "Maximum number of words per DocumentChunk")
+	indexDocsCmd.Flags().String("extractor", "rules", "Feature extractor: llm (chat completion API, key from CODEGRAPH_LLM_API_KEY or OPENAI_API_KEY) or rules")
+	indexDocsCmd.Flags().String("llm-endpoint", documents.DefaultLLMEndpoint, "OpenAI-compatible chat completion endpoint for --extractor llm")
+	indexDocsCmd.Flags().String("llm-model", documents.DefaultLLMModel, "Model used by --extractor llm")
+
+	// Flags for clean command
+	indexCleanCmd.Flags().StringP("service", "s", "", "Service name")
+	indexCleanCmd.Flags().Bool("dry-run", false, "Only report what would be deleted")
 
 	// Query subcommands
 	queryCmd.AddCommand(querySearchCmd)
 	queryCmd.AddCommand(querySourceCmd)
-	
+	queryCmd.AddCommand(queryReferencesCmd)
+	queryCmd.AddCommand(queryOutlineCmd)
+	queryCmd.AddCommand(queryNewSinceCmd)
+	queryCmd.AddCommand(queryComplexityCmd)
+	queryCmd.AddCommand(queryCallGraphCmd)
+	queryCmd.AddCommand(queryUncheckedErrorsCmd)
+	queryCmd.AddCommand(queryDependenciesCmd)
+	queryCmd.AddCommand(queryDuplicatesCmd)
+
 	// Query flags
+	queryCmd.PersistentFlags().String("version", "", "Only return nodes indexed at this service version")
 	querySearchCmd.Flags().IntP("limit", "l", 0, "Limit search results (0 = no limit)")
+	querySearchCmd.Flags().Bool("fulltext", false, "Search through a full-text index covering the searched labels when one exists")
+	querySearchCmd.Flags().Bool("fuzzy", false, "When the full-text search finds nothing, retry matching words within one typo (implies --fulltext)")
+	querySearchCmd.Flags().StringArray("boost", nil, "Weight of matches in a full-text index field as [index:]field=weight, e.g. content=0.5 (repeatable; name and title are boosted by default)")
+	querySourceCmd.Flags().Int64("max-file-size", neo4j.DefaultSourceReadLimits.MaxFileSize, "Refuse to read source files larger than this many bytes")
+	querySourceCmd.Flags().Duration("read-timeout", neo4j.DefaultSourceReadLimits.Timeout, "Abandon source file reads taking longer than this")
+	queryReferencesCmd.Flags().IntP("limit", "l", 0, "Limit references (0 = no limit)")
+	queryReferencesCmd.Flags().String("file-prefix", "", "Only list references in files under this path")
+	queryOutlineCmd.Flags().StringP("service", "s", "", "Only outline the file of this service")
+	queryNewSinceCmd.Flags().Bool("since-last-run", false, "Use the start of the service's last index run as the cutoff")
+	queryNewSinceCmd.Flags().StringP("service", "s", "context-maximiser", "Only list symbols of this service; with --since-last-run, the service whose last index run is used")
+	queryNewSinceCmd.Flags().IntP("limit", "l", 0, "Limit results (0 = no limit)")
+	queryComplexityCmd.Flags().StringP("service", "s", "", "Only analyze functions of this service")
+	queryComplexityCmd.Flags().StringP("file", "f", "", "Only analyze functions in this file")
+	queryComplexityCmd.Flags().Int("threshold", query.DefaultHighComplexityThreshold, "Cyclomatic complexity above which a function counts as highly complex")
+	queryComplexityCmd.Flags().IntP("limit", "l", 0, "Limit results (0 = no limit)")
+	queryCallGraphCmd.Flags().IntP("depth", "d", query.DefaultCallGraphDepth, "Maximum number of calls to follow from the function")
+	queryCallGraphCmd.Flags().String("direction", "outgoing", "Follow calls made by the function (outgoing), to it (incoming), or both")
+	queryCallGraphCmd.Flags().StringP("format", "o", "json", "Output format: json or dot")
+	queryUncheckedErrorsCmd.Flags().StringP("service", "s", "", "Only check functions of this service")
+	queryUncheckedErrorsCmd.Flags().StringP("file", "f", "", "Only check functions in this file")
+	queryDependenciesCmd.Flags().StringP("service", "s", "", "Service whose dependencies are listed")
+	queryDependenciesCmd.Flags().Bool("include-internal", false, "Also list the service's own packages")
+	queryDependenciesCmd.Flags().Bool("include-stdlib", false, "Also list standard library packages")
+	queryDuplicatesCmd.Flags().StringP("service", "s", "", "Only compare definitions of this service")
+	queryDuplicatesCmd.Flags().StringP("file", "f", "", "Only compare definitions in this file")
+	queryDuplicatesCmd.Flags().String("metric", string(similarity.DefaultMetric), "Similarity metric: exact, jaccard or levenshtein")
+	queryDuplicatesCmd.Flags().Float64("threshold", query.DefaultDuplicateThreshold, "Similarity (0-1) at which definitions count as duplicates")
 
 	// Server flags
 	serverCmd.Flags().IntP("port", "p", 8080, "Server port")
+	serverCmd.Flags().Duration("read-timeout", 30*time.Second, "Maximum duration for reading a request")
+	serverCmd.Flags().Duration("write-timeout", 60*time.Second, "Maximum duration for writing a response")
+	serverCmd.Flags().String("version", "", "Only return nodes indexed at this service version")
+
+	// Benchmark subcommands
+	benchmarkCmd.AddCommand(benchmarkSearchCmd)
+
+	// Flags for search benchmark
+	benchmarkSearchCmd.Flags().StringSlice("modes", []string{"scan", "fulltext"}, "Search modes to compare: scan (substring match on names, signatures and symbols) and fulltext (full-text index)")
+	benchmarkSearchCmd.Flags().StringSlice("types", []string{"Function", "Method"}, "Node labels to search")
+	benchmarkSearchCmd.Flags().IntP("k", "k", 10, "Number of results per query considered for recall@k")
+	benchmarkSearchCmd.Flags().Int("runs", 1, "Times each query is run per mode")
+	benchmarkSearchCmd.Flags().String("version", "", "Only search nodes indexed at this service version")
 }
 
 func main() {
@@ -588,12 +1840,113 @@ func main() {
 
 // createNeo4jClient creates a new Neo4j client using configuration
 func createNeo4jClient() (*neo4j.Client, error) {
+	password, err := neo4jPassword(viper.GetViper(), rootCmd.PersistentFlags().Changed("neo4j-password"))
+	if err != nil {
+		return nil, err
+	}
+
 	config := neo4j.Config{
-		URI:      viper.GetString("neo4j.uri"),
-		Username: viper.GetString("neo4j.username"),
-		Password: viper.GetString("neo4j.password"),
-		Database: viper.GetString("neo4j.database"),
+		URI:          viper.GetString("neo4j.uri"),
+		Username:     viper.GetString("neo4j.username"),
+		Password:     password,
+		Database:     viper.GetString("neo4j.database"),
+		MaxRetries:   viper.GetInt("neo4j.max_retries"),
+		QueryTimeout: viper.GetDuration("neo4j.query_timeout"),
+
+		MaxConnectionPoolSize:        viper.GetInt("neo4j.max_connection_pool_size"),
+		ConnectionAcquisitionTimeout: viper.GetDuration("neo4j.connection_acquisition_timeout"),
+		MaxConnectionLifetime:        viper.GetDuration("neo4j.max_connection_lifetime"),
+
+		Encrypted:     viper.GetBool("neo4j.encrypted"),
+		TrustStrategy: viper.GetString("neo4j.trust_strategy"),
+		CACertPath:    viper.GetString("neo4j.ca_cert"),
 	}
 
 	return neo4j.NewClient(config)
-}
\ No newline at end of file
+}
+
+// neo4jEnv lists the environment variables each Neo4j setting is read from;
+// the first one set wins
+var neo4jEnv = map[string][]string{
+	"neo4j.uri":            {"NEO4J_URI"},
+	"neo4j.username":       {"NEO4J_USERNAME", "NEO4J_USER"},
+	"neo4j.password":       {"NEO4J_PASSWORD"},
+	"neo4j.password_file":  {"NEO4J_PASSWORD_FILE"},
+	"neo4j.database":       {"NEO4J_DATABASE"},
+	"neo4j.max_retries":    {"NEO4J_MAX_RETRIES"},
+	"neo4j.query_timeout":  {"NEO4J_QUERY_TIMEOUT"},
+	"neo4j.encrypted":      {"NEO4J_ENCRYPTED"},
+	"neo4j.trust_strategy": {"NEO4J_TRUST_STRATEGY"},
+	"neo4j.ca_cert":        {"NEO4J_CA_CERT"},
+}
+
+// bindNeo4jEnv binds the Neo4j settings of v to their environment variables,
+// which take precedence over the config file but not over flags
+func bindNeo4jEnv(v *viper.Viper) {
+	for key, env := range neo4jEnv {
+		v.BindEnv(append([]string{key}, env...)...)
+	}
+}
+
+// neo4jPassword resolves the Neo4j password from, in order of precedence, the
+// --neo4j-password flag when passed, the password file, the environment and
+// the config file
+func neo4jPassword(v *viper.Viper, flagPassed bool) (string, error) {
+	if flagPassed {
+		return v.GetString("neo4j.password"), nil
+	}
+	if path := v.GetString("neo4j.password_file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read Neo4j password file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return v.GetString("neo4j.password"), nil
+}
+
+// jsonOutput reports whether the global --output flag asks for JSON
+func jsonOutput() (bool, error) {
+	switch output := viper.GetString("output"); output {
+	case "", "text":
+		return false, nil
+	case "json":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported output format %q: expected text or json", output)
+	}
+}
+
+// redaction returns the node properties to omit from output, set by --redact
+func redaction() (*models.RedactionConfig, error) {
+	return models.ParseRedaction(viper.GetString("redact"))
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// isTerminal reports whether the file is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// parseTimestamp parses a Unix timestamp in seconds or an RFC 3339 time
+func parseTimestamp(value string) (int64, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: expected Unix seconds or RFC 3339", value)
+	}
+	return t.Unix(), nil
+}
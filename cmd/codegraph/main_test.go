@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNeo4jSettings returns a viper bound like the global one, with the
+// password flags of cmd and the given config file contents
+func newNeo4jSettings(t *testing.T, cmd *cobra.Command, config string) *viper.Viper {
+	t.Helper()
+	cmd.Flags().String("neo4j-user", "neo4j", "")
+	cmd.Flags().String("neo4j-password", "password123", "")
+	cmd.Flags().String("neo4j-password-file", "", "")
+
+	v := viper.New()
+	require.NoError(t, v.BindPFlag("neo4j.username", cmd.Flags().Lookup("neo4j-user")))
+	require.NoError(t, v.BindPFlag("neo4j.password", cmd.Flags().Lookup("neo4j-password")))
+	require.NoError(t, v.BindPFlag("neo4j.password_file", cmd.Flags().Lookup("neo4j-password-file")))
+	bindNeo4jEnv(v)
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(config)))
+	return v
+}
+
+func TestNeo4jPasswordPrecedence(t *testing.T) {
+	for _, env := range []string{"NEO4J_PASSWORD", "NEO4J_PASSWORD_FILE"} {
+		t.Setenv(env, "")
+	}
+	cmd := &cobra.Command{}
+	v := newNeo4jSettings(t, cmd, "neo4j:\n  password: from-config\n")
+	password := func() string {
+		t.Helper()
+		password, err := neo4jPassword(v, cmd.Flags().Changed("neo4j-password"))
+		require.NoError(t, err)
+		return password
+	}
+
+	assert.Equal(t, "from-config", password())
+
+	t.Setenv("NEO4J_PASSWORD", "from-env")
+	assert.Equal(t, "from-env", password(), "The environment overrides the config file")
+
+	path := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(path, []byte("  from-file\n"), 0600))
+	require.NoError(t, cmd.Flags().Set("neo4j-password-file", path))
+	assert.Equal(t, "from-file", password(), "The password file overrides the environment and is trimmed")
+
+	require.NoError(t, cmd.Flags().Set("neo4j-password", "from-flag"))
+	assert.Equal(t, "from-flag", password(), "The flag overrides everything")
+}
+
+func TestNeo4jPasswordSources(t *testing.T) {
+	for _, env := range []string{"NEO4J_PASSWORD", "NEO4J_PASSWORD_FILE", "NEO4J_USERNAME", "NEO4J_USER"} {
+		t.Setenv(env, "")
+	}
+
+	cmd := &cobra.Command{}
+	v := newNeo4jSettings(t, cmd, "")
+	password, err := neo4jPassword(v, false)
+	require.NoError(t, err)
+	assert.Equal(t, "password123", password, "The flag default applies without other sources")
+
+	// The file can be named in the environment too, as with mounted secrets
+	path := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(path, []byte("secret\n"), 0600))
+	t.Setenv("NEO4J_PASSWORD_FILE", path)
+	t.Setenv("NEO4J_PASSWORD", "from-env")
+	password, err = neo4jPassword(v, false)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", password)
+
+	t.Setenv("NEO4J_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	_, err = neo4jPassword(v, false)
+	assert.Error(t, err)
+
+	t.Setenv("NEO4J_USER", "from-user")
+	assert.Equal(t, "from-user", v.GetString("neo4j.username"))
+	t.Setenv("NEO4J_USERNAME", "from-username")
+	assert.Equal(t, "from-username", v.GetString("neo4j.username"), "NEO4J_USERNAME wins over NEO4J_USER")
+}
+
+func TestRedactFlag(t *testing.T) {
+	defer viper.Set("redact", "")
+	search := func() *query.SearchResult {
+		return &query.SearchResult{
+			Name:     "SaveUser",
+			Type:     "Function",
+			Labels:   []string{"Function"},
+			FilePath: "app/user.go",
+			Properties: map[string]any{
+				"name":      "SaveUser",
+				"filePath":  "app/user.go",
+				"embedding": []float64{0.1, 0.2},
+			},
+		}
+	}
+
+	// Embeddings are redacted unless the flag says otherwise
+	config, err := redaction()
+	require.NoError(t, err)
+	result := search()
+	query.RedactSearchResults([]*query.SearchResult{result}, config)
+	assert.NotContains(t, result.Properties, "embedding")
+	assert.Equal(t, "app/user.go", result.FilePath)
+
+	viper.Set("redact", "Function.filePath")
+	config, err = redaction()
+	require.NoError(t, err)
+	result = search()
+	query.RedactSearchResults([]*query.SearchResult{result}, config)
+	assert.Contains(t, result.Properties, "embedding")
+	assert.NotContains(t, result.Properties, "filePath")
+	assert.Empty(t, result.FilePath, "Fields read from redacted properties are cleared")
+	assert.Equal(t, "SaveUser", result.Name)
+
+	viper.Set("redact", "Function.")
+	_, err = redaction()
+	assert.Error(t, err)
+}
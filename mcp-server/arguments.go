@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ArgumentError describes a tool argument that failed validation
+type ArgumentError struct {
+	Argument string
+	Message  string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("invalid argument '%s': %s", e.Argument, e.Message)
+}
+
+// findTool looks up a tool definition by name
+func findTool(name string) (MCPTool, bool) {
+	for _, tool := range toolDefinitions() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return MCPTool{}, false
+}
+
+// validateArguments checks tool call arguments against the tool's input schema.
+// Required arguments must be present (and non-empty for strings) and known
+// arguments must have the declared type.
+func validateArguments(tool MCPTool, args map[string]interface{}) error {
+	properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+	required, _ := tool.InputSchema["required"].([]string)
+
+	for _, name := range required {
+		value, ok := args[name]
+		if !ok || value == nil {
+			return &ArgumentError{Argument: name, Message: "is required"}
+		}
+		if str, ok := value.(string); ok && str == "" {
+			return &ArgumentError{Argument: name, Message: "must not be empty"}
+		}
+	}
+
+	// Check in a stable order so the reported argument is deterministic
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err := validateValue(name, schema, args[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateValue checks a single argument value against its property schema
+func validateValue(name string, schema map[string]interface{}, value interface{}) error {
+	switch schema["type"] {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return &ArgumentError{Argument: name, Message: fmt.Sprintf("must be a string, got %s", jsonTypeName(value))}
+		}
+		if allowed, ok := schema["enum"].([]string); ok && !slices.Contains(allowed, str) {
+			return &ArgumentError{Argument: name, Message: fmt.Sprintf("must be one of %s", strings.Join(allowed, ", "))}
+		}
+	case "number":
+		number, ok := value.(float64)
+		if !ok {
+			return &ArgumentError{Argument: name, Message: fmt.Sprintf("must be a number, got %s", jsonTypeName(value))}
+		}
+		if number != math.Trunc(number) {
+			return &ArgumentError{Argument: name, Message: "must be a whole number"}
+		}
+		if minimum, ok := schema["minimum"].(int); ok && number < float64(minimum) {
+			return &ArgumentError{Argument: name, Message: fmt.Sprintf("must be at least %d", minimum)}
+		}
+		if maximum, ok := schema["maximum"].(int); ok && number > float64(maximum) {
+			return &ArgumentError{Argument: name, Message: fmt.Sprintf("must be at most %d", maximum)}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return &ArgumentError{Argument: name, Message: fmt.Sprintf("must be an array, got %s", jsonTypeName(value))}
+		}
+		if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range items {
+				if err := validateValue(fmt.Sprintf("%s[%d]", name, i), itemSchema, item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonTypeName returns the JSON type name of a decoded value
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// sendArgumentError reports an invalid tool argument as a JSON-RPC invalid params error
+func (s *CodeGraphMCPServer) sendArgumentError(id interface{}, err error) {
+	response := MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &MCPError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Invalid params: %v", err),
+		},
+	}
+	if argErr, ok := err.(*ArgumentError); ok {
+		response.Error.Data = map[string]interface{}{"argument": argErr.Argument}
+	}
+
+	s.writeMessage(response)
+}
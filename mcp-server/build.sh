@@ -4,7 +4,7 @@ set -e
 echo "Building CodeGraph MCP Server..."
 
 # Build the MCP server binary
-go build -o codegraph-mcp main.go
+go build -o codegraph-mcp .
 
 echo "✓ Built codegraph-mcp binary"
 
@@ -5,6 +5,13 @@ go 1.24.3
 require (
 	github.com/context-maximiser/code-graph v0.0.0
 	github.com/neo4j/neo4j-go-driver/v5 v5.28.3
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/context-maximiser/code-graph => ../
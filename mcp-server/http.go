@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxHTTPMessageSize bounds the JSON-RPC messages accepted over HTTP
+const maxHTTPMessageSize = 4 << 20
+
+// httpHandler serves the MCP Streamable HTTP transport on the /mcp endpoint.
+// Each POST carries a JSON-RPC message or batch, dispatched through
+// handleRequest like stdio messages. Replies are sent as a Server-Sent Events
+// stream when the client accepts one, and as a JSON body otherwise. The server
+// keeps no sessions and sends no messages of its own, so GET is not supported.
+func (s *CodeGraphMCPServer) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", s.serveMCP)
+	return mux
+}
+
+// HTTP server timeouts. Tool calls can run long queries, so responses are
+// not bounded by a write timeout.
+const (
+	httpReadHeaderTimeout = 10 * time.Second
+	httpReadTimeout       = 30 * time.Second
+	httpIdleTimeout       = 2 * time.Minute
+	httpShutdownTimeout   = 10 * time.Second
+)
+
+// runHTTP serves the HTTP transport on addr until ctx is cancelled, then
+// shuts down gracefully, or until the server fails
+func (s *CodeGraphMCPServer) runHTTP(ctx context.Context, addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.httpHandler(),
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+		ReadTimeout:       httpReadTimeout,
+		IdleTimeout:       httpIdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+	return nil
+}
+
+func (s *CodeGraphMCPServer) serveMCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowedOrigin(r) {
+		http.Error(w, "forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPMessageSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+	var requests []MCPRequest
+	if batch {
+		err = json.Unmarshal(body, &requests)
+	} else {
+		var request MCPRequest
+		err = json.Unmarshal(body, &request)
+		requests = []MCPRequest{request}
+	}
+	if err != nil {
+		var output bytes.Buffer
+		server := *s
+		server.output = &output
+		server.sendError(nil, -32700, "Parse error")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(output.Bytes())
+		return
+	}
+
+	var responses [][]byte
+	for _, request := range requests {
+		// Notifications and responses from the client expect no reply
+		if request.ID == nil {
+			continue
+		}
+
+		// Capture the reply of this request only
+		var output bytes.Buffer
+		server := *s
+		server.output = &output
+		server.handleRequest(r.Context(), request)
+		responses = append(responses, bytes.TrimSpace(output.Bytes()))
+	}
+
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if acceptsEventStream(r) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		flusher, _ := w.(http.Flusher)
+		for _, response := range responses {
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", response)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		w.Write([]byte("["))
+		w.Write(bytes.Join(responses, []byte(",")))
+		w.Write([]byte("]"))
+		return
+	}
+	w.Write(responses[0])
+}
+
+// allowedOrigin reports whether the request comes from an acceptable origin.
+// Browsers send an Origin header on cross-site requests, so a foreign origin
+// means a web page (possibly through DNS rebinding) is calling the server.
+// Requests without an Origin, and those from the server's own host or a
+// loopback host, are allowed.
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if u.Host == r.Host {
+		return true
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// acceptsEventStream reports whether the client accepts Server-Sent Events
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == "text/event-stream" {
+				return true
+			}
+		}
+	}
+	return false
+}
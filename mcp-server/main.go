@@ -4,12 +4,21 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/context-maximiser/code-graph/pkg/logging"
 	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/query"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
 )
 
@@ -29,8 +38,9 @@ type MCPResponse struct {
 }
 
 type MCPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // MCP Tool Definitions
@@ -59,9 +69,23 @@ type ToolContent struct {
 type CodeGraphMCPServer struct {
 	client       *neo4j.Client
 	queryBuilder *neo4j.QueryBuilder
+	output       io.Writer    // Destination for JSON-RPC messages
+	logger       *slog.Logger // Destination for logs, never stdout
 }
 
 func main() {
+	transport := flag.String("transport", "stdio", "Transport: stdio (JSON-RPC over stdin/stdout) or http (Streamable HTTP at /mcp)")
+	addr := flag.String("addr", "localhost:8080", "Address the http transport listens on")
+	verbose := flag.Bool("verbose", false, "Log debug messages")
+	flag.Parse()
+
+	logger := configureLogging(*verbose)
+
+	if *transport != "stdio" && *transport != "http" {
+		logger.Error("Unknown transport (use stdio or http)", "transport", *transport)
+		os.Exit(1)
+	}
+
 	// Initialize Neo4j client
 	config := neo4j.Config{
 		URI:      getEnvOrDefault("NEO4J_URI", "bolt://localhost:7687"),
@@ -72,21 +96,57 @@ func main() {
 
 	client, err := neo4j.NewClient(config)
 	if err != nil {
-		log.Fatalf("Failed to create Neo4j client: %v", err)
+		logger.Error("Failed to create Neo4j client", "error", err)
+		os.Exit(1)
 	}
 	defer client.Close(context.Background())
 
+	sourceLimits := neo4j.SourceReadLimits{
+		MaxFileSize: int64(getEnvIntOrDefault("CODEGRAPH_MAX_SOURCE_FILE_SIZE", int(neo4j.DefaultSourceReadLimits.MaxFileSize))),
+		Timeout:     time.Duration(getEnvIntOrDefault("CODEGRAPH_SOURCE_READ_TIMEOUT_MS", int(neo4j.DefaultSourceReadLimits.Timeout.Milliseconds()))) * time.Millisecond,
+	}
+
 	server := &CodeGraphMCPServer{
 		client:       client,
-		queryBuilder: neo4j.NewQueryBuilder(client),
+		queryBuilder: neo4j.NewQueryBuilder(client).WithSourceReadLimits(sourceLimits),
+		output:       os.Stdout,
+		logger:       logger,
 	}
 
 	// Start MCP server
-	server.run()
+	if *transport == "http" {
+		logger.Info("Serving MCP over HTTP", "url", "http://"+*addr+"/mcp")
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := server.runHTTP(ctx, *addr); err != nil {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	server.run(os.Stdin)
+}
+
+// configureLogging sends every log, including the standard log package, to
+// stderr: stdout carries JSON-RPC messages on the stdio transport
+func configureLogging(verbose bool) *slog.Logger {
+	log.SetOutput(os.Stderr)
+	logger := logging.New(os.Stderr, verbose)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// log returns the server's logger, or the default logger when none is set
+func (s *CodeGraphMCPServer) log() *slog.Logger {
+	if s.logger == nil {
+		return slog.Default()
+	}
+	return s.logger
 }
 
-func (s *CodeGraphMCPServer) run() {
-	scanner := bufio.NewScanner(os.Stdin)
+// run serves JSON-RPC messages read line by line from input
+func (s *CodeGraphMCPServer) run(input io.Reader) {
+	scanner := bufio.NewScanner(input)
 	
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -96,26 +156,29 @@ func (s *CodeGraphMCPServer) run() {
 
 		var request MCPRequest
 		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			s.log().Warn("Failed to parse request", "error", err)
 			s.sendError(request.ID, -32700, "Parse error")
 			continue
 		}
 
-		s.handleRequest(request)
+		s.handleRequest(context.Background(), request)
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Printf("Error reading input: %v", err)
+		s.log().Error("Failed to read input", "error", err)
 	}
 }
 
-func (s *CodeGraphMCPServer) handleRequest(request MCPRequest) {
+// handleRequest answers a JSON-RPC request. Tool calls stop their queries
+// when ctx is cancelled.
+func (s *CodeGraphMCPServer) handleRequest(ctx context.Context, request MCPRequest) {
 	switch request.Method {
 	case "initialize":
 		s.handleInitialize(request)
 	case "tools/list":
 		s.handleToolsList(request)
 	case "tools/call":
-		s.handleToolCall(request)
+		s.handleToolCall(ctx, request)
 	default:
 		s.sendError(request.ID, -32601, "Method not found")
 	}
@@ -137,7 +200,19 @@ func (s *CodeGraphMCPServer) handleInitialize(request MCPRequest) {
 }
 
 func (s *CodeGraphMCPServer) handleToolsList(request MCPRequest) {
-	tools := []MCPTool{
+	tools := toolDefinitions()
+
+	result := map[string]interface{}{
+		"tools": tools,
+	}
+
+	s.sendResponse(request.ID, result)
+}
+
+// toolDefinitions returns the tools exposed by the server. The input schemas
+// are also used to validate tool call arguments.
+func toolDefinitions() []MCPTool {
+	return []MCPTool{
 		{
 			Name:        "codegraph_search",
 			Description: "Search for functions, methods, classes, and other code entities in the codebase",
@@ -152,6 +227,7 @@ func (s *CodeGraphMCPServer) handleToolsList(request MCPRequest) {
 						"type":        "number",
 						"description": "Maximum number of results to return (default: 20, 0 for unlimited)",
 						"default":     20,
+						"minimum":     0,
 					},
 					"types": map[string]interface{}{
 						"type":        "array",
@@ -180,7 +256,7 @@ func (s *CodeGraphMCPServer) handleToolsList(request MCPRequest) {
 		},
 		{
 			Name:        "codegraph_find_references",
-			Description: "Find all references (usages) of a specific symbol in the codebase",
+			Description: "Find references (usages) of a specific symbol in the codebase, one page at a time",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -188,6 +264,22 @@ func (s *CodeGraphMCPServer) handleToolsList(request MCPRequest) {
 						"type":        "string",
 						"description": "Symbol to find references for",
 					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of references to return (default: 50, 0 for unlimited)",
+						"default":     50,
+						"minimum":     0,
+					},
+					"offset": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of references to skip, for paging (default: 0)",
+						"default":     0,
+						"minimum":     0,
+					},
+					"file_prefix": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return references in files whose path starts with this prefix",
+					},
 				},
 				"required": []string{"symbol"},
 			},
@@ -206,24 +298,88 @@ func (s *CodeGraphMCPServer) handleToolsList(request MCPRequest) {
 				"required": []string{"function_name"},
 			},
 		},
+		{
+			Name:        "codegraph_file_outline",
+			Description: "Get a structural outline of a file: its functions, types, variables, and the relationships between them",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path of the indexed file to outline; a relative path must match a single indexed file",
+					},
+					"service": map[string]interface{}{
+						"type":        "string",
+						"description": "Only outline the file of this service",
+					},
+				},
+				"required": []string{"file_path"},
+			},
+		},
+		{
+			Name:        "codegraph_callgraph",
+			Description: "Get the call graph around a function as an indented tree and a Graphviz DOT graph",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"function_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name, signature or symbol of the function at the root of the graph",
+					},
+					"depth": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Maximum number of calls to follow from the function (default: %d)", query.DefaultCallGraphDepth),
+						"default":     query.DefaultCallGraphDepth,
+						"minimum":     1,
+						"maximum":     maxCallGraphDepth,
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Follow calls made by the function (outgoing), calls to it (incoming), or both",
+						"enum":        []string{"outgoing", "incoming", "both"},
+						"default":     "outgoing",
+					},
+				},
+				"required": []string{"function_name"},
+			},
+		},
+		{
+			Name:        "codegraph_impact_analysis",
+			Description: "Find the API endpoints (and downstream functions) affected by changing a function",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"function_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the function or method to analyze",
+					},
+				},
+				"required": []string{"function_name"},
+			},
+		},
 	}
-
-	result := map[string]interface{}{
-		"tools": tools,
-	}
-
-	s.sendResponse(request.ID, result)
 }
 
-func (s *CodeGraphMCPServer) handleToolCall(request MCPRequest) {
+func (s *CodeGraphMCPServer) handleToolCall(ctx context.Context, request MCPRequest) {
 	var toolCall ToolCallRequest
 	paramsBytes, _ := json.Marshal(request.Params)
 	if err := json.Unmarshal(paramsBytes, &toolCall); err != nil {
-		s.sendError(request.ID, -32602, "Invalid params")
+		s.sendError(request.ID, -32602, fmt.Sprintf("Invalid params: %v", err))
+		return
+	}
+
+	s.log().Debug("Calling tool", "tool", toolCall.Name, "arguments", toolCall.Arguments)
+	tool, ok := findTool(toolCall.Name)
+	if !ok {
+		s.sendError(request.ID, -32601, "Unknown tool")
+		return
+	}
+
+	if err := validateArguments(tool, toolCall.Arguments); err != nil {
+		s.sendArgumentError(request.ID, err)
 		return
 	}
 
-	ctx := context.Background()
 	var response ToolCallResponse
 
 	switch toolCall.Name {
@@ -235,22 +391,25 @@ func (s *CodeGraphMCPServer) handleToolCall(request MCPRequest) {
 		response = s.handleFindReferencesTool(ctx, toolCall.Arguments)
 	case "codegraph_analyze_function":
 		response = s.handleAnalyzeFunctionTool(ctx, toolCall.Arguments)
+	case "codegraph_file_outline":
+		response = s.handleFileOutlineTool(ctx, toolCall.Arguments)
+	case "codegraph_callgraph":
+		response = s.handleCallGraphTool(ctx, toolCall.Arguments)
+	case "codegraph_impact_analysis":
+		response = s.handleImpactAnalysisTool(ctx, toolCall.Arguments)
 	default:
 		s.sendError(request.ID, -32601, "Unknown tool")
 		return
 	}
 
+	if response.IsError && len(response.Content) > 0 {
+		s.log().Warn("Tool failed", "tool", toolCall.Name, "error", response.Content[0].Text)
+	}
 	s.sendResponse(request.ID, response)
 }
 
 func (s *CodeGraphMCPServer) handleSearchTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
-	query, ok := args["query"].(string)
-	if !ok {
-		return ToolCallResponse{
-			Content: []ToolContent{{Type: "text", Text: "Error: query parameter is required"}},
-			IsError: true,
-		}
-	}
+	query, _ := args["query"].(string)
 
 	limit := 20
 	if l, ok := args["limit"].(float64); ok {
@@ -300,7 +459,7 @@ func (s *CodeGraphMCPServer) handleSearchTool(ctx context.Context, args map[stri
 					nodeType = labels[0]
 				}
 
-				name := getStringProp(props, "name")
+				name := displayLabel(props, labels)
 				filePath := getStringProp(props, "filePath")
 				signature := getStringProp(props, "signature")
 
@@ -339,13 +498,7 @@ func (s *CodeGraphMCPServer) handleSearchTool(ctx context.Context, args map[stri
 }
 
 func (s *CodeGraphMCPServer) handleGetSourceTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
-	functionName, ok := args["function_name"].(string)
-	if !ok {
-		return ToolCallResponse{
-			Content: []ToolContent{{Type: "text", Text: "Error: function_name parameter is required"}},
-			IsError: true,
-		}
-	}
+	functionName, _ := args["function_name"].(string)
 
 	sourceCode, err := s.queryBuilder.GetFunctionSourceCode(ctx, functionName)
 	if err != nil {
@@ -367,15 +520,18 @@ func (s *CodeGraphMCPServer) handleGetSourceTool(ctx context.Context, args map[s
 }
 
 func (s *CodeGraphMCPServer) handleFindReferencesTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
-	symbol, ok := args["symbol"].(string)
-	if !ok {
-		return ToolCallResponse{
-			Content: []ToolContent{{Type: "text", Text: "Error: symbol parameter is required"}},
-			IsError: true,
-		}
+	symbol, _ := args["symbol"].(string)
+
+	page := neo4j.ReferencePage{Limit: 50}
+	if l, ok := args["limit"].(float64); ok {
+		page.Limit = int(l)
+	}
+	if o, ok := args["offset"].(float64); ok {
+		page.Offset = int(o)
 	}
+	page.FilePrefix, _ = args["file_prefix"].(string)
 
-	references, err := s.queryBuilder.FindAllReferences(ctx, symbol)
+	references, total, err := s.queryBuilder.FindReferencesPage(ctx, symbol, page)
 	if err != nil {
 		return ToolCallResponse{
 			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Error finding references for '%s': %v", symbol, err)}},
@@ -384,13 +540,17 @@ func (s *CodeGraphMCPServer) handleFindReferencesTool(ctx context.Context, args
 	}
 
 	if len(references) == 0 {
+		message := fmt.Sprintf("No references found for symbol: %s", symbol)
+		if total > 0 {
+			message = fmt.Sprintf("No references at offset %d; symbol '%s' has %d reference(s)", page.Offset, symbol, total)
+		}
 		return ToolCallResponse{
-			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("No references found for symbol: %s", symbol)}},
+			Content: []ToolContent{{Type: "text", Text: message}},
 		}
 	}
 
 	var output strings.Builder
-	output.WriteString(fmt.Sprintf("Found %d reference(s) for '%s':\n\n", len(references), symbol))
+	output.WriteString(referencePageHeader(symbol, page, len(references), total))
 
 	for _, ref := range references {
 		output.WriteString(fmt.Sprintf("**%s**\n", ref.FilePath))
@@ -410,15 +570,23 @@ func (s *CodeGraphMCPServer) handleFindReferencesTool(ctx context.Context, args
 	}
 }
 
-func (s *CodeGraphMCPServer) handleAnalyzeFunctionTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
-	functionName, ok := args["function_name"].(string)
-	if !ok {
-		return ToolCallResponse{
-			Content: []ToolContent{{Type: "text", Text: "Error: function_name parameter is required"}},
-			IsError: true,
-		}
+// referencePageHeader describes which references of the total a page holds
+// and how to request the next page
+func referencePageHeader(symbol string, page neo4j.ReferencePage, count, total int) string {
+	if count == total {
+		return fmt.Sprintf("Found %d reference(s) for '%s':\n\n", total, symbol)
 	}
 
+	header := fmt.Sprintf("Showing references %d-%d of %d for '%s'", page.Offset+1, page.Offset+count, total, symbol)
+	if next := page.Offset + count; next < total {
+		header += fmt.Sprintf(" (use offset %d for the next page)", next)
+	}
+	return header + ":\n\n"
+}
+
+func (s *CodeGraphMCPServer) handleAnalyzeFunctionTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
+	functionName, _ := args["function_name"].(string)
+
 	// Get function metadata
 	cypher := `
 		MATCH (f:Function {name: $name})
@@ -429,7 +597,7 @@ func (s *CodeGraphMCPServer) handleAnalyzeFunctionTool(ctx context.Context, args
 		LIMIT 1
 	`
 
-	result, err := s.client.ExecuteQuery(ctx, cypher, map[string]any{"name": functionName})
+	result, err := s.client.ExecuteReadQuery(ctx, cypher, map[string]any{"name": functionName})
 	if err != nil {
 		return ToolCallResponse{
 			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Error analyzing function '%s': %v", functionName, err)}},
@@ -481,7 +649,7 @@ func (s *CodeGraphMCPServer) handleAnalyzeFunctionTool(ctx context.Context, args
 		RETURN caller.name as callerName, caller.filePath as callerFile
 		LIMIT 10
 	`
-	callers, _ := s.client.ExecuteQuery(ctx, callersQuery, map[string]any{"name": functionName})
+	callers, _ := s.client.ExecuteReadQuery(ctx, callersQuery, map[string]any{"name": functionName})
 
 	output.WriteString("### Called By\n")
 	if len(callers) > 0 {
@@ -503,7 +671,7 @@ func (s *CodeGraphMCPServer) handleAnalyzeFunctionTool(ctx context.Context, args
 		RETURN callee.name as calleeName, callee.filePath as calleeFile
 		LIMIT 10
 	`
-	callees, _ := s.client.ExecuteQuery(ctx, calleesQuery, map[string]any{"name": functionName})
+	callees, _ := s.client.ExecuteReadQuery(ctx, calleesQuery, map[string]any{"name": functionName})
 
 	output.WriteString("### Calls\n")
 	if len(callees) > 0 {
@@ -522,6 +690,173 @@ func (s *CodeGraphMCPServer) handleAnalyzeFunctionTool(ctx context.Context, args
 	}
 }
 
+func (s *CodeGraphMCPServer) handleFileOutlineTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
+	filePath, _ := args["file_path"].(string)
+	serviceName, _ := args["service"].(string)
+
+	outline, err := s.queryBuilder.GetFileContents(ctx, serviceName, filePath)
+	if err != nil {
+		return ToolCallResponse{
+			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Error getting outline for '%s': %v", filePath, err)}},
+			IsError: true,
+		}
+	}
+
+	names := make(map[string]string)
+	for _, entry := range outline.Entries {
+		names[entry.ID] = entry.Name
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("## Outline for '%s'\n\n", filePath))
+
+	var writeEntries func(parentID, indent string)
+	writeEntries = func(parentID, indent string) {
+		for _, entry := range outline.Children(parentID) {
+			output.WriteString(fmt.Sprintf("%s- **%s** (%s) lines %d-%d\n", indent, entry.Name, entry.Kind, entry.StartLine, entry.EndLine))
+			if entry.Signature != "" && entry.Signature != entry.Name {
+				output.WriteString(fmt.Sprintf("%s  Signature: %s\n", indent, entry.Signature))
+			}
+			writeEntries(entry.ID, indent+"  ")
+		}
+	}
+	writeEntries("", "")
+
+	if len(outline.Relationships) > 0 {
+		output.WriteString("\n### Relationships\n")
+		for _, rel := range outline.Relationships {
+			output.WriteString(fmt.Sprintf("- %s -[%s]-> %s\n", names[rel.FromID], rel.Type, names[rel.ToID]))
+		}
+	}
+
+	return ToolCallResponse{
+		Content: []ToolContent{{Type: "text", Text: output.String()}},
+	}
+}
+
+// maxCallGraphDepth bounds the traversal requested through codegraph_callgraph
+const maxCallGraphDepth = 10
+
+func (s *CodeGraphMCPServer) handleCallGraphTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
+	functionName, _ := args["function_name"].(string)
+
+	request := query.CallGraphRequest{RootFunction: functionName, MaxDepth: query.DefaultCallGraphDepth, Direction: "outgoing"}
+	if d, ok := args["depth"].(float64); ok {
+		request.MaxDepth = int(d)
+	}
+	if direction, ok := args["direction"].(string); ok {
+		request.Direction = direction
+	}
+
+	graph, err := query.NewAdvancedQueryServiceWithBuilder(s.queryBuilder).BuildCallGraph(ctx, request)
+	if err != nil {
+		return ToolCallResponse{
+			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Error building call graph for '%s': %v", functionName, err)}},
+			IsError: true,
+		}
+	}
+
+	return ToolCallResponse{
+		Content: []ToolContent{{Type: "text", Text: formatCallGraph(graph)}},
+	}
+}
+
+// formatCallGraph renders a call graph as a tree followed by its DOT source
+func formatCallGraph(graph *query.CallGraphResponse) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("## Call graph for '%s' (%s, %d function(s), %d call(s))\n\n",
+		graph.RootFunction, graph.Direction, len(graph.Nodes), len(graph.Edges)))
+	output.WriteString("```\n")
+	output.WriteString(graph.Tree())
+	output.WriteString("```\n\n")
+	output.WriteString("### DOT\n\n```dot\n")
+	output.WriteString(graph.DOT())
+	output.WriteString("```\n")
+	return output.String()
+}
+
+func (s *CodeGraphMCPServer) handleImpactAnalysisTool(ctx context.Context, args map[string]interface{}) ToolCallResponse {
+	functionName, _ := args["function_name"].(string)
+
+	// Resolve the SCIP symbols of every function or method with the name
+	cypher := `
+		MATCH (f)-[:DEFINES]->(s:Symbol)
+		WHERE (f:Function OR f:Method) AND f.name = $name
+		RETURN DISTINCT s.symbol AS symbol
+		ORDER BY symbol
+	`
+	result, err := s.client.ExecuteReadQuery(ctx, cypher, map[string]any{"name": functionName})
+	if err != nil {
+		return ToolCallResponse{
+			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Error resolving function '%s': %v", functionName, err)}},
+			IsError: true,
+		}
+	}
+
+	if len(result) == 0 {
+		return ToolCallResponse{
+			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Function not found: %s", functionName)}},
+			IsError: true,
+		}
+	}
+
+	service := query.NewAdvancedQueryServiceWithBuilder(s.queryBuilder)
+	var impacts []*query.ImpactAnalysisResponse
+	for _, record := range result {
+		symbol := getStringFromRecord(record.AsMap(), "symbol")
+		impact, err := service.AnalyzeImpact(ctx, query.ImpactAnalysisRequest{FunctionSymbol: symbol})
+		if err != nil {
+			return ToolCallResponse{
+				Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Error analyzing impact of '%s': %v", functionName, err)}},
+				IsError: true,
+			}
+		}
+		impacts = append(impacts, impact)
+	}
+
+	return ToolCallResponse{
+		Content: []ToolContent{{Type: "text", Text: formatImpactAnalysis(functionName, impacts)}},
+	}
+}
+
+// formatImpactAnalysis renders the impact of changing each definition of a
+// function, listing affected endpoints as method and path
+func formatImpactAnalysis(functionName string, impacts []*query.ImpactAnalysisResponse) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("## Impact analysis for '%s'\n", functionName))
+
+	for _, impact := range impacts {
+		if len(impacts) > 1 {
+			output.WriteString(fmt.Sprintf("\n### %s\n", impact.FunctionSymbol))
+		}
+
+		output.WriteString(fmt.Sprintf("\nAffected API endpoints (%d):\n", impact.EndpointCount))
+		if len(impact.AffectedEndpoints) == 0 {
+			output.WriteString("- None found\n")
+		}
+		for _, route := range impact.AffectedEndpoints {
+			method := route.Method
+			if method == "" {
+				method = route.Protocol
+			}
+			line := fmt.Sprintf("- **%s %s**", method, route.Path)
+			if route.Description != "" {
+				line += " - " + route.Description
+			}
+			output.WriteString(line + "\n")
+		}
+
+		if len(impact.AffectedFunctions) > 0 {
+			output.WriteString(fmt.Sprintf("\nAffected functions (%d):\n", impact.FunctionCount))
+			for _, fn := range impact.AffectedFunctions {
+				output.WriteString(fmt.Sprintf("- **%s** (%s, depth %d)\n", fn.Name, fn.FilePath, fn.Depth))
+			}
+		}
+	}
+
+	return output.String()
+}
+
 func (s *CodeGraphMCPServer) sendResponse(id interface{}, result interface{}) {
 	response := MCPResponse{
 		JSONRPC: "2.0",
@@ -529,8 +864,22 @@ func (s *CodeGraphMCPServer) sendResponse(id interface{}, result interface{}) {
 		Result:  result,
 	}
 
-	jsonBytes, _ := json.Marshal(response)
-	fmt.Println(string(jsonBytes))
+	s.writeMessage(response)
+}
+
+// writeMessage writes one JSON-RPC message per line to the output. It is the
+// only writer of the output, so stdout carries nothing but protocol frames.
+func (s *CodeGraphMCPServer) writeMessage(response MCPResponse) {
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		s.log().Error("Failed to encode response", "error", err)
+		jsonBytes, _ = json.Marshal(MCPResponse{
+			JSONRPC: "2.0",
+			ID:      response.ID,
+			Error:   &MCPError{Code: -32603, Message: "Internal error"},
+		})
+	}
+	s.output.Write(append(jsonBytes, '\n'))
 }
 
 func (s *CodeGraphMCPServer) sendError(id interface{}, code int, message string) {
@@ -543,8 +892,7 @@ func (s *CodeGraphMCPServer) sendError(id interface{}, code int, message string)
 		},
 	}
 
-	jsonBytes, _ := json.Marshal(response)
-	fmt.Println(string(jsonBytes))
+	s.writeMessage(response)
 }
 
 // Helper functions
@@ -555,6 +903,45 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvIntOrDefault returns the integer value of an environment variable,
+// or defaultValue when it is unset or not a number
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// contentPreviewLength bounds the content shown for nodes without a name
+const contentPreviewLength = 60
+
+// displayLabel names a search result. Code nodes have a name; other nodes fall
+// back to the property identifying their kind: a document's title, a file's
+// path, a symbol's SCIP symbol, or a preview of a document chunk's content.
+func displayLabel(props map[string]interface{}, labels []string) string {
+	if name := getStringProp(props, "name"); name != "" {
+		return name
+	}
+	for _, key := range []string{"title", "path", "displayName", "symbol"} {
+		if value := getStringProp(props, key); value != "" {
+			return value
+		}
+	}
+
+	content := strings.Join(strings.Fields(getStringProp(props, "content")), " ")
+	if content != "" {
+		if runes := []rune(content); len(runes) > contentPreviewLength {
+			content = string(runes[:contentPreviewLength]) + "..."
+		}
+		return content
+	}
+
+	if len(labels) > 0 {
+		return "Unnamed " + labels[0]
+	}
+	return "Unnamed node"
+}
+
 func getStringProp(props map[string]interface{}, key string) string {
 	if val, ok := props[key]; ok {
 		if str, ok := val.(string); ok {
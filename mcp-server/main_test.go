@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/logging"
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callTool sends a tools/call request to a server without a database and
+// returns the decoded response
+func callTool(t *testing.T, params interface{}) MCPResponse {
+	var output bytes.Buffer
+	server := &CodeGraphMCPServer{output: &output}
+
+	server.handleRequest(context.Background(), MCPRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+
+	var response MCPResponse
+	require.NoError(t, json.Unmarshal(output.Bytes(), &response))
+	return response
+}
+
+func TestStdioOutputIsJSONOnly(t *testing.T) {
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"codegraph_search","arguments":{"query":"x","limit":"ten"}}}`,
+		`{"jsonrpc":`,
+	}, "\n")
+
+	var stdout, stderr bytes.Buffer
+	server := &CodeGraphMCPServer{output: &stdout, logger: logging.New(&stderr, true)}
+	server.run(strings.NewReader(input))
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 4)
+	for _, line := range lines {
+		var response MCPResponse
+		require.NoError(t, json.Unmarshal([]byte(line), &response), "stdout line is not JSON: %q", line)
+		assert.Equal(t, "2.0", response.JSONRPC)
+	}
+	assert.Contains(t, stderr.String(), "tool=codegraph_search")
+	assert.Contains(t, stderr.String(), "Failed to parse request")
+}
+
+func TestStdoutCarriesOnlyJSONRPC(t *testing.T) {
+	stdout, stderr := os.Stdout, os.Stderr
+	logWriter, defaultLogger := log.Writer(), slog.Default()
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	require.NoError(t, err)
+	stderrFile, err := os.CreateTemp(t.TempDir(), "stderr")
+	require.NoError(t, err)
+	os.Stdout, os.Stderr = stdoutWriter, stderrFile
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		slog.SetDefault(defaultLogger)
+		log.SetOutput(logWriter)
+	}()
+
+	// Run the stdio transport as main does, with logs from every logger
+	server := &CodeGraphMCPServer{output: os.Stdout, logger: configureLogging(true)}
+	log.Printf("standard logger message")
+	slog.Info("default logger message")
+	server.run(strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"codegraph_get_source"}}`,
+	}, "\n")))
+	stdoutWriter.Close()
+
+	captured, err := io.ReadAll(stdoutReader)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(captured)), "\n")
+	require.Len(t, lines, 2)
+	for i, line := range lines {
+		var response MCPResponse
+		require.NoError(t, json.Unmarshal([]byte(line), &response), "stdout line is not JSON-RPC: %q", line)
+		assert.Equal(t, "2.0", response.JSONRPC)
+		assert.EqualValues(t, i+1, response.ID)
+	}
+
+	logs, err := os.ReadFile(stderrFile.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(logs), "standard logger message")
+	assert.Contains(t, string(logs), "default logger message")
+	assert.Contains(t, string(logs), "tool=codegraph_get_source")
+}
+
+func TestToolArgumentValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]interface{}
+		argument string
+	}{
+		{"search missing query", "codegraph_search", map[string]interface{}{}, "query"},
+		{"search empty query", "codegraph_search", map[string]interface{}{"query": ""}, "query"},
+		{"search query wrong type", "codegraph_search", map[string]interface{}{"query": 42}, "query"},
+		{"search limit wrong type", "codegraph_search", map[string]interface{}{"query": "Index", "limit": "ten"}, "limit"},
+		{"search limit fractional", "codegraph_search", map[string]interface{}{"query": "Index", "limit": 2.5}, "limit"},
+		{"search limit negative", "codegraph_search", map[string]interface{}{"query": "Index", "limit": -1}, "limit"},
+		{"search types wrong type", "codegraph_search", map[string]interface{}{"query": "Index", "types": "Function"}, "types"},
+		{"search types item wrong type", "codegraph_search", map[string]interface{}{"query": "Index", "types": []interface{}{"Function", 1}}, "types[1]"},
+		{"get source missing name", "codegraph_get_source", map[string]interface{}{}, "function_name"},
+		{"get source name wrong type", "codegraph_get_source", map[string]interface{}{"function_name": true}, "function_name"},
+		{"find references missing symbol", "codegraph_find_references", nil, "symbol"},
+		{"find references symbol wrong type", "codegraph_find_references", map[string]interface{}{"symbol": []interface{}{"a"}}, "symbol"},
+		{"find references limit negative", "codegraph_find_references", map[string]interface{}{"symbol": "s", "limit": -5}, "limit"},
+		{"find references offset fractional", "codegraph_find_references", map[string]interface{}{"symbol": "s", "offset": 1.5}, "offset"},
+		{"find references file prefix wrong type", "codegraph_find_references", map[string]interface{}{"symbol": "s", "file_prefix": 7}, "file_prefix"},
+		{"analyze function missing name", "codegraph_analyze_function", map[string]interface{}{"function_name": nil}, "function_name"},
+		{"analyze function name wrong type", "codegraph_analyze_function", map[string]interface{}{"function_name": 3}, "function_name"},
+		{"file outline missing path", "codegraph_file_outline", map[string]interface{}{}, "file_path"},
+		{"file outline path wrong type", "codegraph_file_outline", map[string]interface{}{"file_path": map[string]interface{}{}}, "file_path"},
+		{"callgraph missing name", "codegraph_callgraph", map[string]interface{}{"depth": 2}, "function_name"},
+		{"callgraph depth zero", "codegraph_callgraph", map[string]interface{}{"function_name": "main", "depth": 0}, "depth"},
+		{"callgraph depth too large", "codegraph_callgraph", map[string]interface{}{"function_name": "main", "depth": 50}, "depth"},
+		{"callgraph unknown direction", "codegraph_callgraph", map[string]interface{}{"function_name": "main", "direction": "sideways"}, "direction"},
+		{"impact analysis missing name", "codegraph_impact_analysis", map[string]interface{}{}, "function_name"},
+		{"impact analysis name wrong type", "codegraph_impact_analysis", map[string]interface{}{"function_name": 1}, "function_name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := callTool(t, map[string]interface{}{"name": tt.tool, "arguments": tt.args})
+
+			require.NotNil(t, response.Error, "Expected a JSON-RPC error")
+			assert.Nil(t, response.Result)
+			assert.Equal(t, -32602, response.Error.Code)
+			assert.Contains(t, response.Error.Message, "'"+tt.argument+"'")
+			assert.Equal(t, map[string]interface{}{"argument": tt.argument}, response.Error.Data)
+		})
+	}
+}
+
+func TestToolCallMalformedParams(t *testing.T) {
+	response := callTool(t, map[string]interface{}{"name": "codegraph_search", "arguments": "query=Index"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = callTool(t, map[string]interface{}{"name": "codegraph_unknown", "arguments": map[string]interface{}{}})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32601, response.Error.Code)
+}
+
+func TestToolSchemasDeclareRequiredProperties(t *testing.T) {
+	for _, tool := range toolDefinitions() {
+		properties, ok := tool.InputSchema["properties"].(map[string]interface{})
+		require.True(t, ok, "%s should declare properties", tool.Name)
+
+		required, _ := tool.InputSchema["required"].([]string)
+		for _, name := range required {
+			assert.Contains(t, properties, name, "%s requires undeclared argument %s", tool.Name, name)
+		}
+	}
+}
+
+func TestReferencePageHeader(t *testing.T) {
+	assert.Equal(t, "Found 3 reference(s) for 'sym':\n\n",
+		referencePageHeader("sym", neo4j.ReferencePage{Limit: 50}, 3, 3))
+	assert.Equal(t, "Showing references 1-50 of 120 for 'sym' (use offset 50 for the next page):\n\n",
+		referencePageHeader("sym", neo4j.ReferencePage{Limit: 50}, 50, 120))
+	assert.Equal(t, "Showing references 101-120 of 120 for 'sym':\n\n",
+		referencePageHeader("sym", neo4j.ReferencePage{Limit: 50, Offset: 100}, 20, 120))
+}
+
+func TestDisplayLabel(t *testing.T) {
+	assert.Equal(t, "ProcessOrder", displayLabel(map[string]interface{}{"name": "ProcessOrder", "filePath": "orders.go"}, []string{"Function"}))
+	assert.Equal(t, "Payments", displayLabel(map[string]interface{}{"name": "Payments", "description": "Card payments"}, []string{"Feature"}))
+	assert.Equal(t, "Architecture", displayLabel(map[string]interface{}{"title": "Architecture", "sourceUrl": "docs/arch.md"}, []string{"Document"}))
+	assert.Equal(t, "pkg/orders/orders.go", displayLabel(map[string]interface{}{"path": "pkg/orders/orders.go", "language": "go"}, []string{"File"}))
+	assert.Equal(t, "SaveUser", displayLabel(map[string]interface{}{"symbol": "scip-go gomod app v1 `app`/SaveUser().", "displayName": "SaveUser"}, []string{"Symbol"}))
+
+	// Chunks show their content on one line, cut to the preview length
+	assert.Equal(t, "# Payments The payment service charges customers.",
+		displayLabel(map[string]interface{}{"content": "# Payments\n\nThe payment service charges customers."}, []string{"DocumentChunk"}))
+	long := strings.Repeat("word ", 20)
+	assert.Equal(t, long[:contentPreviewLength]+"...", displayLabel(map[string]interface{}{"content": long}, []string{"DocumentChunk"}))
+
+	assert.Equal(t, "Unnamed Variable", displayLabel(map[string]interface{}{}, []string{"Variable"}))
+}
+
+func TestHTTPTransport(t *testing.T) {
+	server := httptest.NewServer((&CodeGraphMCPServer{}).httpHandler())
+	defer server.Close()
+
+	post := func(body, accept string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/mcp", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// initialize answered as plain JSON
+	resp := post(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`, "application/json")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var initResponse MCPResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&initResponse))
+	assert.EqualValues(t, 1, initResponse.ID)
+	result, _ := initResponse.Result.(map[string]interface{})
+	assert.Equal(t, "2024-11-05", result["protocolVersion"])
+
+	// Notifications are accepted without a reply
+	resp = post(`{"jsonrpc":"2.0","method":"notifications/initialized"}`, "application/json, text/event-stream")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	// tools/list streamed as a Server-Sent Event
+	resp = post(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, "application/json, text/event-stream")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	stream, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	data, ok := strings.CutPrefix(string(stream), "event: message\ndata: ")
+	require.True(t, ok, "Unexpected event stream %q", stream)
+
+	var listResponse MCPResponse
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(data)), &listResponse))
+	assert.EqualValues(t, 2, listResponse.ID)
+	tools, _ := listResponse.Result.(map[string]interface{})["tools"].([]interface{})
+	assert.Len(t, tools, len(toolDefinitions()))
+
+	// Malformed messages and other methods are rejected
+	resp = post(`{"jsonrpc":`, "application/json")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/mcp")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHTTPTransportRejects(t *testing.T) {
+	server := httptest.NewServer((&CodeGraphMCPServer{}).httpHandler())
+	defer server.Close()
+
+	post := func(body, origin string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/mcp", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+	assert.Equal(t, http.StatusOK, post(initialize, "http://localhost:3000"))
+	assert.Equal(t, http.StatusOK, post(initialize, server.URL))
+	assert.Equal(t, http.StatusForbidden, post(initialize, "https://evil.example.com"))
+	assert.Equal(t, http.StatusForbidden, post(initialize, "null"))
+
+	// Bodies over the limit are refused rather than truncated
+	oversized := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"pad":"` +
+		strings.Repeat("x", maxHTTPMessageSize) + `"}}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(oversized, ""))
+}
+
+func TestHTTPTransportShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- (&CodeGraphMCPServer{}).runHTTP(ctx, "127.0.0.1:0")
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "Cancelling the context shuts the server down cleanly")
+	case <-time.After(httpShutdownTimeout):
+		t.Fatal("HTTP server did not shut down")
+	}
+}
+
+func TestFormatImpactAnalysis(t *testing.T) {
+	impact := &query.ImpactAnalysisResponse{
+		FunctionSymbol: "scip-go gomod app v1 `app`/SaveUser().",
+		AffectedEndpoints: []*models.APIRoute{
+			{Method: "POST", Path: "/users", Description: "Create a user"},
+			{Protocol: "grpc", Path: "/users.Users/Get"},
+		},
+		EndpointCount: 2,
+	}
+	assert.Equal(t, "## Impact analysis for 'SaveUser'\n"+
+		"\nAffected API endpoints (2):\n"+
+		"- **POST /users** - Create a user\n"+
+		"- **grpc /users.Users/Get**\n",
+		formatImpactAnalysis("SaveUser", []*query.ImpactAnalysisResponse{impact}))
+
+	// Each definition of an overloaded name gets its own section
+	unused := &query.ImpactAnalysisResponse{FunctionSymbol: "scip-go gomod app v1 `app`/Repo#SaveUser()."}
+	output := formatImpactAnalysis("SaveUser", []*query.ImpactAnalysisResponse{impact, unused})
+	assert.Contains(t, output, "### "+unused.FunctionSymbol+"\n\nAffected API endpoints (0):\n- None found\n")
+}
+
+func TestImpactAnalysisTool(t *testing.T) {
+	client, err := neo4j.NewClient(neo4j.Config{
+		URI:      getEnvOrDefault("TEST_NEO4J_URI", "bolt://localhost:7687"),
+		Username: getEnvOrDefault("TEST_NEO4J_USER", "neo4j"),
+		Password: getEnvOrDefault("TEST_NEO4J_PASS", "password123"),
+		Database: getEnvOrDefault("TEST_NEO4J_DB", "neo4j"),
+	})
+	if err != nil {
+		t.Skipf("Cannot connect to Neo4j: %v (set TEST_NEO4J_URI to run integration tests)", err)
+	}
+	ctx := context.Background()
+	defer func() {
+		client.ExecuteQuery(ctx, "MATCH (n) DETACH DELETE n", nil)
+		client.Close(ctx)
+	}()
+
+	// CreateUserHandler calls SaveUser, which exposes POST /users
+	fixture := `
+		CREATE (save:Function {name: 'SaveUser'})-[:DEFINES]->(:Symbol {symbol: 'app/SaveUser().'})
+		CREATE (handler:Function {name: 'CreateUserHandler'})-[:DEFINES]->(:Symbol {symbol: 'app/CreateUserHandler().'})
+		CREATE (handler)-[:CALLS]->(save)
+		CREATE (save)-[:EXPOSES_API]->(:APIRoute {protocol: 'http', method: 'POST', path: '/users'})
+		CREATE (handler)-[:EXPOSES_API]->(:APIRoute {protocol: 'http', method: 'GET', path: '/health'})
+	`
+	_, err = client.ExecuteQuery(ctx, fixture, nil)
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	server := &CodeGraphMCPServer{client: client, queryBuilder: neo4j.NewQueryBuilder(client), output: &output}
+	server.handleRequest(context.Background(), MCPRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: map[string]interface{}{
+		"name":      "codegraph_impact_analysis",
+		"arguments": map[string]interface{}{"function_name": "CreateUserHandler"},
+	}})
+
+	var response struct {
+		Result ToolCallResponse `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &response))
+	require.False(t, response.Result.IsError, "%v", response.Result.Content)
+	require.Len(t, response.Result.Content, 1)
+	assert.Contains(t, response.Result.Content[0].Text, "- **POST /users**")
+
+	output.Reset()
+	server.handleRequest(context.Background(), MCPRequest{JSONRPC: "2.0", ID: 2, Method: "tools/call", Params: map[string]interface{}{
+		"name":      "codegraph_impact_analysis",
+		"arguments": map[string]interface{}{"function_name": "Missing"},
+	}})
+	require.NoError(t, json.Unmarshal(output.Bytes(), &response))
+	assert.True(t, response.Result.IsError)
+}
+
+func TestFormatCallGraph(t *testing.T) {
+	graph := &query.CallGraphResponse{
+		RootFunction: "main",
+		Direction:    "outgoing",
+		Nodes: map[string]*query.CallGraphNode{
+			"a": {Symbol: "a", Name: "main", FilePath: "main.go", Depth: 0},
+			"b": {Symbol: "b", Name: "run", FilePath: "main.go", Depth: 1},
+		},
+		Edges: []*query.CallGraphEdge{{From: "a", To: "b"}, {From: "b", To: "b", Recursive: true}},
+	}
+
+	output := formatCallGraph(graph)
+	assert.Contains(t, output, "## Call graph for 'main' (outgoing, 2 function(s), 2 call(s))")
+	assert.Contains(t, output, "main (main.go)\n  -> run (main.go)\n    -> run (main.go) [shown above]\n")
+	assert.Contains(t, output, "```dot\ndigraph callgraph {")
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/query"
+)
+
+// Server serves the code graph over a JSON REST API
+type Server struct {
+	queryBuilder *neo4j.QueryBuilder
+	lsp          *query.LSPService
+	analysis     *query.AdvancedQueryService
+	redaction    *models.RedactionConfig
+}
+
+// NewServer creates an API server running its queries through the query
+// builder. Node properties are redacted with the default redaction.
+func NewServer(queryBuilder *neo4j.QueryBuilder) *Server {
+	return &Server{
+		queryBuilder: queryBuilder,
+		lsp:          query.NewLSPServiceWithBuilder(queryBuilder),
+		analysis:     query.NewAdvancedQueryServiceWithBuilder(queryBuilder),
+		redaction:    models.DefaultRedaction(),
+	}
+}
+
+// WithRedaction sets the node properties omitted from responses
+func (s *Server) WithRedaction(config *models.RedactionConfig) *Server {
+	s.redaction = config
+	return s
+}
+
+// Handler returns the API routes:
+//
+//	GET /healthz
+//	GET /search?q=term[&limit=n][&types=Function,Method]
+//	GET /source/{function}
+//	GET /references/{symbol}[?limit=n&offset=n&file_prefix=path]
+//	GET /analyze/{function}
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("GET /source/{function}", s.handleSource)
+	mux.HandleFunc("GET /references/{symbol...}", s.handleReferences) // SCIP symbols contain slashes
+	mux.HandleFunc("GET /analyze/{function}", s.handleAnalyze)
+	return mux
+}
+
+// ErrorResponse is the body of every failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// SourceResponse is the body of GET /source/{function}
+type SourceResponse struct {
+	Function string `json:"function"`
+	Source   string `json:"source"`
+}
+
+// ReferencesResponse is the body of GET /references/{symbol}
+type ReferencesResponse struct {
+	Symbol     string                    `json:"symbol"`
+	References []*models.SymbolReference `json:"references"`
+	Count      int                       `json:"count"`
+	Total      int                       `json:"total"`
+	Limit      int                       `json:"limit"`
+	Offset     int                       `json:"offset"`
+}
+
+// AnalyzeResponse is the body of GET /analyze/{function}
+type AnalyzeResponse struct {
+	Function *query.CallGraphNode   `json:"function"`
+	Callers  []*query.CallGraphNode `json:"callers"`
+	Callees  []*query.CallGraphNode `json:"callees"`
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	term := strings.TrimSpace(params.Get("q"))
+	if term == "" {
+		writeError(w, http.StatusBadRequest, errors.New("query parameter 'q' is required"))
+		return
+	}
+	limit, err := intParam(params, "limit", 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := query.SearchRequest{Query: term, Limit: limit}
+	if types := params.Get("types"); types != "" {
+		req.NodeTypes = strings.Split(types, ",")
+	}
+
+	results, err := s.lsp.Search(r.Context(), req)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	query.RedactSearchResults(results.Results, s.redaction)
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) handleSource(w http.ResponseWriter, r *http.Request) {
+	function := r.PathValue("function")
+
+	source, err := s.queryBuilder.GetFunctionSourceCode(r.Context(), function)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SourceResponse{Function: function, Source: source})
+}
+
+func (s *Server) handleReferences(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	params := r.URL.Query()
+
+	page := neo4j.ReferencePage{FilePrefix: params.Get("file_prefix")}
+	var err error
+	if page.Limit, err = intParam(params, "limit", 50); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if page.Offset, err = intParam(params, "offset", 0); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	references, total, err := s.queryBuilder.FindReferencesPage(r.Context(), symbol, page)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if references == nil {
+		references = []*models.SymbolReference{}
+	}
+	writeJSON(w, http.StatusOK, ReferencesResponse{
+		Symbol:     symbol,
+		References: references,
+		Count:      len(references),
+		Total:      total,
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+	})
+}
+
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	function := r.PathValue("function")
+
+	graph, err := s.analysis.BuildCallGraph(r.Context(), query.CallGraphRequest{
+		RootFunction: function,
+		MaxDepth:     1,
+		Direction:    "both",
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	response := AnalyzeResponse{Callers: []*query.CallGraphNode{}, Callees: []*query.CallGraphNode{}}
+	var rootID string
+	for id, node := range graph.Nodes {
+		if node.Depth == 0 {
+			rootID = id
+			response.Function = node
+		}
+	}
+	for _, edge := range graph.Edges {
+		switch {
+		case edge.To == rootID && graph.Nodes[edge.From] != nil:
+			response.Callers = append(response.Callers, graph.Nodes[edge.From])
+		case edge.From == rootID && graph.Nodes[edge.To] != nil:
+			response.Callees = append(response.Callees, graph.Nodes[edge.To])
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// intParam parses a non-negative integer query parameter
+func intParam(params url.Values, name string, defaultValue int) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("query parameter '%s' must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// statusFor maps query errors to HTTP status codes
+func statusFor(err error) int {
+	if errors.Is(err, neo4j.ErrFunctionNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, neo4j.ErrInvalidIdentifier) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
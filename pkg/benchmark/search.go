@@ -0,0 +1,167 @@
+// Package benchmark measures the latency and recall of graph searches
+package benchmark
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	driver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+// SearchQuery is one query of a search benchmark, optionally with the name of
+// the node expected among the top results
+type SearchQuery struct {
+	Query    string `json:"query"`
+	Expected string `json:"expected,omitempty"`
+}
+
+// LoadSearchQueries reads one query per line. A tab separates the query from
+// the name of its expected result; blank lines and lines starting with # are
+// skipped.
+func LoadSearchQueries(r io.Reader) ([]SearchQuery, error) {
+	var queries []SearchQuery
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		query, expected, _ := strings.Cut(line, "\t")
+		queries = append(queries, SearchQuery{
+			Query:    strings.TrimSpace(query),
+			Expected: strings.TrimSpace(expected),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queries: %w", err)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no queries found")
+	}
+	return queries, nil
+}
+
+// SearchModes maps the benchmarked search modes to the options selecting them
+var SearchModes = map[string][]neo4j.SearchOption{
+	"scan":     nil,
+	"fulltext": {neo4j.UseFulltextIndex()},
+}
+
+// SearchConfig configures a search benchmark
+type SearchConfig struct {
+	Modes []string // Keys of SearchModes
+	Types []string // Node labels searched; full-text mode needs at least one
+	K     int      // Results per query considered for recall
+	Runs  int      // Times each query is run per mode
+}
+
+// ModeResult summarizes the runs of one search mode
+type ModeResult struct {
+	Mode    string        `json:"mode"`
+	Queries int           `json:"queries"`
+	P50     time.Duration `json:"p50"`
+	P95     time.Duration `json:"p95"`
+	P99     time.Duration `json:"p99"`
+	Max     time.Duration `json:"max"`
+	Judged  int           `json:"judged"` // Queries with an expected result
+	Hits    int           `json:"hits"`   // Judged queries whose expected result was in the top k
+	Recall  float64       `json:"recall"` // Hits / Judged, 0 without judged queries
+}
+
+// RunSearch runs every query in every mode and reports latency percentiles and
+// recall@k per mode
+func RunSearch(ctx context.Context, queryBuilder *neo4j.QueryBuilder, queries []SearchQuery, config SearchConfig) ([]*ModeResult, error) {
+	if config.K <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", config.K)
+	}
+	runs := max(config.Runs, 1)
+
+	var results []*ModeResult
+	for _, mode := range config.Modes {
+		opts, ok := SearchModes[mode]
+		if !ok {
+			return nil, fmt.Errorf("unknown search mode %q", mode)
+		}
+
+		result := &ModeResult{Mode: mode, Queries: len(queries)}
+		var latencies []time.Duration
+		for _, query := range queries {
+			var names []string
+			for run := 0; run < runs; run++ {
+				start := time.Now()
+				records, err := queryBuilder.SearchNodes(ctx, query.Query, config.Types, config.K, opts...)
+				latencies = append(latencies, time.Since(start))
+				if err != nil {
+					return nil, fmt.Errorf("%s search for %q failed: %w", mode, query.Query, err)
+				}
+				names = resultNames(records)
+			}
+
+			if query.Expected != "" {
+				result.Judged++
+				if slices.Contains(names, query.Expected) {
+					result.Hits++
+				}
+			}
+		}
+
+		result.P50 = percentile(latencies, 50)
+		result.P95 = percentile(latencies, 95)
+		result.P99 = percentile(latencies, 99)
+		result.Max = percentile(latencies, 100)
+		if result.Judged > 0 {
+			result.Recall = float64(result.Hits) / float64(result.Judged)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// resultNames returns the names of the nodes SearchNodes returned, in order
+func resultNames(records []*driver.Record) []string {
+	var names []string
+	for _, record := range records {
+		value, _ := record.Get("n")
+		if node, ok := value.(dbtype.Node); ok {
+			name, _ := node.Props["name"].(string)
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// percentile returns the nearest-rank percentile of the latencies
+func percentile(latencies []time.Duration, p int) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// PrintComparison prints the results of the modes side by side
+func PrintComparison(w io.Writer, results []*ModeResult, k int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "MODE\tQUERIES\tP50\tP95\tP99\tMAX\tRECALL@%d\n", k)
+	for _, result := range results {
+		recall := "-"
+		if result.Judged > 0 {
+			recall = fmt.Sprintf("%.1f%% (%d/%d)", result.Recall*100, result.Hits, result.Judged)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", result.Mode, result.Queries,
+			result.P50.Round(time.Microsecond), result.P95.Round(time.Microsecond),
+			result.P99.Round(time.Microsecond), result.Max.Round(time.Microsecond), recall)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,196 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/models"
+)
+
+// FeatureExtractor finds the features described in a chunk of a document
+type FeatureExtractor interface {
+	ExtractFeatures(ctx context.Context, chunk, filePath string) ([]*models.Feature, error)
+}
+
+// RuleExtractor extracts features with regular expressions over phrases such
+// as "implements X" or "feature: X" and markdown section headers. It needs no
+// external service and is the default extractor.
+type RuleExtractor struct{}
+
+// ExtractFeatures implements FeatureExtractor
+func (RuleExtractor) ExtractFeatures(ctx context.Context, chunk, filePath string) ([]*models.Feature, error) {
+	return ruleBasedFeatures(chunk, filePath), nil
+}
+
+// DefaultLLMEndpoint and DefaultLLMModel are used when an LLMExtractor is not
+// configured otherwise
+const (
+	DefaultLLMEndpoint = "https://api.openai.com/v1/chat/completions"
+	DefaultLLMModel    = "gpt-4o-mini"
+)
+
+// LLMExtractor extracts features by sending each chunk to an OpenAI-compatible
+// chat completion endpoint and asking for a JSON list of features
+type LLMExtractor struct {
+	Endpoint string
+	APIKey   string
+	Model    string
+	Client   *http.Client
+}
+
+// NewLLMExtractor creates an extractor for the chat completion endpoint.
+// Empty endpoint and model use the defaults.
+func NewLLMExtractor(endpoint, apiKey, model string) *LLMExtractor {
+	if endpoint == "" {
+		endpoint = DefaultLLMEndpoint
+	}
+	if model == "" {
+		model = DefaultLLMModel
+	}
+	return &LLMExtractor{
+		Endpoint: endpoint,
+		APIKey:   apiKey,
+		Model:    model,
+		Client:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// featureExtractionPrompt instructs the model; the response format enforces
+// the shape of its answer
+const featureExtractionPrompt = `You extract product features from technical and business documents.
+List every feature, capability or requirement the text describes. For each give:
+- name: a short title case name
+- description: one sentence describing it
+- status: one of planned, in_progress, implemented, documented
+- priority: one of high, medium, low
+Return an empty list when the text describes no features.`
+
+// featureSchema is the JSON schema of the expected response
+var featureSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"features": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":        map[string]any{"type": "string"},
+					"description": map[string]any{"type": "string"},
+					"status":      map[string]any{"type": "string", "enum": []string{"planned", "in_progress", "implemented", "documented"}},
+					"priority":    map[string]any{"type": "string", "enum": []string{"high", "medium", "low"}},
+				},
+				"required":             []string{"name", "description", "status", "priority"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"features"},
+	"additionalProperties": false,
+}
+
+// ExtractFeatures implements FeatureExtractor
+func (e *LLMExtractor) ExtractFeatures(ctx context.Context, chunk, filePath string) ([]*models.Feature, error) {
+	request := map[string]any{
+		"model": e.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": featureExtractionPrompt},
+			{"role": "user", "content": chunk},
+		},
+		"response_format": map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "features",
+				"strict": true,
+				"schema": featureSchema,
+			},
+		},
+		"temperature": 0,
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extraction request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extraction request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("extraction request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extraction response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("extraction request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return nil, fmt.Errorf("failed to decode extraction response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("extraction response has no choices")
+	}
+
+	var extracted struct {
+		Features []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Status      string `json:"status"`
+			Priority    string `json:"priority"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &extracted); err != nil {
+		return nil, fmt.Errorf("failed to decode extracted features: %w", err)
+	}
+
+	docType := strings.ToLower(inferDocumentType(filePath))
+	var features []*models.Feature
+	for _, f := range extracted.Features {
+		name := strings.TrimSpace(f.Name)
+		if name == "" {
+			continue
+		}
+		feature := &models.Feature{
+			Name:        name,
+			Description: strings.TrimSpace(f.Description),
+			Status:      f.Status,
+			Priority:    f.Priority,
+			Tags:        []string{"llm", docType},
+		}
+		if feature.Status == "" {
+			feature.Status = inferFeatureStatus(chunk, name)
+		}
+		if feature.Priority == "" {
+			feature.Priority = "medium"
+		}
+		features = append(features, feature)
+	}
+	return features, nil
+}
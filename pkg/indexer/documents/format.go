@@ -0,0 +1,129 @@
+package documents
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DocumentFormat reads the structure of a markup language
+type DocumentFormat interface {
+	// Title returns the document title, or "" when the content has none
+	Title(content string) string
+	// Headings returns the section headings of the top three levels, title included
+	Headings(content string) []string
+}
+
+// formatFor returns the format of a document judging by its extension.
+// Unknown formats are read as Markdown.
+func formatFor(filePath string) DocumentFormat {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".rst":
+		return rstFormat{}
+	case ".adoc", ".asciidoc":
+		return asciidocFormat{}
+	default:
+		return markdownFormat{}
+	}
+}
+
+var (
+	markdownTitlePattern   = regexp.MustCompile(`(?m)^#\s+(.+)$`)
+	markdownHeadingPattern = regexp.MustCompile(`(?m)^#{1,3}\s+(.+)$`)
+	asciidocHeadingPattern = regexp.MustCompile(`(?m)^(={1,3})\s+(.+)$`)
+)
+
+// markdownFormat reads ATX headings such as "# Title" and "## Section"
+type markdownFormat struct{}
+
+func (markdownFormat) Title(content string) string {
+	if match := markdownTitlePattern.FindStringSubmatch(content); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return ""
+}
+
+func (markdownFormat) Headings(content string) []string {
+	var headings []string
+	for _, match := range markdownHeadingPattern.FindAllStringSubmatch(content, -1) {
+		headings = append(headings, strings.TrimSpace(match[1]))
+	}
+	return headings
+}
+
+// asciidocFormat reads "= Title", "== Section" and "=== Subsection" headings
+type asciidocFormat struct{}
+
+func (asciidocFormat) Title(content string) string {
+	for _, match := range asciidocHeadingPattern.FindAllStringSubmatch(content, -1) {
+		if match[1] == "=" {
+			return strings.TrimSpace(match[2])
+		}
+	}
+	return ""
+}
+
+func (asciidocFormat) Headings(content string) []string {
+	var headings []string
+	for _, match := range asciidocHeadingPattern.FindAllStringSubmatch(content, -1) {
+		headings = append(headings, strings.TrimSpace(match[2]))
+	}
+	return headings
+}
+
+// rstFormat reads reStructuredText section titles: a line of text underlined,
+// and optionally overlined, by a line of one repeated punctuation character.
+// Heading levels follow the order in which adornment styles first appear.
+type rstFormat struct{}
+
+func (rstFormat) Title(content string) string {
+	if headings := rstHeadings(content, 1); len(headings) > 0 {
+		return headings[0]
+	}
+	return ""
+}
+
+func (rstFormat) Headings(content string) []string {
+	return rstHeadings(content, 3)
+}
+
+// rstHeadings returns the section titles of the first maxLevel levels
+func rstHeadings(content string, maxLevel int) []string {
+	lines := strings.Split(content, "\n")
+	levels := make(map[string]int) // Adornment style to level
+	var headings []string
+
+	for i := 0; i+1 < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		underline := strings.TrimRight(lines[i+1], " \t\r")
+		if text == "" || isRSTAdornment(text) || !isRSTAdornment(underline) ||
+			utf8.RuneCountInString(underline) < utf8.RuneCountInString(text) {
+			continue
+		}
+
+		style := underline[:1]
+		if i > 0 && strings.TrimRight(lines[i-1], " \t\r") == underline {
+			style += "/" // Overlined titles are a separate style
+		}
+		level, ok := levels[style]
+		if !ok {
+			level = len(levels) + 1
+			levels[style] = level
+		}
+		if level <= maxLevel {
+			headings = append(headings, text)
+		}
+		i++ // Skip the underline
+	}
+	return headings
+}
+
+// isRSTAdornment reports whether line is a section adornment: at least two
+// repetitions of a single punctuation character
+func isRSTAdornment(line string) bool {
+	if len(line) < 2 || !strings.ContainsRune(`!"#$%&'()*+,-./:;<=>?@[\]^_`+"`"+`{|}~`, rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
@@ -3,6 +3,7 @@ package documents
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +17,7 @@ import (
 type DocumentIndexer struct {
 	client *neo4j.Client
 	parser *DocumentParser
+	logger *slog.Logger
 }
 
 // NewDocumentIndexer creates a new document indexer
@@ -23,12 +25,29 @@ func NewDocumentIndexer(client *neo4j.Client) *DocumentIndexer {
 	return &DocumentIndexer{
 		client: client,
 		parser: NewDocumentParser(),
+		logger: slog.Default(),
 	}
 }
 
+// SetLogger sets the logger receiving progress and warnings
+func (di *DocumentIndexer) SetLogger(logger *slog.Logger) {
+	di.logger = logger
+	di.parser.SetLogger(logger)
+}
+
+// SetChunkSize sets the maximum number of words per DocumentChunk
+func (di *DocumentIndexer) SetChunkSize(words int) {
+	di.parser.SetChunkSize(words)
+}
+
+// SetFeatureExtractor sets how features are extracted from documents
+func (di *DocumentIndexer) SetFeatureExtractor(extractor FeatureExtractor) {
+	di.parser.SetFeatureExtractor(extractor)
+}
+
 // IndexDocument indexes a single document file
 func (di *DocumentIndexer) IndexDocument(ctx context.Context, filePath string) error {
-	fmt.Printf("Indexing document: %s\n", filePath)
+	di.logger.Info("Indexing document", "path", filePath)
 
 	// Parse the document
 	doc, features, err := di.parser.ParseDocument(filePath)
@@ -36,7 +55,7 @@ func (di *DocumentIndexer) IndexDocument(ctx context.Context, filePath string) e
 		return fmt.Errorf("failed to parse document %s: %w", filePath, err)
 	}
 
-	fmt.Printf("Extracted %d features from document\n", len(features))
+	di.logger.Debug("Extracted features from document", "count", len(features))
 
 	// Create document node
 	docID, err := di.createDocumentNode(ctx, doc)
@@ -44,33 +63,41 @@ func (di *DocumentIndexer) IndexDocument(ctx context.Context, filePath string) e
 		return fmt.Errorf("failed to create document node: %w", err)
 	}
 
+	// Create chunk nodes for document search
+	chunkCount, err := di.indexChunks(ctx, docID, doc)
+	if err != nil {
+		di.logger.Warn("Failed to index document chunks", "path", filePath, "error", err)
+	} else {
+		di.logger.Debug("Created document chunks", "count", chunkCount)
+	}
+
 	// Create feature nodes and relationships
 	for _, feature := range features {
 		featureID, err := di.createFeatureNode(ctx, feature)
 		if err != nil {
-			fmt.Printf("Warning: failed to create feature node for %s: %v\n", feature.Name, err)
+			di.logger.Warn("Failed to create feature node", "feature", feature.Name, "error", err)
 			continue
 		}
 
 		// Create DESCRIBES relationship from document to feature
-		_, err = di.client.CreateRelationship(ctx, docID, featureID, "DESCRIBES", nil)
+		_, err = di.client.MergeRelationship(ctx, docID, featureID, "DESCRIBES", nil)
 		if err != nil {
-			fmt.Printf("Warning: failed to create DESCRIBES relationship: %v\n", err)
+			di.logger.Warn("Failed to create DESCRIBES relationship", "error", err)
 		}
 	}
 
 	// Create relationships to code symbols if they exist
 	if err := di.linkToCodeSymbols(ctx, docID, doc.Content); err != nil {
-		fmt.Printf("Warning: failed to link to code symbols: %v\n", err)
+		di.logger.Warn("Failed to link to code symbols", "error", err)
 	}
 
-	fmt.Printf("Successfully indexed document: %s\n", doc.Title)
+	di.logger.Info("Successfully indexed document", "title", doc.Title)
 	return nil
 }
 
 // IndexDirectory recursively indexes all documents in a directory
 func (di *DocumentIndexer) IndexDirectory(ctx context.Context, dirPath string) error {
-	fmt.Printf("Indexing documents in directory: %s\n", dirPath)
+	di.logger.Info("Indexing documents in directory", "path", dirPath)
 
 	return filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -85,7 +112,7 @@ func (di *DocumentIndexer) IndexDirectory(ctx context.Context, dirPath string) e
 		// Only process document files
 		if di.isDocumentFile(path) {
 			if err := di.IndexDocument(ctx, path); err != nil {
-				fmt.Printf("Warning: failed to index %s: %v\n", path, err)
+				di.logger.Warn("Failed to index document", "path", path, "error", err)
 				// Continue processing other files
 			}
 		}
@@ -108,6 +135,48 @@ func (di *DocumentIndexer) createDocumentNode(ctx context.Context, doc *models.D
 		map[string]any{"sourceUrl": doc.SourceURL}, docProps)
 }
 
+// indexChunks creates a DocumentChunk node with a PART_OF relationship to the
+// document for every chunk of its content. Chunks left over from a longer
+// earlier version of the document are removed.
+func (di *DocumentIndexer) indexChunks(ctx context.Context, docID string, doc *models.Document) (int, error) {
+	chunks := di.parser.ChunkDocument(doc.Content)
+
+	for i, chunk := range chunks {
+		chunkProps := map[string]any{
+			"content":    chunk,
+			"chunkIndex": i,
+			"sourceUrl":  doc.SourceURL,
+			"wordCount":  len(strings.Fields(chunk)),
+		}
+
+		chunkID, err := di.client.MergeNode(ctx, []string{"DocumentChunk"},
+			map[string]any{"sourceUrl": doc.SourceURL, "chunkIndex": i}, chunkProps)
+		if err != nil {
+			return i, fmt.Errorf("failed to create chunk %d: %w", i, err)
+		}
+
+		cypher := `
+			MATCH (c:DocumentChunk), (d:Document)
+			WHERE elementId(c) = $chunkId AND elementId(d) = $docId
+			MERGE (c)-[:PART_OF]->(d)
+		`
+		if _, err := di.client.ExecuteQuery(ctx, cypher, map[string]any{"chunkId": chunkID, "docId": docID}); err != nil {
+			return i, fmt.Errorf("failed to link chunk %d: %w", i, err)
+		}
+	}
+
+	cypher := `
+		MATCH (c:DocumentChunk {sourceUrl: $sourceUrl})
+		WHERE c.chunkIndex >= $count
+		DETACH DELETE c
+	`
+	if _, err := di.client.ExecuteQuery(ctx, cypher, map[string]any{"sourceUrl": doc.SourceURL, "count": len(chunks)}); err != nil {
+		return len(chunks), fmt.Errorf("failed to remove stale chunks: %w", err)
+	}
+
+	return len(chunks), nil
+}
+
 // createFeatureNode creates a Feature node in Neo4j
 func (di *DocumentIndexer) createFeatureNode(ctx context.Context, feature *models.Feature) (string, error) {
 	featureProps := map[string]any{
@@ -148,7 +217,7 @@ func (di *DocumentIndexer) linkToCodeSymbols(ctx context.Context, docID string,
 			recordMap := record.AsMap()
 			if symbolObj, ok := recordMap["s"]; ok {
 				if symbolNode, ok := symbolObj.(dbtype.Node); ok {
-					_, err = di.client.CreateRelationship(ctx, docID, symbolNode.ElementId, "MENTIONS", 
+					_, err = di.client.MergeRelationship(ctx, docID, symbolNode.ElementId, "MENTIONS", 
 						map[string]any{"context": symbolRef})
 					if err != nil {
 						continue // Skip failed relationships
@@ -156,6 +225,10 @@ func (di *DocumentIndexer) linkToCodeSymbols(ctx context.Context, docID string,
 				}
 			}
 		}
+
+		if err := di.linkToCodeNodes(ctx, docID, symbolRef); err != nil {
+			di.logger.Warn("Failed to link mention to code", "mention", symbolRef, "error", err)
+		}
 	}
 
 	return nil
@@ -169,21 +242,101 @@ func (di *DocumentIndexer) isDocumentFile(path string) bool {
 		".txt": true,
 		".rst": true,
 		".adoc": true,
+		".asciidoc": true,
 	}
 	
 	return documentExts[ext]
 }
 
+// linkToCodeNodes creates MENTIONS relationships from a document to the
+// functions, methods and classes named by a code reference such as
+// `IndexProject`, `StaticIndexer.IndexProject` or `IndexProject()`. A qualifier
+// narrows the candidates to those contained in a class or module of that name.
+// When several candidates remain every one is linked, with a confidence of
+// 1/candidates on the edge.
+func (di *DocumentIndexer) linkToCodeNodes(ctx context.Context, docID, symbolRef string) error {
+	ref := strings.TrimSuffix(symbolRef, "()")
+	name, qualifier := ref, ""
+	if i := strings.LastIndex(ref, "."); i >= 0 {
+		qualifier, name = ref[:i], ref[i+1:]
+		qualifier = qualifier[strings.LastIndex(qualifier, ".")+1:]
+	}
+
+	cypher := `
+		MATCH (n)
+		WHERE (n:Function OR n:Method OR n:Class) AND n.name = $name
+		OPTIONAL MATCH (parent)-[:CONTAINS]->(n)
+		RETURN elementId(n) AS id, collect(parent.name) AS parents
+	`
+	results, err := di.client.ExecuteQuery(ctx, cypher, map[string]any{"name": name})
+	if err != nil {
+		return fmt.Errorf("failed to find code nodes named %s: %w", name, err)
+	}
+
+	var all, qualified []string
+	for _, record := range results {
+		recordMap := record.AsMap()
+		id, _ := recordMap["id"].(string)
+		all = append(all, id)
+
+		parents, _ := recordMap["parents"].([]any)
+		for _, parent := range parents {
+			if parent == qualifier {
+				qualified = append(qualified, id)
+				break
+			}
+		}
+	}
+
+	// Fall back to every candidate when the qualifier matches none of them
+	candidates := all
+	if len(qualified) > 0 {
+		candidates = qualified
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	confidence := 1 / float64(len(candidates))
+
+	// Keep the strongest mention when a document references a node repeatedly
+	cypher = `
+		MATCH (d:Document), (n)
+		WHERE elementId(d) = $docId AND elementId(n) = $nodeId
+		MERGE (d)-[r:MENTIONS]->(n)
+		WITH r
+		WHERE r.confidence IS NULL OR r.confidence < $confidence
+		SET r.context = $context, r.confidence = $confidence
+	`
+	for _, nodeID := range candidates {
+		_, err := di.client.ExecuteQuery(ctx, cypher, map[string]any{
+			"docId":      docID,
+			"nodeId":     nodeID,
+			"context":    symbolRef,
+			"confidence": confidence,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to link %s: %w", symbolRef, err)
+		}
+	}
+
+	return nil
+}
+
 // GetDocumentStats returns statistics about indexed documents
 func (di *DocumentIndexer) GetDocumentStats(ctx context.Context) (map[string]any, error) {
 	cypher := `
 		MATCH (d:Document)
 		OPTIONAL MATCH (d)-[:DESCRIBES]->(f:Feature)
 		OPTIONAL MATCH (d)-[:MENTIONS]->(s:Symbol)
+		OPTIONAL MATCH (c:DocumentChunk)-[:PART_OF]->(d)
+		OPTIONAL MATCH (d)-[:MENTIONS]->(code)
+		WHERE code:Function OR code:Method OR code:Class
 		RETURN 
 			count(DISTINCT d) as documentCount,
 			count(DISTINCT f) as featureCount,
+			count(DISTINCT c) as chunkCount,
 			count(DISTINCT s) as mentionedSymbolCount,
+			count(DISTINCT code) as mentionedCodeCount,
 			collect(DISTINCT d.type) as documentTypes
 	`
 	
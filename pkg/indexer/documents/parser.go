@@ -1,25 +1,63 @@
 package documents
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/context-maximiser/code-graph/pkg/similarity"
 )
 
 // DocumentParser handles parsing and feature extraction from documents
 type DocumentParser struct {
-	chunkSize int
+	chunkSize      int
+	featureMatcher similarity.Matcher // Decides when two feature names are duplicates
+	extractor      FeatureExtractor
+	logger         *slog.Logger
 }
 
 // NewDocumentParser creates a new document parser
 func NewDocumentParser() *DocumentParser {
 	return &DocumentParser{
-		chunkSize: 1000, // Default chunk size in words
+		chunkSize:      1000, // Default chunk size in words
+		featureMatcher: similarity.Matcher{Metric: similarity.Exact, Threshold: 1},
+		extractor:      RuleExtractor{},
+		logger:         slog.Default(),
+	}
+}
+
+// SetLogger sets the logger receiving warnings
+func (dp *DocumentParser) SetLogger(logger *slog.Logger) {
+	dp.logger = logger
+}
+
+// SetFeatureExtractor sets how features are extracted from document chunks.
+// Nil restores the rule-based extractor.
+func (dp *DocumentParser) SetFeatureExtractor(extractor FeatureExtractor) {
+	if extractor == nil {
+		extractor = RuleExtractor{}
+	}
+	dp.extractor = extractor
+}
+
+// SetFeatureSimilarity sets how extracted features are deduplicated. Names are
+// normalized for case and whitespace before they are compared. The default
+// merges only features with the same normalized name.
+func (dp *DocumentParser) SetFeatureSimilarity(matcher similarity.Matcher) {
+	dp.featureMatcher = matcher
+}
+
+// SetChunkSize sets the maximum number of words per chunk. Paragraphs are
+// never split, so a single long paragraph may exceed it.
+func (dp *DocumentParser) SetChunkSize(words int) {
+	if words > 0 {
+		dp.chunkSize = words
 	}
 }
 
@@ -32,7 +70,7 @@ func (dp *DocumentParser) ParseDocument(filePath string) (*models.Document, []*m
 
 	// Extract document metadata
 	doc := &models.Document{
-		Title:     extractTitle(string(content)),
+		Title:     extractTitle(string(content), formatFor(filePath)),
 		Type:      inferDocumentType(filePath),
 		SourceURL: filePath,
 		Content:   string(content),
@@ -89,14 +127,19 @@ func (dp *DocumentParser) ChunkDocument(content string) []string {
 	return chunks
 }
 
-// extractFeatures simulates LLM-based feature extraction
-// In a real implementation, this would call an LLM API
+// extractFeatures runs the feature extractor over every chunk of the
+// content. Chunks the extractor fails on fall back to the rule-based extractor.
 func (dp *DocumentParser) extractFeatures(content, filePath string) ([]*models.Feature, error) {
+	ctx := context.Background()
 	chunks := dp.ChunkDocument(content)
 	var allFeatures []*models.Feature
 
 	for i, chunk := range chunks {
-		features := dp.simulateLLMExtraction(chunk, filePath, i)
+		features, err := dp.extractor.ExtractFeatures(ctx, chunk, filePath)
+		if err != nil {
+			dp.logger.Warn("Feature extraction failed, using rules", "chunk", i, "path", filePath, "error", err)
+			features = ruleBasedFeatures(chunk, filePath)
+		}
 		allFeatures = append(allFeatures, features...)
 	}
 
@@ -104,9 +147,9 @@ func (dp *DocumentParser) extractFeatures(content, filePath string) ([]*models.F
 	return dp.deduplicateFeatures(allFeatures), nil
 }
 
-// simulateLLMExtraction simulates what an LLM would extract from a text chunk
-// This is a simplified rule-based approach for demonstration
-func (dp *DocumentParser) simulateLLMExtraction(chunk, filePath string, chunkIndex int) []*models.Feature {
+// ruleBasedFeatures extracts features from a text chunk with regular
+// expressions and markdown section headers
+func ruleBasedFeatures(chunk, filePath string) []*models.Feature {
 	var features []*models.Feature
 
 	// Patterns to identify features
@@ -139,22 +182,17 @@ func (dp *DocumentParser) simulateLLMExtraction(chunk, filePath string, chunkInd
 	}
 
 	// Extract section headers as features (for structured documents)
-	headerPattern := regexp.MustCompile(`(?m)^#{1,3}\s+(.+)$`)
-	headerMatches := headerPattern.FindAllStringSubmatch(chunk, -1)
-	for _, match := range headerMatches {
-		if len(match) > 1 {
-			headerText := strings.TrimSpace(match[1])
-			// Skip very generic headers
-			if !isGenericHeader(headerText) {
-				feature := &models.Feature{
-					Name:        headerText,
-					Description: fmt.Sprintf("Section: %s", headerText),
-					Status:      "documented",
-					Priority:    "medium",
-					Tags:        []string{"section", "documentation"},
-				}
-				features = append(features, feature)
+	for _, headerText := range formatFor(filePath).Headings(chunk) {
+		// Skip very generic headers
+		if !isGenericHeader(headerText) {
+			feature := &models.Feature{
+				Name:        headerText,
+				Description: fmt.Sprintf("Section: %s", headerText),
+				Status:      "documented",
+				Priority:    "medium",
+				Tags:        []string{"section", "documentation"},
 			}
+			features = append(features, feature)
 		}
 	}
 
@@ -163,15 +201,21 @@ func (dp *DocumentParser) simulateLLMExtraction(chunk, filePath string, chunkInd
 
 // deduplicateFeatures removes similar features and merges them
 func (dp *DocumentParser) deduplicateFeatures(features []*models.Feature) []*models.Feature {
-	seen := make(map[string]*models.Feature)
 	var result []*models.Feature
+	var names []string // Normalized names of the kept features
 
 	for _, feature := range features {
-		// Create a normalized key for deduplication
-		normalizedName := strings.ToLower(strings.TrimSpace(feature.Name))
-		normalizedName = regexp.MustCompile(`\s+`).ReplaceAllString(normalizedName, " ")
+		normalizedName := similarity.Normalize(feature.Name)
+
+		var existing *models.Feature
+		for i, name := range names {
+			if dp.featureMatcher.Similar(name, normalizedName) {
+				existing = result[i]
+				break
+			}
+		}
 
-		if existing, exists := seen[normalizedName]; exists {
+		if existing != nil {
 			// Merge with existing feature
 			if len(feature.Description) > len(existing.Description) {
 				existing.Description = feature.Description
@@ -180,7 +224,7 @@ func (dp *DocumentParser) deduplicateFeatures(features []*models.Feature) []*mod
 			existing.Tags = append(existing.Tags, feature.Tags...)
 			existing.Tags = removeDuplicateStrings(existing.Tags)
 		} else {
-			seen[normalizedName] = feature
+			names = append(names, normalizedName)
 			result = append(result, feature)
 		}
 	}
@@ -190,12 +234,10 @@ func (dp *DocumentParser) deduplicateFeatures(features []*models.Feature) []*mod
 
 // Helper functions
 
-func extractTitle(content string) string {
-	// Try to find title from markdown header
-	titlePattern := regexp.MustCompile(`(?m)^#\s+(.+)$`)
-	matches := titlePattern.FindStringSubmatch(content)
-	if len(matches) > 1 {
-		return strings.TrimSpace(matches[1])
+func extractTitle(content string, format DocumentFormat) string {
+	// Try to find title from the format's headings
+	if title := format.Title(content); title != "" {
+		return title
 	}
 
 	// Try to find title from first line
@@ -235,6 +277,8 @@ func inferDocumentType(filePath string) string {
 		return "Text Document"
 	case ".rst":
 		return "reStructuredText"
+	case ".adoc", ".asciidoc":
+		return "AsciiDoc"
 	default:
 		return "Document"
 	}
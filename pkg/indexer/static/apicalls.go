@@ -0,0 +1,191 @@
+package static
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPClientPattern describes a call that sends an HTTP request. Callees use
+// the names reported for calls, e.g. net/http.Get or (*net/http.Client).Post;
+// without type information only package functions written as pkg.Func match.
+type HTTPClientPattern struct {
+	Callee    string
+	Method    string // HTTP method, empty when read from MethodArg
+	MethodArg int    // Argument holding the method when Method is empty
+	URLArg    int    // Argument holding the URL
+}
+
+// DefaultHTTPClientPatterns returns the net/http client calls
+func DefaultHTTPClientPatterns() []HTTPClientPattern {
+	var patterns []HTTPClientPattern
+	for _, receiver := range []string{"net/http.", "(*net/http.Client)."} {
+		patterns = append(patterns,
+			HTTPClientPattern{Callee: receiver + "Get", Method: "GET"},
+			HTTPClientPattern{Callee: receiver + "Head", Method: "HEAD"},
+			HTTPClientPattern{Callee: receiver + "Post", Method: "POST"},
+			HTTPClientPattern{Callee: receiver + "PostForm", Method: "POST"},
+		)
+	}
+	// Requests built for Client.Do
+	return append(patterns,
+		HTTPClientPattern{Callee: "net/http.NewRequest", MethodArg: 0, URLArg: 1},
+		HTTPClientPattern{Callee: "net/http.NewRequestWithContext", MethodArg: 1, URLArg: 2},
+	)
+}
+
+// ParseHTTPClientPattern parses a pattern written as callee:METHOD:urlArg,
+// where METHOD is an HTTP method or $N to read the method from argument N,
+// e.g. "example.com/api.(*Client).Fetch:GET:0"
+func ParseHTTPClientPattern(spec string) (HTTPClientPattern, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return HTTPClientPattern{}, fmt.Errorf("invalid HTTP client pattern %q (use callee:METHOD:urlArg)", spec)
+	}
+
+	urlArg, err := strconv.Atoi(parts[2])
+	if err != nil || urlArg < 0 {
+		return HTTPClientPattern{}, fmt.Errorf("invalid URL argument in HTTP client pattern %q", spec)
+	}
+	pattern := HTTPClientPattern{Callee: parts[0], URLArg: urlArg}
+
+	if arg, ok := strings.CutPrefix(parts[1], "$"); ok {
+		pattern.MethodArg, err = strconv.Atoi(arg)
+		if err != nil || pattern.MethodArg < 0 {
+			return HTTPClientPattern{}, fmt.Errorf("invalid method argument in HTTP client pattern %q", spec)
+		}
+	} else {
+		pattern.Method = strings.ToUpper(parts[1])
+	}
+	return pattern, nil
+}
+
+// SetHTTPClientPatterns enables linking functions to the external endpoints
+// they call through the given client patterns. Nil disables the detection.
+func (si *StaticIndexer) SetHTTPClientPatterns(patterns []HTTPClientPattern) {
+	si.httpClientPatterns = patterns
+}
+
+// apiCall is an outbound HTTP request found in a function body
+type apiCall struct {
+	method string
+	url    string
+	line   int
+}
+
+// outboundAPICalls returns the calls in body matching the configured client
+// patterns whose URL is a string constant
+func (v *astVisitor) outboundAPICalls(body *ast.BlockStmt) []apiCall {
+	if len(v.indexer.httpClientPatterns) == 0 || body == nil {
+		return nil
+	}
+
+	var calls []apiCall
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		pattern, ok := v.matchHTTPClient(call)
+		if !ok || pattern.URLArg >= len(call.Args) {
+			return true
+		}
+
+		rawURL, ok := v.stringConstant(call.Args[pattern.URLArg])
+		if !ok {
+			return true
+		}
+		method := pattern.Method
+		if method == "" {
+			if pattern.MethodArg >= len(call.Args) {
+				return true
+			}
+			if method, ok = v.stringConstant(call.Args[pattern.MethodArg]); !ok {
+				return true
+			}
+		}
+
+		calls = append(calls, apiCall{
+			method: strings.ToUpper(method),
+			url:    rawURL,
+			line:   v.fset.Position(call.Pos()).Line,
+		})
+		return true
+	})
+	return calls
+}
+
+// matchHTTPClient returns the client pattern matching the called function.
+// Without type information callees are written as in source, so patterns
+// also match by the last element of their package path.
+func (v *astVisitor) matchHTTPClient(call *ast.CallExpr) (HTTPClientPattern, bool) {
+	var name string
+	if v.typesInfo != nil {
+		name = v.calleeName(call)
+	} else {
+		name = types.ExprString(call.Fun)
+	}
+
+	for _, pattern := range v.indexer.httpClientPatterns {
+		if name == pattern.Callee {
+			return pattern, true
+		}
+		if v.typesInfo == nil && !strings.HasPrefix(pattern.Callee, "(") {
+			if short := pattern.Callee[strings.LastIndex(pattern.Callee, "/")+1:]; name == short {
+				return pattern, true
+			}
+		}
+	}
+	return HTTPClientPattern{}, false
+}
+
+// stringConstant returns the value of a string literal or constant expression
+func (v *astVisitor) stringConstant(expr ast.Expr) (string, bool) {
+	if v.typesInfo != nil {
+		if tv, ok := v.typesInfo.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			return constant.StringVal(tv.Value), true
+		}
+	}
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if value, err := strconv.Unquote(lit.Value); err == nil {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// indexAPICalls links a function to the external endpoints it calls with
+// CALLS_API relationships
+func (v *astVisitor) indexAPICalls(body *ast.BlockStmt, funcID string) {
+	for _, call := range v.outboundAPICalls(body) {
+		endpointProps := map[string]any{
+			"method":    call.method,
+			"url":       call.url,
+			"createdAt": time.Now().UTC().Unix(),
+			"updatedAt": time.Now().UTC().Unix(),
+		}
+		if parsed, err := url.Parse(call.url); err == nil {
+			endpointProps["host"] = parsed.Host
+			endpointProps["path"] = parsed.Path
+		}
+
+		endpointID, err := v.indexer.mergeNode(v.ctx, []string{"ExternalEndpoint"},
+			map[string]any{"method": call.method, "url": call.url}, endpointProps)
+		if err != nil {
+			v.indexer.logger.Warn("Failed to create external endpoint", "method", call.method, "url", call.url, "error", err)
+			continue
+		}
+
+		_, err = v.indexer.createRelationship(v.ctx, funcID, endpointID, "CALLS_API",
+			map[string]any{"line": call.line})
+		if err != nil {
+			v.indexer.logger.Warn("Failed to link API call", "method", call.method, "url", call.url, "error", err)
+		}
+	}
+}
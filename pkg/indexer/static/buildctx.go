@@ -0,0 +1,119 @@
+package static
+
+import (
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// knownOS and knownArch list the GOOS and GOARCH values recognized in file
+// name suffixes such as _linux.go or _windows_amd64.go
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "arm": true, "arm64": true, "loong64": true,
+		"mips": true, "mipsle": true, "mips64": true, "mips64le": true, "ppc64": true,
+		"ppc64le": true, "riscv64": true, "s390x": true, "wasm": true,
+	}
+)
+
+// SetBuildContext restricts indexing to the files built for goos and goarch
+// with the given build tags. Empty values default to the host platform. Without
+// a build context every Go file is indexed regardless of its constraints.
+func (si *StaticIndexer) SetBuildContext(goos, goarch string, tags []string) {
+	if goos == "" && goarch == "" && len(tags) == 0 {
+		si.buildContext = nil
+		return
+	}
+
+	ctxt := build.Default
+	if goos != "" {
+		ctxt.GOOS = goos
+	}
+	if goarch != "" {
+		ctxt.GOARCH = goarch
+	}
+	ctxt.BuildTags = tags
+	si.buildContext = &ctxt
+	si.packages = nil // Loaded for a different platform
+}
+
+// matchesBuildContext reports whether the file at path is part of the
+// configured build, judging by its name and //go:build line. Files of other
+// languages are not subject to build constraints.
+func (si *StaticIndexer) matchesBuildContext(path string) bool {
+	if si.buildContext == nil || filepath.Ext(path) != ".go" {
+		return true
+	}
+	match, err := si.buildContext.MatchFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		si.logger.Warn("Failed to evaluate build constraints", "path", path, "error", err)
+		return true
+	}
+	return match
+}
+
+// packageLoadEnv returns the environment and build flags that make package
+// loading follow the configured build context
+func (si *StaticIndexer) packageLoadEnv() (env, buildFlags []string) {
+	if si.buildContext == nil {
+		return nil, nil
+	}
+	env = append(env, "GOOS="+si.buildContext.GOOS, "GOARCH="+si.buildContext.GOARCH)
+	if len(si.buildContext.BuildTags) > 0 {
+		buildFlags = []string{"-tags=" + strings.Join(si.buildContext.BuildTags, ",")}
+	}
+	return env, buildFlags
+}
+
+// fileBuildConstraint returns the build constraint a file requires, combining
+// its //go:build line with the GOOS and GOARCH implied by its name, e.g.
+// "linux && amd64". Unconstrained files return "".
+func fileBuildConstraint(file *ast.File, filename string) string {
+	var exprs []constraint.Expr
+
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) {
+				continue
+			}
+			if expr, err := constraint.Parse(comment.Text); err == nil {
+				exprs = append(exprs, expr)
+			}
+		}
+	}
+
+	// Name suffixes: name_GOOS.go, name_GOARCH.go or name_GOOS_GOARCH.go
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(filename), ".go"), "_")
+	if n := len(parts); n >= 3 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		exprs = append(exprs, &constraint.TagExpr{Tag: parts[n-2]}, &constraint.TagExpr{Tag: parts[n-1]})
+	} else if n >= 2 && (knownOS[parts[n-1]] || knownArch[parts[n-1]]) {
+		exprs = append(exprs, &constraint.TagExpr{Tag: parts[n-1]})
+	}
+
+	if len(exprs) == 0 {
+		return ""
+	}
+	combined := exprs[0]
+	for _, expr := range exprs[1:] {
+		combined = &constraint.AndExpr{X: combined, Y: expr}
+	}
+	return combined.String()
+}
+
+// tagBuildConstraint records the file's build constraint on node properties
+func (v *astVisitor) tagBuildConstraint(props map[string]any) {
+	if v.buildConstraint != "" {
+		props["buildConstraint"] = v.buildConstraint
+	}
+}
@@ -0,0 +1,73 @@
+package static
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/context-maximiser/code-graph/pkg/models"
+)
+
+// ModuleMerges returns how many Module nodes were merged into the graph, i.e.
+// how many module lookups missed the cache
+func (si *StaticIndexer) ModuleMerges() int {
+	return si.moduleMerges
+}
+
+// WarmCaches fills the module and symbol caches from the service's existing
+// graph, so incremental runs reuse known nodes instead of merging them again.
+// It returns the number of modules and symbols loaded.
+func (si *StaticIndexer) WarmCaches(ctx context.Context) (modules, symbols int, err error) {
+	params := map[string]any{"service": si.serviceName, "version": si.version}
+
+	moduleCypher := `
+		MATCH (:Service {name: $service})-[:CONTAINS]->(:File {version: $version})<-[:CONTAINS]-(m:Module {version: $version})
+		RETURN DISTINCT elementId(m) AS id, m.name AS name, m.fqn AS fqn
+	`
+
+	result, err := si.client.ExecuteQuery(ctx, moduleCypher, params)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load modules for service %s: %w", si.serviceName, err)
+	}
+
+	for _, record := range result {
+		recordMap := record.AsMap()
+		id, _ := recordMap["id"].(string)
+		fqn, _ := recordMap["fqn"].(string)
+		if id == "" || fqn == "" {
+			continue
+		}
+		name, _ := recordMap["name"].(string)
+		si.packageMap[fqn] = &models.Module{
+			BaseNode: models.BaseNode{ID: id},
+			Name:     name,
+			FQN:      fqn,
+		}
+		modules++
+	}
+
+	symbolCypher := `
+		MATCH (:Service {name: $service})-[:CONTAINS]->(f:File {version: $version})
+		WITH collect(f.path) AS paths
+		MATCH (n)-[:DEFINES]->(sym:Symbol)
+		WHERE n.filePath IN paths AND sym.version = $version
+		RETURN sym.symbol AS symbol, elementId(n) AS id
+	`
+
+	result, err = si.client.ExecuteQuery(ctx, symbolCypher, params)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load symbols for service %s: %w", si.serviceName, err)
+	}
+
+	for _, record := range result {
+		recordMap := record.AsMap()
+		symbol, _ := recordMap["symbol"].(string)
+		id, _ := recordMap["id"].(string)
+		if symbol == "" || id == "" {
+			continue
+		}
+		si.symbolMap[symbol] = id
+		symbols++
+	}
+
+	return modules, symbols, nil
+}
@@ -0,0 +1,373 @@
+package static
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotGitRepository is returned by GitChanges for roots outside a git work tree
+var ErrNotGitRepository = errors.New("not a git repository")
+
+// FileChanges are the files under a root that differ from the graph
+type FileChanges struct {
+	Method  string       `json:"method"`  // How changes were detected, git or hash
+	Changed []string     `json:"changed"` // Added or modified files to reindex
+	Deleted []string     `json:"deleted"` // Removed files whose nodes are deleted
+	Renamed []FileRename `json:"renamed"` // Moved files whose nodes are kept, set by IndexChanges
+}
+
+// FileRename is a file moved to another path without changing its content
+type FileRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GitChanges lists the files to index that differ between ref and the work
+// tree, untracked files included, using git diff. Paths are joined to
+// rootPath the way PlanProject reports them.
+func (si *StaticIndexer) GitChanges(rootPath, ref string) (*FileChanges, error) {
+	if _, err := git(rootPath, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotGitRepository, rootPath)
+	}
+
+	// --relative limits the diff to rootPath and reports paths relative to
+	// it; without renames, a moved file shows up as deleted and added
+	diffed, err := git(rootPath, "diff", "--name-only", "--relative", "--no-renames", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff against %s: %w", ref, err)
+	}
+	untracked, err := git(rootPath, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	changes := &FileChanges{Method: "git"}
+	for _, rel := range append(diffed, untracked...) {
+		path := filepath.Join(rootPath, filepath.FromSlash(rel))
+		if si.inSkippedDir(rel) || !si.isSourceFile(path) {
+			continue
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			changes.Deleted = append(changes.Deleted, path)
+		} else if si.matchesBuildContext(path) {
+			changes.Changed = append(changes.Changed, path)
+		}
+	}
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Deleted)
+	return changes, nil
+}
+
+// git runs a git command in dir and returns its output lines
+func git(dir string, args ...string) ([]string, error) {
+	var stderr bytes.Buffer
+	// Unquoted paths keep non-ASCII file names intact
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "core.quotePath=false"}, args...)...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// inSkippedDir reports whether a slash-separated path relative to the root
+// lies in a directory the walk skips
+func (si *StaticIndexer) inSkippedDir(rel string) bool {
+	dirs := strings.Split(rel, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if si.shouldSkipDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// HashChanges compares the files to index under rootPath with the hashes of
+// the service's File nodes. It walks and hashes the whole tree, so GitChanges
+// is cheaper where git is available.
+func (si *StaticIndexer) HashChanges(ctx context.Context, rootPath string) (*FileChanges, error) {
+	plan, err := si.PlanProject(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := si.client.ExecuteQuery(ctx, `
+		MATCH (:Service {name: $service})-[:CONTAINS]->(f:File {version: $version})
+		RETURN f.path AS path, f.hash AS hash
+	`, map[string]any{"service": si.serviceName, "version": si.version})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file hashes: %w", err)
+	}
+	stored := make(map[string]string)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		path, _ := recordMap["path"].(string)
+		hash, _ := recordMap["hash"].(string)
+		stored[path] = hash
+	}
+
+	changes := &FileChanges{Method: "hash"}
+	planned := make(map[string]bool)
+	for _, path := range plan.Files {
+		planned[path] = true
+		hash, err := si.calculateFileHash(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate file hash: %w", err)
+		}
+		if stored[path] != hash {
+			changes.Changed = append(changes.Changed, path)
+		}
+	}
+	for path := range stored {
+		// Files of the service indexed from other roots are left alone
+		rel, err := filepath.Rel(rootPath, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !planned[path] {
+			changes.Deleted = append(changes.Deleted, path)
+		}
+	}
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Deleted)
+	return changes, nil
+}
+
+// IndexChanges reindexes the files changed since ref and removes the nodes of
+// deleted files, then relinks the project. Outside a git work tree changes
+// are detected by comparing file hashes with the graph instead.
+func (si *StaticIndexer) IndexChanges(ctx context.Context, rootPath, ref string) (*FileChanges, error) {
+	startedAt := time.Now().UTC().Unix()
+	changes, err := si.GitChanges(rootPath, ref)
+	if errors.Is(err, ErrNotGitRepository) {
+		si.logger.Info("Not a git repository, detecting changes by file hash", "path", rootPath)
+		changes, err = si.HashChanges(ctx, rootPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := si.detectRenames(ctx, changes); err != nil {
+		return nil, err
+	}
+	si.logger.Info("Detected changed files", "method", changes.Method,
+		"changed", len(changes.Changed), "deleted", len(changes.Deleted), "renamed", len(changes.Renamed))
+
+	for _, rename := range changes.Renamed {
+		if err := si.renameFile(ctx, rename); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range append(changes.Deleted, changes.Changed...) {
+		if err := si.RemoveFile(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(changes.Changed) > 0 {
+		serviceID, err := si.createServiceNode(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create service node: %w", err)
+		}
+		si.packages = nil // The cached syntax predates the changes
+		if _, err := si.loadPackages(rootPath); err != nil {
+			si.logger.Warn("Type information unavailable", "error", err)
+		}
+		si.indexFiles(ctx, changes.Changed, serviceID)
+
+		if err := si.linkDataFlows(ctx); err != nil {
+			si.logger.Warn("Failed to link data flows", "error", err)
+		}
+		if err := si.linkRoutes(ctx); err != nil {
+			si.logger.Warn("Failed to link API routes", "error", err)
+		}
+		if err := si.linkEmbeddings(ctx); err != nil {
+			si.logger.Warn("Failed to link embedded types", "error", err)
+		}
+		if err := si.indexImplementations(ctx, rootPath); err != nil {
+			si.logger.Warn("Failed to index interface implementations", "error", err)
+		}
+	}
+
+	removed, err := si.SweepOrphanSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if removed > 0 {
+		si.logger.Info("Removed orphaned symbols", "count", removed)
+	}
+
+	if err := recordIndexRun(ctx, si.client, si.serviceName, si.version, "ast", startedAt, len(changes.Changed)); err != nil {
+		si.logger.Warn("Failed to record index run", "error", err)
+	}
+	return changes, nil
+}
+
+// detectRenames moves pairs of a deleted and a changed file with the same
+// content from Deleted and Changed to Renamed. The deleted file's content is
+// the hash stored on its File node.
+func (si *StaticIndexer) detectRenames(ctx context.Context, changes *FileChanges) error {
+	if len(changes.Deleted) == 0 || len(changes.Changed) == 0 {
+		return nil
+	}
+
+	result, err := si.client.ExecuteQuery(ctx, `
+		MATCH (f:File {version: $version}) WHERE f.path IN $paths
+		RETURN f.path AS path, f.hash AS hash
+	`, map[string]any{"paths": changes.Deleted, "version": si.version})
+	if err != nil {
+		return fmt.Errorf("failed to read file hashes: %w", err)
+	}
+	deletedByHash := make(map[string][]string)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		path, _ := recordMap["path"].(string)
+		hash, _ := recordMap["hash"].(string)
+		if hash != "" {
+			deletedByHash[hash] = append(deletedByHash[hash], path)
+		}
+	}
+	if len(deletedByHash) == 0 {
+		return nil
+	}
+	for _, paths := range deletedByHash {
+		sort.Strings(paths)
+	}
+
+	renamedFrom := make(map[string]bool)
+	var changed []string
+	for _, path := range changes.Changed {
+		hash, err := si.calculateFileHash(path)
+		if err != nil {
+			return fmt.Errorf("failed to calculate file hash: %w", err)
+		}
+		// Identical files pair up in path order
+		if candidates := deletedByHash[hash]; len(candidates) > 0 {
+			deletedByHash[hash] = candidates[1:]
+			renamedFrom[candidates[0]] = true
+			changes.Renamed = append(changes.Renamed, FileRename{From: candidates[0], To: path})
+			continue
+		}
+		changed = append(changed, path)
+	}
+
+	var deleted []string
+	for _, path := range changes.Deleted {
+		if !renamedFrom[path] {
+			deleted = append(deleted, path)
+		}
+	}
+	changes.Changed, changes.Deleted = changed, deleted
+	return nil
+}
+
+// renameFile moves a File node and the nodes declared in it to a new path,
+// keeping their identity and any edges added to them. Go files moved to
+// another directory are also moved to the Module of their new package, and
+// their declarations requalified with its import path.
+func (si *StaticIndexer) renameFile(ctx context.Context, rename FileRename) error {
+	// Nodes left at the new path by an earlier index run would collide
+	if err := si.RemoveFile(ctx, rename.To); err != nil {
+		return err
+	}
+
+	cypher := `
+		MATCH (f:File {path: $from, version: $version})
+		SET f.path = $to, f.absolutePath = $to, f.updatedAt = $now
+		WITH f
+		OPTIONAL MATCH (n) WHERE n.filePath = $from AND n.version = $version
+		SET n.filePath = $to,
+		    n.fqn = CASE WHEN n.fqn STARTS WITH $from + ':' THEN $to + substring(n.fqn, size($from)) ELSE n.fqn END
+		RETURN elementId(f) AS fileId
+	`
+	result, err := si.client.ExecuteQuery(ctx, cypher, map[string]any{
+		"from":    rename.From,
+		"to":      rename.To,
+		"version": si.version,
+		"now":     time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename file %s to %s: %w", rename.From, rename.To, err)
+	}
+	if len(result) == 0 || filepath.Ext(rename.To) != ".go" || filepath.Dir(rename.From) == filepath.Dir(rename.To) {
+		return nil
+	}
+	fileID, _ := result[0].AsMap()["fileId"].(string)
+
+	file, err := parser.ParseFile(token.NewFileSet(), rename.To, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return fmt.Errorf("failed to parse file %s: %w", rename.To, err)
+	}
+	packageFQN := si.getPackageFQN(rename.To, file.Name.Name)
+	moduleID, err := si.getOrCreateModule(ctx, file.Name.Name, packageFQN, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to create module node: %w", err)
+	}
+
+	params := map[string]any{"moduleId": moduleID, "path": rename.To, "version": si.version}
+	result, err = si.client.ExecuteQuery(ctx, `
+		MATCH (old:Module)-[:CONTAINS]->(:File {path: $path, version: $version})
+		WHERE elementId(old) <> $moduleId
+		RETURN old.fqn AS fqn LIMIT 1
+	`, params)
+	if err != nil {
+		return fmt.Errorf("failed to find the module of %s: %w", rename.From, err)
+	}
+	var oldPackage string
+	if len(result) > 0 {
+		oldPackage, _ = result[0].AsMap()["fqn"].(string)
+	}
+
+	cypher = `
+		MATCH (m:Module) WHERE elementId(m) = $moduleId
+		MATCH (old:Module)-[r:CONTAINS]->(n)
+		WHERE old <> m AND n.version = $version AND (n.filePath = $path OR (n:File AND n.path = $path))
+		MERGE (m)-[:CONTAINS]->(n)
+		DELETE r
+	`
+	if _, err := si.client.ExecuteQuery(ctx, cypher, params); err != nil {
+		return fmt.Errorf("failed to move file %s to its module: %w", rename.To, err)
+	}
+	if oldPackage == "" || oldPackage == packageFQN {
+		return nil
+	}
+
+	// Declarations are qualified by the import path of their package, and
+	// so are the symbols of types
+	cypher = `
+		MATCH (n)
+		WHERE n.filePath = $path AND n.version = $version
+		  AND (n:Function OR n:Method OR n:Class OR n:Interface)
+		  AND n.fqn STARTS WITH $from + '.'
+		WITH n, n.fqn AS oldFqn, $to + substring(n.fqn, size($from)) AS newFqn
+		SET n.fqn = newFqn
+		WITH n, oldFqn, newFqn
+		OPTIONAL MATCH (n)-[:DEFINES]->(s:Symbol)
+		WHERE s.symbol ENDS WITH ' ' + oldFqn
+		SET s.symbol = left(s.symbol, size(s.symbol) - size(oldFqn)) + newFqn
+	`
+	params = map[string]any{"path": rename.To, "version": si.version, "from": oldPackage, "to": packageFQN}
+	if _, err := si.client.ExecuteQuery(ctx, cypher, params); err != nil {
+		return fmt.Errorf("failed to requalify declarations of %s: %w", rename.To, err)
+	}
+	return nil
+}
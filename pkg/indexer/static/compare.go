@@ -0,0 +1,332 @@
+package static
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/scip/bindings/go/scip"
+)
+
+// IndexedDefinition is a function, method or type declaration as seen by one
+// indexing strategy. Locations refer to the declared name.
+type IndexedDefinition struct {
+	Kind      string // Function, Method or Type
+	Name      string // Methods are qualified by their receiver, e.g. Server.Handle
+	FilePath  string // Relative to the project root
+	Line      int    // 1-based
+	StartByte int
+	EndByte   int
+	Signature string // Normalized signature of functions and methods, empty when unknown
+}
+
+// key identifies a definition across indexers
+func (d IndexedDefinition) key() string {
+	return d.FilePath + ":" + d.Name
+}
+
+// IndexDiscrepancy is a difference between the AST and SCIP view of a definition
+type IndexDiscrepancy struct {
+	Type     string `json:"type"` // missing-in-ast, missing-in-scip, offset or signature
+	FilePath string `json:"filePath"`
+	Name     string `json:"name"`
+	AST      string `json:"ast,omitempty"`
+	SCIP     string `json:"scip,omitempty"`
+}
+
+// IndexComparison summarizes how the AST and SCIP indexers agree
+type IndexComparison struct {
+	ASTCount      int                `json:"astCount"`
+	SCIPCount     int                `json:"scipCount"`
+	Matched       int                `json:"matched"` // Definitions found by both without discrepancies
+	Discrepancies []IndexDiscrepancy `json:"discrepancies"`
+}
+
+// CompareDefinitions matches definitions by file and name and reports those
+// found by only one indexer, differing byte offsets and differing signatures.
+// Signatures are only compared when both sides know them.
+func CompareDefinitions(astDefs, scipDefs []IndexedDefinition) *IndexComparison {
+	comparison := &IndexComparison{
+		ASTCount:      len(astDefs),
+		SCIPCount:     len(scipDefs),
+		Discrepancies: []IndexDiscrepancy{},
+	}
+
+	scipByKey := make(map[string]IndexedDefinition, len(scipDefs))
+	for _, def := range scipDefs {
+		scipByKey[def.key()] = def
+	}
+
+	astKeys := make(map[string]bool, len(astDefs))
+	for _, astDef := range astDefs {
+		astKeys[astDef.key()] = true
+		scipDef, ok := scipByKey[astDef.key()]
+		if !ok {
+			comparison.Discrepancies = append(comparison.Discrepancies, IndexDiscrepancy{
+				Type:     "missing-in-scip",
+				FilePath: astDef.FilePath,
+				Name:     astDef.Name,
+				AST:      fmt.Sprintf("%s at line %d", astDef.Kind, astDef.Line),
+			})
+			continue
+		}
+
+		matched := true
+		if astDef.StartByte != scipDef.StartByte || astDef.EndByte != scipDef.EndByte {
+			matched = false
+			comparison.Discrepancies = append(comparison.Discrepancies, IndexDiscrepancy{
+				Type:     "offset",
+				FilePath: astDef.FilePath,
+				Name:     astDef.Name,
+				AST:      fmt.Sprintf("%d-%d", astDef.StartByte, astDef.EndByte),
+				SCIP:     fmt.Sprintf("%d-%d", scipDef.StartByte, scipDef.EndByte),
+			})
+		}
+		if astDef.Signature != "" && scipDef.Signature != "" && astDef.Signature != scipDef.Signature {
+			matched = false
+			comparison.Discrepancies = append(comparison.Discrepancies, IndexDiscrepancy{
+				Type:     "signature",
+				FilePath: astDef.FilePath,
+				Name:     astDef.Name,
+				AST:      astDef.Signature,
+				SCIP:     scipDef.Signature,
+			})
+		}
+		if matched {
+			comparison.Matched++
+		}
+	}
+
+	for _, scipDef := range scipDefs {
+		if !astKeys[scipDef.key()] {
+			comparison.Discrepancies = append(comparison.Discrepancies, IndexDiscrepancy{
+				Type:     "missing-in-ast",
+				FilePath: scipDef.FilePath,
+				Name:     scipDef.Name,
+				SCIP:     fmt.Sprintf("%s at line %d", scipDef.Kind, scipDef.Line),
+			})
+		}
+	}
+
+	sort.SliceStable(comparison.Discrepancies, func(i, j int) bool {
+		a, b := comparison.Discrepancies[i], comparison.Discrepancies[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		return a.Name < b.Name
+	})
+
+	return comparison
+}
+
+// CollectDefinitions parses the project at rootPath the way IndexProject does
+// and returns its function, method and type declarations without writing to
+// the graph
+func (si *StaticIndexer) CollectDefinitions(rootPath string) ([]IndexedDefinition, error) {
+	files, err := si.CollectGoFiles(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := si.loadPackages(rootPath); err != nil {
+		si.logger.Warn("Type information unavailable", "error", err)
+	}
+
+	var defs []IndexedDefinition
+	for _, path := range files {
+		if si.backendFor(path) != nil {
+			continue // Only Go declarations are compared
+		}
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			relPath = path
+		}
+
+		visitor := &astVisitor{indexer: si, filePath: path}
+		var node *ast.File
+		if typed := si.packages.lookupFile(path); typed != nil {
+			visitor.fset = si.packages.fset
+			visitor.typesInfo = typed.pkg.TypesInfo
+			visitor.typesPkg = typed.pkg.Types
+			node = typed.syntax
+		} else {
+			visitor.fset = token.NewFileSet()
+			node, err = parser.ParseFile(visitor.fset, path, nil, parser.ParseComments)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse file %s: %w", path, err)
+			}
+		}
+
+		define := func(kind, name string, ident *ast.Ident, signature string) {
+			start := visitor.fset.Position(ident.Pos())
+			defs = append(defs, IndexedDefinition{
+				Kind:      kind,
+				Name:      name,
+				FilePath:  filepath.ToSlash(relPath),
+				Line:      start.Line,
+				StartByte: start.Offset,
+				EndByte:   visitor.fset.Position(ident.End()).Offset,
+				Signature: normalizeSignature(signature),
+			})
+		}
+
+		for _, decl := range node.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				signature := visitor.buildFunctionSignature(d)
+				if recv := receiverTypeName(d); recv != "" {
+					define("Method", recv+"."+d.Name.Name, d.Name, signature)
+				} else if d.Recv == nil {
+					define("Function", d.Name.Name, d.Name, signature)
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+						define("Type", typeSpec.Name.Name, typeSpec.Name, "")
+					}
+				}
+			}
+		}
+	}
+
+	return defs, nil
+}
+
+// CollectDefinitions runs the SCIP indexer over the project and returns the
+// function, method and type definitions it reports without writing to the graph
+func (si *SCIPIndexer) CollectDefinitions(projectPath string) ([]IndexedDefinition, error) {
+	parser, err := si.generateSCIPIndex(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SCIP index: %w", err)
+	}
+
+	var defs []IndexedDefinition
+	for _, doc := range parser.index.Documents {
+		signatures := make(map[string]string)
+		for _, info := range doc.Symbols {
+			if info.SignatureDocumentation != nil {
+				signatures[info.Symbol] = info.SignatureDocumentation.Text
+			}
+		}
+
+		for _, occurrence := range doc.Occurrences {
+			if occurrence.SymbolRoles&int32(scip.SymbolRole_Definition) == 0 {
+				continue
+			}
+			symbol, err := scip.ParseSymbol(occurrence.Symbol)
+			if err != nil || len(symbol.Descriptors) == 0 {
+				continue
+			}
+			kind, name, ok := definitionName(symbol.Descriptors)
+			if !ok {
+				continue
+			}
+
+			startLine, startColumn, endLine, endColumn := occurrenceRange(occurrence.Range)
+			startByte, endByte := si.calculateByteOffsets(filepath.Join(projectPath, doc.RelativePath),
+				startLine+1, startColumn, endLine+1, endColumn)
+
+			signature := ""
+			if kind != "Type" {
+				signature = normalizeSignature(signatures[occurrence.Symbol])
+			}
+			defs = append(defs, IndexedDefinition{
+				Kind:      kind,
+				Name:      name,
+				FilePath:  filepath.ToSlash(doc.RelativePath),
+				Line:      startLine + 1,
+				StartByte: startByte,
+				EndByte:   endByte,
+				Signature: signature,
+			})
+		}
+	}
+
+	return defs, nil
+}
+
+// definitionName maps the descriptors of a package-level SCIP symbol to the
+// definition kind and name used by IndexedDefinition. Fields, parameters and
+// other nested symbols are not compared.
+func definitionName(descriptors []*scip.Descriptor) (kind, name string, ok bool) {
+	// Skip the package path
+	var rest []*scip.Descriptor
+	for i, descriptor := range descriptors {
+		if descriptor.Suffix != scip.Descriptor_Namespace && descriptor.Suffix != scip.Descriptor_Package {
+			rest = descriptors[i:]
+			break
+		}
+	}
+
+	switch {
+	case len(rest) == 1 && rest[0].Suffix == scip.Descriptor_Method:
+		return "Function", rest[0].Name, true
+	case len(rest) == 1 && rest[0].Suffix == scip.Descriptor_Type:
+		return "Type", rest[0].Name, true
+	case len(rest) == 2 && rest[0].Suffix == scip.Descriptor_Type && rest[1].Suffix == scip.Descriptor_Method:
+		return "Method", rest[0].Name + "." + rest[1].Name, true
+	}
+	return "", "", false
+}
+
+// occurrenceRange expands a SCIP range, which omits the end line when the
+// range fits on one line. Lines and columns are 0-based.
+func occurrenceRange(r []int32) (startLine, startColumn, endLine, endColumn int) {
+	switch len(r) {
+	case 3:
+		return int(r[0]), int(r[1]), int(r[0]), int(r[2])
+	case 4:
+		return int(r[0]), int(r[1]), int(r[2]), int(r[3])
+	}
+	return 0, 0, 0, 0
+}
+
+// receiverTypeName returns the receiver type of a method, or "" for functions
+func receiverTypeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	recvType := fn.Recv.List[0].Type
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+	}
+	switch t := recvType.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr: // Generic receiver, e.g. List[T]
+		if ident, ok := t.X.(*ast.Ident); ok {
+			return ident.Name
+		}
+	case *ast.IndexListExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			return ident.Name
+		}
+	}
+	return ""
+}
+
+// normalizeSignature drops the func keyword and receiver and collapses
+// whitespace, so "func (s *Server) Handle(id string) error" and
+// "Handle(id string) error" compare equal
+func normalizeSignature(signature string) string {
+	signature = strings.Join(strings.Fields(signature), " ")
+	signature = strings.TrimPrefix(signature, "func ")
+	if strings.HasPrefix(signature, "(") {
+		depth := 0
+		for i, r := range signature {
+			if r == '(' {
+				depth++
+			} else if r == ')' {
+				depth--
+				if depth == 0 {
+					signature = strings.TrimSpace(signature[i+1:])
+					break
+				}
+			}
+		}
+	}
+	return signature
+}
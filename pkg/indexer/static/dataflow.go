@@ -0,0 +1,284 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"time"
+)
+
+// dataFlow is a FLOWS_TO edge found in a function body. Assignments know their
+// target node; argument flows name the callee and are resolved to its
+// parameter once every file has been indexed.
+type dataFlow struct {
+	SourceID string
+	TargetID string
+	ModuleID string
+	Callee   string
+	IsMethod bool
+	Position int
+	Line     int
+	FlowType string // direct or indirect
+}
+
+// flowMap converts the flow to a Cypher parameter map
+func (f dataFlow) flowMap() map[string]any {
+	return map[string]any{
+		"sourceId": f.SourceID,
+		"targetId": f.TargetID,
+		"moduleId": f.ModuleID,
+		"callee":   f.Callee,
+		"isMethod": f.IsMethod,
+		"position": f.Position,
+		"line":     f.Line,
+		"flowType": f.FlowType,
+	}
+}
+
+// indexDataFlow records how values move between the parameters and local
+// variables of a function through assignments and call arguments. Names are
+// resolved in a single function-wide scope, so shadowed variables share a node.
+func (v *astVisitor) indexDataFlow(body *ast.BlockStmt, funcID, signature string, scope map[string]string) {
+	if body == nil {
+		return
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			v.flowAssignment(node.Lhs, node.Rhs, node.Tok == token.DEFINE, funcID, signature, scope)
+		case *ast.DeclStmt:
+			if gen, ok := node.Decl.(*ast.GenDecl); ok && gen.Tok == token.VAR {
+				for _, spec := range gen.Specs {
+					if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+						lhs := make([]ast.Expr, len(valueSpec.Names))
+						for i, name := range valueSpec.Names {
+							lhs[i] = name
+						}
+						v.flowAssignment(lhs, valueSpec.Values, true, funcID, signature, scope)
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			var lhs []ast.Expr
+			for _, expr := range []ast.Expr{node.Key, node.Value} {
+				if expr != nil {
+					lhs = append(lhs, expr)
+				}
+			}
+			v.flowAssignment(lhs, []ast.Expr{node.X}, node.Tok == token.DEFINE, funcID, signature, scope)
+		case *ast.CallExpr:
+			v.flowArguments(node, scope)
+		}
+		return true
+	})
+}
+
+// flowAssignment links the variables read on the right-hand side to the
+// variables assigned on the left. With define set, unknown names on the left
+// become local variables.
+func (v *astVisitor) flowAssignment(lhs, rhs []ast.Expr, define bool, funcID, signature string, scope map[string]string) {
+	// Resolve sources before binding new names so x := x + 1 reads the outer x
+	sources := make([][]string, len(lhs))
+	flowTypes := make([]string, len(lhs))
+	for i := range lhs {
+		var values []ast.Expr
+		if len(lhs) == len(rhs) {
+			values = rhs[i : i+1]
+		} else {
+			values = rhs
+		}
+		flowTypes[i] = "indirect"
+		if len(values) == 1 {
+			if _, ok := values[0].(*ast.Ident); ok {
+				flowTypes[i] = "direct"
+			}
+		}
+		for _, value := range values {
+			sources[i] = append(sources[i], v.resolveIdents(value, scope)...)
+		}
+	}
+
+	for i, expr := range lhs {
+		ident, ok := expr.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			continue
+		}
+
+		targetID, known := scope[ident.Name]
+		if !known {
+			if !define {
+				continue // Assignment to a package-level variable
+			}
+			targetID = v.indexLocalVariable(ident, funcID, signature)
+			if targetID == "" {
+				continue
+			}
+			scope[ident.Name] = targetID
+		}
+
+		line := v.fset.Position(ident.Pos()).Line
+		for _, sourceID := range sources[i] {
+			if sourceID == targetID {
+				continue
+			}
+			v.indexer.addFlow(dataFlow{
+				SourceID: sourceID,
+				TargetID: targetID,
+				Line:     line,
+				FlowType: flowTypes[i],
+			})
+		}
+	}
+}
+
+// flowArguments links variables passed as call arguments to the parameter at
+// the same position of a function or method declared in the same package
+func (v *astVisitor) flowArguments(call *ast.CallExpr, scope map[string]string) {
+	var callee string
+	isMethod := false
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		callee = fun.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := fun.X.(*ast.Ident); ok && v.importNames[pkg.Name] {
+			return // Calls into other packages are out of scope
+		}
+		callee = fun.Sel.Name
+		isMethod = true
+	default:
+		return
+	}
+
+	line := v.fset.Position(call.Pos()).Line
+	for position, arg := range call.Args {
+		flowType := "indirect"
+		if _, ok := arg.(*ast.Ident); ok {
+			flowType = "direct"
+		}
+		for _, sourceID := range v.resolveIdents(arg, scope) {
+			v.indexer.addFlow(dataFlow{
+				SourceID: sourceID,
+				ModuleID: v.moduleID,
+				Callee:   callee,
+				IsMethod: isMethod,
+				Position: position,
+				Line:     line,
+				FlowType: flowType,
+			})
+		}
+	}
+}
+
+// resolveIdents returns the nodes of the in-scope variables read by an
+// expression. Field and composite literal key names are not variable reads.
+func (v *astVisitor) resolveIdents(expr ast.Expr, scope map[string]string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			ast.Inspect(node.X, visit)
+			return false
+		case *ast.KeyValueExpr:
+			ast.Inspect(node.Value, visit)
+			return false
+		case *ast.Ident:
+			if id, ok := scope[node.Name]; ok && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return true
+	}
+	ast.Inspect(expr, visit)
+	return ids
+}
+
+// indexLocalVariable creates a LocalVariable node owned by a function
+func (v *astVisitor) indexLocalVariable(name *ast.Ident, funcID, signature string) string {
+	startPos := v.fset.Position(name.Pos())
+	endPos := v.fset.Position(name.End())
+
+	localProps := map[string]any{
+		"name":      name.Name,
+		"function":  signature,
+		"filePath":  v.filePath,
+		"startLine": startPos.Line,
+		"endLine":   endPos.Line,
+		"version":   v.indexer.version,
+		"createdAt": time.Now().UTC().Unix(),
+		"updatedAt": time.Now().UTC().Unix(),
+	}
+
+	localID, err := v.indexer.mergeNode(v.ctx, []string{"LocalVariable"},
+		map[string]any{"name": name.Name, "filePath": v.filePath, "function": signature, "version": v.indexer.version}, localProps)
+	if err != nil {
+		v.indexer.logger.Warn("Failed to create local variable node", "name", name.Name, "error", err)
+		return ""
+	}
+
+	_, err = v.indexer.mergeRelationship(v.ctx, funcID, localID, "CONTAINS", nil)
+	if err != nil {
+		v.indexer.logger.Warn("Failed to link local variable to function", "error", err)
+	}
+
+	return localID
+}
+
+// addFlow records a FLOWS_TO edge to create once every file is indexed
+func (si *StaticIndexer) addFlow(flow dataFlow) {
+	si.mu.Lock()
+	si.pendingFlows = append(si.pendingFlows, flow)
+	si.mu.Unlock()
+}
+
+// linkDataFlows creates the FLOWS_TO edges collected while indexing. Argument
+// flows only link when the callee name is unique within its package.
+func (si *StaticIndexer) linkDataFlows(ctx context.Context) error {
+	var assignments, arguments []map[string]any
+	for _, flow := range si.pendingFlows {
+		if flow.TargetID != "" {
+			assignments = append(assignments, flow.flowMap())
+		} else {
+			arguments = append(arguments, flow.flowMap())
+		}
+	}
+	si.pendingFlows = nil
+
+	if len(assignments) > 0 {
+		cypher := `
+			UNWIND $flows AS flow
+			MATCH (src), (dst)
+			WHERE elementId(src) = flow.sourceId AND elementId(dst) = flow.targetId
+			MERGE (src)-[r:FLOWS_TO {kind: 'assignment', line: flow.line}]->(dst)
+			SET r.flowType = flow.flowType
+		`
+		if _, err := si.client.ExecuteQuery(ctx, cypher, map[string]any{"flows": assignments}); err != nil {
+			return fmt.Errorf("failed to create assignment flows: %w", err)
+		}
+	}
+
+	if len(arguments) > 0 {
+		cypher := `
+			UNWIND $flows AS flow
+			MATCH (src) WHERE elementId(src) = flow.sourceId
+			MATCH (m:Module)-[:CONTAINS]->(callee)
+			WHERE elementId(m) = flow.moduleId AND callee.name = flow.callee
+			  AND ((flow.isMethod AND callee:Method) OR (NOT flow.isMethod AND callee:Function))
+			WITH flow, src, collect(DISTINCT callee) AS callees
+			WHERE size(callees) = 1
+			WITH flow, src, callees[0] AS callee
+			MATCH (callee)-[:CONTAINS]->(param:Parameter {position: flow.position})
+			MERGE (src)-[r:FLOWS_TO {kind: 'argument', line: flow.line}]->(param)
+			SET r.flowType = flow.flowType, r.callee = flow.callee
+		`
+		if _, err := si.client.ExecuteQuery(ctx, cypher, map[string]any{"flows": arguments}); err != nil {
+			return fmt.Errorf("failed to create argument flows: %w", err)
+		}
+	}
+
+	return nil
+}
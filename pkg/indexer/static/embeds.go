@@ -0,0 +1,94 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+)
+
+// embedding is a type embedded in a struct, linked with EMBEDS once every
+// file is indexed so that types declared later can be found
+type embedding struct {
+	StructID string
+	TypeFQN  string // Package import path and type name, the fqn of Class and Interface nodes
+	Pointer  bool   // Embedded through a pointer, e.g. *Base
+}
+
+// addEmbedding records an embedded type to link once every file is indexed
+func (si *StaticIndexer) addEmbedding(embed embedding) {
+	si.mu.Lock()
+	si.pendingEmbeds = append(si.pendingEmbeds, embed)
+	si.mu.Unlock()
+}
+
+// embeddedTypeFQN returns the fqn of the type of an embedded field, e.g.
+// example.com/app/models.Base for models.Base, *models.Base or
+// models.Base[T], and whether
+// it is embedded through a pointer. It returns "" for types without a
+// Class or Interface node, like embedded type parameters.
+func (v *astVisitor) embeddedTypeFQN(expr ast.Expr) (string, bool) {
+	pointer := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, pointer = star.X, true
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+
+	// Type information knows the declaring package's import path
+	if v.typesInfo != nil {
+		if named, ok := v.typesInfo.TypeOf(expr).(*types.Named); ok && named.Obj().Pkg() != nil {
+			return fmt.Sprintf("%s.%s", named.Obj().Pkg().Path(), named.Obj().Name()), pointer
+		}
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return fmt.Sprintf("%s.%s", v.packageFQN, t.Name), pointer
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		for importPath, name := range v.importPaths {
+			if name == pkg.Name {
+				return fmt.Sprintf("%s.%s", importPath, t.Sel.Name), pointer
+			}
+		}
+	}
+	return "", pointer
+}
+
+// linkEmbeddings creates the EMBEDS edges collected while indexing. Embedded
+// types without a Class or Interface node in the graph, such as types of
+// packages that were not indexed, are not linked.
+func (si *StaticIndexer) linkEmbeddings(ctx context.Context) error {
+	var embeds []map[string]any
+	for _, embed := range si.pendingEmbeds {
+		embeds = append(embeds, map[string]any{
+			"structId": embed.StructID,
+			"fqn":      embed.TypeFQN,
+			"pointer":  embed.Pointer,
+		})
+	}
+	si.pendingEmbeds = nil
+	if len(embeds) == 0 {
+		return nil
+	}
+
+	cypher := `
+		UNWIND $embeds AS embed
+		MATCH (s:Class) WHERE elementId(s) = embed.structId
+		MATCH (t) WHERE (t:Class OR t:Interface) AND t.fqn = embed.fqn AND t.version = $version
+		MERGE (s)-[r:EMBEDS]->(t)
+		SET r.pointer = embed.pointer
+	`
+	if _, err := si.client.ExecuteQuery(ctx, cypher, map[string]any{"embeds": embeds, "version": si.version}); err != nil {
+		return fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package static
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// neverFailing lists error-returning functions whose error is conventionally
+// ignored. Method entries cover every method of the receiver type.
+var neverFailing = []string{
+	"fmt.Print",
+	"fmt.Printf",
+	"fmt.Println",
+	"(*bytes.Buffer).",
+	"(*strings.Builder).",
+}
+
+// uncheckedErrorCalls returns the calls in a function body whose error result
+// is discarded, either by using the call as a statement or by assigning the
+// error to the blank identifier. Deferred and go calls are not reported.
+// Each entry reads "<callee> at line <n>". Without type information the
+// error results cannot be identified and nil is returned.
+func (v *astVisitor) uncheckedErrorCalls(body *ast.BlockStmt) []string {
+	if v.typesInfo == nil || body == nil {
+		return nil
+	}
+
+	unchecked := []string{}
+	report := func(call *ast.CallExpr) {
+		unchecked = append(unchecked, fmt.Sprintf("%s at line %d",
+			v.calleeName(call), v.fset.Position(call.Pos()).Line))
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.ExprStmt:
+			if call, ok := stmt.X.(*ast.CallExpr); ok && len(v.errorResults(call)) > 0 {
+				report(call)
+			}
+		case *ast.AssignStmt:
+			if len(stmt.Rhs) != 1 {
+				return true
+			}
+			call, ok := stmt.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			for _, i := range v.errorResults(call) {
+				if i >= len(stmt.Lhs) {
+					break
+				}
+				if ident, ok := stmt.Lhs[i].(*ast.Ident); ok && ident.Name == "_" {
+					report(call)
+					break
+				}
+			}
+		}
+		return true
+	})
+
+	return unchecked
+}
+
+// errorResults returns the positions of the error results of a call
+func (v *astVisitor) errorResults(call *ast.CallExpr) []int {
+	sig, ok := v.typesInfo.TypeOf(call.Fun).(*types.Signature)
+	if !ok {
+		return nil // Conversions and builtins
+	}
+
+	name := v.calleeName(call)
+	for _, prefix := range neverFailing {
+		if name == prefix || (strings.HasSuffix(prefix, ".") && strings.HasPrefix(name, prefix)) {
+			return nil
+		}
+	}
+
+	errorType := types.Universe.Lookup("error").Type()
+	var indexes []int
+	for i := 0; i < sig.Results().Len(); i++ {
+		if types.Identical(sig.Results().At(i).Type(), errorType) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// calleeName returns the qualified name of the called function, e.g.
+// os.Remove or (*os.File).Close, falling back to the call expression
+func (v *astVisitor) calleeName(call *ast.CallExpr) string {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	}
+	if ident != nil {
+		if fn, ok := v.typesInfo.Uses[ident].(*types.Func); ok {
+			return fn.FullName()
+		}
+	}
+	return types.ExprString(call.Fun)
+}
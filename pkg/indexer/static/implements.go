@@ -0,0 +1,198 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"strings"
+	"time"
+)
+
+// Implementation records that a struct type satisfies an interface
+type Implementation struct {
+	ClassFQN     string
+	InterfaceFQN string
+	PointerOnly  bool // Only *T satisfies the interface
+	Stdlib       bool // The interface is declared in the standard library
+}
+
+// SetIncludeStdlibInterfaces controls whether implementations of standard
+// library interfaces (io.Reader, fmt.Stringer, ...) are recorded
+func (si *StaticIndexer) SetIncludeStdlibInterfaces(include bool) {
+	si.includeStdlibInterfaces = include
+}
+
+// ResolveImplementations type-checks the project at rootPath and returns the
+// struct types that satisfy each non-empty interface. Package type information
+// already loaded for the project is reused.
+func (si *StaticIndexer) ResolveImplementations(rootPath string) ([]Implementation, error) {
+	cache, err := si.loadPackages(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := cache.pkgs
+
+	var structs, interfaces []*types.TypeName
+	stdlib := make(map[*types.TypeName]bool)
+	seenImports := make(map[string]bool)
+	for _, pkg := range pkgs {
+		seenImports[pkg.PkgPath] = true // Project packages are never stdlib
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+
+		s, i := collectTypeNames(pkg.Types, false)
+		structs = append(structs, s...)
+		interfaces = append(interfaces, i...)
+
+		if !si.includeStdlibInterfaces {
+			continue
+		}
+		for _, imp := range pkg.Types.Imports() {
+			if seenImports[imp.Path()] || !isStdlibPackage(imp.Path()) {
+				continue
+			}
+			seenImports[imp.Path()] = true
+
+			_, i := collectTypeNames(imp, true)
+			for _, iface := range i {
+				stdlib[iface] = true
+			}
+			interfaces = append(interfaces, i...)
+		}
+	}
+
+	var implementations []Implementation
+	for _, structName := range structs {
+		structType := structName.Type()
+		ptrType := types.NewPointer(structType)
+
+		for _, ifaceName := range interfaces {
+			iface := ifaceName.Type().Underlying().(*types.Interface)
+
+			valueImpl := types.Implements(structType, iface)
+			if !valueImpl && !types.Implements(ptrType, iface) {
+				continue
+			}
+
+			implementations = append(implementations, Implementation{
+				ClassFQN:     typeFQN(structName),
+				InterfaceFQN: typeFQN(ifaceName),
+				PointerOnly:  !valueImpl,
+				Stdlib:       stdlib[ifaceName],
+			})
+		}
+	}
+
+	return implementations, nil
+}
+
+// indexImplementations creates IMPLEMENTS relationships between Class and Interface nodes
+func (si *StaticIndexer) indexImplementations(ctx context.Context, rootPath string) error {
+	implementations, err := si.ResolveImplementations(rootPath)
+	if err != nil {
+		return err
+	}
+
+	// Standard library interfaces have no declaration in the project, so
+	// create their nodes on demand
+	for _, impl := range implementations {
+		if !impl.Stdlib {
+			continue
+		}
+		name := impl.InterfaceFQN[strings.LastIndex(impl.InterfaceFQN, ".")+1:]
+		interfaceProps := map[string]any{
+			"name":      name,
+			"fqn":       impl.InterfaceFQN,
+			"isStdlib":  true,
+			"version":   si.version,
+			"createdAt": time.Now().UTC().Unix(),
+			"updatedAt": time.Now().UTC().Unix(),
+		}
+		if _, err := si.client.MergeNode(ctx, []string{"Interface"},
+			map[string]any{"fqn": impl.InterfaceFQN, "version": si.version}, interfaceProps); err != nil {
+			return fmt.Errorf("failed to create interface node %s: %w", impl.InterfaceFQN, err)
+		}
+	}
+
+	var pairs []map[string]any
+	for _, impl := range implementations {
+		pairs = append(pairs, map[string]any{
+			"classFqn":     impl.ClassFQN,
+			"interfaceFqn": impl.InterfaceFQN,
+			"pointerOnly":  impl.PointerOnly,
+		})
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	// Only types declared in the service's files are linked; standard
+	// library interfaces belong to no service
+	cypher := `
+		UNWIND $pairs AS pair
+		MATCH (c:Class {fqn: pair.classFqn, version: $version})
+		WHERE EXISTS {
+			MATCH (:Service {name: $service})-[:CONTAINS]->(:File {path: c.filePath, version: $version})
+		}
+		MATCH (i:Interface {fqn: pair.interfaceFqn, version: $version})
+		WHERE i.isStdlib = true OR EXISTS {
+			MATCH (:Service {name: $service})-[:CONTAINS]->(:File {path: i.filePath, version: $version})
+		}
+		MERGE (c)-[r:IMPLEMENTS]->(i)
+		SET r.pointerReceiver = pair.pointerOnly
+		RETURN count(r) AS created
+	`
+
+	params := map[string]any{"pairs": pairs, "service": si.serviceName, "version": si.version}
+	if _, err := si.client.ExecuteQuery(ctx, cypher, params); err != nil {
+		return fmt.Errorf("failed to create IMPLEMENTS relationships: %w", err)
+	}
+
+	si.logger.Info("Linked interface implementations", "count", len(pairs))
+	return nil
+}
+
+// collectTypeNames returns the named struct and non-empty interface types
+// declared at package scope. Generic types are skipped.
+func collectTypeNames(pkg *types.Package, exportedOnly bool) (structs, interfaces []*types.TypeName) {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() || (exportedOnly && !typeName.Exported()) {
+			continue
+		}
+
+		named, ok := typeName.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+
+		switch underlying := named.Underlying().(type) {
+		case *types.Struct:
+			structs = append(structs, typeName)
+		case *types.Interface:
+			// Every type satisfies the empty interface and constraint
+			// interfaces cannot be implemented
+			if underlying.NumMethods() > 0 && underlying.IsMethodSet() {
+				interfaces = append(interfaces, typeName)
+			}
+		}
+	}
+	return structs, interfaces
+}
+
+// typeFQN returns the fully qualified name used for Class and Interface
+// nodes, the package import path and the type name
+func typeFQN(typeName *types.TypeName) string {
+	return fmt.Sprintf("%s.%s", typeName.Pkg().Path(), typeName.Name())
+}
+
+// isStdlibPackage reports whether the import path belongs to the standard library
+func isStdlibPackage(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,66 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// indexImports links the module of a file to the packages the file imports
+// with IMPORTS relationships. Imported packages outside the file's Go module
+// get Module nodes flagged isExternal, and standard library packages isStdlib;
+// packages of the project keep the Module node their own files create.
+func (si *StaticIndexer) indexImports(ctx context.Context, filePath, moduleID string, imports []*ast.ImportSpec) error {
+	if len(imports) == 0 {
+		return nil
+	}
+
+	var project string
+	if dir, err := filepath.Abs(filepath.Dir(filePath)); err == nil {
+		if mod := si.findGoModule(dir); mod != nil {
+			project = mod.path
+		}
+	}
+
+	var targets []map[string]any
+	for _, imp := range imports {
+		importPath := strings.Trim(imp.Path.Value, `"`)
+		internal := project != "" && (importPath == project || strings.HasPrefix(importPath, project+"/"))
+		targets = append(targets, map[string]any{
+			"fqn":        importPath,
+			"name":       path.Base(importPath),
+			"isExternal": !internal,
+			"isStdlib":   isStdlibPackage(importPath),
+		})
+	}
+
+	// Module nodes are merged under the same lock as getOrCreateModule so
+	// concurrent files do not race on them
+	si.moduleMu.Lock()
+	defer si.moduleMu.Unlock()
+
+	cypher := `
+		MATCH (m:Module) WHERE elementId(m) = $moduleId
+		UNWIND $imports AS imp
+		MERGE (target:Module {fqn: imp.fqn, version: $version})
+		ON CREATE SET target.name = imp.name, target.type = 'package', target.isExported = true,
+			target.createdAt = $now, target.updatedAt = $now
+		SET target.isExternal = imp.isExternal, target.isStdlib = imp.isStdlib
+		MERGE (m)-[:IMPORTS]->(target)
+	`
+	params := map[string]any{
+		"moduleId": moduleID,
+		"imports":  targets,
+		"version":  si.version,
+		"now":      time.Now().UTC().Unix(),
+	}
+	defer since(&si.writes.relationships, time.Now())
+	if _, err := si.client.ExecuteQuery(ctx, cypher, params); err != nil {
+		return fmt.Errorf("failed to link imports: %w", err)
+	}
+	return nil
+}
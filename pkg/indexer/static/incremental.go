@@ -0,0 +1,211 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/context-maximiser/code-graph/pkg/models"
+)
+
+// ReindexFile replaces the graph contents of a single file. Deleted files are
+// removed from the graph. Symbols left without definitions or references are
+// swept afterwards.
+func (si *StaticIndexer) ReindexFile(ctx context.Context, filePath string) error {
+	if err := si.RemoveFile(ctx, filePath); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filePath); err == nil {
+		serviceID, err := si.createServiceNode(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create service node: %w", err)
+		}
+		si.packages = nil // The cached syntax predates the change
+		if err := si.indexFile(ctx, filePath, serviceID); err != nil {
+			return fmt.Errorf("failed to index file %s: %w", filePath, err)
+		}
+		if err := si.linkDataFlows(ctx); err != nil {
+			return err
+		}
+		if err := si.linkRoutes(ctx); err != nil {
+			return err
+		}
+		if err := si.linkEmbeddings(ctx); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat file %s: %w", filePath, err)
+	}
+
+	removed, err := si.SweepOrphanSymbols(ctx)
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		si.logger.Info("Removed orphaned symbols", "count", removed)
+	}
+
+	return nil
+}
+
+// RemoveFile deletes the indexer version's File node and the declaration and
+// reference nodes it owns. Only nodes whose filePath is the file are removed,
+// so Symbol nodes shared with other files are left in place; use
+// SweepOrphanSymbols to clean up symbols that lost their last definition or
+// reference.
+func (si *StaticIndexer) RemoveFile(ctx context.Context, filePath string) error {
+	cypher := `
+		OPTIONAL MATCH (n)
+		WHERE n.filePath = $path AND n.version = $version
+		  AND (n:Function OR n:Method OR n:Class OR n:Interface OR n:Variable OR n:LocalVariable OR n:Parameter OR n:Reference)
+		WITH collect(n) AS owned
+		WITH owned, [n IN owned | elementId(n)] AS ownedIds,
+		     reduce(ids = [], n IN owned | ids + [(n)-[:DEFINES|REFERENCES]-(sym:Symbol) | elementId(sym)]) AS symbolIds
+		FOREACH (n IN owned | DETACH DELETE n)
+		WITH ownedIds, symbolIds
+		OPTIONAL MATCH (f:File {path: $path, version: $version})
+		DETACH DELETE f
+		RETURN ownedIds, symbolIds
+	`
+
+	result, err := si.client.ExecuteQuery(ctx, cypher, map[string]any{"path": filePath, "version": si.version})
+	if err != nil {
+		return fmt.Errorf("failed to remove file %s: %w", filePath, err)
+	}
+
+	// Drop cached symbol mappings that pointed at removed nodes
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	removed := make(map[string]bool)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		if ids, ok := recordMap["ownedIds"].([]any); ok {
+			for _, id := range ids {
+				if s, ok := id.(string); ok {
+					removed[s] = true
+				}
+			}
+		}
+		if ids, ok := recordMap["symbolIds"].([]any); ok {
+			for _, id := range ids {
+				if s, ok := id.(string); ok {
+					si.sweepSymbols = append(si.sweepSymbols, s)
+				}
+			}
+		}
+	}
+	for symbol, nodeID := range si.symbolMap {
+		if removed[nodeID] {
+			delete(si.symbolMap, symbol)
+		}
+	}
+
+	return nil
+}
+
+// SweepOrphanSymbols deletes the Symbol nodes that lost their last DEFINES or
+// REFERENCES relationship to the nodes RemoveFile removed, and returns how
+// many were removed. Symbols of other services are left alone.
+func (si *StaticIndexer) SweepOrphanSymbols(ctx context.Context) (int, error) {
+	si.mu.Lock()
+	ids := si.sweepSymbols
+	si.sweepSymbols = nil
+	si.mu.Unlock()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	cypher := `
+		MATCH (s:Symbol)
+		WHERE elementId(s) IN $ids AND NOT (s)-[:DEFINES|REFERENCES]-()
+		DETACH DELETE s
+		RETURN count(s) AS removed
+	`
+
+	result, err := si.client.ExecuteQuery(ctx, cypher, map[string]any{"ids": ids})
+	if err != nil {
+		si.mu.Lock()
+		si.sweepSymbols = append(si.sweepSymbols, ids...)
+		si.mu.Unlock()
+		return 0, fmt.Errorf("failed to sweep orphaned symbols: %w", err)
+	}
+
+	if len(result) == 0 {
+		return 0, nil
+	}
+	removed, _ := result[0].AsMap()["removed"].(int64)
+	return int(removed), nil
+}
+
+// serviceNodesCypher collects every node owned by the service into `targets`:
+// the Service itself, its files, the declarations in those files, its index
+// runs, everything reachable from them via CONTAINS and the symbols only they
+// define or reference. Modules are shared by every service indexing the same
+// package at the same version, so a module is only owned once all it contains
+// is.
+const serviceNodesCypher = `
+	MATCH (s:Service {name: $service})
+	OPTIONAL MATCH (s)-[:CONTAINS*]->(f:File)
+	WITH s, collect(DISTINCT f) AS files
+	OPTIONAL MATCH (m:Module)-[:CONTAINS]->(f:File)
+	WHERE f IN files
+	WITH s, files, collect(DISTINCT m) AS modules
+	OPTIONAL MATCH (m:Module)-[:CONTAINS]->(d)
+	WHERE m IN modules AND NOT d:File
+	  AND any(f IN files WHERE f.path = d.filePath AND f.version = d.version)
+	WITH s, files, modules, collect(DISTINCT d) AS declared
+	OPTIONAL MATCH (run:IndexRun {service: $service})
+	WITH s, files, modules, declared, collect(run) AS runs
+	UNWIND [s] + files + declared + runs AS root
+	OPTIONAL MATCH (root)-[:CONTAINS*0..]->(n)
+	WITH modules, collect(DISTINCT n) AS contained
+	WITH contained + [m IN modules WHERE all(c IN [(m)-[:CONTAINS]->(x) | x] WHERE c IN contained)] AS owned
+	OPTIONAL MATCH (sym:Symbol)-[:DEFINES|REFERENCES]-(owner)
+	WHERE owner IN owned
+	WITH owned, collect(DISTINCT sym) AS candidates
+	WITH owned + [sym IN candidates WHERE all(o IN [(sym)-[:DEFINES|REFERENCES]-(x) | x] WHERE o IN owned)] AS targets
+`
+
+// CleanService deletes the indexer's service and every node belonging to it,
+// returning the number of nodes per label. With dryRun nothing is deleted and
+// the counts describe what would be removed.
+func (si *StaticIndexer) CleanService(ctx context.Context, dryRun bool) (map[string]int, error) {
+	params := map[string]any{"service": si.serviceName}
+
+	countCypher := serviceNodesCypher + `
+		UNWIND targets AS t
+		RETURN labels(t)[0] AS label, count(t) AS count
+	`
+
+	result, err := si.client.ExecuteQuery(ctx, countCypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect nodes for service %s: %w", si.serviceName, err)
+	}
+
+	counts := make(map[string]int)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		label, _ := recordMap["label"].(string)
+		count, _ := recordMap["count"].(int64)
+		counts[label] += int(count)
+	}
+
+	if dryRun || len(counts) == 0 {
+		return counts, nil
+	}
+
+	deleteCypher := serviceNodesCypher + `
+		FOREACH (t IN targets | DETACH DELETE t)
+	`
+
+	if _, err := si.client.ExecuteQuery(ctx, deleteCypher, params); err != nil {
+		return nil, fmt.Errorf("failed to clean service %s: %w", si.serviceName, err)
+	}
+
+	// Cached module and symbol node IDs are no longer valid
+	si.packageMap = make(map[string]*models.Module)
+	si.symbolMap = make(map[string]string)
+
+	return counts, nil
+}
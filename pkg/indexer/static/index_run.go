@@ -0,0 +1,26 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+)
+
+// recordIndexRun stores an IndexRun node describing a completed indexing run
+func recordIndexRun(ctx context.Context, client *neo4j.Client, service, version, indexer string, startedAt int64, fileCount int) error {
+	runProps := map[string]any{
+		"service":    service,
+		"version":    version,
+		"indexer":    indexer,
+		"startedAt":  startedAt,
+		"finishedAt": time.Now().UTC().Unix(),
+		"fileCount":  fileCount,
+	}
+
+	if _, err := client.CreateNode(ctx, []string{"IndexRun"}, runProps); err != nil {
+		return fmt.Errorf("failed to record index run: %w", err)
+	}
+	return nil
+}
@@ -1,20 +1,30 @@
 package static
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
+	"go/printer"
 	"go/token"
+	"go/types"
 	"io/fs"
-	"log"
+	"log/slog"
+	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/context-maximiser/code-graph/pkg/models"
 	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"golang.org/x/mod/modfile"
 )
 
 // StaticIndexer indexes Go source code into the graph database
@@ -23,62 +33,321 @@ type StaticIndexer struct {
 	serviceName string
 	version     string
 	repoURL     string
+	logger      *slog.Logger
 	packageMap  map[string]*models.Module // Cache for package/module nodes
 	symbolMap   map[string]string         // Cache for symbol -> node ID mapping
+	goModules   map[string]*goModule      // Cache for directory -> enclosing Go module
+
+	mu       sync.Mutex // Guards the caches, pendingFlows, sweepSymbols and moduleMerges while files are indexed concurrently
+	moduleMu sync.Mutex // Serializes module creation so each package is merged once
+
+	followSymlinks          bool // Descend into symlinked files and directories
+	skipDirs                map[string]bool // Names of directories not descended into
+	includeTests            bool // Index _test.go files too
+	exportedOnly            bool // Only index exported declarations
+	buildContext            *build.Context // Platform and tags selecting files, nil indexes every file
+	httpClientPatterns      []HTTPClientPattern // Outbound HTTP calls linked with CALLS_API, nil disables
+	routePatterns           []RoutePattern // Handler registrations creating APIRoute nodes, nil disables
+	includeStdlibInterfaces bool // Link structs to standard library interfaces they implement
+	concurrency             int  // Number of files indexed in parallel
+	backends                map[string]LanguageBackend // Backends of other languages by file extension
+
+	pendingFlows  []dataFlow          // FLOWS_TO edges awaiting creation
+	pendingRoutes []routeRegistration // APIRoute nodes awaiting creation
+	pendingEmbeds []embedding         // EMBEDS edges awaiting creation
+	sweepSymbols  []string            // Symbols linked to removed nodes, checked by SweepOrphanSymbols
+
+	packages     *packageCache // Syntax and type information of the project being indexed
+	packageLoads int           // Number of times packages were loaded
+	moduleMerges int           // Number of Module nodes merged into the graph
+
+	writes  writeTimers  // Time spent writing nodes and relationships
+	timings IndexTimings // Phase durations of the last IndexProject run
 }
 
 // NewStaticIndexer creates a new static indexer
 func NewStaticIndexer(client *neo4j.Client, serviceName, version, repoURL string) *StaticIndexer {
-	return &StaticIndexer{
+	si := &StaticIndexer{
 		client:      client,
 		serviceName: serviceName,
 		version:     version,
 		repoURL:     repoURL,
+		logger:      slog.Default(),
 		packageMap:  make(map[string]*models.Module),
 		symbolMap:   make(map[string]string),
+		goModules:   make(map[string]*goModule),
+		concurrency: 1,
 	}
+	si.SetSkipDirs(DefaultSkipDirs())
+	return si
 }
 
 // IndexProject indexes an entire Go project
 func (si *StaticIndexer) IndexProject(ctx context.Context, rootPath string) error {
-	log.Printf("Starting to index project at %s", rootPath)
+	si.logger.Info("Starting to index project", "path", rootPath)
+	startedAt := time.Now().UTC().Unix()
+	start := time.Now()
+	si.timings = IndexTimings{}
 	
 	// Create or update the service node
 	serviceID, err := si.createServiceNode(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create service node: %w", err)
 	}
-	log.Printf("Created service node with ID: %s", serviceID)
+	si.logger.Debug("Created service node", "id", serviceID)
 
-	// Walk the directory tree and index all Go files
-	err = filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	// Collect and index all Go files
+	phase := time.Now()
+	files, err := si.CollectGoFiles(rootPath)
+	if err != nil {
+		return err
+	}
+	si.timings.Walk = time.Since(phase)
 
-		// Skip vendor, .git, and other directories
-		if d.IsDir() && shouldSkipDir(d.Name()) {
-			return filepath.SkipDir
-		}
+	// Load type information once for the whole project; files outside the
+	// loaded packages are parsed on their own without it
+	phase = time.Now()
+	if _, err := si.loadPackages(rootPath); err != nil {
+		si.logger.Warn("Type information unavailable", "error", err)
+	}
+	si.timings.Load = time.Since(phase)
+
+	phase = time.Now()
+	si.writes.nodes.Store(0)
+	si.writes.relationships.Store(0)
+	si.indexFiles(ctx, files, serviceID)
+	si.timings.Files = time.Since(phase)
+	si.timings.Nodes = time.Duration(si.writes.nodes.Load())
+	si.timings.Relationships = time.Duration(si.writes.relationships.Load())
+
+	phase = time.Now()
+	// Argument flows need every callee indexed before they can be linked
+	if err := si.linkDataFlows(ctx); err != nil {
+		si.logger.Warn("Failed to link data flows", "error", err)
+	}
+
+	// Handlers may be declared in files indexed after their registration
+	if err := si.linkRoutes(ctx); err != nil {
+		si.logger.Warn("Failed to link API routes", "error", err)
+	}
+
+	// Embedded types may likewise be declared in later files
+	if err := si.linkEmbeddings(ctx); err != nil {
+		si.logger.Warn("Failed to link embedded types", "error", err)
+	}
+
+	// Link structs to the interfaces they implement once all types are indexed
+	if err := si.indexImplementations(ctx, rootPath); err != nil {
+		si.logger.Warn("Failed to index interface implementations", "error", err)
+	}
+	si.timings.Link = time.Since(phase)
+
+	if err := recordIndexRun(ctx, si.client, si.serviceName, si.version, "ast", startedAt, len(files)); err != nil {
+		si.logger.Warn("Failed to record index run", "error", err)
+	}
+	si.timings.Total = time.Since(start)
 
-		// Only process .go files
-		if !d.IsDir() && strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
-			log.Printf("Indexing file: %s", path)
-			if err := si.indexFile(ctx, path, serviceID); err != nil {
-				log.Printf("Warning: failed to index file %s: %v", path, err)
-				// Continue with other files instead of failing completely
+	si.logger.Info("Successfully indexed project", "service", si.serviceName)
+	return nil
+}
+
+// SetLogger sets the logger receiving progress and warnings
+func (si *StaticIndexer) SetLogger(logger *slog.Logger) {
+	si.logger = logger
+}
+
+// SetConcurrency sets how many files are parsed and indexed in parallel;
+// values below 1 index files one at a time
+func (si *StaticIndexer) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	si.concurrency = n
+}
+
+// indexFiles indexes the files with a pool of si.concurrency workers
+func (si *StaticIndexer) indexFiles(ctx context.Context, files []string, serviceID string) {
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < si.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				si.logger.Debug("Indexing file", "path", path)
+				if err := si.indexFile(ctx, path, serviceID); err != nil {
+					si.logger.Warn("Failed to index file", "path", path, "error", err)
+					// Continue with other files instead of failing completely
+				}
 			}
-		}
+		}()
+	}
 
-		return nil
-	})
+	for _, path := range files {
+		paths <- path
+	}
+	close(paths)
+	wg.Wait()
+}
+
+// SetFollowSymlinks controls whether symlinked files and directories are indexed
+func (si *StaticIndexer) SetFollowSymlinks(follow bool) {
+	si.followSymlinks = follow
+}
+
+// IndexPlan lists what IndexProject would index under a root, and what it
+// would leave out, without touching the graph
+type IndexPlan struct {
+	Files       []string `json:"files"`       // Go source files that would be indexed
+	SkippedDirs []string `json:"skippedDirs"` // Directories skipped by name, e.g. vendor or .git
+	TestFiles   []string `json:"testFiles"`   // _test.go files, skipped unless tests are included
+	Excluded    []string `json:"excluded"`    // Go files not built for the build context
+}
 
+// CollectGoFiles returns the Go source files under rootPath that would be indexed.
+// When following symlinks, each real directory and file is visited only once so
+// symlink loops and aliased trees do not cause repeated indexing.
+func (si *StaticIndexer) CollectGoFiles(rootPath string) ([]string, error) {
+	plan, err := si.PlanProject(rootPath)
 	if err != nil {
-		return fmt.Errorf("failed to walk directory: %w", err)
+		return nil, err
 	}
+	return plan.Files, nil
+}
 
-	log.Printf("Successfully indexed project %s", si.serviceName)
-	return nil
+// PlanProject walks rootPath like IndexProject and reports the files it would
+// index along with the directories and files it would skip
+func (si *StaticIndexer) PlanProject(rootPath string) (*IndexPlan, error) {
+	plan := &IndexPlan{}
+	visitedDirs := make(map[string]bool)
+	visitedFiles := make(map[string]bool)
+
+	// walk traverses realRoot while reporting paths relative to logicalRoot,
+	// so files reached through a symlink keep the path they were found at
+	var walk func(realRoot, logicalRoot string) error
+	walk = func(realRoot, logicalRoot string) error {
+		return filepath.WalkDir(realRoot, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			logicalPath := path
+			if realRoot != logicalRoot {
+				rel, err := filepath.Rel(realRoot, path)
+				if err != nil {
+					return fmt.Errorf("failed to map path %s: %w", path, err)
+				}
+				logicalPath = filepath.Join(logicalRoot, rel)
+			}
+
+			if d.Type()&fs.ModeSymlink != 0 {
+				if !si.followSymlinks {
+					return nil
+				}
+
+				target, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					si.logger.Warn("Failed to resolve symlink", "path", logicalPath, "error", err)
+					return nil
+				}
+				info, err := os.Stat(target)
+				if err != nil {
+					si.logger.Warn("Failed to stat symlink target", "path", target, "error", err)
+					return nil
+				}
+
+				if info.IsDir() {
+					if si.shouldSkipDir(d.Name()) {
+						plan.SkippedDirs = append(plan.SkippedDirs, logicalPath)
+						return nil
+					}
+					if visitedDirs[target] {
+						return nil
+					}
+					return walk(target, logicalPath)
+				}
+
+				if si.isSourceFile(logicalPath) && !visitedFiles[target] && si.matchesBuildContext(target) {
+					visitedFiles[target] = true
+					plan.Files = append(plan.Files, logicalPath)
+				}
+				return nil
+			}
+
+			if d.IsDir() {
+				// Skip vendor, .git, and other directories
+				if si.shouldSkipDir(d.Name()) {
+					plan.SkippedDirs = append(plan.SkippedDirs, logicalPath)
+					return filepath.SkipDir
+				}
+				if si.followSymlinks {
+					realPath, err := filepath.EvalSymlinks(path)
+					if err != nil {
+						return fmt.Errorf("failed to resolve directory %s: %w", path, err)
+					}
+					if visitedDirs[realPath] {
+						return filepath.SkipDir
+					}
+					visitedDirs[realPath] = true
+				}
+				return nil
+			}
+
+			// Only process .go files built for the configured platform and
+			// files of the selected languages
+			if !si.isSourceFile(path) {
+				if si.isTestFile(path) {
+					plan.TestFiles = append(plan.TestFiles, logicalPath)
+				}
+				return nil
+			}
+			if !si.matchesBuildContext(path) {
+				plan.Excluded = append(plan.Excluded, logicalPath)
+				return nil
+			}
+			if si.followSymlinks {
+				realPath, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return fmt.Errorf("failed to resolve file %s: %w", path, err)
+				}
+				if visitedFiles[realPath] {
+					return nil
+				}
+				visitedFiles[realPath] = true
+			}
+			plan.Files = append(plan.Files, logicalPath)
+			return nil
+		})
+	}
+
+	if err := walk(rootPath, rootPath); err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	return plan, nil
+}
+
+// isGoSourceFile reports whether path is a Go source file to index, which
+// excludes test files unless they are included
+func (si *StaticIndexer) isGoSourceFile(path string) bool {
+	return strings.HasSuffix(path, ".go") && (si.includeTests || !strings.HasSuffix(path, "_test.go"))
+}
+
+// isSourceFile reports whether path is a Go source file or a file of a
+// selected language to index
+func (si *StaticIndexer) isSourceFile(path string) bool {
+	if si.backendFor(path) != nil {
+		return si.includeTests || !isBackendTestFile(path)
+	}
+	return si.isGoSourceFile(path)
+}
+
+// isTestFile reports whether path is a test file of Go or a selected language
+func (si *StaticIndexer) isTestFile(path string) bool {
+	if si.backendFor(path) != nil {
+		return isBackendTestFile(path)
+	}
+	return strings.HasSuffix(path, "_test.go")
 }
 
 // createServiceNode creates the service node in the graph
@@ -96,13 +365,30 @@ func (si *StaticIndexer) createServiceNode(ctx context.Context) (string, error)
 		map[string]any{"name": si.serviceName}, serviceProps)
 }
 
-// indexFile indexes a single Go source file
+// indexFile indexes a single Go source file, or a file of a selected
+// language through its backend
 func (si *StaticIndexer) indexFile(ctx context.Context, filePath string, serviceID string) error {
-	// Parse the file
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
-	if err != nil {
-		return fmt.Errorf("failed to parse file %s: %w", filePath, err)
+	if backend := si.backendFor(filePath); backend != nil {
+		return si.indexBackendFile(ctx, filePath, serviceID, backend)
+	}
+
+	// Reuse the file parsed while loading packages, or parse it on its own
+	var fset *token.FileSet
+	var node *ast.File
+	var typesInfo *types.Info
+	var typesPkg *types.Package
+	if typed := si.packages.lookupFile(filePath); typed != nil {
+		fset = si.packages.fset
+		node = typed.syntax
+		typesInfo = typed.pkg.TypesInfo
+		typesPkg = typed.pkg.Types
+	} else {
+		fset = token.NewFileSet()
+		parsed, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+		if err != nil {
+			return fmt.Errorf("failed to parse file %s: %w", filePath, err)
+		}
+		node = parsed
 	}
 
 	// Calculate file hash
@@ -118,18 +404,23 @@ func (si *StaticIndexer) indexFile(ctx context.Context, filePath string, service
 		"language":     "Go",
 		"hash":         fileHash,
 		"lineCount":    fset.Position(node.End()).Line,
+		"version":      si.version,
 		"createdAt":    time.Now().UTC().Unix(),
 		"updatedAt":    time.Now().UTC().Unix(),
 	}
+	buildConstraint := fileBuildConstraint(node, filePath)
+	if buildConstraint != "" {
+		fileProps["buildConstraint"] = buildConstraint
+	}
 
-	fileID, err := si.client.MergeNode(ctx, []string{"File"}, 
-		map[string]any{"path": filePath}, fileProps)
+	fileID, err := si.mergeNode(ctx, []string{"File"}, 
+		map[string]any{"path": filePath, "version": si.version}, fileProps)
 	if err != nil {
 		return fmt.Errorf("failed to create file node: %w", err)
 	}
 
 	// Link file to service
-	_, err = si.client.CreateRelationship(ctx, serviceID, fileID, "CONTAINS", nil)
+	_, err = si.mergeRelationship(ctx, serviceID, fileID, "CONTAINS", nil)
 	if err != nil {
 		return fmt.Errorf("failed to link file to service: %w", err)
 	}
@@ -143,6 +434,23 @@ func (si *StaticIndexer) indexFile(ctx context.Context, filePath string, service
 		return fmt.Errorf("failed to create module node: %w", err)
 	}
 
+	if err := si.indexImports(ctx, filePath, moduleID, node.Imports); err != nil {
+		si.logger.Warn("Failed to index imports", "path", filePath, "error", err)
+	}
+
+	// Names under which imported packages are referenced in this file
+	importNames := make(map[string]bool)
+	importPaths := make(map[string]string)
+	for _, imp := range node.Imports {
+		importPath := strings.Trim(imp.Path.Value, `"`)
+		name := path.Base(importPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		importNames[name] = true
+		importPaths[importPath] = name
+	}
+
 	// Create a visitor to traverse the AST
 	visitor := &astVisitor{
 		indexer:   si,
@@ -152,6 +460,12 @@ func (si *StaticIndexer) indexFile(ctx context.Context, filePath string, service
 		filePath:  filePath,
 		fset:      fset,
 		packageName: packageName,
+		packageFQN:  packageFQN,
+		importNames: importNames,
+		importPaths: importPaths,
+		typesInfo:   typesInfo,
+		typesPkg:    typesPkg,
+		buildConstraint: buildConstraint,
 	}
 
 	// Visit all nodes in the AST
@@ -169,7 +483,13 @@ type astVisitor struct {
 	filePath    string
 	fset        *token.FileSet
 	packageName string
+	packageFQN  string // Import path of the package, qualifies Class and Interface fqns
 	currentClass string // Track current class/struct for methods
+	importNames map[string]bool // Package names imported by the file
+	importPaths map[string]string // Import path -> name the file references the package by
+	typesInfo   *types.Info     // Type information of the file, nil when unavailable
+	typesPkg    *types.Package  // Type-checked package of the file
+	buildConstraint string      // Build constraint the file requires, e.g. "linux && amd64"
 }
 
 // Visit implements ast.Visitor
@@ -181,12 +501,22 @@ func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
 	switch n := node.(type) {
 	case *ast.FuncDecl:
 		v.indexFunction(n)
+		// Declarations inside function bodies are not part of the API
+		if v.indexer.exportedOnly {
+			return nil
+		}
 	case *ast.TypeSpec:
 		v.indexType(n)
 	case *ast.GenDecl:
 		v.indexGenDecl(n)
 	case *ast.InterfaceType:
 		v.indexInterface(n)
+	case *ast.DeclStmt:
+		// Variables declared in function bodies are local variables, indexed
+		// with the function's data flow
+		if gen, ok := n.Decl.(*ast.GenDecl); ok && gen.Tok == token.VAR {
+			return nil
+		}
 	}
 
 	return v
@@ -209,17 +539,7 @@ func (v *astVisitor) indexFunction(fn *ast.FuncDecl) {
 		// Try to find the receiver type and link to it
 		if fn.Recv != nil && len(fn.Recv.List) > 0 {
 			if recv := fn.Recv.List[0]; recv.Type != nil {
-				// Extract receiver type name
-				var recvTypeName string
-				switch t := recv.Type.(type) {
-				case *ast.Ident:
-					recvTypeName = t.Name
-				case *ast.StarExpr:
-					if ident, ok := t.X.(*ast.Ident); ok {
-						recvTypeName = ident.Name
-					}
-				}
-				v.currentClass = recvTypeName
+				v.currentClass = receiverTypeName(fn)
 				// TODO: Link to the actual struct/type node
 				parentID = v.moduleID // For now, link to module
 			}
@@ -228,6 +548,10 @@ func (v *astVisitor) indexFunction(fn *ast.FuncDecl) {
 		parentID = v.moduleID
 	}
 
+	if v.indexer.exportedOnly && !v.isExportedFunction(fn) {
+		return
+	}
+
 	// Build function signature
 	signature := v.buildFunctionSignature(fn)
 
@@ -236,13 +560,26 @@ func (v *astVisitor) indexFunction(fn *ast.FuncDecl) {
 	if fn.Type.Results != nil {
 		returnType = v.extractTypeString(fn.Type.Results)
 	}
+	if typed := v.resultTypeString(fn); typed != "" {
+		returnType = typed
+	}
 
 	// Check if function is exported
 	isExported := ast.IsExported(fn.Name.Name)
 
+	// Methods are qualified by their receiver so that methods sharing a
+	// signature, like String() string, stay distinct
+	fqn := fmt.Sprintf("%s.%s", v.packageFQN, fn.Name.Name)
+	functionKey := signature // Keys the parameters and locals of the function
+	if isMethod && v.currentClass != "" {
+		fqn = fmt.Sprintf("%s.%s.%s", v.packageFQN, v.currentClass, fn.Name.Name)
+		functionKey = v.currentClass + "." + signature
+	}
+
 	// Create function/method node with enhanced location metadata
 	funcProps := map[string]any{
 		"name":        fn.Name.Name,
+		"fqn":         fqn,
 		"signature":   signature,
 		"returnType":  returnType,
 		"filePath":    v.filePath,
@@ -255,12 +592,20 @@ func (v *astVisitor) indexFunction(fn *ast.FuncDecl) {
 		"linesOfCode": endPos.Line - startPos.Line + 1,
 		"isExported":  isExported,
 		"isAsync":     false, // Go doesn't have async functions like JS
-		"complexity":  1,     // TODO: Calculate cyclomatic complexity
+		"complexity":  cyclomaticComplexity(fn.Body),
 		"docstring":   v.extractDocstring(fn.Doc),
+		"version":     v.indexer.version,
 		"createdAt":   time.Now().UTC().Unix(),
 		"updatedAt":   time.Now().UTC().Unix(),
 	}
 
+	if calls := v.uncheckedErrorCalls(fn.Body); calls != nil {
+		funcProps["uncheckedErrors"] = calls
+	}
+	if typeParams := v.typeParamsString(fn.Type.TypeParams); typeParams != "" {
+		funcProps["typeParams"] = typeParams
+	}
+
 	var labels []string
 	if isMethod {
 		labels = []string{"Method"}
@@ -270,41 +615,83 @@ func (v *astVisitor) indexFunction(fn *ast.FuncDecl) {
 		labels = []string{"Function"}
 	}
 
-	funcID, err := v.indexer.client.MergeNode(v.ctx, labels, 
-		map[string]any{"signature": signature, "filePath": v.filePath}, funcProps)
+	v.tagBuildConstraint(funcProps)
+	funcID, err := v.indexer.mergeNode(v.ctx, labels, 
+		map[string]any{"fqn": fqn, "signature": signature, "filePath": v.filePath, "version": v.indexer.version}, funcProps)
 	if err != nil {
-		log.Printf("Failed to create function node %s: %v", fn.Name.Name, err)
+		v.indexer.logger.Warn("Failed to create function node", "name", fn.Name.Name, "error", err)
 		return
 	}
 
 	// Link to parent (module or class)
 	if parentID != "" {
-		_, err = v.indexer.client.CreateRelationship(v.ctx, parentID, funcID, "CONTAINS", nil)
+		_, err = v.indexer.mergeRelationship(v.ctx, parentID, funcID, "CONTAINS", nil)
 		if err != nil {
-			log.Printf("Failed to link function to parent: %v", err)
+			v.indexer.logger.Warn("Failed to link function to parent", "error", err)
 		}
 	}
 
 	// Create symbol for the function
 	v.createSymbol(fn.Name.Name, "Function", funcID, signature)
 
-	// Index parameters
+	// Index parameters. position counts names, whereas index counts fields.
+	scope := make(map[string]string)
 	if fn.Type.Params != nil {
+		position := 0
 		for i, param := range fn.Type.Params.List {
 			for _, name := range param.Names {
-				v.indexParameter(name, param, i, funcID)
+				paramID := v.indexParameter(name, param, i, position, funcID, functionKey, v.parameterDescriptor(fn, name.Name))
+				if paramID != "" && name.Name != "_" {
+					scope[name.Name] = paramID
+				}
+				position++
 			}
 		}
 	}
 
+	// Index data flow between parameters and local variables
+	v.indexDataFlow(fn.Body, funcID, functionKey, scope)
+
+	// Link outbound HTTP calls to the endpoints they target
+	v.indexAPICalls(fn.Body, funcID)
+
+	// Record the HTTP handlers the function registers
+	v.indexRoutes(fn.Body)
+
 	// TODO: Index function calls and references within the function body
 }
 
+// isExportedFunction reports whether a function is part of its package's API:
+// an exported function, or an exported method on an exported receiver type.
+// Exported methods of unexported types are left out; they can only be reached
+// through an interface or an exported type embedding the receiver.
+func (v *astVisitor) isExportedFunction(fn *ast.FuncDecl) bool {
+	if !fn.Name.IsExported() {
+		return false
+	}
+	if fn.Recv == nil {
+		return true
+	}
+	return ast.IsExported(receiverTypeName(fn))
+}
+
+// parameterDescriptor returns the SCIP descriptor of a parameter, e.g.
+// Handle().(id) or Server#Handle().(id) for methods
+func (v *astVisitor) parameterDescriptor(fn *ast.FuncDecl, paramName string) string {
+	if fn.Recv != nil && v.currentClass != "" {
+		return fmt.Sprintf("%s#%s().(%s)", v.currentClass, fn.Name.Name, paramName)
+	}
+	return fmt.Sprintf("%s().(%s)", fn.Name.Name, paramName)
+}
+
 // indexType indexes type declarations (structs, aliases, etc.)
 func (v *astVisitor) indexType(typeSpec *ast.TypeSpec) {
 	if typeSpec.Name == nil {
 		return
 	}
+	if v.indexer.exportedOnly && !typeSpec.Name.IsExported() {
+		return
+	}
 
 	startPos := v.fset.Position(typeSpec.Pos())
 	endPos := v.fset.Position(typeSpec.End())
@@ -312,15 +699,15 @@ func (v *astVisitor) indexType(typeSpec *ast.TypeSpec) {
 	// Determine the type of declaration
 	switch t := typeSpec.Type.(type) {
 	case *ast.StructType:
-		v.indexStruct(typeSpec.Name.Name, t, startPos, endPos)
+		v.indexStruct(typeSpec.Name.Name, t, typeSpec.TypeParams, startPos, endPos)
 	case *ast.InterfaceType:
-		v.indexInterfaceType(typeSpec.Name.Name, t, startPos, endPos)
+		v.indexInterfaceType(typeSpec.Name.Name, t, typeSpec.TypeParams, startPos, endPos)
 	}
 }
 
 // indexStruct indexes a struct type
-func (v *astVisitor) indexStruct(name string, structType *ast.StructType, startPos, endPos token.Position) {
-	fqn := fmt.Sprintf("%s.%s", v.packageName, name)
+func (v *astVisitor) indexStruct(name string, structType *ast.StructType, typeParams *ast.FieldList, startPos, endPos token.Position) {
+	fqn := fmt.Sprintf("%s.%s", v.packageFQN, name)
 	
 	classProps := map[string]any{
 		"name":           name,
@@ -337,29 +724,40 @@ func (v *astVisitor) indexStruct(name string, structType *ast.StructType, startP
 		"isAbstract":     false,
 		"isInterface":    false,
 		"docstring":      "", // TODO: Extract docstring
+		"version":        v.indexer.version,
 		"createdAt":      time.Now().UTC().Unix(),
 		"updatedAt":      time.Now().UTC().Unix(),
 	}
 
-	classID, err := v.indexer.client.MergeNode(v.ctx, []string{"Class"}, 
-		map[string]any{"fqn": fqn}, classProps)
+	if params := v.typeParamsString(typeParams); params != "" {
+		classProps["typeParams"] = params
+	}
+	v.tagBuildConstraint(classProps)
+	classID, err := v.indexer.mergeNode(v.ctx, []string{"Class"}, 
+		map[string]any{"fqn": fqn, "version": v.indexer.version}, classProps)
 	if err != nil {
-		log.Printf("Failed to create struct node %s: %v", name, err)
+		v.indexer.logger.Warn("Failed to create struct node", "name", name, "error", err)
 		return
 	}
 
 	// Link to module
-	_, err = v.indexer.client.CreateRelationship(v.ctx, v.moduleID, classID, "CONTAINS", nil)
+	_, err = v.indexer.mergeRelationship(v.ctx, v.moduleID, classID, "CONTAINS", nil)
 	if err != nil {
-		log.Printf("Failed to link struct to module: %v", err)
+		v.indexer.logger.Warn("Failed to link struct to module", "error", err)
 	}
 
 	// Create symbol for the struct
 	v.createSymbol(name, "Type", classID, fqn)
 
-	// Index fields
+	// Index fields; embedded fields have no names and link to their type
 	if structType.Fields != nil {
 		for _, field := range structType.Fields.List {
+			if len(field.Names) == 0 {
+				if fqn, pointer := v.embeddedTypeFQN(field.Type); fqn != "" {
+					v.indexer.addEmbedding(embedding{StructID: classID, TypeFQN: fqn, Pointer: pointer})
+				}
+				continue
+			}
 			for _, fieldName := range field.Names {
 				v.indexField(fieldName, field, classID)
 			}
@@ -368,8 +766,8 @@ func (v *astVisitor) indexStruct(name string, structType *ast.StructType, startP
 }
 
 // indexInterfaceType indexes an interface type
-func (v *astVisitor) indexInterfaceType(name string, interfaceType *ast.InterfaceType, startPos, endPos token.Position) {
-	fqn := fmt.Sprintf("%s.%s", v.packageName, name)
+func (v *astVisitor) indexInterfaceType(name string, interfaceType *ast.InterfaceType, typeParams *ast.FieldList, startPos, endPos token.Position) {
+	fqn := fmt.Sprintf("%s.%s", v.packageFQN, name)
 	
 	interfaceProps := map[string]any{
 		"name":        name,
@@ -383,21 +781,26 @@ func (v *astVisitor) indexInterfaceType(name string, interfaceType *ast.Interfac
 		"endByte":     endPos.Offset,
 		"linesOfCode": endPos.Line - startPos.Line + 1,
 		"docstring":   "", // TODO: Extract docstring
+		"version":     v.indexer.version,
 		"createdAt":   time.Now().UTC().Unix(),
 		"updatedAt":   time.Now().UTC().Unix(),
 	}
 
-	interfaceID, err := v.indexer.client.MergeNode(v.ctx, []string{"Interface"}, 
-		map[string]any{"fqn": fqn}, interfaceProps)
+	if params := v.typeParamsString(typeParams); params != "" {
+		interfaceProps["typeParams"] = params
+	}
+	v.tagBuildConstraint(interfaceProps)
+	interfaceID, err := v.indexer.mergeNode(v.ctx, []string{"Interface"}, 
+		map[string]any{"fqn": fqn, "version": v.indexer.version}, interfaceProps)
 	if err != nil {
-		log.Printf("Failed to create interface node %s: %v", name, err)
+		v.indexer.logger.Warn("Failed to create interface node", "name", name, "error", err)
 		return
 	}
 
 	// Link to module
-	_, err = v.indexer.client.CreateRelationship(v.ctx, v.moduleID, interfaceID, "CONTAINS", nil)
+	_, err = v.indexer.mergeRelationship(v.ctx, v.moduleID, interfaceID, "CONTAINS", nil)
 	if err != nil {
-		log.Printf("Failed to link interface to module: %v", err)
+		v.indexer.logger.Warn("Failed to link interface to module", "error", err)
 	}
 
 	// Create symbol for the interface
@@ -406,28 +809,53 @@ func (v *astVisitor) indexInterfaceType(name string, interfaceType *ast.Interfac
 
 // indexGenDecl indexes general declarations (vars, consts, types)
 func (v *astVisitor) indexGenDecl(gen *ast.GenDecl) {
+	// In const blocks a spec without values repeats the previous spec's
+	// type and expressions (the iota pattern)
+	var prevType ast.Expr
+	var prevValues []ast.Expr
+
 	for _, spec := range gen.Specs {
 		switch s := spec.(type) {
 		case *ast.ValueSpec:
-			v.indexValueSpec(s, gen.Tok)
+			specType, values := s.Type, s.Values
+			if gen.Tok == token.CONST {
+				if len(values) == 0 {
+					specType, values = prevType, prevValues
+				} else {
+					prevType, prevValues = specType, values
+				}
+			}
+			v.indexValueSpec(s, gen.Tok, specType, values)
 		}
 	}
 }
 
 // indexValueSpec indexes variable or constant declarations
-func (v *astVisitor) indexValueSpec(spec *ast.ValueSpec, tok token.Token) {
-	for _, name := range spec.Names {
+func (v *astVisitor) indexValueSpec(spec *ast.ValueSpec, tok token.Token, specType ast.Expr, values []ast.Expr) {
+	for i, name := range spec.Names {
 		if name.Name == "_" { // Skip blank identifier
 			continue
 		}
+		if v.indexer.exportedOnly && !name.IsExported() {
+			continue
+		}
 
 		startPos := v.fset.Position(name.Pos())
 		endPos := v.fset.Position(name.End())
 
 		// Determine variable type
 		varType := ""
-		if spec.Type != nil {
-			varType = v.extractTypeString(&ast.FieldList{List: []*ast.Field{{Type: spec.Type}}})
+		if specType != nil {
+			varType = v.typeString(specType)
+		}
+
+		// Determine the initial value. A single multi-value expression
+		// (var a, b = f()) initializes every name.
+		initialValue := ""
+		if i < len(values) {
+			initialValue = v.renderExpr(values[i])
+		} else if len(values) == 1 {
+			initialValue = v.renderExpr(values[0])
 		}
 
 		// Determine scope and if it's a constant
@@ -445,22 +873,24 @@ func (v *astVisitor) indexValueSpec(spec *ast.ValueSpec, tok token.Token) {
 			"startLine":    startPos.Line,
 			"endLine":      endPos.Line,
 			"isConstant":   isConstant,
-			"initialValue": "", // TODO: Extract initial value
+			"initialValue": initialValue,
+			"version":      v.indexer.version,
 			"createdAt":    time.Now().UTC().Unix(),
 			"updatedAt":    time.Now().UTC().Unix(),
 		}
 
-		varID, err := v.indexer.client.MergeNode(v.ctx, []string{"Variable"}, 
-			map[string]any{"name": name.Name, "filePath": v.filePath}, varProps)
+		v.tagBuildConstraint(varProps)
+		varID, err := v.indexer.mergeNode(v.ctx, []string{"Variable"}, 
+			map[string]any{"name": name.Name, "filePath": v.filePath, "version": v.indexer.version}, varProps)
 		if err != nil {
-			log.Printf("Failed to create variable node %s: %v", name.Name, err)
+			v.indexer.logger.Warn("Failed to create variable node", "name", name.Name, "error", err)
 			continue
 		}
 
 		// Link to module
-		_, err = v.indexer.client.CreateRelationship(v.ctx, v.moduleID, varID, "CONTAINS", nil)
+		_, err = v.indexer.mergeRelationship(v.ctx, v.moduleID, varID, "CONTAINS", nil)
 		if err != nil {
-			log.Printf("Failed to link variable to module: %v", err)
+			v.indexer.logger.Warn("Failed to link variable to module", "error", err)
 		}
 
 		// Create symbol for the variable
@@ -472,43 +902,58 @@ func (v *astVisitor) indexValueSpec(spec *ast.ValueSpec, tok token.Token) {
 	}
 }
 
-// indexParameter indexes function parameters
-func (v *astVisitor) indexParameter(name *ast.Ident, param *ast.Field, index int, funcID string) {
-	paramType := v.extractTypeString(&ast.FieldList{List: []*ast.Field{param}})
+// indexParameter indexes function parameters and returns the parameter node ID.
+// Parameters are keyed by their function's signature, qualified by the
+// receiver for methods, so that same-named parameters of different functions
+// in a file stay distinct.
+func (v *astVisitor) indexParameter(name *ast.Ident, param *ast.Field, index, position int, funcID, signature, descriptor string) string {
+	paramType := v.typeString(param.Type)
+	if typed := v.exprTypeString(param.Type); typed != "" {
+		paramType = typed
+	}
 
 	paramProps := map[string]any{
 		"name":         name.Name,
 		"type":         paramType,
 		"index":        index,
+		"position":     position,
+		"startLine":    v.fset.Position(name.Pos()).Line,
 		"isOptional":   false, // Go doesn't have optional parameters
 		"defaultValue": "",
+		"version":      v.indexer.version,
 		"createdAt":    time.Now().UTC().Unix(),
 		"updatedAt":    time.Now().UTC().Unix(),
 	}
 
-	paramID, err := v.indexer.client.MergeNode(v.ctx, []string{"Parameter"}, 
-		map[string]any{"name": name.Name, "filePath": v.filePath, "index": index}, paramProps)
+	paramID, err := v.indexer.mergeNode(v.ctx, []string{"Parameter"}, 
+		map[string]any{"name": name.Name, "filePath": v.filePath, "index": index, "function": signature, "version": v.indexer.version}, paramProps)
 	if err != nil {
-		log.Printf("Failed to create parameter node %s: %v", name.Name, err)
-		return
+		v.indexer.logger.Warn("Failed to create parameter node", "name", name.Name, "error", err)
+		return ""
 	}
 
 	// Link to function
-	_, err = v.indexer.client.CreateRelationship(v.ctx, funcID, paramID, "CONTAINS", nil)
+	_, err = v.indexer.mergeRelationship(v.ctx, funcID, paramID, "CONTAINS", nil)
 	if err != nil {
-		log.Printf("Failed to link parameter to function: %v", err)
+		v.indexer.logger.Warn("Failed to link parameter to function", "error", err)
 	}
 
 	// Create symbol for the parameter
-	v.createSymbol(name.Name, "Parameter", paramID, "")
+	v.createSymbol(name.Name, "Parameter", paramID, descriptor)
+
+	return paramID
 }
 
 // indexField indexes struct fields
 func (v *astVisitor) indexField(name *ast.Ident, field *ast.Field, classID string) {
+	if v.indexer.exportedOnly && !name.IsExported() {
+		return
+	}
+
 	startPos := v.fset.Position(name.Pos())
 	endPos := v.fset.Position(name.End())
 
-	fieldType := v.extractTypeString(&ast.FieldList{List: []*ast.Field{field}})
+	fieldType := v.typeString(field.Type)
 
 	varProps := map[string]any{
 		"name":         name.Name,
@@ -519,21 +964,28 @@ func (v *astVisitor) indexField(name *ast.Ident, field *ast.Field, classID strin
 		"endLine":      endPos.Line,
 		"isConstant":   false,
 		"initialValue": "",
+		"version":      v.indexer.version,
 		"createdAt":    time.Now().UTC().Unix(),
 		"updatedAt":    time.Now().UTC().Unix(),
 	}
+	// Keep the tag as written between the quotes, e.g. json:"id,omitempty"
+	if field.Tag != nil {
+		if tag, err := strconv.Unquote(field.Tag.Value); err == nil {
+			varProps["tag"] = tag
+		}
+	}
 
-	fieldID, err := v.indexer.client.MergeNode(v.ctx, []string{"Variable"}, 
-		map[string]any{"name": name.Name, "filePath": v.filePath}, varProps)
+	fieldID, err := v.indexer.mergeNode(v.ctx, []string{"Variable"}, 
+		map[string]any{"name": name.Name, "filePath": v.filePath, "version": v.indexer.version}, varProps)
 	if err != nil {
-		log.Printf("Failed to create field node %s: %v", name.Name, err)
+		v.indexer.logger.Warn("Failed to create field node", "name", name.Name, "error", err)
 		return
 	}
 
 	// Link to class
-	_, err = v.indexer.client.CreateRelationship(v.ctx, classID, fieldID, "CONTAINS", nil)
+	_, err = v.indexer.mergeRelationship(v.ctx, classID, fieldID, "CONTAINS", nil)
 	if err != nil {
-		log.Printf("Failed to link field to class: %v", err)
+		v.indexer.logger.Warn("Failed to link field to class", "error", err)
 	}
 
 	// Create symbol for the field
@@ -550,78 +1002,207 @@ func (v *astVisitor) createSymbol(name, kind, nodeID, descriptor string) {
 		"kind":          kind,
 		"displayName":   name,
 		"documentation": "",
+		"version":       v.indexer.version,
 		"createdAt":     time.Now().UTC().Unix(),
 		"updatedAt":     time.Now().UTC().Unix(),
 	}
 
-	symbolID, err := v.indexer.client.MergeNode(v.ctx, []string{"Symbol"}, 
+	symbolID, err := v.indexer.mergeNode(v.ctx, []string{"Symbol"}, 
 		map[string]any{"symbol": scipSymbol.String()}, symbolProps)
 	if err != nil {
-		log.Printf("Failed to create symbol for %s: %v", name, err)
+		v.indexer.logger.Warn("Failed to create symbol", "name", name, "error", err)
 		return
 	}
 
 	// Create DEFINES relationship
-	_, err = v.indexer.client.CreateRelationship(v.ctx, nodeID, symbolID, "DEFINES", 
+	_, err = v.indexer.mergeRelationship(v.ctx, nodeID, symbolID, "DEFINES", 
 		map[string]any{"isExported": ast.IsExported(name)})
 	if err != nil {
-		log.Printf("Failed to create DEFINES relationship for %s: %v", name, err)
+		v.indexer.logger.Warn("Failed to create DEFINES relationship", "name", name, "error", err)
 	}
 
 	// Cache the symbol mapping
+	v.indexer.mu.Lock()
 	v.indexer.symbolMap[scipSymbol.String()] = nodeID
+	v.indexer.mu.Unlock()
 }
 
 func (v *astVisitor) buildFunctionSignature(fn *ast.FuncDecl) string {
 	var parts []string
 	
 	parts = append(parts, fn.Name.Name)
+	parts = append(parts, v.typeParamsString(fn.Type.TypeParams))
 	parts = append(parts, "(")
-	
-	if fn.Type.Params != nil {
-		var params []string
-		for _, param := range fn.Type.Params.List {
-			paramType := v.extractTypeString(&ast.FieldList{List: []*ast.Field{param}})
-			for _, name := range param.Names {
-				params = append(params, fmt.Sprintf("%s %s", name.Name, paramType))
-			}
-		}
-		parts = append(parts, strings.Join(params, ", "))
-	}
-	
+	parts = append(parts, strings.Join(v.signatureFields(fn.Type.Params), ", "))
 	parts = append(parts, ")")
 	
+	// Results are parenthesized when there are several or they are named
 	if fn.Type.Results != nil {
-		parts = append(parts, " ")
-		parts = append(parts, v.extractTypeString(fn.Type.Results))
+		results := v.signatureFields(fn.Type.Results)
+		if len(results) == 1 && len(fn.Type.Results.List[0].Names) == 0 {
+			parts = append(parts, " ", results[0])
+		} else if len(results) > 0 {
+			parts = append(parts, " (", strings.Join(results, ", "), ")")
+		}
 	}
 	
 	return strings.Join(parts, "")
 }
 
+// signatureFields renders each parameter or result of a field list, as
+// "name type" for named ones and the type alone for unnamed ones. Groups like
+// (a, b int) expand to one entry per name.
+func (v *astVisitor) signatureFields(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+
+	var rendered []string
+	for _, field := range fields.List {
+		fieldType := v.typeString(field.Type)
+		if typed := v.exprTypeString(field.Type); typed != "" {
+			fieldType = typed
+		}
+		if len(field.Names) == 0 {
+			rendered = append(rendered, fieldType)
+			continue
+		}
+		for _, name := range field.Names {
+			rendered = append(rendered, fmt.Sprintf("%s %s", name.Name, fieldType))
+		}
+	}
+	return rendered
+}
+
+// typeParamsString renders a type parameter list as written, e.g.
+// "[K comparable, V any]". It returns "" for non-generic declarations.
+func (v *astVisitor) typeParamsString(typeParams *ast.FieldList) string {
+	if typeParams == nil || len(typeParams.List) == 0 {
+		return ""
+	}
+
+	var groups []string
+	for _, field := range typeParams.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		groups = append(groups, strings.Join(names, ", ")+" "+v.renderExpr(field.Type))
+	}
+	return "[" + strings.Join(groups, ", ") + "]"
+}
+
+// resultTypeString renders a function's results from type information, e.g.
+// "error" or "(int, error)". It returns "" without type information.
+func (v *astVisitor) resultTypeString(fn *ast.FuncDecl) string {
+	if v.typesInfo == nil {
+		return ""
+	}
+	obj, ok := v.typesInfo.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return ""
+	}
+	results := obj.Type().(*types.Signature).Results()
+	switch results.Len() {
+	case 0:
+		return ""
+	case 1:
+		return types.TypeString(results.At(0).Type(), packageQualifier(v.typesPkg))
+	default:
+		var parts []string
+		for i := 0; i < results.Len(); i++ {
+			parts = append(parts, types.TypeString(results.At(i).Type(), packageQualifier(v.typesPkg)))
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	}
+}
+
+// exprTypeString renders the type of a type expression from type information.
+// It returns "" without type information.
+func (v *astVisitor) exprTypeString(expr ast.Expr) string {
+	if v.typesInfo == nil {
+		return ""
+	}
+	typ := v.typesInfo.TypeOf(expr)
+	if typ == nil {
+		return ""
+	}
+	return types.TypeString(typ, packageQualifier(v.typesPkg))
+}
+
+// extractTypeString renders the types of a field list, such as the results of
+// a function. A list of several values renders as "(int, error)".
 func (v *astVisitor) extractTypeString(fieldList *ast.FieldList) string {
 	if fieldList == nil || len(fieldList.List) == 0 {
 		return ""
 	}
-	
-	// Simple type extraction - can be enhanced
-	field := fieldList.List[0]
-	if field.Type != nil {
-		switch t := field.Type.(type) {
-		case *ast.Ident:
-			return t.Name
-		case *ast.StarExpr:
-			if ident, ok := t.X.(*ast.Ident); ok {
-				return "*" + ident.Name
+
+	var typeStrings []string
+	for _, field := range fieldList.List {
+		// Fields like (a, b int) declare one value per name
+		for i := 0; i < max(len(field.Names), 1); i++ {
+			typeStrings = append(typeStrings, v.typeString(field.Type))
+		}
+	}
+	if len(typeStrings) == 1 {
+		return typeStrings[0]
+	}
+	return "(" + strings.Join(typeStrings, ", ") + ")"
+}
+
+// typeString renders a type expression as written in the source, e.g.
+// "[]*pkg.T", "map[string][]int", "<-chan T" or "func(context.Context) error".
+// It returns "unknown" when the type cannot be rendered.
+func (v *astVisitor) typeString(expr ast.Expr) string {
+	if rendered := v.renderExpr(expr); rendered != "" {
+		return rendered
+	}
+	return "unknown"
+}
+
+// cyclomaticComplexity returns the number of independent paths through a
+// function body: one plus each branch point and short-circuit operator.
+// Branches inside closures count towards the enclosing function.
+func cyclomaticComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	if body == nil {
+		return complexity
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if node.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				complexity++
 			}
-		case *ast.SelectorExpr:
-			if pkg, ok := t.X.(*ast.Ident); ok {
-				return pkg.Name + "." + t.Sel.Name
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				complexity++
 			}
 		}
+		return true
+	})
+
+	return complexity
+}
+
+// renderExpr renders an expression back to Go source
+func (v *astVisitor) renderExpr(expr ast.Expr) string {
+	if expr == nil {
+		return ""
 	}
-	
-	return "unknown"
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, v.fset, expr); err != nil {
+		return ""
+	}
+	return buf.String()
 }
 
 func (v *astVisitor) extractDocstring(commentGroup *ast.CommentGroup) string {
@@ -645,11 +1226,33 @@ func (v *astVisitor) extractDocstring(commentGroup *ast.CommentGroup) string {
 
 // getOrCreateModule gets or creates a module node for a package
 func (si *StaticIndexer) getOrCreateModule(ctx context.Context, packageName, fqn, fileID string) (string, error) {
+	moduleID, err := si.moduleID(ctx, packageName, fqn)
+	if err != nil {
+		return "", err
+	}
+
+	// Link file to module
+	_, err = si.mergeRelationship(ctx, moduleID, fileID, "CONTAINS", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to link file to module: %w", err)
+	}
+
+	return moduleID, nil
+}
+
+// moduleID returns the cached module node of a package, merging it into the
+// graph on first use. Files of the same package indexed concurrently wait for
+// the first one instead of merging the module again.
+func (si *StaticIndexer) moduleID(ctx context.Context, packageName, fqn string) (string, error) {
+	si.moduleMu.Lock()
+	defer si.moduleMu.Unlock()
+
 	// Check cache first
-	if module, exists := si.packageMap[fqn]; exists {
-		// Link file to existing module
-		_, err := si.client.CreateRelationship(ctx, module.ID, fileID, "CONTAINS", nil)
-		return module.ID, err
+	si.mu.Lock()
+	module, exists := si.packageMap[fqn]
+	si.mu.Unlock()
+	if exists {
+		return module.ID, nil
 	}
 
 	// Create new module
@@ -658,57 +1261,132 @@ func (si *StaticIndexer) getOrCreateModule(ctx context.Context, packageName, fqn
 		"fqn":        fqn,
 		"type":       "package",
 		"isExported": true, // Go packages are generally exported
+		"isExternal": false,
+		"isStdlib":   false,
+		"version":    si.version,
 		"createdAt":  time.Now().UTC().Unix(),
 		"updatedAt":  time.Now().UTC().Unix(),
 	}
 
-	moduleID, err := si.client.MergeNode(ctx, []string{"Module"}, 
-		map[string]any{"fqn": fqn}, moduleProps)
+	moduleID, err := si.mergeNode(ctx, []string{"Module"}, 
+		map[string]any{"fqn": fqn, "version": si.version}, moduleProps)
 	if err != nil {
 		return "", fmt.Errorf("failed to create module: %w", err)
 	}
 
 	// Cache the module
+	si.mu.Lock()
+	si.moduleMerges++
 	si.packageMap[fqn] = &models.Module{
 		BaseNode: models.BaseNode{ID: moduleID},
 		Name:     packageName,
 		FQN:      fqn,
 	}
-
-	// Link file to module
-	_, err = si.client.CreateRelationship(ctx, moduleID, fileID, "CONTAINS", nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to link file to module: %w", err)
-	}
+	si.mu.Unlock()
 
 	return moduleID, nil
 }
 
 // Helper functions
 func (si *StaticIndexer) getPackageFQN(filePath, packageName string) string {
-	// Simple FQN generation - can be enhanced with proper module detection
+	// Use the package import path when the file belongs to a Go module
+	if dir, err := filepath.Abs(filepath.Dir(filePath)); err == nil {
+		if mod := si.findGoModule(dir); mod != nil {
+			if rel, err := filepath.Rel(mod.root, dir); err == nil {
+				if rel == "." {
+					return mod.path
+				}
+				return mod.path + "/" + filepath.ToSlash(rel)
+			}
+		}
+	}
+
 	return fmt.Sprintf("%s/%s", si.serviceName, packageName)
 }
 
+// goModule describes a go.mod file found while indexing
+type goModule struct {
+	root string // Directory containing go.mod
+	path string // Module path declared in go.mod
+}
+
+// findGoModule returns the module of the nearest go.mod at or above dir, or nil
+func (si *StaticIndexer) findGoModule(dir string) *goModule {
+	si.mu.Lock()
+	mod, ok := si.goModules[dir]
+	si.mu.Unlock()
+	if ok {
+		return mod
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if modulePath := modfile.ModulePath(data); modulePath != "" {
+			mod = &goModule{root: dir, path: modulePath}
+		}
+	}
+	if mod == nil {
+		if parent := filepath.Dir(dir); parent != dir {
+			mod = si.findGoModule(parent)
+		}
+	}
+
+	si.mu.Lock()
+	si.goModules[dir] = mod
+	si.mu.Unlock()
+	return mod
+}
+
+// calculateFileHash returns the hash of a file's contents, which change
+// detection compares with the hash stored on its File node
 func (si *StaticIndexer) calculateFileHash(filePath string) (string, error) {
-	// For now, just hash the file path - in production, should hash file contents
-	hash := sha256.Sum256([]byte(filePath))
-	return fmt.Sprintf("%x", hash), nil
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return contentHash(content), nil
+}
+
+// contentHash is the hex sha256 hash stored as the hash of File nodes by
+// both the AST and the SCIP indexer
+func contentHash(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
 }
 
-func shouldSkipDir(dirName string) bool {
-	skipDirs := []string{
+// DefaultSkipDirs returns the names of the directories skipped when walking a
+// project unless SetSkipDirs replaces them
+func DefaultSkipDirs() []string {
+	return []string{
 		"vendor", ".git", ".github", "node_modules", ".vscode",
 		"bin", "build", "dist", "tmp", ".idea",
 	}
-	
-	for _, skip := range skipDirs {
-		if dirName == skip {
-			return true
-		}
+}
+
+// SetSkipDirs sets the names of the directories skipped when walking a
+// project, replacing DefaultSkipDirs
+func (si *StaticIndexer) SetSkipDirs(dirs []string) {
+	si.skipDirs = make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		si.skipDirs[dir] = true
 	}
-	
-	return false
+}
+
+// SetIncludeTests controls whether _test.go files are indexed. Test files are
+// not type-checked, so they are indexed from their syntax alone.
+func (si *StaticIndexer) SetIncludeTests(include bool) {
+	si.includeTests = include
+}
+
+// SetExportedOnly limits indexing to exported functions, types, fields,
+// variables and constants, and exported methods of exported types. Types and
+// constants declared inside function bodies are skipped, as are the data
+// flows, API calls and routes of unexported functions.
+func (si *StaticIndexer) SetExportedOnly(exportedOnly bool) {
+	si.exportedOnly = exportedOnly
+}
+
+func (si *StaticIndexer) shouldSkipDir(dirName string) bool {
+	return si.skipDirs[dirName]
 }
 
 // indexInterface indexes an interface declaration
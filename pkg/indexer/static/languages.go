@@ -0,0 +1,199 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LanguageBackend parses the source files of a language other than Go into
+// declarations. Go files are always indexed through go/parser.
+type LanguageBackend interface {
+	Name() string         // Language stored on File nodes, e.g. TypeScript
+	Extensions() []string // File extensions the backend handles, e.g. .ts
+	Parse(ctx context.Context, src []byte) ([]Declaration, error)
+}
+
+// Declaration is a top-level declaration found by a LanguageBackend
+type Declaration struct {
+	Kind       string // Function, Method, Class, Interface or Variable
+	Name       string
+	Signature  string // Functions and methods, e.g. add(a: number, b: number): number
+	ReturnType string // Functions and methods, when annotated
+	Type       string // Variables, when annotated
+	IsConstant bool   // Variables declared with const
+	IsExported bool
+	Docstring  string
+
+	StartLine, EndLine     int // 1-based
+	StartColumn, EndColumn int // 1-based
+	StartByte, EndByte     int
+
+	Members []Declaration // Methods of a class
+}
+
+// languageBackends builds the backends selectable with SetLanguages, by name
+var languageBackends = map[string]func() (LanguageBackend, error){
+	"typescript": newTypeScriptBackend,
+	"javascript": newJavaScriptBackend,
+}
+
+// Languages returns the names SetLanguages accepts
+func Languages() []string {
+	names := []string{"go"}
+	for name := range languageBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// SetLanguages selects the languages indexed next to Go, e.g. typescript and
+// javascript. Files are matched to a language by extension.
+func (si *StaticIndexer) SetLanguages(names []string) error {
+	backends := make(map[string]LanguageBackend)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "go" || name == "" {
+			continue
+		}
+		newBackend, ok := languageBackends[name]
+		if !ok {
+			return fmt.Errorf("unsupported language %q: supported languages are %s", name, strings.Join(Languages(), ", "))
+		}
+		backend, err := newBackend()
+		if err != nil {
+			return err
+		}
+		for _, ext := range backend.Extensions() {
+			backends[ext] = backend
+		}
+	}
+	si.backends = backends
+	return nil
+}
+
+// backendFor returns the backend indexing a file, or nil for Go files and
+// files of languages that are not selected
+func (si *StaticIndexer) backendFor(path string) LanguageBackend {
+	return si.backends[filepath.Ext(path)]
+}
+
+// isBackendTestFile reports whether a file of another language is a test by
+// the common naming conventions, e.g. user.test.ts or user.spec.js
+func isBackendTestFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.")
+}
+
+// indexBackendFile indexes a file of another language into the same File,
+// Function, Method, Class, Interface and Variable nodes as Go files. Without
+// packages, declarations hang off their File node, and their fqn is the file
+// path and name, e.g. src/user.ts:UserService.
+func (si *StaticIndexer) indexBackendFile(ctx context.Context, filePath, serviceID string, backend LanguageBackend) error {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	decls, err := backend.Parse(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to parse file %s: %w", filePath, err)
+	}
+
+	fileHash, err := si.calculateFileHash(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to calculate file hash: %w", err)
+	}
+	fileID, err := si.mergeNode(ctx, []string{"File"}, map[string]any{"path": filePath, "version": si.version}, map[string]any{
+		"path":         filePath,
+		"absolutePath": filePath,
+		"language":     backend.Name(),
+		"hash":         fileHash,
+		"lineCount":    strings.Count(string(src), "\n") + 1,
+		"version":      si.version,
+		"createdAt":    time.Now().UTC().Unix(),
+		"updatedAt":    time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create file node: %w", err)
+	}
+	if _, err := si.mergeRelationship(ctx, serviceID, fileID, "CONTAINS", nil); err != nil {
+		return fmt.Errorf("failed to link file to service: %w", err)
+	}
+
+	for _, decl := range decls {
+		if si.exportedOnly && !decl.IsExported {
+			continue
+		}
+		fqn := filePath + ":" + decl.Name
+		declID, err := si.indexDeclaration(ctx, filePath, fqn, decl)
+		if err != nil {
+			si.logger.Warn("Failed to index declaration", "path", filePath, "name", decl.Name, "error", err)
+			continue
+		}
+		if _, err := si.mergeRelationship(ctx, fileID, declID, "CONTAINS", nil); err != nil {
+			si.logger.Warn("Failed to link declaration to file", "name", decl.Name, "error", err)
+		}
+
+		for _, member := range decl.Members {
+			memberID, err := si.indexDeclaration(ctx, filePath, fqn+"."+member.Name, member)
+			if err != nil {
+				si.logger.Warn("Failed to index declaration", "path", filePath, "name", member.Name, "error", err)
+				continue
+			}
+			if _, err := si.mergeRelationship(ctx, declID, memberID, "CONTAINS", nil); err != nil {
+				si.logger.Warn("Failed to link member to class", "name", member.Name, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// indexDeclaration merges the node of a declaration, keyed like the Go
+// declarations of the same kind
+func (si *StaticIndexer) indexDeclaration(ctx context.Context, filePath, fqn string, decl Declaration) (string, error) {
+	props := map[string]any{
+		"name":        decl.Name,
+		"filePath":    filePath,
+		"startLine":   decl.StartLine,
+		"endLine":     decl.EndLine,
+		"startColumn": decl.StartColumn,
+		"endColumn":   decl.EndColumn,
+		"startByte":   decl.StartByte,
+		"endByte":     decl.EndByte,
+		"linesOfCode": decl.EndLine - decl.StartLine + 1,
+		"docstring":   decl.Docstring,
+		"version":     si.version,
+		"createdAt":   time.Now().UTC().Unix(),
+		"updatedAt":   time.Now().UTC().Unix(),
+	}
+
+	switch decl.Kind {
+	case "Function", "Method":
+		props["fqn"] = fqn
+		props["signature"] = decl.Signature
+		props["returnType"] = decl.ReturnType
+		props["isExported"] = decl.IsExported
+		return si.mergeNode(ctx, []string{decl.Kind},
+			map[string]any{"fqn": fqn, "signature": decl.Signature, "filePath": filePath, "version": si.version}, props)
+	case "Class", "Interface":
+		props["fqn"] = fqn
+		if decl.Kind == "Class" {
+			props["isAbstract"] = false
+			props["isInterface"] = false
+		}
+		return si.mergeNode(ctx, []string{decl.Kind}, map[string]any{"fqn": fqn, "version": si.version}, props)
+	case "Variable":
+		props["type"] = decl.Type
+		props["scope"] = "module"
+		props["isConstant"] = decl.IsConstant
+		return si.mergeNode(ctx, []string{"Variable"},
+			map[string]any{"name": decl.Name, "filePath": filePath, "version": si.version}, props)
+	default:
+		return "", fmt.Errorf("unknown declaration kind %q", decl.Kind)
+	}
+}
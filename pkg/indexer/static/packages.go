@@ -0,0 +1,109 @@
+package static
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// packageCache holds the syntax and type information of every package under a
+// project root. It is loaded once per project and shared by the file walk and
+// the type-dependent post-passes.
+type packageCache struct {
+	rootPath string
+	fset     *token.FileSet
+	pkgs     []*packages.Package
+	files    map[string]*typedFile // Absolute file path -> parsed file
+}
+
+// typedFile is a parsed source file with the type information of its package
+type typedFile struct {
+	syntax *ast.File
+	pkg    *packages.Package
+}
+
+// PackageLoads returns how many times package type information was loaded
+func (si *StaticIndexer) PackageLoads() int {
+	return si.packageLoads
+}
+
+// loadPackages type-checks the project at rootPath, reusing the cached result
+// when the same root was loaded before
+func (si *StaticIndexer) loadPackages(rootPath string) (*packageCache, error) {
+	absRoot, err := filepath.Abs(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", rootPath, err)
+	}
+	if si.packages != nil && si.packages.rootPath == absRoot {
+		return si.packages, nil
+	}
+
+	// Type-check dependencies from source rather than export data so the
+	// result does not depend on the installed toolchain's export format
+	fset := token.NewFileSet()
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedTypesInfo |
+			packages.NeedImports | packages.NeedDeps | packages.NeedSyntax,
+		Dir:  absRoot,
+		Fset: fset,
+	}
+	if env, buildFlags := si.packageLoadEnv(); env != nil {
+		cfg.Env = append(os.Environ(), env...)
+		cfg.BuildFlags = buildFlags
+	}
+
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	si.packageLoads++
+
+	cache := &packageCache{
+		rootPath: absRoot,
+		fset:     fset,
+		pkgs:     pkgs,
+		files:    make(map[string]*typedFile),
+	}
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			si.logger.Warn("Type checking failed", "package", pkg.PkgPath, "error", pkgErr)
+		}
+		for _, file := range pkg.Syntax {
+			filename := fset.Position(file.Pos()).Filename
+			cache.files[filename] = &typedFile{syntax: file, pkg: pkg}
+		}
+	}
+
+	si.packages = cache
+	return cache, nil
+}
+
+// lookupFile returns the cached syntax and type information of a file, or nil
+// when the file was not part of the loaded packages (test files, files reached
+// through symlinks, or a project that failed to load)
+func (c *packageCache) lookupFile(filePath string) *typedFile {
+	if c == nil {
+		return nil
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil
+	}
+	return c.files[absPath]
+}
+
+// packageQualifier renders types from other packages with their package name,
+// matching how they are written in source
+func packageQualifier(current *types.Package) types.Qualifier {
+	return func(pkg *types.Package) string {
+		if pkg == current {
+			return ""
+		}
+		return pkg.Name()
+	}
+}
@@ -0,0 +1,91 @@
+package static
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/scip/bindings/go/scip"
+)
+
+// referenceRoleNames maps flag names to SCIP symbol roles
+var referenceRoleNames = map[string]scip.SymbolRole{
+	"import":    scip.SymbolRole_Import,
+	"write":     scip.SymbolRole_WriteAccess,
+	"read":      scip.SymbolRole_ReadAccess,
+	"generated": scip.SymbolRole_Generated,
+	"test":      scip.SymbolRole_Test,
+	"forward":   scip.SymbolRole_ForwardDefinition,
+}
+
+// ReferenceRoleFilter selects which SCIP occurrences become REFERENCES edges
+// based on their symbol roles. A nil filter accepts every occurrence.
+type ReferenceRoleFilter struct {
+	include int32 // Roles of which an occurrence needs at least one
+	exclude int32 // Roles that reject an occurrence
+	plain   bool  // Whether occurrences without roles, such as calls, are accepted
+}
+
+// ParseReferenceRoles parses a comma-separated list of SCIP symbol roles:
+// import, write, read, generated, test and forward, plus "reference" for plain
+// occurrences without roles and "all" for everything. Prefixing a role with
+// "-" rejects occurrences that have it, e.g. "all,-import". An empty spec
+// accepts every occurrence.
+func ParseReferenceRoles(spec string) (*ReferenceRoleFilter, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	filter := &ReferenceRoleFilter{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		excluded := strings.HasPrefix(entry, "-")
+		name := strings.TrimPrefix(entry, "-")
+
+		switch name {
+		case "all":
+			if excluded {
+				return nil, fmt.Errorf("invalid reference role %q: all cannot be excluded", entry)
+			}
+			filter.plain = true
+			for _, role := range referenceRoleNames {
+				filter.include |= int32(role)
+			}
+		case "reference":
+			filter.plain = !excluded
+		default:
+			role, ok := referenceRoleNames[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown reference role %q", name)
+			}
+			if excluded {
+				filter.exclude |= int32(role)
+			} else {
+				filter.include |= int32(role)
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// Allows reports whether an occurrence with the given SCIP symbol roles should
+// create a REFERENCES edge. The definition role is ignored.
+func (f *ReferenceRoleFilter) Allows(roles int32) bool {
+	if f == nil {
+		return true
+	}
+
+	roles &^= int32(scip.SymbolRole_Definition)
+	if roles&f.exclude != 0 {
+		return false
+	}
+	if roles == 0 {
+		return f.plain
+	}
+	return roles&f.include != 0
+}
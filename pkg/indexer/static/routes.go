@@ -0,0 +1,350 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RoutePattern describes a call registering an HTTP handler, e.g. gin's
+// (*RouterGroup).GET. Callees are written like HTTPClientPattern callees. The
+// handler is always the last argument of the call.
+type RoutePattern struct {
+	Callee    string
+	Method    string // HTTP method, empty when read from MethodArg
+	MethodArg int    // Argument holding the method; negative accepts any method
+	PathArg   int    // Argument holding the path
+}
+
+// DefaultRoutePatterns returns the route registrations of net/http,
+// gorilla/mux, chi and gin
+func DefaultRoutePatterns() []RoutePattern {
+	anyMethod := func(callee string) RoutePattern {
+		return RoutePattern{Callee: callee, MethodArg: -1}
+	}
+
+	// Go 1.22 patterns such as "GET /users" carry their method in the path
+	patterns := []RoutePattern{
+		anyMethod("net/http.HandleFunc"),
+		anyMethod("net/http.Handle"),
+		anyMethod("(*net/http.ServeMux).HandleFunc"),
+		anyMethod("(*net/http.ServeMux).Handle"),
+		// Methods are read from a chained .Methods("GET") call
+		anyMethod("(*github.com/gorilla/mux.Router).HandleFunc"),
+		anyMethod("(*github.com/gorilla/mux.Router).Handle"),
+	}
+
+	for _, receiver := range []string{"(github.com/go-chi/chi/v5.Router).", "(*github.com/go-chi/chi/v5.Mux)."} {
+		for _, method := range []string{"Get", "Post", "Put", "Patch", "Delete", "Head", "Options"} {
+			patterns = append(patterns, RoutePattern{Callee: receiver + method, Method: strings.ToUpper(method)})
+		}
+		patterns = append(patterns,
+			anyMethod(receiver+"HandleFunc"),
+			anyMethod(receiver+"Handle"),
+			RoutePattern{Callee: receiver + "Method", MethodArg: 0, PathArg: 1},
+			RoutePattern{Callee: receiver + "MethodFunc", MethodArg: 0, PathArg: 1},
+		)
+	}
+
+	for _, receiver := range []string{"(*github.com/gin-gonic/gin.RouterGroup).", "(github.com/gin-gonic/gin.IRoutes).", "(github.com/gin-gonic/gin.IRouter)."} {
+		for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"} {
+			patterns = append(patterns, RoutePattern{Callee: receiver + method, Method: method})
+		}
+		patterns = append(patterns,
+			anyMethod(receiver+"Any"),
+			RoutePattern{Callee: receiver + "Handle", MethodArg: 0, PathArg: 1},
+		)
+	}
+	return patterns
+}
+
+// ParseRoutePattern parses a pattern written as callee:METHOD:pathArg, where
+// METHOD is an HTTP method, $N to read the method from argument N, or * for
+// handlers accepting any method, e.g. "example.com/web.(*Router).Route:$0:1"
+func ParseRoutePattern(spec string) (RoutePattern, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return RoutePattern{}, fmt.Errorf("invalid route pattern %q (use callee:METHOD:pathArg)", spec)
+	}
+
+	pathArg, err := strconv.Atoi(parts[2])
+	if err != nil || pathArg < 0 {
+		return RoutePattern{}, fmt.Errorf("invalid path argument in route pattern %q", spec)
+	}
+	pattern := RoutePattern{Callee: parts[0], PathArg: pathArg}
+
+	if arg, ok := strings.CutPrefix(parts[1], "$"); ok {
+		pattern.MethodArg, err = strconv.Atoi(arg)
+		if err != nil || pattern.MethodArg < 0 {
+			return RoutePattern{}, fmt.Errorf("invalid method argument in route pattern %q", spec)
+		}
+	} else if parts[1] == "*" {
+		pattern.MethodArg = -1
+	} else {
+		pattern.Method = strings.ToUpper(parts[1])
+	}
+	return pattern, nil
+}
+
+// SetRoutePatterns enables creating APIRoute nodes for the handlers registered
+// through the given patterns. Nil disables the detection.
+func (si *StaticIndexer) SetRoutePatterns(patterns []RoutePattern) {
+	si.routePatterns = patterns
+}
+
+// routeRegistration is a handler registration found in a function body. The
+// handler is resolved to its Function or Method node once every file has
+// been indexed.
+type routeRegistration struct {
+	Method   string
+	Path     string
+	ModuleID string // Module of the registering file, where unqualified handlers are looked up
+	Package  string // Import path of a handler written as pkg.Func
+	Handler  string // Name of the handler, empty for function literals
+	IsMethod bool
+	FilePath string
+	Line     int
+}
+
+// routeMap converts the registration to a Cypher parameter map
+func (r routeRegistration) routeMap() map[string]any {
+	return map[string]any{
+		"method":   r.Method,
+		"path":     r.Path,
+		"moduleId": r.ModuleID,
+		"package":  r.Package,
+		"handler":  r.Handler,
+		"isMethod": r.IsMethod,
+		"filePath": r.FilePath,
+		"line":     r.Line,
+	}
+}
+
+// indexRoutes records the handler registrations of a function body matching
+// the configured route patterns whose path is a string constant. Paths are
+// recorded as registered: prefixes added by router groups are not resolved.
+func (v *astVisitor) indexRoutes(body *ast.BlockStmt) {
+	if len(v.indexer.routePatterns) == 0 || body == nil {
+		return
+	}
+
+	// gorilla/mux restricts methods with a call chained to the registration
+	chainedMethods := make(map[*ast.CallExpr][]string)
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Methods" {
+			if inner, ok := sel.X.(*ast.CallExpr); ok {
+				for _, arg := range call.Args {
+					if method, ok := v.stringConstant(arg); ok {
+						chainedMethods[inner] = append(chainedMethods[inner], strings.ToUpper(method))
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		pattern, ok := v.matchRoute(call)
+		if !ok || pattern.PathArg >= len(call.Args)-1 {
+			return true
+		}
+		routePath, ok := v.stringConstant(call.Args[pattern.PathArg])
+		if !ok {
+			return true
+		}
+
+		var methods []string
+		switch {
+		case pattern.Method != "":
+			methods = []string{pattern.Method}
+		case pattern.MethodArg >= 0:
+			if pattern.MethodArg >= len(call.Args) {
+				return true
+			}
+			method, ok := v.stringConstant(call.Args[pattern.MethodArg])
+			if !ok {
+				return true
+			}
+			methods = []string{strings.ToUpper(method)}
+		default:
+			if method, rest, ok := strings.Cut(routePath, " "); ok && !strings.HasPrefix(method, "/") && method == strings.ToUpper(method) {
+				methods, routePath = []string{method}, strings.TrimSpace(rest)
+			} else if chained := chainedMethods[call]; len(chained) > 0 {
+				methods = chained
+			} else {
+				methods = []string{"ANY"}
+			}
+		}
+
+		registration := routeRegistration{
+			Path:     routePath,
+			ModuleID: v.moduleID,
+			FilePath: v.filePath,
+			Line:     v.fset.Position(call.Pos()).Line,
+		}
+		v.resolveHandler(call.Args[len(call.Args)-1], &registration)
+		for _, method := range methods {
+			registration.Method = method
+			v.indexer.addRoute(registration)
+		}
+		return true
+	})
+}
+
+// matchRoute returns the route pattern matching the called function. Calls
+// whose callee cannot be resolved, such as methods of routers from packages
+// missing type information, match patterns by function or method name when
+// the file imports the pattern's package.
+func (v *astVisitor) matchRoute(call *ast.CallExpr) (RoutePattern, bool) {
+	sel, _ := call.Fun.(*ast.SelectorExpr)
+	name := types.ExprString(call.Fun)
+	resolved := false
+	if v.typesInfo != nil {
+		name = v.calleeName(call)
+		resolved = sel != nil && v.typesInfo.Uses[sel.Sel] != nil
+	}
+
+	for _, pattern := range v.indexer.routePatterns {
+		if name == pattern.Callee {
+			return pattern, true
+		}
+		if resolved || sel == nil {
+			continue
+		}
+
+		pkgPath, funcName, isMethod := splitCallee(pattern.Callee)
+		localName, imported := v.importPaths[pkgPath]
+		if !imported || sel.Sel.Name != funcName {
+			continue
+		}
+		x, isIdent := sel.X.(*ast.Ident)
+		isPackage := isIdent && v.importNames[x.Name]
+		if (isMethod && !isPackage) || (!isMethod && isIdent && x.Name == localName) {
+			// Methods of different routers share names, e.g. Handle, so the
+			// path argument must look like a path
+			if pattern.PathArg < len(call.Args) {
+				if routePath, ok := v.stringConstant(call.Args[pattern.PathArg]); ok && looksLikeRoute(routePath) {
+					return pattern, true
+				}
+			}
+		}
+	}
+	return RoutePattern{}, false
+}
+
+// looksLikeRoute reports whether a string is a path, optionally preceded by a
+// method as in "GET /users"
+func looksLikeRoute(routePath string) bool {
+	if _, rest, ok := strings.Cut(routePath, " "); ok {
+		routePath = strings.TrimSpace(rest)
+	}
+	return strings.HasPrefix(routePath, "/")
+}
+
+// splitCallee splits a callee such as (*net/http.ServeMux).Handle or
+// net/http.Handle into its package path and function or method name
+func splitCallee(callee string) (pkgPath, name string, isMethod bool) {
+	qualified := callee
+	if receiver, method, ok := strings.Cut(strings.TrimPrefix(callee, "("), ")."); ok && strings.HasPrefix(callee, "(") {
+		qualified, name, isMethod = strings.TrimPrefix(receiver, "*"), method, true
+	}
+	slash := strings.LastIndex(qualified, "/")
+	dot := strings.Index(qualified[slash+1:], ".")
+	if dot < 0 {
+		return "", "", false
+	}
+	pkgPath = qualified[:slash+1+dot]
+	if !isMethod {
+		name = qualified[slash+1+dot+1:]
+	}
+	return pkgPath, name, isMethod
+}
+
+// resolveHandler names the function handling a route. Conversions and
+// middleware wrapping a single handler, such as http.HandlerFunc(h), are
+// unwrapped; function literals have no name to resolve.
+func (v *astVisitor) resolveHandler(expr ast.Expr, registration *routeRegistration) {
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			break
+		}
+		expr = call.Args[0]
+	}
+
+	switch handler := expr.(type) {
+	case *ast.Ident:
+		registration.Handler = handler.Name
+	case *ast.SelectorExpr:
+		registration.Handler = handler.Sel.Name
+		if pkg, ok := handler.X.(*ast.Ident); ok && v.importNames[pkg.Name] {
+			for importPath, name := range v.importPaths {
+				if name == pkg.Name {
+					registration.Package = importPath
+				}
+			}
+		} else {
+			registration.IsMethod = true
+		}
+	}
+}
+
+// addRoute records a route to create once every file is indexed
+func (si *StaticIndexer) addRoute(registration routeRegistration) {
+	si.mu.Lock()
+	si.pendingRoutes = append(si.pendingRoutes, registration)
+	si.mu.Unlock()
+}
+
+// linkRoutes creates the APIRoute nodes collected while indexing and links
+// them to their handlers with EXPOSES_API. Handlers only link when their name
+// is unique within the package.
+func (si *StaticIndexer) linkRoutes(ctx context.Context) error {
+	var routes []map[string]any
+	for _, registration := range si.pendingRoutes {
+		routes = append(routes, registration.routeMap())
+	}
+	si.pendingRoutes = nil
+	if len(routes) == 0 {
+		return nil
+	}
+
+	cypher := `
+		UNWIND $routes AS route
+		MERGE (r:APIRoute {protocol: 'http', method: route.method, path: route.path, service: $service, version: $version})
+		ON CREATE SET r.createdAt = $now
+		SET r.filePath = route.filePath, r.line = route.line, r.updatedAt = $now
+		WITH r, route
+		WHERE route.handler <> ''
+		MATCH (m:Module)-[:CONTAINS]->(handler)
+		WHERE handler.name = route.handler
+		  AND ((route.package = '' AND elementId(m) = route.moduleId) OR (m.fqn = route.package AND m.version = $version))
+		  AND ((route.isMethod AND handler:Method) OR (NOT route.isMethod AND handler:Function))
+		WITH r, route, collect(DISTINCT handler) AS handlers
+		WHERE size(handlers) = 1
+		WITH r, handlers[0] AS handler
+		MERGE (handler)-[:EXPOSES_API]->(r)
+	`
+	params := map[string]any{
+		"routes":  routes,
+		"service": si.serviceName,
+		"version": si.version,
+		"now":     time.Now().UTC().Unix(),
+	}
+	if _, err := si.client.ExecuteQuery(ctx, cypher, params); err != nil {
+		return fmt.Errorf("failed to create API routes: %w", err)
+	}
+	return nil
+}
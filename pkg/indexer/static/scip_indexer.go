@@ -1,24 +1,33 @@
 package static
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/context-maximiser/code-graph/pkg/models"
 	"github.com/context-maximiser/code-graph/pkg/neo4j"
 )
 
-// SCIPIndexer indexes Go projects using the SCIP protocol
+// SCIPIndexer indexes projects using the SCIP indexer of their language
 type SCIPIndexer struct {
 	client      *neo4j.Client
 	serviceName string
 	version     string
 	repoURL     string
+	logger      *slog.Logger
 	scipBinary  string
+	language    LanguageConfig
+	// referenceRoles limits which occurrences create REFERENCES edges
+	referenceRoles *ReferenceRoleFilter
+	// debug prints a summary of the SCIP index before it is written
+	debug bool
 }
 
 // NewSCIPIndexer creates a new SCIP-based indexer
@@ -28,32 +37,27 @@ func NewSCIPIndexer(client *neo4j.Client, serviceName, version, repoURL string)
 		serviceName: serviceName,
 		version:     version,
 		repoURL:     repoURL,
+		logger:      slog.Default(),
 		scipBinary:  "scip-go", // Assume scip-go is in PATH
+		language:    LanguageConfigs["go"],
 	}
 }
 
-// IndexProject indexes a Go project using SCIP
+// IndexProject indexes a project using SCIP
 func (si *SCIPIndexer) IndexProject(ctx context.Context, projectPath string) error {
-	fmt.Printf("Starting SCIP indexing for project at %s\n", projectPath)
+	si.logger.Info("Starting SCIP indexing", "path", projectPath)
+	startedAt := time.Now().UTC().Unix()
 
-	// Step 1: Generate SCIP index file
-	scipFile, err := si.generateSCIPIndex(projectPath)
+	// Steps 1-2: Generate and parse the SCIP index
+	parser, err := si.generateSCIPIndex(projectPath)
 	if err != nil {
 		return fmt.Errorf("failed to generate SCIP index: %w", err)
 	}
-	defer os.Remove(scipFile) // Clean up temporary file
 
-	fmt.Printf("Generated SCIP index file: %s\n", scipFile)
-
-	// Step 2: Parse the SCIP file
-	parser := NewSCIPParser()
-	if err := parser.ParseFile(scipFile); err != nil {
-		return fmt.Errorf("failed to parse SCIP file: %w", err)
-	}
-
-	// Debug: Print SCIP file contents
-	if err := parser.DebugPrintSCIPFile(); err != nil {
-		fmt.Printf("Warning: failed to debug print SCIP file: %v\n", err)
+	if si.debug {
+		if err := parser.DebugPrintSCIPFile(os.Stderr); err != nil {
+			si.logger.Warn("Failed to print SCIP index summary", "error", err)
+		}
 	}
 
 	// Step 3: Create service node
@@ -70,15 +74,21 @@ func (si *SCIPIndexer) IndexProject(ctx context.Context, projectPath string) err
 
 	fileNodes := make(map[string]string) // filePath -> nodeID mapping
 	for _, file := range files {
+		if file.Language == "unknown" {
+			file.Language = si.language.Name
+		}
+		if err := readFileStats(file, filepath.Join(projectPath, file.Path)); err != nil {
+			si.logger.Warn("Failed to read file", "path", file.Path, "error", err)
+		}
 		fileID, err := si.createFileNode(ctx, file, serviceID)
 		if err != nil {
-			fmt.Printf("Warning: failed to create file node for %s: %v\n", file.Path, err)
+			si.logger.Warn("Failed to create file node", "path", file.Path, "error", err)
 			continue
 		}
 		fileNodes[file.Path] = fileID
 	}
 
-	fmt.Printf("Created %d file nodes\n", len(fileNodes))
+	si.logger.Info("Created file nodes", "count", len(fileNodes))
 
 	// Step 5: Index symbols and their relationships
 	symbolDefs, err := parser.ExtractSymbols()
@@ -90,52 +100,101 @@ func (si *SCIPIndexer) IndexProject(ctx context.Context, projectPath string) err
 		return fmt.Errorf("failed to index symbols: %w", err)
 	}
 
-	fmt.Printf("Successfully indexed %d symbols from SCIP data\n", len(symbolDefs))
+	if err := recordIndexRun(ctx, si.client, si.serviceName, si.version, "scip", startedAt, len(fileNodes)); err != nil {
+		si.logger.Warn("Failed to record index run", "error", err)
+	}
+
+	si.logger.Info("Successfully indexed SCIP symbols", "count", len(symbolDefs))
 	return nil
 }
 
-// generateSCIPIndex runs scip-go to generate a SCIP index file
-func (si *SCIPIndexer) generateSCIPIndex(projectPath string) (string, error) {
-	// Check if scip-go is available
-	if _, err := exec.LookPath(si.scipBinary); err != nil {
-		return "", fmt.Errorf("scip-go not found in PATH. Install with: go install github.com/sourcegraph/scip-go/cmd/scip-go@latest")
+// PlanProject runs the SCIP indexer and returns the paths of the documents it
+// found, which IndexProject would turn into File nodes, without touching the
+// graph
+func (si *SCIPIndexer) PlanProject(projectPath string) ([]string, error) {
+	parser, err := si.generateSCIPIndex(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SCIP index: %w", err)
+	}
+	files, err := parser.ExtractDocuments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract documents: %w", err)
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	return paths, nil
+}
+
+// generateSCIPIndex runs the SCIP indexer over the project and parses the index it
+// writes. The index is written to a temporary directory, removed before
+// returning, so nothing is left in the indexed tree.
+func (si *SCIPIndexer) generateSCIPIndex(projectPath string) (*SCIPParser, error) {
+	if err := si.ValidateEnvironment(); err != nil {
+		return nil, err
 	}
 
-	// Create temporary output file
-	outputFile := filepath.Join(projectPath, "index.scip")
+	outputDir, err := os.MkdirTemp("", "codegraph-scip-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(outputDir)
+	outputFile := filepath.Join(outputDir, "index.scip")
 
-	// Prepare scip-go command
-	cmd := exec.Command(si.scipBinary,
-		"--module-name", si.serviceName,
-		"--module-version", si.version,
-		"--output", outputFile,
-	)
+	// Prepare the indexer command
+	cmd := exec.Command(si.scipBinary, si.language.expandArgs(si.serviceName, si.version, outputFile)...)
 
 	// Set working directory
 	cmd.Dir = projectPath
 
 	// Run the command
-	fmt.Printf("Running: %s in %s\n", cmd.String(), projectPath)
+	si.logger.Debug("Running SCIP indexer", "command", cmd.String(), "dir", projectPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("scip-go command failed: %w\nOutput: %s", err, string(output))
+		return nil, fmt.Errorf("%s command failed: %w\nOutput: %s", si.scipBinary, err, string(output))
 	}
 
-	fmt.Printf("scip-go output: %s\n", string(output))
+	si.logger.Debug("SCIP indexer finished", "binary", si.scipBinary, "output", string(output))
 
 	// Verify the output file exists
 	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
-		return "", fmt.Errorf("SCIP index file was not generated: %s", outputFile)
+		return nil, fmt.Errorf("SCIP index file was not generated: %s", outputFile)
+	}
+
+	parser := NewSCIPParser()
+	if err := parser.ParseFile(outputFile); err != nil {
+		return nil, fmt.Errorf("failed to parse SCIP file: %w", err)
+	}
+	return parser, nil
+}
+
+// readFileStats fills in the absolute path, line count and sha256 hash
+// of a document, which SCIP does not record, from the file on disk
+func readFileStats(file *models.File, path string) error {
+	file.AbsolutePath = file.Path
+	if absolutePath, err := filepath.Abs(path); err == nil {
+		file.AbsolutePath = absolutePath
 	}
 
-	return outputFile, nil
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file.Hash = contentHash(content)
+	file.LineCount = bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		file.LineCount++ // Last line without a newline
+	}
+	return nil
 }
 
 // createServiceNode creates the service node in Neo4j
 func (si *SCIPIndexer) createServiceNode(ctx context.Context) (string, error) {
 	serviceProps := map[string]any{
 		"name":          si.serviceName,
-		"language":      "Go",
+		"language":      si.language.Name,
 		"version":       si.version,
 		"repositoryUrl": si.repoURL,
 	}
@@ -148,39 +207,50 @@ func (si *SCIPIndexer) createServiceNode(ctx context.Context) (string, error) {
 func (si *SCIPIndexer) createFileNode(ctx context.Context, file *models.File, serviceID string) (string, error) {
 	fileProps := map[string]any{
 		"path":         file.Path,
-		"absolutePath": file.Path, // SCIP only provides relative paths
+		"absolutePath": file.AbsolutePath,
 		"language":     file.Language,
-		"hash":         "", // Not available from SCIP
-		"lineCount":    0,  // Not available from SCIP
+		"hash":         file.Hash,
+		"lineCount":    file.LineCount,
+		"version":      si.version,
+		"createdAt":    time.Now().UTC().Unix(),
+		"updatedAt":    time.Now().UTC().Unix(),
 	}
 
 	fileID, err := si.client.MergeNode(ctx, []string{"File"}, 
-		map[string]any{"path": file.Path}, fileProps)
+		map[string]any{"path": file.Path, "version": si.version}, fileProps)
 	if err != nil {
 		return "", err
 	}
 
 	// Link file to service
-	_, err = si.client.CreateRelationship(ctx, serviceID, fileID, "CONTAINS", nil)
+	_, err = si.client.MergeRelationship(ctx, serviceID, fileID, "CONTAINS", nil)
 	return fileID, err
 }
 
 // indexSymbols indexes all symbols and their relationships
 func (si *SCIPIndexer) indexSymbols(ctx context.Context, symbolDefs []*models.SymbolDefinition, fileNodes map[string]string) error {
-	fmt.Printf("Indexing %d symbols...\n", len(symbolDefs))
+	si.logger.Info("Indexing symbols", "count", len(symbolDefs))
 
 	symbolNodes := make(map[string]string) // symbol -> nodeID mapping
 
+	// Packages defining a symbol in the index belong to the service; symbols
+	// of any other package, the standard library included, are external
+	internalPackages := make(map[string]bool)
+	for _, symbolDef := range symbolDefs {
+		if symbolDef.Info.FilePath != "" {
+			internalPackages[symbolDef.Symbol.Package()] = true
+		}
+	}
+
 	// First pass: Create all symbol nodes
 	for i, symbolDef := range symbolDefs {
 		if i%100 == 0 {
-			fmt.Printf("Processing symbol %d/%d\n", i, len(symbolDefs))
+			si.logger.Debug("Processing symbols", "done", i, "total", len(symbolDefs))
 		}
 
-		symbolID, err := si.createSymbolNode(ctx, symbolDef.Info)
+		symbolID, err := si.createSymbolNode(ctx, symbolDef.Info, !internalPackages[symbolDef.Symbol.Package()])
 		if err != nil {
-			fmt.Printf("Warning: failed to create symbol node for %s: %v\n", 
-				symbolDef.Symbol.String(), err)
+			si.logger.Warn("Failed to create symbol node", "symbol", symbolDef.Symbol.String(), "error", err)
 			continue
 		}
 
@@ -190,22 +260,22 @@ func (si *SCIPIndexer) indexSymbols(ctx context.Context, symbolDefs []*models.Sy
 		if symbolDef.Info.FilePath != "" {
 			definitionID, err := si.createDefinitionNode(ctx, symbolDef.Info)
 			if err != nil {
-				fmt.Printf("Warning: failed to create definition node: %v\n", err)
+				si.logger.Warn("Failed to create definition node", "error", err)
 				continue
 			}
 
 			// Link definition to symbol
-			_, err = si.client.CreateRelationship(ctx, definitionID, symbolID, "DEFINES", 
-				map[string]any{"isExported": true}) // Assume exported for now
+			_, err = si.client.MergeRelationship(ctx, definitionID, symbolID, "DEFINES", 
+				map[string]any{"isExported": symbolDef.Info.IsExported()})
 			if err != nil {
-				fmt.Printf("Warning: failed to link definition to symbol: %v\n", err)
+				si.logger.Warn("Failed to link definition to symbol", "error", err)
 			}
 
 			// Link definition to file if file exists
 			if fileID, exists := fileNodes[symbolDef.Info.FilePath]; exists {
-				_, err = si.client.CreateRelationship(ctx, fileID, definitionID, "CONTAINS", nil)
+				_, err = si.client.MergeRelationship(ctx, fileID, definitionID, "CONTAINS", nil)
 				if err != nil {
-					fmt.Printf("Warning: failed to link definition to file: %v\n", err)
+					si.logger.Warn("Failed to link definition to file", "error", err)
 				}
 			}
 		}
@@ -219,26 +289,32 @@ func (si *SCIPIndexer) indexSymbols(ctx context.Context, symbolDefs []*models.Sy
 		}
 
 		for _, ref := range symbolDef.Refs {
-			if !ref.IsDefinition { // Skip definitions, we already handled those
+			// Skip definitions, we already handled those
+			if !ref.IsDefinition && si.referenceRoles.Allows(ref.Roles) {
 				err := si.createReferenceRelationship(ctx, ref, symbolID, fileNodes)
 				if err != nil {
-					fmt.Printf("Warning: failed to create reference relationship: %v\n", err)
+					si.logger.Warn("Failed to create reference relationship", "error", err)
 				}
 			}
 		}
 	}
 
-	fmt.Printf("Completed indexing symbols\n")
+	si.logger.Info("Completed indexing symbols")
 	return nil
 }
 
-// createSymbolNode creates a Symbol node in Neo4j
-func (si *SCIPIndexer) createSymbolNode(ctx context.Context, symbolInfo *models.SymbolInfo) (string, error) {
+// createSymbolNode creates a Symbol node in Neo4j, tagged with the package
+// and module it comes from and whether they lie outside the service
+func (si *SCIPIndexer) createSymbolNode(ctx context.Context, symbolInfo *models.SymbolInfo, isExternal bool) (string, error) {
 	symbolProps := map[string]any{
 		"symbol":        symbolInfo.Symbol.String(),
 		"kind":          string(symbolInfo.Kind),
 		"displayName":   symbolInfo.DisplayName,
 		"documentation": symbolInfo.Documentation,
+		"package":       symbolInfo.Symbol.Package(),
+		"module":        symbolInfo.Symbol.Name,
+		"isExternal":    isExternal,
+		"version":       si.version,
 	}
 
 	return si.client.MergeNode(ctx, []string{"Symbol"}, 
@@ -279,6 +355,9 @@ func (si *SCIPIndexer) createDefinitionNode(ctx context.Context, symbolInfo *mod
 		"endLine":     symbolInfo.EndLine,
 		"startColumn": symbolInfo.StartColumn,
 		"endColumn":   symbolInfo.EndColumn,
+		"version":     si.version,
+		"createdAt":   time.Now().UTC().Unix(),
+		"updatedAt":   time.Now().UTC().Unix(),
 	}
 
 	// Calculate additional metadata for Functions and Methods
@@ -305,13 +384,13 @@ func (si *SCIPIndexer) createDefinitionNode(ctx context.Context, symbolInfo *mod
 	// Add type-specific properties
 	switch nodeLabel {
 	case "Function", "Method":
-		props["returnType"] = ""
-		props["isExported"] = true
-		props["complexity"] = 1
+		props["returnType"] = symbolInfo.ReturnType
+		props["isExported"] = symbolInfo.IsExported()
+		props["complexity"] = 1 // SCIP carries no control flow
 		props["docstring"] = symbolInfo.Documentation
 	case "Class":
 		props["fqn"] = symbolInfo.Symbol.String()
-		props["accessModifier"] = "public"
+		props["accessModifier"] = string(symbolInfo.Scope)
 		props["isAbstract"] = false
 		props["docstring"] = symbolInfo.Documentation
 	case "Variable":
@@ -321,10 +400,12 @@ func (si *SCIPIndexer) createDefinitionNode(ctx context.Context, symbolInfo *mod
 	}
 
 	return si.client.MergeNode(ctx, []string{nodeLabel}, 
-		map[string]any{"signature": symbolInfo.Signature, "filePath": symbolInfo.FilePath}, props)
+		map[string]any{"signature": symbolInfo.Signature, "filePath": symbolInfo.FilePath, "version": si.version}, props)
 }
 
-// createReferenceRelationship creates reference relationships
+// createReferenceRelationship merges the reference node of an occurrence,
+// keyed by its position, and links it to the symbol and file. Indexing the
+// same version again reuses the node and its relationships.
 func (si *SCIPIndexer) createReferenceRelationship(ctx context.Context, ref *models.SymbolReference, symbolID string, fileNodes map[string]string) error {
 	// For now, we'll create a simple reference node and link it to the symbol
 	// In a full implementation, we might want to find the exact AST node that contains the reference
@@ -336,17 +417,20 @@ func (si *SCIPIndexer) createReferenceRelationship(ctx context.Context, ref *mod
 		"startColumn": ref.StartColumn,
 		"endColumn":   ref.EndColumn,
 		"context":     ref.Context,
+		"version":     si.version,
 	}
 
-	refID, err := si.client.CreateNode(ctx, []string{"Reference"}, refProps)
+	refID, err := si.client.MergeNode(ctx, []string{"Reference"},
+		map[string]any{"filePath": ref.FilePath, "startLine": ref.StartLine, "startColumn": ref.StartColumn, "version": si.version}, refProps)
 	if err != nil {
 		return err
 	}
 
 	// Link reference to symbol
-	_, err = si.client.CreateRelationship(ctx, refID, symbolID, "REFERENCES", 
+	_, err = si.client.MergeRelationship(ctx, refID, symbolID, "REFERENCES", 
 		map[string]any{
 			"isDefinition": ref.IsDefinition,
+			"roles": ref.Roles,
 			"line": ref.StartLine,
 			"column": ref.StartColumn,
 		})
@@ -356,7 +440,7 @@ func (si *SCIPIndexer) createReferenceRelationship(ctx context.Context, ref *mod
 
 	// Link reference to file if file exists
 	if fileID, exists := fileNodes[ref.FilePath]; exists {
-		_, err = si.client.CreateRelationship(ctx, fileID, refID, "CONTAINS", nil)
+		_, err = si.client.MergeRelationship(ctx, fileID, refID, "CONTAINS", nil)
 		if err != nil {
 			return err
 		}
@@ -370,10 +454,38 @@ func (si *SCIPIndexer) SetSCIPBinary(binary string) {
 	si.scipBinary = binary
 }
 
+// SetLanguage selects the SCIP indexer of a language from LanguageConfigs,
+// replacing any binary set before
+func (si *SCIPIndexer) SetLanguage(language string) error {
+	config, err := LookupLanguage(language)
+	if err != nil {
+		return err
+	}
+	si.language = config
+	si.scipBinary = config.Binary
+	return nil
+}
+
+// SetReferenceRoles restricts REFERENCES edges to occurrences accepted by the
+// filter. A nil filter, the default, keeps every non-definition occurrence.
+func (si *SCIPIndexer) SetReferenceRoles(filter *ReferenceRoleFilter) {
+	si.referenceRoles = filter
+}
+
+// SetDebug enables printing a summary of each SCIP index to stderr before it is written
+func (si *SCIPIndexer) SetDebug(debug bool) {
+	si.debug = debug
+}
+
+// SetLogger sets the logger receiving progress and warnings
+func (si *SCIPIndexer) SetLogger(logger *slog.Logger) {
+	si.logger = logger
+}
+
 // ValidateEnvironment checks if the required tools are available
 func (si *SCIPIndexer) ValidateEnvironment() error {
 	if _, err := exec.LookPath(si.scipBinary); err != nil {
-		return fmt.Errorf("scip-go not found in PATH. Install with: go install github.com/sourcegraph/scip-go/cmd/scip-go@latest")
+		return fmt.Errorf("%s not found in PATH. Install with: %s", si.scipBinary, si.language.Install)
 	}
 	return nil
 }
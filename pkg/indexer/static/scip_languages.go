@@ -0,0 +1,62 @@
+package static
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LanguageConfig describes the SCIP indexer of one language
+type LanguageConfig struct {
+	Name    string   // Language recorded on Service and File nodes
+	Binary  string   // Indexer executable, looked up in PATH
+	Args    []string // Argument template; see expandArgs for the placeholders
+	Install string   // How to install the indexer, shown when it is missing
+}
+
+// LanguageConfigs maps the values accepted by --language to their indexers
+var LanguageConfigs = map[string]LanguageConfig{
+	"go": {
+		Name:    "Go",
+		Binary:  "scip-go",
+		Args:    []string{"--module-name", "{module}", "--module-version", "{version}", "--output", "{output}"},
+		Install: "go install github.com/sourcegraph/scip-go/cmd/scip-go@latest",
+	},
+	"typescript": {
+		Name:    "TypeScript",
+		Binary:  "scip-typescript",
+		Args:    []string{"index", "--output", "{output}"},
+		Install: "npm install -g @sourcegraph/scip-typescript",
+	},
+	"python": {
+		Name:    "Python",
+		Binary:  "scip-python",
+		Args:    []string{"index", ".", "--project-name", "{module}", "--project-version", "{version}", "--output", "{output}"},
+		Install: "npm install -g @sourcegraph/scip-python",
+	},
+}
+
+// LookupLanguage returns the indexer configuration of a language
+func LookupLanguage(language string) (LanguageConfig, error) {
+	config, ok := LanguageConfigs[strings.ToLower(language)]
+	if !ok {
+		var known []string
+		for name := range LanguageConfigs {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return LanguageConfig{}, fmt.Errorf("unsupported language %q: expected one of %s", language, strings.Join(known, ", "))
+	}
+	return config, nil
+}
+
+// expandArgs fills the argument template: {module} is the service name,
+// {version} the service version and {output} the index file to write
+func (lc LanguageConfig) expandArgs(module, version, output string) []string {
+	replacer := strings.NewReplacer("{module}", module, "{version}", version, "{output}", output)
+	args := make([]string, len(lc.Args))
+	for i, arg := range lc.Args {
+		args[i] = replacer.Replace(arg)
+	}
+	return args
+}
@@ -3,6 +3,10 @@ package static
 import (
 	"bufio"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
 	"os"
 	"strings"
 
@@ -45,97 +49,129 @@ func (sp *SCIPParser) GetMetadata() *scip.Metadata {
 	return sp.index.Metadata
 }
 
-// ExtractSymbols extracts all symbol information from the SCIP index
+// ExtractSymbols extracts all symbol information from the SCIP index. Symbol
+// kinds, documentation and relationships come from the SymbolInformation of
+// documents and external symbols. A symbol's location is that of its
+// definition occurrence, so symbols only referenced in the index have none.
+// Lines are converted to 1-based numbers; columns stay 0-based byte offsets.
 func (sp *SCIPParser) ExtractSymbols() ([]*models.SymbolDefinition, error) {
 	if sp.index == nil {
 		return nil, fmt.Errorf("no SCIP index loaded")
 	}
 
 	var symbolDefs []*models.SymbolDefinition
+	bySymbol := make(map[string]*models.SymbolDefinition)
 
-	// Process external symbols first
-	for _, symbolInfo := range sp.index.ExternalSymbols {
-		scipSymbol, err := models.ParseSCIPSymbol(symbolInfo.Symbol)
+	// symbolDef finds or creates the definition of a symbol, returning nil for
+	// local and malformed symbols
+	symbolDef := func(symbol string) *models.SymbolDefinition {
+		if existing, ok := bySymbol[symbol]; ok {
+			return existing
+		}
+		scipSymbol, err := models.ParseSCIPSymbol(symbol)
 		if err != nil {
-			continue // Skip invalid symbols
+			return nil
 		}
-
-		symbolDef := &models.SymbolDefinition{
+		kind, name := descriptorKindAndName(symbol)
+		def := &models.SymbolDefinition{
 			Symbol: scipSymbol,
 			Info: &models.SymbolInfo{
-				Symbol:        scipSymbol,
-				Kind:          convertSymbolKind(symbolInfo.Kind),
-				DisplayName:   extractDisplayName(symbolInfo.Symbol),
-				Documentation: strings.Join(symbolInfo.Documentation, " "),
-				Signature:     extractSignature(symbolInfo),
+				Symbol:      scipSymbol,
+				Kind:        kind,
+				Scope:       goScope(name),
+				DisplayName: name,
 			},
 			Refs: []*models.SymbolReference{},
 		}
+		bySymbol[symbol] = def
+		symbolDefs = append(symbolDefs, def)
+		return def
+	}
 
-		symbolDefs = append(symbolDefs, symbolDef)
+	// Process external symbols first
+	for _, symbolInfo := range sp.index.ExternalSymbols {
+		if def := symbolDef(symbolInfo.Symbol); def != nil {
+			applySymbolInformation(def, symbolInfo)
+		}
 	}
 
 	// Process documents and their symbols
 	for _, doc := range sp.index.Documents {
 		filePath := doc.RelativePath
+
+		for _, symbolInfo := range doc.Symbols {
+			if def := symbolDef(symbolInfo.Symbol); def != nil {
+				applySymbolInformation(def, symbolInfo)
+			}
+		}
 		
 		// Process occurrences in this document
 		for _, occurrence := range doc.Occurrences {
-			scipSymbol, err := models.ParseSCIPSymbol(occurrence.Symbol)
-			if err != nil {
-				continue // Skip invalid symbols
+			def := symbolDef(occurrence.Symbol)
+			if def == nil {
+				continue // Skip local and invalid symbols
 			}
 
-			// Convert SCIP ranges to our format
-			startLine, startColumn := convertRange(occurrence.Range, true)
-			endLine, endColumn := convertRange(occurrence.Range, false)
+			// Convert SCIP's 0-based lines to the 1-based lines used in the graph
+			startLine, startColumn, endLine, endColumn := occurrenceRange(occurrence.Range)
+			startLine++
+			endLine++
 
 			ref := &models.SymbolReference{
-				Symbol:      scipSymbol,
+				Symbol:      def.Symbol,
 				FilePath:    filePath,
 				StartLine:   startLine,
 				EndLine:     endLine,
 				StartColumn: startColumn,
 				EndColumn:   endColumn,
 				IsDefinition: occurrence.SymbolRoles&int32(scip.SymbolRole_Definition) != 0,
+				Roles:       occurrence.SymbolRoles,
 			}
 
-			// Find or create the symbol definition
-			var targetSymbolDef *models.SymbolDefinition
-			for _, existing := range symbolDefs {
-				if existing.Symbol.String() == scipSymbol.String() {
-					targetSymbolDef = existing
-					break
-				}
-			}
-
-			if targetSymbolDef == nil {
-				// Create new symbol definition
-				targetSymbolDef = &models.SymbolDefinition{
-					Symbol: scipSymbol,
-					Info: &models.SymbolInfo{
-						Symbol:      scipSymbol,
-						Kind:        inferSymbolKind(occurrence.Symbol),
-						DisplayName: extractDisplayName(occurrence.Symbol),
-						FilePath:    filePath,
-						StartLine:   startLine,
-						EndLine:     endLine,
-						StartColumn: startColumn,
-						EndColumn:   endColumn,
-					},
-					Refs: []*models.SymbolReference{},
-				}
-				symbolDefs = append(symbolDefs, targetSymbolDef)
+			if ref.IsDefinition && def.Info.FilePath == "" {
+				def.Info.FilePath = filePath
+				def.Info.StartLine = startLine
+				def.Info.EndLine = endLine
+				def.Info.StartColumn = startColumn
+				def.Info.EndColumn = endColumn
 			}
 
 			// Add reference to symbol definition
-			targetSymbolDef.AddReference(ref)
+			def.AddReference(ref)
 		}
 	}
 
 	return symbolDefs, nil
 }
 
+// applySymbolInformation copies the kind, names, documentation and
+// relationships SCIP reports for a symbol onto its definition
+func applySymbolInformation(def *models.SymbolDefinition, symbolInfo *scip.SymbolInformation) {
+	if symbolInfo.Kind != scip.SymbolInformation_UnspecifiedKind {
+		def.Info.Kind = convertSymbolKind(symbolInfo.Kind)
+	}
+	if symbolInfo.DisplayName != "" {
+		def.Info.DisplayName = symbolInfo.DisplayName
+	}
+	if len(symbolInfo.Documentation) > 0 {
+		def.Info.Documentation = strings.Join(symbolInfo.Documentation, " ")
+	}
+	def.Info.Signature = extractSignature(symbolInfo)
+	if symbolInfo.SignatureDocumentation != nil {
+		def.Info.ReturnType = goReturnType(symbolInfo.SignatureDocumentation.Text)
+	}
+
+	for _, relationship := range symbolInfo.Relationships {
+		def.Relationships = append(def.Relationships, &models.SymbolRelationship{
+			Symbol:           relationship.Symbol,
+			IsReference:      relationship.IsReference,
+			IsImplementation: relationship.IsImplementation,
+			IsTypeDefinition: relationship.IsTypeDefinition,
+			IsDefinition:     relationship.IsDefinition,
+		})
+	}
+}
+
 // ExtractDocuments extracts file information from the SCIP index
 func (sp *SCIPParser) ExtractDocuments() ([]*models.File, error) {
 	if sp.index == nil {
@@ -180,11 +216,11 @@ func convertSymbolKind(scipKind scip.SymbolInformation_Kind) models.SymbolKind {
 	switch scipKind {
 	case scip.SymbolInformation_UnspecifiedKind:
 		return models.VariableSymbol
-	case scip.SymbolInformation_Namespace:
+	case scip.SymbolInformation_Namespace, scip.SymbolInformation_Package:
 		return models.PackageSymbol
-	case scip.SymbolInformation_Type:
+	case scip.SymbolInformation_Type, scip.SymbolInformation_TypeAlias:
 		return models.TypeSymbol
-	case scip.SymbolInformation_Class:
+	case scip.SymbolInformation_Class, scip.SymbolInformation_Struct, scip.SymbolInformation_Enum:
 		return models.TypeSymbol
 	case scip.SymbolInformation_Interface:
 		return models.InterfaceSymbol
@@ -205,6 +241,74 @@ func convertSymbolKind(scipKind scip.SymbolInformation_Kind) models.SymbolKind {
 	}
 }
 
+// descriptorKindAndName derives the kind and name of a symbol from the suffix
+// of its final descriptor, falling back to string heuristics for symbols the
+// SCIP grammar rejects. SymbolInformation, when present, refines the kind:
+// descriptors cannot tell an interface from a struct.
+func descriptorKindAndName(symbol string) (models.SymbolKind, string) {
+	parsed, err := scip.ParseSymbol(symbol)
+	if err != nil || len(parsed.Descriptors) == 0 {
+		return inferSymbolKind(symbol), extractDisplayName(symbol)
+	}
+
+	descriptors := parsed.Descriptors
+	last := descriptors[len(descriptors)-1]
+	nested := len(descriptors) > 1 && descriptors[len(descriptors)-2].Suffix == scip.Descriptor_Type
+
+	switch last.Suffix {
+	case scip.Descriptor_Namespace:
+		return models.PackageSymbol, last.Name
+	case scip.Descriptor_Type, scip.Descriptor_TypeParameter:
+		return models.TypeSymbol, last.Name
+	case scip.Descriptor_Method:
+		if nested {
+			return models.MethodSymbol, last.Name
+		}
+		return models.FunctionSymbol, last.Name
+	case scip.Descriptor_Term:
+		if nested {
+			return models.FieldSymbol, last.Name
+		}
+		return models.VariableSymbol, last.Name
+	case scip.Descriptor_Parameter:
+		return models.ParameterSymbol, last.Name
+	case scip.Descriptor_Local:
+		return models.LocalSymbol, last.Name
+	}
+	return models.VariableSymbol, last.Name
+}
+
+// goScope returns the visibility of a Go identifier: exported names start
+// with an upper-case letter
+func goScope(name string) models.SymbolScope {
+	if token.IsExported(name) {
+		return models.PublicScope
+	}
+	return models.PackageScope
+}
+
+// goReturnType returns the results of a Go function signature such as
+// "func (c Circle) Area() float64", or "" when it has none or is not Go
+func goReturnType(signature string) string {
+	if !strings.HasPrefix(signature, "func") {
+		return ""
+	}
+	src := "package p\n" + signature
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil || len(file.Decls) == 0 {
+		return ""
+	}
+	fn, ok := file.Decls[0].(*ast.FuncDecl)
+	if !ok || fn.Type.Results == nil {
+		return ""
+	}
+
+	// Results span from the type or opening parenthesis to their end
+	results := fn.Type.Results
+	return src[fset.Position(results.Pos()).Offset:fset.Position(results.End()).Offset]
+}
+
 func inferSymbolKind(symbol string) models.SymbolKind {
 	// Simple heuristic to infer symbol kind from SCIP symbol string
 	if strings.Contains(symbol, "#") && strings.Contains(symbol, "().") {
@@ -246,21 +350,11 @@ func extractDisplayName(symbol string) string {
 }
 
 func extractSignature(symbolInfo *scip.SymbolInformation) string {
-	// For now, use the display name as signature
-	// In a full implementation, we might extract more detailed signature info
-	return symbolInfo.Symbol
-}
-
-func convertRange(scipRange []int32, isStart bool) (int, int) {
-	if len(scipRange) < 4 {
-		return 0, 0
-	}
-	
-	if isStart {
-		return int(scipRange[0]), int(scipRange[1])
-	} else {
-		return int(scipRange[2]), int(scipRange[3])
+	// Prefer the signature the indexer rendered, falling back to the symbol
+	if symbolInfo.SignatureDocumentation != nil && symbolInfo.SignatureDocumentation.Text != "" {
+		return symbolInfo.SignatureDocumentation.Text
 	}
+	return symbolInfo.Symbol
 }
 
 func inferLanguage(filePath string) string {
@@ -276,51 +370,51 @@ func inferLanguage(filePath string) string {
 	return "unknown"
 }
 
-// DebugPrintSCIPFile prints a human-readable representation of the SCIP file
-func (sp *SCIPParser) DebugPrintSCIPFile() error {
+// DebugPrintSCIPFile writes a human-readable summary of the SCIP file to w
+func (sp *SCIPParser) DebugPrintSCIPFile(w io.Writer) error {
 	if sp.index == nil {
 		return fmt.Errorf("no SCIP index loaded")
 	}
 
-	fmt.Println("=== SCIP Index Debug Output ===")
+	fmt.Fprintln(w, "=== SCIP Index Debug Output ===")
 	
 	// Print metadata
 	if metadata := sp.index.Metadata; metadata != nil {
-		fmt.Printf("Project Root: %s\n", metadata.ProjectRoot)
-		fmt.Printf("Version: %s\n", metadata.Version)
-		fmt.Printf("Tool Info: %s %s\n", metadata.ToolInfo.Name, metadata.ToolInfo.Version)
+		fmt.Fprintf(w, "Project Root: %s\n", metadata.ProjectRoot)
+		fmt.Fprintf(w, "Version: %s\n", metadata.Version)
+		fmt.Fprintf(w, "Tool Info: %s %s\n", metadata.ToolInfo.Name, metadata.ToolInfo.Version)
 	}
 
 	// Print external symbols
-	fmt.Printf("\nExternal Symbols (%d):\n", len(sp.index.ExternalSymbols))
+	fmt.Fprintf(w, "\nExternal Symbols (%d):\n", len(sp.index.ExternalSymbols))
 	for i, symbol := range sp.index.ExternalSymbols {
 		if i < 10 { // Limit output
-			fmt.Printf("  %s (Kind: %s)\n", symbol.Symbol, symbol.Kind.String())
+			fmt.Fprintf(w, "  %s (Kind: %s)\n", symbol.Symbol, symbol.Kind.String())
 		}
 	}
 	if len(sp.index.ExternalSymbols) > 10 {
-		fmt.Printf("  ... and %d more\n", len(sp.index.ExternalSymbols)-10)
+		fmt.Fprintf(w, "  ... and %d more\n", len(sp.index.ExternalSymbols)-10)
 	}
 
 	// Print documents
-	fmt.Printf("\nDocuments (%d):\n", len(sp.index.Documents))
+	fmt.Fprintf(w, "\nDocuments (%d):\n", len(sp.index.Documents))
 	for i, doc := range sp.index.Documents {
 		if i < 5 { // Limit output
-			fmt.Printf("  %s (%d occurrences)\n", doc.RelativePath, len(doc.Occurrences))
+			fmt.Fprintf(w, "  %s (%d occurrences)\n", doc.RelativePath, len(doc.Occurrences))
 			
 			// Print first few occurrences
 			for j, occ := range doc.Occurrences {
 				if j < 3 {
-					fmt.Printf("    %s [%v] (Roles: %d)\n", occ.Symbol, occ.Range, occ.SymbolRoles)
+					fmt.Fprintf(w, "    %s [%v] (Roles: %d)\n", occ.Symbol, occ.Range, occ.SymbolRoles)
 				}
 			}
 			if len(doc.Occurrences) > 3 {
-				fmt.Printf("    ... and %d more occurrences\n", len(doc.Occurrences)-3)
+				fmt.Fprintf(w, "    ... and %d more occurrences\n", len(doc.Occurrences)-3)
 			}
 		}
 	}
 	if len(sp.index.Documents) > 5 {
-		fmt.Printf("  ... and %d more documents\n", len(sp.index.Documents)-5)
+		fmt.Fprintf(w, "  ... and %d more documents\n", len(sp.index.Documents)-5)
 	}
 
 	return nil
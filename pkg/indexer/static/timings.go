@@ -0,0 +1,58 @@
+package static
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// IndexTimings is the time the last IndexProject run spent in each phase.
+// Walk, Load, Files and Link are wall-clock durations and add up to nearly
+// Total. Nodes and Relationships break down the database writes made during
+// Files; they add up the time of every worker, so with concurrent workers
+// they may exceed Files.
+type IndexTimings struct {
+	Walk          time.Duration `json:"walk"`          // Collecting the files to index
+	Load          time.Duration `json:"load"`          // Loading and type-checking packages
+	Files         time.Duration `json:"files"`         // Parsing files and writing their nodes
+	Nodes         time.Duration `json:"nodes"`         // Writing nodes, summed over workers
+	Relationships time.Duration `json:"relationships"` // Writing relationships, summed over workers
+	Link          time.Duration `json:"link"`          // Linking data flows, routes and implementations
+	Total         time.Duration `json:"total"`
+}
+
+// writeTimers accumulate the time spent in database writes across workers
+type writeTimers struct {
+	nodes         atomic.Int64
+	relationships atomic.Int64
+}
+
+// Timings returns the phase durations of the last IndexProject run
+func (si *StaticIndexer) Timings() IndexTimings {
+	return si.timings
+}
+
+// since adds the time elapsed since start to a write timer
+func since(timer *atomic.Int64, start time.Time) {
+	timer.Add(int64(time.Since(start)))
+}
+
+// mergeNode merges a node, counting the time as a node write
+func (si *StaticIndexer) mergeNode(ctx context.Context, labels []string, mergeProps, setProps map[string]any) (string, error) {
+	defer since(&si.writes.nodes, time.Now())
+	return si.client.MergeNode(ctx, labels, mergeProps, setProps)
+}
+
+// createRelationship creates a relationship, counting the time as a
+// relationship write
+func (si *StaticIndexer) createRelationship(ctx context.Context, fromID, toID, relType string, properties map[string]any) (string, error) {
+	defer since(&si.writes.relationships, time.Now())
+	return si.client.CreateRelationship(ctx, fromID, toID, relType, properties)
+}
+
+// mergeRelationship merges a relationship, counting the time as a
+// relationship write
+func (si *StaticIndexer) mergeRelationship(ctx context.Context, fromID, toID, relType string, properties map[string]any) (string, error) {
+	defer since(&si.writes.relationships, time.Now())
+	return si.client.MergeRelationship(ctx, fromID, toID, relType, properties)
+}
@@ -0,0 +1,219 @@
+//go:build cgo
+
+package static
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// treeSitterBackend extracts declarations from the syntax tree of a
+// tree-sitter grammar. TypeScript and JavaScript share the node types used
+// here; JavaScript trees simply lack type annotations.
+type treeSitterBackend struct {
+	name       string
+	extensions []string
+	language   *sitter.Language
+}
+
+func newTypeScriptBackend() (LanguageBackend, error) {
+	return &treeSitterBackend{
+		name:       "TypeScript",
+		extensions: []string{".ts", ".mts", ".cts"},
+		language:   typescript.GetLanguage(),
+	}, nil
+}
+
+func newJavaScriptBackend() (LanguageBackend, error) {
+	return &treeSitterBackend{
+		name:       "JavaScript",
+		extensions: []string{".js", ".jsx", ".mjs", ".cjs"},
+		language:   javascript.GetLanguage(),
+	}, nil
+}
+
+func (b *treeSitterBackend) Name() string         { return b.name }
+func (b *treeSitterBackend) Extensions() []string { return b.extensions }
+
+// Parse returns the top-level functions, classes, interfaces and variables
+// of a file. Parsers are not safe for concurrent use, so each call makes its
+// own.
+func (b *treeSitterBackend) Parse(ctx context.Context, src []byte) ([]Declaration, error) {
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(b.language)
+
+	tree, err := parser.ParseCtx(ctx, nil, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", b.name, err)
+	}
+	defer tree.Close()
+
+	root := tree.RootNode()
+	var decls []Declaration
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		decls = append(decls, declarations(root.NamedChild(i), src, false)...)
+	}
+	return decls, nil
+}
+
+// declarations converts a top-level statement into declarations
+func declarations(node *sitter.Node, src []byte, exported bool) []Declaration {
+	switch node.Type() {
+	case "export_statement":
+		if decl := node.ChildByFieldName("declaration"); decl != nil {
+			return declarations(decl, src, true)
+		}
+	case "function_declaration", "generator_function_declaration":
+		decl := newDeclaration("Function", node, src, exported)
+		decl.Signature, decl.ReturnType = functionSignature(decl.Name, node, src)
+		return []Declaration{decl}
+	case "class_declaration", "abstract_class_declaration":
+		decl := newDeclaration("Class", node, src, exported)
+		if body := node.ChildByFieldName("body"); body != nil {
+			for i := 0; i < int(body.NamedChildCount()); i++ {
+				member := body.NamedChild(i)
+				if member.Type() != "method_definition" {
+					continue
+				}
+				method := newDeclaration("Method", member, src, exported && !isPrivateMember(member, src))
+				method.Signature, method.ReturnType = functionSignature(method.Name, member, src)
+				decl.Members = append(decl.Members, method)
+			}
+		}
+		return []Declaration{decl}
+	case "interface_declaration":
+		return []Declaration{newDeclaration("Interface", node, src, exported)}
+	case "lexical_declaration", "variable_declaration":
+		isConstant := node.Child(0) != nil && node.Child(0).Type() == "const"
+		var decls []Declaration
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			declarator := node.NamedChild(i)
+			if declarator.Type() != "variable_declarator" {
+				continue
+			}
+			// Destructuring patterns declare no single name
+			if name := declarator.ChildByFieldName("name"); name == nil || name.Type() != "identifier" {
+				continue
+			}
+			decls = append(decls, variableDeclaration(node, declarator, src, exported, isConstant))
+		}
+		return decls
+	}
+	return nil
+}
+
+// variableDeclaration converts a declarator into a Function when it is
+// assigned an arrow function or function expression, and a Variable otherwise.
+// Positions span the whole statement so that docstrings line up.
+func variableDeclaration(statement, declarator *sitter.Node, src []byte, exported, isConstant bool) Declaration {
+	name := declarator.ChildByFieldName("name").Content(src)
+	value := declarator.ChildByFieldName("value")
+	if value != nil {
+		switch value.Type() {
+		case "arrow_function", "function", "function_expression", "generator_function":
+			decl := newDeclaration("Function", statement, src, exported)
+			decl.Name = name
+			decl.Signature, decl.ReturnType = functionSignature(name, value, src)
+			return decl
+		}
+	}
+
+	decl := newDeclaration("Variable", statement, src, exported)
+	decl.Name = name
+	decl.IsConstant = isConstant
+	if typ := declarator.ChildByFieldName("type"); typ != nil {
+		decl.Type = typeAnnotation(typ, src)
+	}
+	return decl
+}
+
+// newDeclaration fills in the name, position and docstring of a declaration
+func newDeclaration(kind string, node *sitter.Node, src []byte, exported bool) Declaration {
+	decl := Declaration{
+		Kind:        kind,
+		IsExported:  exported,
+		Docstring:   docComment(node, src),
+		StartLine:   int(node.StartPoint().Row) + 1,
+		EndLine:     int(node.EndPoint().Row) + 1,
+		StartColumn: int(node.StartPoint().Column) + 1,
+		EndColumn:   int(node.EndPoint().Column) + 1,
+		StartByte:   int(node.StartByte()),
+		EndByte:     int(node.EndByte()),
+	}
+	if name := node.ChildByFieldName("name"); name != nil {
+		decl.Name = name.Content(src)
+	}
+	return decl
+}
+
+// functionSignature renders the signature of a function, method or arrow
+// function, e.g. add(a: number, b: number): number, along with its return
+// type when annotated
+func functionSignature(name string, node *sitter.Node, src []byte) (string, string) {
+	params := "()"
+	if p := node.ChildByFieldName("parameters"); p != nil {
+		params = p.Content(src)
+	} else if p := node.ChildByFieldName("parameter"); p != nil {
+		params = "(" + p.Content(src) + ")" // Arrow function with a bare parameter, x => x
+	}
+
+	var returnType string
+	if r := node.ChildByFieldName("return_type"); r != nil {
+		returnType = typeAnnotation(r, src)
+	}
+	if returnType == "" {
+		return name + params, ""
+	}
+	return name + params + ": " + returnType, returnType
+}
+
+// typeAnnotation returns the type of an annotation without its colon
+func typeAnnotation(node *sitter.Node, src []byte) string {
+	return strings.TrimSpace(strings.TrimPrefix(node.Content(src), ":"))
+}
+
+// isPrivateMember reports whether a class member is private, either with
+// the private modifier or a #name
+func isPrivateMember(member *sitter.Node, src []byte) bool {
+	if name := member.ChildByFieldName("name"); name != nil && name.Type() == "private_property_identifier" {
+		return true
+	}
+	for i := 0; i < int(member.NamedChildCount()); i++ {
+		child := member.NamedChild(i)
+		if child.Type() == "accessibility_modifier" && child.Content(src) != "public" {
+			return true
+		}
+	}
+	return false
+}
+
+// docComment returns the text of the /** */ comment directly above a
+// declaration, or above the export statement wrapping it
+func docComment(node *sitter.Node, src []byte) string {
+	if parent := node.Parent(); parent != nil && parent.Type() == "export_statement" {
+		node = parent
+	}
+	prev := node.PrevNamedSibling()
+	if prev == nil || prev.Type() != "comment" || prev.EndPoint().Row+1 < node.StartPoint().Row {
+		return ""
+	}
+	text := prev.Content(src)
+	if !strings.HasPrefix(text, "/**") {
+		return ""
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(text, "/**"), "*/"), "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "*")
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,17 @@
+//go:build !cgo
+
+package static
+
+import "errors"
+
+// errTreeSitterUnavailable is returned when selecting a tree-sitter language
+// in a binary built without cgo, which the tree-sitter grammars require
+var errTreeSitterUnavailable = errors.New("indexing languages other than Go requires a build with cgo enabled")
+
+func newTypeScriptBackend() (LanguageBackend, error) {
+	return nil, errTreeSitterUnavailable
+}
+
+func newJavaScriptBackend() (LanguageBackend, error) {
+	return nil, errTreeSitterUnavailable
+}
@@ -0,0 +1,18 @@
+// Package logging builds the leveled loggers shared by the indexers, the CLI
+// and the MCP server
+package logging
+
+import (
+	"io"
+	"log/slog"
+)
+
+// New returns a logger writing text records to w. Debug records are only
+// written when verbose is set.
+func New(w io.Writer, verbose bool) *slog.Logger {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
@@ -16,12 +16,16 @@ const (
 	FunctionNode  NodeType = "Function"
 	MethodNode    NodeType = "Method"
 	VariableNode  NodeType = "Variable"
+	LocalVariableNode NodeType = "LocalVariable"
 	ParameterNode NodeType = "Parameter"
 	SymbolNode    NodeType = "Symbol"
 	APIRouteNode  NodeType = "APIRoute"
+	ExternalEndpointNode NodeType = "ExternalEndpoint"
 	CommentNode   NodeType = "Comment"
 	DocumentNode  NodeType = "Document"
+	DocumentChunkNode NodeType = "DocumentChunk"
 	FeatureNode   NodeType = "Feature"
+	IndexRunNode  NodeType = "IndexRun"
 )
 
 // BaseNode represents common properties for all nodes
@@ -42,6 +46,17 @@ type Service struct {
 	RepositoryURL string `json:"repositoryUrl" neo4j:"repositoryUrl"`
 }
 
+// IndexRun records a single indexing run of a service
+type IndexRun struct {
+	BaseNode
+	Service    string `json:"service" neo4j:"service"`
+	Version    string `json:"version" neo4j:"version"`
+	Indexer    string `json:"indexer" neo4j:"indexer"`       // "ast" or "scip"
+	StartedAt  int64  `json:"startedAt" neo4j:"startedAt"`   // Unix seconds
+	FinishedAt int64  `json:"finishedAt" neo4j:"finishedAt"` // Unix seconds
+	FileCount  int    `json:"fileCount" neo4j:"fileCount"`
+}
+
 // File represents a source code file
 type File struct {
 	BaseNode
@@ -60,6 +75,8 @@ type Module struct {
 	FQN        string `json:"fqn" neo4j:"fqn"`
 	Type       string `json:"type" neo4j:"type"`
 	IsExported bool   `json:"isExported" neo4j:"isExported"`
+	IsExternal bool   `json:"isExternal" neo4j:"isExternal"` // Imported from outside the indexed project
+	IsStdlib   bool   `json:"isStdlib" neo4j:"isStdlib"`
 }
 
 // Class represents an object-oriented class definition
@@ -162,6 +179,15 @@ type APIRoute struct {
 	Version      string `json:"version" neo4j:"version"`
 }
 
+// ExternalEndpoint represents an HTTP endpoint called by the indexed code
+type ExternalEndpoint struct {
+	BaseNode
+	Method string `json:"method" neo4j:"method"`
+	URL    string `json:"url" neo4j:"url"`
+	Host   string `json:"host" neo4j:"host"`
+	Path   string `json:"path" neo4j:"path"`
+}
+
 // Comment represents code comments and docstrings
 type Comment struct {
 	BaseNode
@@ -182,6 +208,16 @@ type Document struct {
 	Content   string `json:"content" neo4j:"content"`
 }
 
+// DocumentChunk represents one chunk of a document's content, the unit
+// document search matches against
+type DocumentChunk struct {
+	BaseNode
+	Content    string `json:"content" neo4j:"content"`
+	ChunkIndex int    `json:"chunkIndex" neo4j:"chunkIndex"` // 0-based position within the document
+	SourceURL  string `json:"sourceUrl" neo4j:"sourceUrl"`   // Source of the parent document
+	WordCount  int    `json:"wordCount" neo4j:"wordCount"`
+}
+
 // Feature represents a specific feature or capability
 type Feature struct {
 	BaseNode
@@ -241,6 +277,10 @@ func NodeFactory(nodeType NodeType, props map[string]any) interface{} {
 		return &APIRoute{
 			BaseNode: BaseNode{Props: props, CreatedAt: now, UpdatedAt: now},
 		}
+	case ExternalEndpointNode:
+		return &ExternalEndpoint{
+			BaseNode: BaseNode{Props: props, CreatedAt: now, UpdatedAt: now},
+		}
 	case CommentNode:
 		return &Comment{
 			BaseNode: BaseNode{Props: props, CreatedAt: now, UpdatedAt: now},
@@ -249,6 +289,10 @@ func NodeFactory(nodeType NodeType, props map[string]any) interface{} {
 		return &Document{
 			BaseNode: BaseNode{Props: props, CreatedAt: now, UpdatedAt: now},
 		}
+	case DocumentChunkNode:
+		return &DocumentChunk{
+			BaseNode: BaseNode{Props: props, CreatedAt: now, UpdatedAt: now},
+		}
 	case FeatureNode:
 		return &Feature{
 			BaseNode: BaseNode{Props: props, CreatedAt: now, UpdatedAt: now},
@@ -0,0 +1,37 @@
+package models
+
+// FileOutline represents the structural map of a single source file
+type FileOutline struct {
+	FilePath      string                 `json:"filePath"`
+	Entries       []*OutlineEntry        `json:"entries"`
+	Relationships []*OutlineRelationship `json:"relationships"`
+}
+
+// OutlineEntry represents a declaration contained in a file
+type OutlineEntry struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"` // Function, Method, Class, Interface, Variable
+	Signature string `json:"signature,omitempty"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	ParentID  string `json:"parentId,omitempty"` // Enclosing declaration within the same file
+}
+
+// OutlineRelationship represents a relationship between two entries of the same file
+type OutlineRelationship struct {
+	FromID string `json:"fromId"`
+	ToID   string `json:"toId"`
+	Type   string `json:"type"`
+}
+
+// Children returns the entries whose parent is the given entry
+func (fo *FileOutline) Children(parentID string) []*OutlineEntry {
+	var children []*OutlineEntry
+	for _, entry := range fo.Entries {
+		if entry.ParentID == parentID {
+			children = append(children, entry)
+		}
+	}
+	return children
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AnyLabel matches every node label in a RedactionConfig
+const AnyLabel = "*"
+
+// RedactionConfig lists node properties to omit from serialized output, keyed by label
+type RedactionConfig struct {
+	Properties map[string][]string `json:"properties"`
+}
+
+// DefaultRedaction strips raw embedding vectors from human-facing output
+func DefaultRedaction() *RedactionConfig {
+	return &RedactionConfig{
+		Properties: map[string][]string{
+			AnyLabel: {"embedding"},
+		},
+	}
+}
+
+// ParseRedaction parses a comma-separated list of properties to redact. Each
+// entry is either "property" (all labels) or "Label.property". An empty spec
+// yields the default redaction and "none" disables redaction.
+func ParseRedaction(spec string) (*RedactionConfig, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "":
+		return DefaultRedaction(), nil
+	case "none":
+		return &RedactionConfig{Properties: map[string][]string{}}, nil
+	}
+
+	config := &RedactionConfig{Properties: make(map[string][]string)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		label, property := AnyLabel, entry
+		if i := strings.LastIndex(entry, "."); i >= 0 {
+			label, property = entry[:i], entry[i+1:]
+		}
+		if label == "" || property == "" {
+			return nil, fmt.Errorf("invalid redaction entry %q: expected property or Label.property", entry)
+		}
+
+		config.Properties[label] = append(config.Properties[label], property)
+	}
+
+	return config, nil
+}
+
+// Redact returns a copy of props without the properties redacted for any of
+// the given labels. The input map is not modified.
+func (rc *RedactionConfig) Redact(labels []string, props map[string]any) map[string]any {
+	if rc == nil || len(rc.Properties) == 0 {
+		return props
+	}
+
+	omit := make(map[string]bool)
+	for _, property := range rc.Properties[AnyLabel] {
+		omit[property] = true
+	}
+	for _, label := range labels {
+		for _, property := range rc.Properties[label] {
+			omit[property] = true
+		}
+	}
+
+	redacted := make(map[string]any, len(props))
+	for key, value := range props {
+		if !omit[key] {
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
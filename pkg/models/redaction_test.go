@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactionConfig(t *testing.T) {
+	props := map[string]any{
+		"name":         "IndexProject",
+		"filePath":     "pkg/indexer/static/indexer.go",
+		"absolutePath": "/home/dev/codegraph/pkg/indexer/static/indexer.go",
+		"embedding":    []float64{0.1, 0.2, 0.3},
+	}
+
+	// The default strips embeddings only
+	redacted := DefaultRedaction().Redact([]string{"Function"}, props)
+	assert.NotContains(t, redacted, "embedding")
+	assert.Equal(t, "IndexProject", redacted["name"])
+	assert.Contains(t, redacted, "absolutePath")
+	assert.Contains(t, props, "embedding", "The input map must not be modified")
+
+	// Label-scoped entries only apply to that label
+	config, err := ParseRedaction("embedding,Function.absolutePath")
+	require.NoError(t, err)
+
+	redacted = config.Redact([]string{"Function"}, props)
+	assert.NotContains(t, redacted, "embedding")
+	assert.NotContains(t, redacted, "absolutePath")
+	assert.Equal(t, "pkg/indexer/static/indexer.go", redacted["filePath"])
+
+	redacted = config.Redact([]string{"File"}, props)
+	assert.NotContains(t, redacted, "embedding")
+	assert.Contains(t, redacted, "absolutePath")
+
+	// Redaction can be disabled entirely
+	config, err = ParseRedaction("none")
+	require.NoError(t, err)
+	assert.Equal(t, props, config.Redact([]string{"Function"}, props))
+
+	_, err = ParseRedaction("Function.")
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ const (
 	ContainsRel   RelationshipType = "CONTAINS"
 	DefinesRel    RelationshipType = "DEFINES"
 	ReferencesRel RelationshipType = "REFERENCES"
+	ImportsRel    RelationshipType = "IMPORTS"
 
 	// Behavioral Relationships
 	CallsRel      RelationshipType = "CALLS"
@@ -28,6 +29,7 @@ const (
 	// Documentation Relationships
 	DescribesRel RelationshipType = "DESCRIBES"
 	MentionsRel  RelationshipType = "MENTIONS"
+	PartOfRel    RelationshipType = "PART_OF"
 )
 
 // BaseRelationship represents common properties for all relationships
@@ -37,6 +37,44 @@ func ParseSCIPSymbol(symbol string) (*SCIPSymbol, error) {
 	}, nil
 }
 
+// Package returns the package a symbol belongs to, read from the namespace
+// descriptors leading its descriptor: github.com/google/uuid for
+// `github.com/google/uuid`/NewString(). and fmt for fmt/Println(). Symbols
+// without a namespace belong to the package named in the symbol.
+func (s *SCIPSymbol) Package() string {
+	var namespaces []string
+	rest := s.Descriptor
+	for rest != "" {
+		var name string
+		if rest[0] == '`' {
+			end := strings.IndexByte(rest[1:], '`')
+			if end < 0 {
+				break
+			}
+			name, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool {
+				return !(r == '_' || r == '+' || r == '-' || r == '$' ||
+					('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9'))
+			})
+			if end <= 0 {
+				break
+			}
+			name, rest = rest[:end], rest[end:]
+		}
+		if !strings.HasPrefix(rest, "/") {
+			break // A type, term or method, not a namespace
+		}
+		namespaces = append(namespaces, name)
+		rest = rest[1:]
+	}
+
+	if len(namespaces) == 0 {
+		return s.Name
+	}
+	return strings.Join(namespaces, "/")
+}
+
 // NewGoSCIPSymbol creates a SCIP symbol for Go code
 func NewGoSCIPSymbol(packageName, version, descriptor string) *SCIPSymbol {
 	return &SCIPSymbol{
@@ -156,6 +194,7 @@ type SymbolReference struct {
 	StartColumn int         `json:"startColumn"`
 	EndColumn   int         `json:"endColumn"`
 	IsDefinition bool       `json:"isDefinition"`
+	Roles       int32       `json:"roles,omitempty"` // SCIP symbol role bitmask
 	Context     string      `json:"context"` // surrounding code context
 }
 
@@ -164,6 +203,17 @@ type SymbolDefinition struct {
 	Symbol *SCIPSymbol  `json:"symbol"`
 	Info   *SymbolInfo  `json:"info"`
 	Refs   []*SymbolReference `json:"references"`
+	Relationships []*SymbolRelationship `json:"relationships,omitempty"`
+}
+
+// SymbolRelationship links a symbol to another one, such as the interface a
+// type implements or the method an override replaces
+type SymbolRelationship struct {
+	Symbol           string `json:"symbol"`
+	IsReference      bool   `json:"isReference,omitempty"`
+	IsImplementation bool   `json:"isImplementation,omitempty"`
+	IsTypeDefinition bool   `json:"isTypeDefinition,omitempty"`
+	IsDefinition     bool   `json:"isDefinition,omitempty"`
 }
 
 // AddReference adds a reference to this symbol definition
@@ -2,10 +2,16 @@ package neo4j
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/auth"
 )
 
 // Config holds the configuration for Neo4j connection
@@ -14,24 +20,88 @@ type Config struct {
 	Username string
 	Password string
 	Database string
+
+	// MaxRetries is how many times a transaction failing with a transient
+	// error is retried: 0 uses DefaultMaxRetries, a negative value disables
+	// retries
+	MaxRetries int
+	// RetryDelay is the wait before the first retry, doubled for each further
+	// one (default DefaultRetryDelay)
+	RetryDelay time.Duration
+	// QueryTimeout bounds each query, retries included, and is sent to the
+	// server as the transaction timeout so it aborts the query too. Zero
+	// leaves queries unbounded.
+	QueryTimeout time.Duration
+
+	// MaxConnectionPoolSize caps the connections the driver keeps open (default
+	// DefaultMaxConnectionPoolSize); keep it above the indexing concurrency
+	MaxConnectionPoolSize int
+	// ConnectionAcquisitionTimeout is how long a query waits for a free pooled
+	// connection (default DefaultConnectionAcquisitionTimeout)
+	ConnectionAcquisitionTimeout time.Duration
+	// MaxConnectionLifetime is the age after which pooled connections are
+	// closed (default DefaultMaxConnectionLifetime)
+	MaxConnectionLifetime time.Duration
+
+	// Encrypted connects over TLS even when URI has a plain bolt:// or
+	// neo4j:// scheme; bolt+s://, neo4j+s:// and their +ssc variants are
+	// always encrypted
+	Encrypted bool
+	// TrustStrategy decides which server certificates an encrypted connection
+	// accepts: TrustSystemCAs by default, TrustCustomCAs when CACertPath is
+	// set, TrustAllCertificates for +ssc URIs
+	TrustStrategy string
+	// CACertPath is a PEM file of the certificate authorities trusted by
+	// TrustCustomCAs
+	CACertPath string
 }
 
+// Trust strategies for the server certificate of encrypted connections
+const (
+	TrustSystemCAs       = "system" // Certificates signed by a CA the system trusts
+	TrustCustomCAs       = "custom" // Certificates signed by a CA in Config.CACertPath
+	TrustAllCertificates = "all"    // Any certificate, without verification
+)
+
+// Connection pool defaults applied to zero Config values
+const (
+	DefaultMaxConnectionPoolSize        = 50
+	DefaultConnectionAcquisitionTimeout = 2 * time.Minute
+	DefaultMaxConnectionLifetime        = 30 * time.Minute
+)
+
+// ErrQueryTimeout is returned when a query runs longer than Config.QueryTimeout
+var ErrQueryTimeout = errors.New("query timed out")
+
 // Client wraps the Neo4j driver and provides higher-level operations
 type Client struct {
-	driver   neo4j.DriverWithContext
-	database string
+	driver       neo4j.DriverWithContext
+	database     string
+	retry        retryPolicy
+	queryTimeout time.Duration
+	newSession   func(ctx context.Context, config neo4j.SessionConfig) session
 }
 
+// driverFactory creates the driver of a client, as neo4j.NewDriverWithContext
+type driverFactory func(target string, token auth.TokenManager, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error)
+
 // NewClient creates a new Neo4j client with the given configuration
 func NewClient(config Config) (*Client, error) {
-	driver, err := neo4j.NewDriverWithContext(
-		config.URI,
+	return newClient(config, neo4j.NewDriverWithContext)
+}
+
+// newClient creates a client whose driver is created by newDriver
+func newClient(config Config, newDriver driverFactory) (*Client, error) {
+	target, tlsConfig, err := config.tlsSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := newDriver(
+		target,
 		neo4j.BasicAuth(config.Username, config.Password, ""),
-		func(c *neo4j.Config) {
-			c.MaxConnectionPoolSize = 50
-			c.MaxConnectionLifetime = 30 * time.Minute
-			c.ConnectionAcquisitionTimeout = 2 * time.Minute
-		},
+		config.configureDriver,
+		func(c *neo4j.Config) { c.TlsConfig = tlsConfig },
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Neo4j driver: %w", err)
@@ -48,56 +118,230 @@ func NewClient(config Config) (*Client, error) {
 	}
 
 	return &Client{
-		driver:   driver,
-		database: config.Database,
+		driver:       driver,
+		database:     config.Database,
+		retry:        newRetryPolicy(config),
+		queryTimeout: config.QueryTimeout,
+		newSession: func(ctx context.Context, config neo4j.SessionConfig) session {
+			return driver.NewSession(ctx, config)
+		},
 	}, nil
 }
 
+// configureDriver applies the connection pool settings, or their defaults, to
+// the driver configuration
+func (config Config) configureDriver(c *neo4j.Config) {
+	c.MaxConnectionPoolSize = DefaultMaxConnectionPoolSize
+	if config.MaxConnectionPoolSize > 0 {
+		c.MaxConnectionPoolSize = config.MaxConnectionPoolSize
+	}
+	c.ConnectionAcquisitionTimeout = DefaultConnectionAcquisitionTimeout
+	if config.ConnectionAcquisitionTimeout > 0 {
+		c.ConnectionAcquisitionTimeout = config.ConnectionAcquisitionTimeout
+	}
+	c.MaxConnectionLifetime = DefaultMaxConnectionLifetime
+	if config.MaxConnectionLifetime > 0 {
+		c.MaxConnectionLifetime = config.MaxConnectionLifetime
+	}
+	// Each managed transaction is attempted once; the client retries
+	// transient failures itself, see retryPolicy
+	c.MaxTransactionRetryTime = 0
+}
+
+// tlsSettings returns the URI the driver connects to and the TLS
+// configuration of the connection. The driver decides on encryption and
+// certificate verification from the URI scheme alone, so Encrypted and
+// TrustStrategy are applied by rewriting the scheme: +s verifies the server
+// certificate, +ssc accepts any certificate.
+func (config Config) tlsSettings() (string, *tls.Config, error) {
+	scheme, rest, found := strings.Cut(config.URI, "://")
+	base, security, _ := strings.Cut(scheme, "+")
+	if !found || (base != "bolt" && base != "neo4j") || security == "unix" {
+		// Unix sockets and malformed URIs are left to the driver
+		if config.Encrypted || config.TrustStrategy != "" || config.CACertPath != "" {
+			return "", nil, fmt.Errorf("TLS settings are not supported for Neo4j URI %q", config.URI)
+		}
+		return config.URI, nil, nil
+	}
+	if security != "" && security != "s" && security != "ssc" {
+		return "", nil, fmt.Errorf("unsupported Neo4j URI scheme %q", scheme)
+	}
+
+	if security == "" && !config.Encrypted {
+		if config.TrustStrategy != "" || config.CACertPath != "" {
+			return "", nil, fmt.Errorf("a trust strategy needs an encrypted connection: enable encryption or use a %s+s:// URI", base)
+		}
+		return config.URI, nil, nil
+	}
+
+	strategy := config.TrustStrategy
+	if strategy == "" {
+		switch {
+		case config.CACertPath != "":
+			strategy = TrustCustomCAs
+		case security == "ssc":
+			strategy = TrustAllCertificates
+		default:
+			strategy = TrustSystemCAs
+		}
+	}
+	if security == "ssc" && strategy != TrustAllCertificates {
+		return "", nil, fmt.Errorf("trust strategy %q conflicts with the %s URI scheme, which trusts all certificates", strategy, scheme)
+	}
+
+	switch strategy {
+	case TrustSystemCAs:
+		if config.CACertPath != "" {
+			return "", nil, fmt.Errorf("a CA certificate needs the %s trust strategy, not %s", TrustCustomCAs, strategy)
+		}
+		return base + "+s://" + rest, nil, nil
+	case TrustAllCertificates:
+		if config.CACertPath != "" {
+			return "", nil, fmt.Errorf("a CA certificate needs the %s trust strategy, not %s", TrustCustomCAs, strategy)
+		}
+		return base + "+ssc://" + rest, nil, nil
+	case TrustCustomCAs:
+		if config.CACertPath == "" {
+			return "", nil, fmt.Errorf("the %s trust strategy needs a CA certificate", TrustCustomCAs)
+		}
+		pem, err := os.ReadFile(config.CACertPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return "", nil, fmt.Errorf("no PEM certificates found in %s", config.CACertPath)
+		}
+		return base + "+s://" + rest, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+	default:
+		return "", nil, fmt.Errorf("unknown trust strategy %q: expected %s, %s or %s",
+			strategy, TrustSystemCAs, TrustCustomCAs, TrustAllCertificates)
+	}
+}
+
 // Close closes the Neo4j driver connection
 func (c *Client) Close(ctx context.Context) error {
 	return c.driver.Close(ctx)
 }
 
-// ExecuteQuery executes a Cypher query and returns the result
+// ExecuteQuery executes a Cypher query in a write transaction and returns the
+// result, retrying the transaction when it fails with a transient error
 func (c *Client) ExecuteQuery(ctx context.Context, cypher string, params map[string]any) ([]*neo4j.Record, error) {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{
-		DatabaseName: c.database,
-	})
-	defer session.Close(ctx)
+	return c.collect(ctx, neo4j.AccessModeWrite, cypher, params)
+}
 
-	result, err := session.Run(ctx, cypher, params)
-	if err != nil {
-		return nil, err
-	}
+// ExecuteReadQuery executes a read-only Cypher query in a read transaction, so
+// that a cluster can route it to any member, and returns the result. The
+// transaction is retried when it fails with a transient error.
+func (c *Client) ExecuteReadQuery(ctx context.Context, cypher string, params map[string]any) ([]*neo4j.Record, error) {
+	return c.collect(ctx, neo4j.AccessModeRead, cypher, params)
+}
 
-	records, err := result.Collect(ctx)
+// collect runs a query in a transaction of the given mode and returns all of
+// its records
+func (c *Client) collect(ctx context.Context, mode neo4j.AccessMode, cypher string, params map[string]any) ([]*neo4j.Record, error) {
+	records, err := c.execute(ctx, mode, func(tx neo4j.ManagedTransaction) (any, error) {
+		result, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		return result.Collect(ctx)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return records, nil
+	result, _ := records.([]*neo4j.Record)
+	return result, nil
 }
 
-// ExecuteWrite executes a write transaction
-func (c *Client) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (any, error)) (any, error) {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{
-		DatabaseName: c.database,
-		AccessMode:   neo4j.AccessModeWrite,
+// ErrStopStream may be returned by the callback of ExecuteQueryStream to stop
+// reading records without failing the query
+var ErrStopStream = errors.New("stop stream")
+
+// ExecuteQueryStream executes a read query and passes each record to fn as it
+// arrives from the server, so large results are never held in memory at once.
+// Returning ErrStopStream from fn stops the stream early; any other error
+// aborts the query and is returned. The query is only retried on transient
+// errors raised before the first record was delivered.
+func (c *Client) ExecuteQueryStream(ctx context.Context, cypher string, params map[string]any, fn func(record *neo4j.Record) error) error {
+	delivered := false
+	_, err := c.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		result, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		for result.Next(ctx) {
+			delivered = true
+			if err := fn(result.Record()); err != nil {
+				// Consuming would pull the remaining records; the
+				// transaction is rolled back with them unread
+				return nil, &permanentError{err: err}
+			}
+		}
+		if err := result.Err(); err != nil {
+			if delivered {
+				return nil, &permanentError{err: err}
+			}
+			return nil, err
+		}
+		return nil, nil
 	})
-	defer session.Close(ctx)
+	if errors.Is(err, ErrStopStream) {
+		return nil
+	}
+	return err
+}
 
-	return session.ExecuteWrite(ctx, work)
+// ExecuteWrite executes a write transaction, retrying it when it fails with a
+// transient error
+func (c *Client) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (any, error)) (any, error) {
+	return c.execute(ctx, neo4j.AccessModeWrite, work)
 }
 
-// ExecuteRead executes a read transaction
+// ExecuteRead executes a read transaction, retrying it when it fails with a
+// transient error
 func (c *Client) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (any, error)) (any, error) {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{
+	return c.execute(ctx, neo4j.AccessModeRead, work)
+}
+
+// execute runs work in a managed transaction of a new session under the
+// client's retry policy and query timeout
+func (c *Client) execute(ctx context.Context, mode neo4j.AccessMode, work neo4j.ManagedTransactionWork) (any, error) {
+	var configurers []func(*neo4j.TransactionConfig)
+	if c.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.queryTimeout)
+		defer cancel()
+		configurers = append(configurers, neo4j.WithTxTimeout(c.queryTimeout))
+	}
+
+	session := c.newSession(ctx, neo4j.SessionConfig{
 		DatabaseName: c.database,
-		AccessMode:   neo4j.AccessModeRead,
+		AccessMode:   mode,
+	})
+	defer session.Close(context.WithoutCancel(ctx))
+
+	result, err := c.retry.run(ctx, func() (any, error) {
+		if mode == neo4j.AccessModeRead {
+			return session.ExecuteRead(ctx, work, configurers...)
+		}
+		return session.ExecuteWrite(ctx, work, configurers...)
 	})
-	defer session.Close(ctx)
+	if err != nil && c.queryTimeout > 0 && isTimeout(ctx, err) {
+		return nil, fmt.Errorf("%w after %s: %w", ErrQueryTimeout, c.queryTimeout, err)
+	}
+	return result, err
+}
 
-	return session.ExecuteRead(ctx, work)
+// isTimeout reports whether a query failed because its context expired or the
+// server aborted its transaction for exceeding the transaction timeout
+func isTimeout(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return true
+	}
+	var neo4jErr *neo4j.Neo4jError
+	return errors.As(err, &neo4jErr) && strings.HasPrefix(neo4jErr.Code, "Neo.ClientError.Transaction.TransactionTimedOut")
 }
 
 // CreateNode creates a single node in the graph
@@ -150,15 +394,29 @@ func (c *Client) MergeNode(ctx context.Context, labels []string, mergeProps, set
 		mergeClause += fmt.Sprintf("%s: $merge.%s", key, key)
 	}
 
+	// createdAt is only written when the node is created so re-indexing keeps
+	// the original creation time
+	onCreateProps := make(map[string]any)
+	updateProps := make(map[string]any, len(setProps))
+	for key, value := range setProps {
+		if key == "createdAt" {
+			onCreateProps[key] = value
+			continue
+		}
+		updateProps[key] = value
+	}
+
 	cypher := fmt.Sprintf(`
 		MERGE (n:%s {%s})
+		ON CREATE SET n += $onCreate
 		SET n += $set
 		RETURN elementId(n) as id
 	`, labelStr, mergeClause)
 
 	params := map[string]any{
-		"merge": mergeProps,
-		"set":   setProps,
+		"merge":    mergeProps,
+		"onCreate": onCreateProps,
+		"set":      updateProps,
 	}
 
 	result, err := c.ExecuteQuery(ctx, cypher, params)
@@ -211,6 +469,44 @@ func (c *Client) CreateRelationship(ctx context.Context, fromID, toID, relType s
 	return id, nil
 }
 
+// MergeRelationship creates a relationship between two nodes unless one of the
+// same type already connects them, then sets the given properties on it.
+// Re-running it is idempotent, which suits structural edges like CONTAINS.
+func (c *Client) MergeRelationship(ctx context.Context, fromID, toID, relType string, properties map[string]any) (string, error) {
+	cypher := fmt.Sprintf(`
+		MATCH (from), (to)
+		WHERE elementId(from) = $fromId AND elementId(to) = $toId
+		MERGE (from)-[r:%s]->(to)
+		SET r += $props
+		RETURN elementId(r) as id
+	`, relType)
+
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	params := map[string]any{
+		"fromId": fromID,
+		"toId":   toID,
+		"props":  properties,
+	}
+
+	result, err := c.ExecuteQuery(ctx, cypher, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to merge relationship: %w", err)
+	}
+
+	if len(result) == 0 {
+		return "", fmt.Errorf("no records returned from merge relationship query")
+	}
+
+	id, ok := result[0].AsMap()["id"].(string)
+	if !ok {
+		return "", fmt.Errorf("failed to extract relationship ID from result")
+	}
+
+	return id, nil
+}
+
 // BatchCreateNodes creates multiple nodes in a single transaction
 func (c *Client) BatchCreateNodes(ctx context.Context, nodes []BatchNode) error {
 	cypher := `
@@ -293,6 +589,32 @@ func (c *Client) GetDatabaseInfo(ctx context.Context) (map[string]any, error) {
 	return info, nil
 }
 
+// CountNodesByLabel returns the number of nodes carrying each label
+func (c *Client) CountNodesByLabel(ctx context.Context) (map[string]int64, error) {
+	return c.countBy(ctx, "MATCH (n) UNWIND labels(n) AS key RETURN key, count(*) AS count")
+}
+
+// CountRelationshipsByType returns the number of relationships of each type
+func (c *Client) CountRelationshipsByType(ctx context.Context) (map[string]int64, error) {
+	return c.countBy(ctx, "MATCH ()-[r]->() RETURN type(r) AS key, count(*) AS count")
+}
+
+// countBy collects the key and count columns of a query into a map
+func (c *Client) countBy(ctx context.Context, cypher string) (map[string]int64, error) {
+	result, err := c.ExecuteQuery(ctx, cypher, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count graph elements: %w", err)
+	}
+
+	counts := make(map[string]int64)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		key, _ := recordMap["key"].(string)
+		counts[key], _ = recordMap["count"].(int64)
+	}
+	return counts, nil
+}
+
 // BatchNode represents a node for batch operations
 type BatchNode struct {
 	Labels     []string       `json:"labels"`
@@ -0,0 +1,163 @@
+package neo4j
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteQueryTimesOut(t *testing.T) {
+	fake := &fakeSession{block: true}
+	client := newFakeClient(fake, Config{QueryTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, err := client.ExecuteQuery(context.Background(), "MATCH (a), (b), (c) RETURN count(*)", nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrQueryTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, 50*time.Millisecond, fake.txTimeout, "The server should be given the timeout too")
+	assert.True(t, fake.closed)
+}
+
+// capturedDriverConfig creates a client with config and returns the driver
+// configuration NewClient would pass to the driver
+func capturedDriverConfig(t *testing.T, config Config) *neo4j.Config {
+	t.Helper()
+	_, captured := capturedDriver(t, config)
+	return captured
+}
+
+// capturedDriver is capturedDriverConfig that also returns the URI the
+// driver would connect to
+func capturedDriver(t *testing.T, config Config) (string, *neo4j.Config) {
+	t.Helper()
+	errStop := errors.New("stop before connecting")
+	var uri string
+	var captured *neo4j.Config
+	_, err := newClient(config, func(target string, token auth.TokenManager, configurers ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+		uri = target
+		captured = &neo4j.Config{}
+		for _, configurer := range configurers {
+			configurer(captured)
+		}
+		return nil, errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	require.NotNil(t, captured)
+	return uri, captured
+}
+
+func TestNewClientConfiguresConnectionPool(t *testing.T) {
+	driverConfig := capturedDriverConfig(t, Config{
+		MaxConnectionPoolSize:        200,
+		ConnectionAcquisitionTimeout: 15 * time.Second,
+		MaxConnectionLifetime:        time.Hour,
+	})
+	assert.Equal(t, 200, driverConfig.MaxConnectionPoolSize)
+	assert.Equal(t, 15*time.Second, driverConfig.ConnectionAcquisitionTimeout)
+	assert.Equal(t, time.Hour, driverConfig.MaxConnectionLifetime)
+	assert.Zero(t, driverConfig.MaxTransactionRetryTime)
+
+	driverConfig = capturedDriverConfig(t, Config{})
+	assert.Equal(t, DefaultMaxConnectionPoolSize, driverConfig.MaxConnectionPoolSize)
+	assert.Equal(t, DefaultConnectionAcquisitionTimeout, driverConfig.ConnectionAcquisitionTimeout)
+	assert.Equal(t, DefaultMaxConnectionLifetime, driverConfig.MaxConnectionLifetime)
+}
+
+// writeCACert writes a self-signed CA certificate to a PEM file and returns
+// its path and the certificate
+func writeCACert(t *testing.T) (string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "codegraph test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return path, cert
+}
+
+func TestNewClientConfiguresTLS(t *testing.T) {
+	uri, driverConfig := capturedDriver(t, Config{URI: "bolt://localhost:7687"})
+	assert.Equal(t, "bolt://localhost:7687", uri)
+	assert.Nil(t, driverConfig.TlsConfig)
+
+	// Encrypted schemes are passed through as they are
+	for _, scheme := range []string{"bolt+s", "neo4j+s", "bolt+ssc", "neo4j+ssc"} {
+		uri, driverConfig = capturedDriver(t, Config{URI: scheme + "://example.databases.neo4j.io"})
+		assert.Equal(t, scheme+"://example.databases.neo4j.io", uri)
+		assert.Nil(t, driverConfig.TlsConfig)
+	}
+
+	uri, _ = capturedDriver(t, Config{URI: "neo4j://localhost:7687", Encrypted: true})
+	assert.Equal(t, "neo4j+s://localhost:7687", uri, "Encryption verifies certificates against the system CAs")
+
+	uri, _ = capturedDriver(t, Config{URI: "bolt://localhost:7687", Encrypted: true, TrustStrategy: TrustAllCertificates})
+	assert.Equal(t, "bolt+ssc://localhost:7687", uri)
+
+	caPath, caCert := writeCACert(t)
+	uri, driverConfig = capturedDriver(t, Config{URI: "neo4j+s://localhost:7687", CACertPath: caPath})
+	assert.Equal(t, "neo4j+s://localhost:7687", uri)
+	require.NotNil(t, driverConfig.TlsConfig)
+	expected := x509.NewCertPool()
+	expected.AddCert(caCert)
+	assert.True(t, expected.Equal(driverConfig.TlsConfig.RootCAs), "Only the custom CA should be trusted")
+	assert.False(t, driverConfig.TlsConfig.InsecureSkipVerify)
+
+	uri, driverConfig = capturedDriver(t, Config{URI: "bolt://localhost:7687", Encrypted: true, TrustStrategy: TrustCustomCAs, CACertPath: caPath})
+	assert.Equal(t, "bolt+s://localhost:7687", uri)
+	require.NotNil(t, driverConfig.TlsConfig)
+}
+
+func TestNewClientRejectsInvalidTLSSettings(t *testing.T) {
+	caPath, _ := writeCACert(t)
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0600))
+
+	for name, config := range map[string]Config{
+		"trust without encryption": {URI: "bolt://localhost:7687", CACertPath: caPath},
+		"unknown strategy":         {URI: "bolt+s://localhost:7687", TrustStrategy: "some"},
+		"custom without CA":        {URI: "bolt+s://localhost:7687", TrustStrategy: TrustCustomCAs},
+		"CA with system strategy":  {URI: "bolt+s://localhost:7687", TrustStrategy: TrustSystemCAs, CACertPath: caPath},
+		"CA with ssc scheme":       {URI: "bolt+ssc://localhost:7687", CACertPath: caPath},
+		"missing CA file":          {URI: "bolt+s://localhost:7687", CACertPath: filepath.Join(t.TempDir(), "missing.pem")},
+		"CA file without PEM":      {URI: "bolt+s://localhost:7687", CACertPath: invalid},
+		"unix socket":              {URI: "bolt+unix:///tmp/neo4j.sock", Encrypted: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newClient(config, func(string, auth.TokenManager, ...func(*neo4j.Config)) (neo4j.DriverWithContext, error) {
+				t.Fatal("The driver should not be created")
+				return nil, nil
+			})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// SearchOption changes how SearchNodes finds matching nodes
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	fulltext bool
+	fuzzy    bool
+	boosts   map[string]map[string]float64 // Field boosts by index name, "" for every index
+}
+
+// UseFulltextIndex makes SearchNodes query an online full-text index covering
+// every requested label. Searches without labels, or without such an index,
+// fall back to scanning the nodes with CONTAINS.
+func UseFulltextIndex() SearchOption {
+	return func(options *searchOptions) {
+		options.fulltext = true
+	}
+}
+
+// FuzzyFallback retries a full-text search that found nothing with fuzzy
+// matching, so that words one edit away from the indexed ones match, e.g.
+// parseConfg finds parseConfig. Records found this way carry fuzzy = true.
+// Searches scanning nodes instead of an index are not retried.
+func FuzzyFallback() SearchOption {
+	return func(options *searchOptions) {
+		options.fuzzy = true
+	}
+}
+
+// DefaultFieldBoosts weigh matches in names and titles above matches in
+// signatures, paths or content when searching a full-text index. Fields
+// without a boost weigh 1.
+var DefaultFieldBoosts = map[string]float64{
+	"name":        3,
+	"title":       3,
+	"displayName": 2,
+}
+
+// BoostFields sets the weight of matches in the fields of a full-text index,
+// e.g. {"name": 5, "content": 0.5}, on top of DefaultFieldBoosts. An empty
+// index name applies the boosts to every index; boosts of a named index take
+// precedence.
+func BoostFields(index string, boosts map[string]float64) SearchOption {
+	return func(options *searchOptions) {
+		if options.boosts == nil {
+			options.boosts = make(map[string]map[string]float64)
+		}
+		if options.boosts[index] == nil {
+			options.boosts[index] = make(map[string]float64)
+		}
+		maps.Copy(options.boosts[index], boosts)
+	}
+}
+
+// boostsFor returns the field boosts applied when searching an index
+func (options searchOptions) boostsFor(index string) map[string]float64 {
+	boosts := maps.Clone(DefaultFieldBoosts)
+	maps.Copy(boosts, options.boosts[""])
+	maps.Copy(boosts, options.boosts[index])
+	return boosts
+}
+
+// ParseFieldBoost parses a field boost given as [index:]field=weight, e.g.
+// name=5 or code_fulltext:content=0.5
+func ParseFieldBoost(spec string) (index, field string, weight float64, err error) {
+	target, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return "", "", 0, fmt.Errorf("invalid field boost %q: expected [index:]field=weight", spec)
+	}
+	index, field, ok = strings.Cut(target, ":")
+	if !ok {
+		index, field = "", target
+	}
+	if field == "" {
+		return "", "", 0, fmt.Errorf("invalid field boost %q: missing field", spec)
+	}
+	weight, err = strconv.ParseFloat(value, 64)
+	if err != nil || weight <= 0 {
+		return "", "", 0, fmt.Errorf("invalid field boost %q: weight must be a positive number", spec)
+	}
+	return index, field, weight, nil
+}
+
+// searchIndexes caches the online full-text node indexes. They are listed once
+// per query builder, and shared with the builders derived from it, so
+// searches do not probe the server each time. A failed listing is not cached
+// and is retried by the next search.
+type searchIndexes struct {
+	mu           sync.Mutex
+	loaded       bool
+	failedBefore bool // A listing failed and was logged
+	indexes      []fulltextIndex
+}
+
+type fulltextIndex struct {
+	name       string
+	labels     []any
+	properties []string
+}
+
+// load lists the online full-text node indexes on first use, and again after
+// a failed listing. The outcome is logged once: searches without a covering
+// index quietly scan the nodes.
+func (si *searchIndexes) load(ctx context.Context, client *Client) []fulltextIndex {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	if si.loaded {
+		return si.indexes
+	}
+
+	result, err := client.ExecuteQuery(ctx, `
+		SHOW FULLTEXT INDEXES YIELD name, entityType, labelsOrTypes, properties, state
+		WHERE entityType = 'NODE' AND state = 'ONLINE'
+		RETURN name, labelsOrTypes, properties
+		ORDER BY name
+	`, nil)
+	if err != nil {
+		if si.failedBefore {
+			slog.Debug("Full-text indexes could not be listed", "error", err)
+		} else {
+			slog.Info("Full-text indexes could not be listed; searches scan nodes instead", "error", err)
+			si.failedBefore = true
+		}
+		return nil
+	}
+	si.loaded = true
+
+	var names []string
+	for _, record := range result {
+		recordMap := record.AsMap()
+		labels, _ := recordMap["labelsOrTypes"].([]any)
+		index := fulltextIndex{name: getString(recordMap, "name"), labels: labels}
+		properties, _ := recordMap["properties"].([]any)
+		for _, property := range properties {
+			if name, ok := property.(string); ok {
+				index.properties = append(index.properties, name)
+			}
+		}
+		si.indexes = append(si.indexes, index)
+		names = append(names, index.name)
+	}
+	if len(names) == 0 {
+		slog.Info("No online full-text index; searches scan nodes instead")
+		return nil
+	}
+	slog.Debug("Full-text indexes available for search", "indexes", names)
+	return si.indexes
+}
+
+// fulltextIndexFor returns an online full-text node index covering all the
+// labels, or nil when there is none
+func (qb *QueryBuilder) fulltextIndexFor(ctx context.Context, labels []string) *fulltextIndex {
+	for _, index := range qb.searchIndexes.load(ctx, qb.client) {
+		covered := true
+		for _, label := range labels {
+			if !slices.Contains(index.labels, any(label)) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return &index
+		}
+	}
+	return nil
+}
+
+// searchFulltext runs a search through a full-text index, returning records
+// shaped like those of the CONTAINS scan, best matches first, with a fuzzy
+// column telling whether words were matched fuzzily
+func (qb *QueryBuilder) searchFulltext(ctx context.Context, index *fulltextIndex, searchTerm string, labelFilter string, limit int, boosts map[string]float64, fuzzy bool) ([]*neo4j.Record, error) {
+	params := map[string]any{
+		"index": index.name,
+		"query": fulltextQuery(searchTerm, index.properties, boosts, fuzzy),
+		"fuzzy": fuzzy,
+	}
+	cypher := fmt.Sprintf(`
+		CALL db.index.fulltext.queryNodes($index, $query) YIELD node AS n, score
+		WHERE (%s) AND %s
+		RETURN n, labels(n) AS nodeLabels, $fuzzy AS fuzzy
+		ORDER BY score DESC, n.name
+	`, labelFilter, qb.versionFilter("n", params))
+	if limit > 0 {
+		cypher += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search full-text index %s: %w", index.name, err)
+	}
+	return result, nil
+}
+
+// fulltextQuery turns a search term into a Lucene query matching nodes that
+// contain every word of it, as the CONTAINS scan does. When a property of the
+// index is boosted, each word is matched against every property by name so
+// that matches in boosted properties score higher. Fuzzy queries match words
+// within one edit instead of as substrings.
+func fulltextQuery(searchTerm string, properties []string, boosts map[string]float64, fuzzy bool) string {
+	boosted := slices.ContainsFunc(properties, func(property string) bool {
+		boost, ok := boosts[property]
+		return ok && boost != 1
+	})
+
+	var clauses []string
+	for _, word := range strings.Fields(strings.ToLower(searchTerm)) {
+		pattern := "*" + escapeLucene(word) + "*"
+		if fuzzy {
+			pattern = escapeLucene(word) + "~1"
+		}
+		if !boosted {
+			clauses = append(clauses, pattern)
+			continue
+		}
+
+		fields := make([]string, len(properties))
+		for i, property := range properties {
+			fields[i] = escapeLucene(property) + ":" + pattern
+			if boost, ok := boosts[property]; ok && boost != 1 {
+				fields[i] += "^" + strconv.FormatFloat(boost, 'g', -1, 64)
+			}
+		}
+		clauses = append(clauses, "("+strings.Join(fields, " OR ")+")")
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// escapeLucene escapes the characters Lucene's query syntax treats specially
+func escapeLucene(term string) string {
+	var escaped strings.Builder
+	for _, r := range term {
+		if strings.ContainsRune(`+-&|!(){}[]^"~*?:\/`, r) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
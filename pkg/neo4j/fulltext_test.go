@@ -0,0 +1,99 @@
+package neo4j
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingFulltextIndexLogsOnce(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(defaultLogger)
+
+	// Every query, the index listing included, returns no records
+	fake := &fakeSession{result: []*neo4j.Record{}}
+	queryBuilder := NewQueryBuilder(newFakeClient(fake, Config{})).WithVersion("v1.0.0")
+
+	for i := 0; i < 3; i++ {
+		_, err := queryBuilder.SearchNodes(context.Background(), "User", []string{"Function"}, 10, UseFulltextIndex())
+		require.NoError(t, err)
+	}
+
+	// One listing, then three scans
+	assert.Equal(t, 4, fake.attempts)
+	assert.Equal(t, 1, strings.Count(logs.String(), "\n"), logs.String())
+	assert.Contains(t, logs.String(), "level=INFO")
+	assert.NotContains(t, logs.String(), "level=WARN")
+}
+
+func TestFailedFulltextIndexListingIsRetried(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	// The first listing fails, every later query returns no records
+	fake := &fakeSession{errs: []error{errors.New("connection reset")}, result: []*neo4j.Record{}}
+	queryBuilder := NewQueryBuilder(newFakeClient(fake, Config{}))
+
+	for i := 0; i < 3; i++ {
+		_, err := queryBuilder.SearchNodes(context.Background(), "User", []string{"Function"}, 10, UseFulltextIndex())
+		require.NoError(t, err)
+	}
+
+	// A failed listing and a scan, a successful listing and a scan, then a
+	// scan with the cached listing
+	assert.Equal(t, 5, fake.attempts)
+	assert.Contains(t, logs.String(), "Full-text indexes could not be listed")
+	assert.Contains(t, logs.String(), "No online full-text index")
+}
+
+func TestFulltextQueryFieldBoosts(t *testing.T) {
+	properties := []string{"name", "content"}
+
+	assert.Equal(t, "*save* AND *user*", fulltextQuery("Save User", properties, nil, false),
+		"Without boosted properties every field weighs the same")
+	assert.Equal(t, "(name:*save*^3 OR content:*save*) AND (name:*user*^3 OR content:*user*)",
+		fulltextQuery("Save User", properties, searchOptions{}.boostsFor("docs"), false))
+
+	var options searchOptions
+	BoostFields("", map[string]float64{"content": 0.5})(&options)
+	BoostFields("docs", map[string]float64{"name": 10})(&options)
+	assert.Equal(t, "(name:*user*^10 OR content:*user*^0.5)", fulltextQuery("user", properties, options.boostsFor("docs"), false))
+	assert.Equal(t, "(name:*user*^3 OR content:*user*^0.5)", fulltextQuery("user", properties, options.boostsFor("code"), false))
+	assert.Equal(t, 3.0, DefaultFieldBoosts["name"], "Options must not modify the defaults")
+}
+
+func TestParseFieldBoost(t *testing.T) {
+	index, field, weight, err := ParseFieldBoost("docs_fulltext:content=0.5")
+	require.NoError(t, err)
+	assert.Equal(t, "docs_fulltext", index)
+	assert.Equal(t, "content", field)
+	assert.Equal(t, 0.5, weight)
+
+	index, field, weight, err = ParseFieldBoost("name=5")
+	require.NoError(t, err)
+	assert.Equal(t, "", index)
+	assert.Equal(t, "name", field)
+	assert.Equal(t, 5.0, weight)
+
+	for _, spec := range []string{"name", "=2", "name=zero", "name=-1"} {
+		_, _, _, err := ParseFieldBoost(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestFulltextQueryFuzzy(t *testing.T) {
+	assert.Equal(t, "parseconfg~1 AND load~1", fulltextQuery("parseConfg load", nil, nil, true))
+	assert.Equal(t, "(name:parseconfg~1^3 OR signature:parseconfg~1)",
+		fulltextQuery("parseConfg", []string{"name", "signature"}, DefaultFieldBoosts, true))
+}
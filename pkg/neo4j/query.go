@@ -2,9 +2,11 @@ package neo4j
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/context-maximiser/code-graph/pkg/models"
@@ -13,22 +15,90 @@ import (
 
 // QueryBuilder helps build Cypher queries programmatically
 type QueryBuilder struct {
-	client *Client
+	client        *Client
+	version       string           // Restrict results to nodes indexed at this service version
+	sourceLimits  SourceReadLimits // Bounds on source files read for code extraction
+	maxResults    int              // Cap on unlimited searches, DefaultMaxSearchResults when zero
+	searchIndexes *searchIndexes   // Full-text indexes, listed on the first full-text search
+}
+
+// DefaultMaxSearchResults caps the records a search without a limit returns,
+// so a broad term cannot pull the whole graph into memory
+const DefaultMaxSearchResults = 10000
+
+// ErrFunctionNotFound is returned when no function or method matches a lookup
+var ErrFunctionNotFound = errors.New("function not found")
+
+// ErrInvalidIdentifier is returned when a label or property name that would be
+// interpolated into Cypher is not a plain identifier
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// identifierPattern matches the labels and property names safe to interpolate
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateIdentifiers rejects names that cannot be interpolated into Cypher
+// unquoted, so caller input can never alter the query
+func validateIdentifiers(names ...string) error {
+	for _, name := range names {
+		if !identifierPattern.MatchString(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+	}
+	return nil
 }
 
 // NewQueryBuilder creates a new query builder
 func NewQueryBuilder(client *Client) *QueryBuilder {
-	return &QueryBuilder{client: client}
+	return &QueryBuilder{client: client, searchIndexes: &searchIndexes{}}
+}
+
+// WithVersion returns a query builder whose search and lookup queries only
+// match nodes indexed at the given service version. An empty version matches
+// all versions.
+func (qb *QueryBuilder) WithVersion(version string) *QueryBuilder {
+	copied := *qb
+	copied.version = version
+	return &copied
+}
+
+// WithMaxResults returns a query builder whose searches without a limit stop
+// after max records instead of DefaultMaxSearchResults
+func (qb *QueryBuilder) WithMaxResults(max int) *QueryBuilder {
+	copied := *qb
+	copied.maxResults = max
+	return &copied
+}
+
+// searchCap returns the number of records an unlimited search may return
+func (qb *QueryBuilder) searchCap() int {
+	if qb.maxResults > 0 {
+		return qb.maxResults
+	}
+	return DefaultMaxSearchResults
+}
+
+// versionFilter returns a Cypher predicate restricting alias to the builder's
+// version and adds the parameter it needs. It returns "true" when unscoped.
+func (qb *QueryBuilder) versionFilter(alias string, params map[string]any) string {
+	if qb.version == "" {
+		return "true"
+	}
+	params["version"] = qb.version
+	return fmt.Sprintf("%s.version = $version", alias)
 }
 
 // FindNodesByLabel finds all nodes with a specific label
 func (qb *QueryBuilder) FindNodesByLabel(ctx context.Context, label string, limit int) ([]*neo4j.Record, error) {
+	if err := validateIdentifiers(label); err != nil {
+		return nil, err
+	}
+
 	cypher := fmt.Sprintf("MATCH (n:%s) RETURN n", label)
 	if limit > 0 {
 		cypher += fmt.Sprintf(" LIMIT %d", limit)
 	}
 
-	result, err := qb.client.ExecuteQuery(ctx, cypher, nil)
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find nodes by label %s: %w", label, err)
 	}
@@ -38,10 +108,14 @@ func (qb *QueryBuilder) FindNodesByLabel(ctx context.Context, label string, limi
 
 // FindNodeByProperty finds nodes by a specific property value
 func (qb *QueryBuilder) FindNodeByProperty(ctx context.Context, label, property string, value any) ([]*neo4j.Record, error) {
+	if err := validateIdentifiers(label, property); err != nil {
+		return nil, err
+	}
+
 	cypher := fmt.Sprintf("MATCH (n:%s {%s: $value}) RETURN n", label, property)
 	params := map[string]any{"value": value}
 
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find node by property %s=%v: %w", property, value, err)
 	}
@@ -64,7 +138,7 @@ func (qb *QueryBuilder) FindSymbolDefinition(ctx context.Context, symbol string)
 	`
 
 	params := map[string]any{"symbol": symbol}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find symbol definition: %w", err)
 	}
@@ -119,47 +193,111 @@ func (qb *QueryBuilder) FindSymbolDefinition(ctx context.Context, symbol string)
 
 // FindAllReferences finds all references to a symbol
 func (qb *QueryBuilder) FindAllReferences(ctx context.Context, symbol string) ([]*models.SymbolReference, error) {
-	cypher := `
+	references, _, err := qb.FindReferencesPage(ctx, symbol, ReferencePage{})
+	return references, err
+}
+
+// ResolveSymbol returns the SCIP symbols a name may refer to. A full SCIP
+// symbol resolves to itself; a bare name resolves to every symbol with that
+// display name, so more than one result means the name is ambiguous.
+func (qb *QueryBuilder) ResolveSymbol(ctx context.Context, name string) ([]string, error) {
+	if _, err := models.ParseSCIPSymbol(name); err == nil {
+		return []string{name}, nil
+	}
+
+	params := map[string]any{"name": name}
+	cypher := fmt.Sprintf(`
+		MATCH (s:Symbol)
+		WHERE s.displayName = $name AND %s
+		RETURN DISTINCT s.symbol AS symbol
+		ORDER BY symbol
+	`, qb.versionFilter("s", params))
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve symbol: %w", err)
+	}
+
+	var symbols []string
+	for _, record := range result {
+		symbols = append(symbols, getString(record.AsMap(), "symbol"))
+	}
+	return symbols, nil
+}
+
+// ReferencePage selects a page of the references to a symbol
+type ReferencePage struct {
+	Limit      int    // Maximum references returned, 0 for all
+	Offset     int    // References skipped
+	FilePrefix string // Only references in files whose path starts with the prefix
+}
+
+// FindReferencesPage finds a page of the references to a symbol, ordered by
+// file and position, and returns it with the number of matching references
+func (qb *QueryBuilder) FindReferencesPage(ctx context.Context, symbol string, page ReferencePage) ([]*models.SymbolReference, int, error) {
+	params := map[string]any{
+		"symbol":     symbol,
+		"filePrefix": page.FilePrefix,
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+	}
+	cypher := fmt.Sprintf(`
 		MATCH (s:Symbol {symbol: $symbol})<-[:REFERENCES]-(usage)
+		WHERE %s
 		MATCH (usage)<-[:CONTAINS*]-(file:File)
-		RETURN 
-			usage.name AS usageName,
-			usage.startLine AS startLine,
-			usage.endLine AS endLine,
-			usage.startColumn AS startColumn,
-			usage.endColumn AS endColumn,
-			file.path AS filePath
-		ORDER BY file.path, startLine
-	`
+		WHERE $filePrefix = '' OR file.path STARTS WITH $filePrefix
+		WITH usage, file
+		ORDER BY file.path, usage.startLine, usage.startColumn, elementId(usage)
+		WITH collect({
+			usageName: usage.name,
+			startLine: usage.startLine,
+			endLine: usage.endLine,
+			startColumn: usage.startColumn,
+			endColumn: usage.endColumn,
+			context: usage.context,
+			filePath: file.path
+		}) AS refs
+		RETURN size(refs) AS total,
+			CASE WHEN $limit > 0 THEN refs[$offset..$offset + $limit] ELSE refs[$offset..] END AS page
+	`, qb.versionFilter("usage", params))
 
-	params := map[string]any{"symbol": symbol}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find symbol references: %w", err)
+		return nil, 0, fmt.Errorf("failed to find symbol references: %w", err)
 	}
 
 	scipSymbol, err := models.ParseSCIPSymbol(symbol)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse SCIP symbol: %w", err)
+		return nil, 0, fmt.Errorf("failed to parse SCIP symbol: %w", err)
+	}
+
+	if len(result) == 0 {
+		return nil, 0, nil
 	}
+	recordMap := result[0].AsMap()
+	total := getInt(recordMap, "total")
+	rows, _ := recordMap["page"].([]any)
 
 	var references []*models.SymbolReference
-	for _, record := range result {
-		recordMap := record.AsMap()
+	for _, row := range rows {
+		refMap, ok := row.(map[string]any)
+		if !ok {
+			continue
+		}
 		
 		ref := &models.SymbolReference{
 			Symbol:      scipSymbol,
-			FilePath:    getString(recordMap, "filePath"),
-			StartLine:   getInt(recordMap, "startLine"),
-			EndLine:     getInt(recordMap, "endLine"),
-			StartColumn: getInt(recordMap, "startColumn"),
-			EndColumn:   getInt(recordMap, "endColumn"),
+			FilePath:    getString(refMap, "filePath"),
+			StartLine:   getInt(refMap, "startLine"),
+			EndLine:     getInt(refMap, "endLine"),
+			StartColumn: getInt(refMap, "startColumn"),
+			EndColumn:   getInt(refMap, "endColumn"),
+			Context:     getString(refMap, "context"),
 			IsDefinition: false, // These are usage references
 		}
 		references = append(references, ref)
 	}
 
-	return references, nil
+	return references, total, nil
 }
 
 // FindImplementations finds all classes that implement an interface
@@ -177,7 +315,7 @@ func (qb *QueryBuilder) FindImplementations(ctx context.Context, interfaceSymbol
 	`
 
 	params := map[string]any{"interfaceSymbol": interfaceSymbol}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find implementations: %w", err)
 	}
@@ -220,7 +358,7 @@ func (qb *QueryBuilder) FindAPIEndpointsAffectedByFunction(ctx context.Context,
 	`
 
 	params := map[string]any{"functionSymbol": functionSymbol}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find affected API endpoints: %w", err)
 	}
@@ -241,85 +379,472 @@ func (qb *QueryBuilder) FindAPIEndpointsAffectedByFunction(ctx context.Context,
 	return routes, nil
 }
 
-// TraceDataFlow traces the flow of data from a parameter to function calls
-func (qb *QueryBuilder) TraceDataFlow(ctx context.Context, paramSymbol string) ([]*models.SymbolReference, error) {
-	cypher := `
+// FindCallPaths returns every CALLS path of up to maxDepth hops from the
+// functions or methods identified by root (a name, signature or symbol).
+// Direction is "outgoing", "incoming" or "both". Each record holds the root
+// node and the nodes and relationships of one path; a root without calls
+// yields a single record with null path columns.
+func (qb *QueryBuilder) FindCallPaths(ctx context.Context, root string, maxDepth int, direction string) ([]*neo4j.Record, error) {
+	if maxDepth < 1 {
+		return nil, fmt.Errorf("max depth must be at least 1, got %d", maxDepth)
+	}
+
+	var pattern string
+	switch direction {
+	case "outgoing":
+		pattern = fmt.Sprintf("-[:CALLS*1..%d]->", maxDepth)
+	case "incoming":
+		pattern = fmt.Sprintf("<-[:CALLS*1..%d]-", maxDepth)
+	case "both":
+		pattern = fmt.Sprintf("-[:CALLS*1..%d]-", maxDepth)
+	default:
+		return nil, fmt.Errorf("invalid call graph direction: %s", direction)
+	}
+
+	params := map[string]any{"root": root}
+	cypher := fmt.Sprintf(`
+		MATCH (root)
+		WHERE (root:Function OR root:Method)
+		  AND (root.name = $root OR root.signature = $root OR
+			   EXISTS { MATCH (root)-[:DEFINES]->(:Symbol {symbol: $root}) })
+		  AND %s
+		OPTIONAL MATCH path = (root)%s(other)
+		WHERE other:Function OR other:Method
+		RETURN root, labels(root) AS rootLabels, nodes(path) AS pathNodes, relationships(path) AS pathRels
+	`, qb.versionFilter("root", params), pattern)
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find call paths for %s: %w", root, err)
+	}
+
+	return result, nil
+}
+
+// TraceDataFlow returns every FLOWS_TO path of up to maxSteps hops from the
+// parameter identified by paramSymbol. Only paths that visit each node once and
+// cannot be extended further (or reach maxSteps) are returned. Each record
+// holds the parameter, the nodes and relationships of one path, the symbol of
+// each node and the function or method containing each node.
+func (qb *QueryBuilder) TraceDataFlow(ctx context.Context, paramSymbol string, maxSteps int) ([]*neo4j.Record, error) {
+	if maxSteps < 1 {
+		return nil, fmt.Errorf("max steps must be at least 1, got %d", maxSteps)
+	}
+
+	params := map[string]any{"paramSymbol": paramSymbol, "maxSteps": maxSteps}
+	cypher := fmt.Sprintf(`
 		MATCH (param:Parameter)-[:DEFINES]->(:Symbol {symbol: $paramSymbol})
-		
-		// Follow the data flow path through intermediate variables
-		MATCH path = (param)-[:FLOWS_TO*1..15]->(usage)
-		
-		// Identify if the final usage is a parameter in another function call
-		MATCH (usage:Parameter)<-[:CONTAINS]-(call:Method)
-		WHERE usage:Parameter
-		
-		RETURN 
-			call.name AS receivingMethod,
-			call.signature AS receivingMethodSignature,
-			nodes(path) AS dataFlowPath
-	`
+		WHERE %s
+
+		// Follow the data flow through local variables and call arguments
+		MATCH path = (param)-[:FLOWS_TO*1..%d]->(sink)
+		WHERE ALL(n IN nodes(path) WHERE single(m IN nodes(path) WHERE m = n))
+		  AND (length(path) = $maxSteps OR NOT EXISTS {
+			MATCH (sink)-[:FLOWS_TO]->(next) WHERE NOT next IN nodes(path)
+		  })
 
-	params := map[string]any{"paramSymbol": paramSymbol}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+		RETURN
+			param,
+			nodes(path) AS pathNodes,
+			relationships(path) AS pathRels,
+			[n IN nodes(path) | head([(n)-[:DEFINES]->(s:Symbol) | s.symbol])] AS pathSymbols,
+			[n IN nodes(path) | head([(f)-[:CONTAINS]->(n) WHERE f:Function OR f:Method | f.name])] AS pathFunctions
+	`, qb.versionFilter("param", params), maxSteps)
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to trace data flow: %w", err)
 	}
 
-	scipSymbol, err := models.ParseSCIPSymbol(paramSymbol)
+	return result, nil
+}
+
+// DependencyUse is one use of a package by a service: calls from one of its
+// functions to a symbol of the package, or an import of the package by one of
+// its modules
+type DependencyUse struct {
+	Package         string // Package name of the SCIP symbol, or import path
+	CallingFunction string // Empty for imports
+	TargetSymbol    string // Empty for imports
+	Calls           int
+	ImportedBy      string // Importing module, empty for calls
+	IsExternal      bool   // Whether the package lies outside the service
+	IsStdlib        bool
+}
+
+// DiscoverServiceDependencies finds the packages a service uses. Calls are
+// attributed to the package the SCIP indexer tagged the called function's
+// symbol with, or for untagged symbols the package named in the symbol;
+// imports come from the IMPORTS relationships of the service's modules. Uses of
+// the service's own packages are returned with IsExternal unset.
+func (qb *QueryBuilder) DiscoverServiceDependencies(ctx context.Context, serviceName string) ([]DependencyUse, error) {
+	params := map[string]any{"serviceName": serviceName}
+	modulesCypher := fmt.Sprintf(`
+		MATCH (:Service {name: $serviceName})-[:CONTAINS]->(:File)<-[:CONTAINS]-(m:Module)
+		WHERE %s
+		OPTIONAL MATCH (m)-[:IMPORTS]->(dep:Module)
+		RETURN m.fqn AS module, m.name AS name,
+			collect({fqn: dep.fqn, isExternal: dep.isExternal, isStdlib: dep.isStdlib}) AS imports
+	`, qb.versionFilter("m", params))
+
+	result, err := qb.client.ExecuteReadQuery(ctx, modulesCypher, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse SCIP symbol: %w", err)
+		return nil, fmt.Errorf("failed to find service modules: %w", err)
 	}
 
-	var references []*models.SymbolReference
+	internal := map[string]bool{serviceName: true}
+	var moduleFQNs []string
+	var uses []DependencyUse
+	seenImports := make(map[[2]string]bool)
 	for _, record := range result {
 		recordMap := record.AsMap()
-		
-		ref := &models.SymbolReference{
-			Symbol:  scipSymbol,
-			Context: getString(recordMap, "receivingMethod"),
+		module := getString(recordMap, "module")
+		internal[module] = true
+		internal[getString(recordMap, "name")] = true
+		moduleFQNs = append(moduleFQNs, module)
+
+		imports, _ := recordMap["imports"].([]any)
+		for _, imp := range imports {
+			impMap, _ := imp.(map[string]any)
+			fqn := getString(impMap, "fqn")
+			if fqn == "" || seenImports[[2]string{module, fqn}] {
+				continue
+			}
+			seenImports[[2]string{module, fqn}] = true
+			isExternal, _ := impMap["isExternal"].(bool)
+			isStdlib, _ := impMap["isStdlib"].(bool)
+			uses = append(uses, DependencyUse{
+				Package:    fqn,
+				ImportedBy: module,
+				IsExternal: isExternal,
+				IsStdlib:   isStdlib,
+			})
 		}
-		references = append(references, ref)
 	}
 
-	return references, nil
+	params = map[string]any{"serviceName": serviceName}
+	callsCypher := fmt.Sprintf(`
+		MATCH (:Service {name: $serviceName})-[:CONTAINS*]->(caller)
+		WHERE (caller:Function OR caller:Method) AND %s
+		MATCH (caller)-[:CALLS]->()-[:DEFINES]->(symbol:Symbol)
+		RETURN caller.name AS callingFunction, symbol.symbol AS targetSymbol,
+			symbol.package AS package, symbol.isExternal AS isExternal, count(*) AS calls
+		ORDER BY callingFunction, targetSymbol
+	`, qb.versionFilter("caller", params))
+
+	result, err = qb.client.ExecuteReadQuery(ctx, callsCypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover service dependencies: %w", err)
+	}
+
+	stdlib := make(map[string]bool)
+	for _, use := range uses {
+		if use.IsStdlib {
+			stdlib[use.Package] = true
+		}
+	}
+	for _, record := range result {
+		recordMap := record.AsMap()
+		target := getString(recordMap, "targetSymbol")
+		// Local symbols and other non-global symbols name no package
+		symbol, err := models.ParseSCIPSymbol(target)
+		if err != nil {
+			continue
+		}
+		pkg := getString(recordMap, "package")
+		isExternal, tagged := recordMap["isExternal"].(bool)
+		if !tagged || pkg == "" {
+			pkg = symbol.Name
+			isExternal = !isInternalPackage(pkg, internal, moduleFQNs)
+		}
+		calls, _ := recordMap["calls"].(int64)
+		uses = append(uses, DependencyUse{
+			Package:         pkg,
+			CallingFunction: getString(recordMap, "callingFunction"),
+			TargetSymbol:    target,
+			Calls:           int(calls),
+			IsExternal:      isExternal,
+			IsStdlib:        stdlib[pkg],
+		})
+	}
+
+	return uses, nil
 }
 
-// DiscoverServiceDependencies finds all external service dependencies
-func (qb *QueryBuilder) DiscoverServiceDependencies(ctx context.Context, serviceName string) ([]map[string]any, error) {
-	cypher := `
-		MATCH (s:Service {name: $serviceName})
-		
-		// Find all functions/methods defined within this service
-		MATCH (s)-[:CONTAINS*]->(caller)
-		WHERE caller:Function OR caller:Method
-		
-		// Find all calls originating from these functions to symbols in other services
-		MATCH (caller)-[:CALLS]->()-[:DEFINES]->(symbol:Symbol)
-		// SCIP symbols for external packages contain the package name
-		// We filter out internal calls by checking that the symbol does not contain the current service's name
-		WHERE symbol.symbol CONTAINS " " AND NOT symbol.symbol CONTAINS $serviceName
-		
-		// Extract the foreign service name from the SCIP symbol string
-		WITH caller, split(symbol.symbol, ' ') AS symbolParts, symbol
-		RETURN DISTINCT
-			symbolParts[2] AS foreignServiceName,
-			caller.name AS callingFunction,
-			symbol.symbol AS targetSymbol
-		ORDER BY foreignServiceName, callingFunction
+// isInternalPackage reports whether a package is one of the service's modules,
+// or the Go module containing them
+func isInternalPackage(pkg string, internal map[string]bool, moduleFQNs []string) bool {
+	if internal[pkg] {
+		return true
+	}
+	for _, fqn := range moduleFQNs {
+		if strings.HasPrefix(fqn, pkg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFunctionMetrics returns the stored size and complexity metrics of functions
+// and methods, along with their parameter and outgoing call counts. Empty
+// serviceName or filePath values do not restrict the results.
+func (qb *QueryBuilder) GetFunctionMetrics(ctx context.Context, serviceName, filePath string) ([]map[string]any, error) {
+	params := map[string]any{
+		"serviceName": serviceName,
+		"filePath":    filePath,
+	}
+	cypher := fmt.Sprintf(`
+		MATCH (f)
+		WHERE (f:Function OR f:Method)
+		  AND ($filePath = '' OR f.filePath = $filePath)
+		  AND ($serviceName = '' OR EXISTS {
+			MATCH (:Service {name: $serviceName})-[:CONTAINS]->(:File {path: f.filePath})
+		  })
+		  AND %s
+		RETURN f.name AS name,
+			   labels(f)[0] AS type,
+			   f.filePath AS filePath,
+			   coalesce(f.complexity, 1) AS complexity,
+			   coalesce(f.linesOfCode, 0) AS linesOfCode,
+			   COUNT { (f)-[:CONTAINS]->(:Parameter) } AS parameterCount,
+			   COUNT { (f)-[:CALLS]->() } AS callCount
+		ORDER BY filePath, f.startLine
+	`, qb.versionFilter("f", params))
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get function metrics: %w", err)
+	}
+
+	metrics := make([]map[string]any, 0, len(result))
+	for _, record := range result {
+		recordMap := record.AsMap()
+		metrics = append(metrics, map[string]any{
+			"name":           getString(recordMap, "name"),
+			"type":           getString(recordMap, "type"),
+			"filePath":       getString(recordMap, "filePath"),
+			"complexity":     getInt(recordMap, "complexity"),
+			"linesOfCode":    getInt(recordMap, "linesOfCode"),
+			"parameterCount": getInt(recordMap, "parameterCount"),
+			"callCount":      getInt(recordMap, "callCount"),
+		})
+	}
+
+	return metrics, nil
+}
+
+// FindUncheckedErrors returns the functions and methods recorded as discarding
+// the error result of a call, with the offending calls. Empty serviceName or
+// filePath values do not restrict the results.
+func (qb *QueryBuilder) FindUncheckedErrors(ctx context.Context, serviceName, filePath string) ([]map[string]any, error) {
+	params := map[string]any{
+		"serviceName": serviceName,
+		"filePath":    filePath,
+	}
+	cypher := fmt.Sprintf(`
+		MATCH (f)
+		WHERE (f:Function OR f:Method)
+		  AND size(coalesce(f.uncheckedErrors, [])) > 0
+		  AND ($filePath = '' OR f.filePath = $filePath)
+		  AND ($serviceName = '' OR EXISTS {
+			MATCH (:Service {name: $serviceName})-[:CONTAINS]->(:File {path: f.filePath})
+		  })
+		  AND %s
+		RETURN f.name AS name,
+			   labels(f)[0] AS type,
+			   f.filePath AS filePath,
+			   f.startLine AS startLine,
+			   f.uncheckedErrors AS calls
+		ORDER BY filePath, startLine
+	`, qb.versionFilter("f", params))
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unchecked errors: %w", err)
+	}
+
+	functions := make([]map[string]any, 0, len(result))
+	for _, record := range result {
+		recordMap := record.AsMap()
+		var calls []string
+		if values, ok := recordMap["calls"].([]any); ok {
+			for _, value := range values {
+				if call, ok := value.(string); ok {
+					calls = append(calls, call)
+				}
+			}
+		}
+		functions = append(functions, map[string]any{
+			"name":      getString(recordMap, "name"),
+			"type":      getString(recordMap, "type"),
+			"filePath":  getString(recordMap, "filePath"),
+			"startLine": getInt(recordMap, "startLine"),
+			"calls":     calls,
+		})
+	}
+
+	return functions, nil
+}
+
+// GetDefinitions lists the functions, methods, classes and interfaces of a
+// service, or of one file, with their signatures and locations
+func (qb *QueryBuilder) GetDefinitions(ctx context.Context, serviceName, filePath string) ([]map[string]any, error) {
+	params := map[string]any{
+		"serviceName": serviceName,
+		"filePath":    filePath,
+	}
+	cypher := fmt.Sprintf(`
+		MATCH (d)
+		WHERE (d:Function OR d:Method OR d:Class OR d:Interface)
+		  AND ($filePath = '' OR d.filePath = $filePath)
+		  AND ($serviceName = '' OR EXISTS {
+			MATCH (:Service {name: $serviceName})-[:CONTAINS]->(:File {path: d.filePath})
+		  })
+		  AND %s
+		RETURN d.name AS name,
+			   labels(d)[0] AS type,
+			   d.filePath AS filePath,
+			   d.startLine AS startLine,
+			   coalesce(d.signature, '') AS signature
+		ORDER BY filePath, startLine
+	`, qb.versionFilter("d", params))
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get definitions: %w", err)
+	}
+
+	definitions := make([]map[string]any, 0, len(result))
+	for _, record := range result {
+		recordMap := record.AsMap()
+		definitions = append(definitions, map[string]any{
+			"name":      getString(recordMap, "name"),
+			"type":      getString(recordMap, "type"),
+			"filePath":  getString(recordMap, "filePath"),
+			"startLine": getInt(recordMap, "startLine"),
+			"signature": getString(recordMap, "signature"),
+		})
+	}
+
+	return definitions, nil
+}
+
+// GetFileContents returns the declarations contained in a file and the
+// relationships between them. A relative filePath matches the indexed file
+// whose path ends with it, and is an error when several files do; an empty
+// serviceName does not restrict the files.
+func (qb *QueryBuilder) GetFileContents(ctx context.Context, serviceName, filePath string) (*models.FileOutline, error) {
+	params := map[string]any{
+		"serviceName": serviceName,
+		"filePath":    filePath,
+		"pathSuffix":  "/" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(filePath)), "./"),
+	}
+
+	// Declarations of the service and version, further matched by path
+	declarations := fmt.Sprintf(`
+		MATCH (n)
+		WHERE (n:Function OR n:Method OR n:Class OR n:Interface OR n:Variable)
+		  AND ($serviceName = '' OR EXISTS {
+			MATCH (:Service {name: $serviceName})-[:CONTAINS]->(:File {path: n.filePath})
+		  })
+		  AND %s
+	`, qb.versionFilter("n", params))
+
+	pathsCypher := declarations + `
+		  AND (n.filePath = $filePath OR n.filePath ENDS WITH $pathSuffix)
+		RETURN DISTINCT n.filePath AS path
+		ORDER BY path
 	`
+	result, err := qb.client.ExecuteReadQuery(ctx, pathsCypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file contents: %w", err)
+	}
+	var paths []string
+	for _, record := range result {
+		path := getString(record.AsMap(), "path")
+		if path == filePath {
+			paths = []string{path}
+			break
+		}
+		paths = append(paths, path)
+	}
+	switch {
+	case len(paths) == 0:
+		return nil, fmt.Errorf("no indexed declarations found for file: %s", filePath)
+	case len(paths) > 1:
+		return nil, fmt.Errorf("file path %s is ambiguous, it matches %s", filePath, strings.Join(paths, ", "))
+	}
+	params["filePath"] = paths[0]
 
-	params := map[string]any{"serviceName": serviceName}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	entriesCypher := declarations + `
+		  AND n.filePath = $filePath
+		RETURN
+			elementId(n) AS id,
+			labels(n)[0] AS kind,
+			n.name AS name,
+			n.signature AS signature,
+			n.startLine AS startLine,
+			n.endLine AS endLine
+		ORDER BY n.startLine, n.name
+	`
+	result, err = qb.client.ExecuteReadQuery(ctx, entriesCypher, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to discover service dependencies: %w", err)
+		return nil, fmt.Errorf("failed to get file contents: %w", err)
 	}
 
-	var dependencies []map[string]any
+	outline := &models.FileOutline{FilePath: paths[0]}
+	entriesByID := make(map[string]*models.OutlineEntry)
 	for _, record := range result {
-		dependencies = append(dependencies, record.AsMap())
+		recordMap := record.AsMap()
+
+		entry := &models.OutlineEntry{
+			ID:        getString(recordMap, "id"),
+			Name:      getString(recordMap, "name"),
+			Kind:      getString(recordMap, "kind"),
+			Signature: getString(recordMap, "signature"),
+			StartLine: getInt(recordMap, "startLine"),
+			EndLine:   getInt(recordMap, "endLine"),
+		}
+		outline.Entries = append(outline.Entries, entry)
+		entriesByID[entry.ID] = entry
+	}
+
+	// Relationships where both ends are declarations in this file
+	relsCypher := `
+		MATCH (a)-[r]->(b)
+		WHERE elementId(a) IN $ids AND elementId(b) IN $ids
+		RETURN elementId(a) AS fromId, elementId(b) AS toId, type(r) AS relType
+	`
+
+	ids := make([]string, 0, len(entriesByID))
+	for id := range entriesByID {
+		ids = append(ids, id)
 	}
 
-	return dependencies, nil
+	result, err = qb.client.ExecuteReadQuery(ctx, relsCypher, map[string]any{"ids": ids})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file relationships: %w", err)
+	}
+
+	for _, record := range result {
+		recordMap := record.AsMap()
+
+		rel := &models.OutlineRelationship{
+			FromID: getString(recordMap, "fromId"),
+			ToID:   getString(recordMap, "toId"),
+			Type:   getString(recordMap, "relType"),
+		}
+		outline.Relationships = append(outline.Relationships, rel)
+
+		// CONTAINS within the file nests the child under its parent (e.g. struct fields)
+		if rel.Type == "CONTAINS" {
+			if child, ok := entriesByID[rel.ToID]; ok {
+				child.ParentID = rel.FromID
+			}
+		}
+	}
+
+	return outline, nil
 }
 
 // Helper functions to safely extract values from record maps
@@ -345,19 +870,46 @@ func getInt(m map[string]any, key string) int {
 }
 
 // SearchNodes performs a full-text search across nodes
-func (qb *QueryBuilder) SearchNodes(ctx context.Context, searchTerm string, nodeTypes []string, limit int) ([]*neo4j.Record, error) {
+func (qb *QueryBuilder) SearchNodes(ctx context.Context, searchTerm string, nodeTypes []string, limit int, opts ...SearchOption) ([]*neo4j.Record, error) {
+	if err := validateIdentifiers(nodeTypes...); err != nil {
+		return nil, err
+	}
+
+	var options searchOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	// Build the label filter
 	var labelFilters []string
 	for _, nodeType := range nodeTypes {
 		labelFilters = append(labelFilters, fmt.Sprintf("n:%s", nodeType))
 	}
+
+	if options.fulltext && len(nodeTypes) > 0 {
+		// Servers that cannot list indexes fall back to the scan below
+		if index := qb.fulltextIndexFor(ctx, nodeTypes); index != nil {
+			if limit <= 0 {
+				limit = qb.searchCap()
+			}
+			labelFilter, boosts := strings.Join(labelFilters, " OR "), options.boostsFor(index.name)
+			result, err := qb.searchFulltext(ctx, index, searchTerm, labelFilter, limit, boosts, false)
+			if err != nil || len(result) > 0 || !options.fuzzy {
+				return result, err
+			}
+			return qb.searchFulltext(ctx, index, searchTerm, labelFilter, limit, boosts, true)
+		}
+	}
 	
+	params := map[string]any{"searchTerm": searchTerm}
+	versionFilter := qb.versionFilter("n", params)
+
 	var cypher string
 	if len(labelFilters) > 0 {
 		labelFilter := strings.Join(labelFilters, " OR ")
 		cypher = fmt.Sprintf(`
 			MATCH (n)
-			WHERE (%s) AND (
+			WHERE (%s) AND %s AND (
 				toLower(n.name) CONTAINS toLower($searchTerm) OR
 				toLower(n.displayName) CONTAINS toLower($searchTerm) OR
 				toLower(n.signature) CONTAINS toLower($searchTerm) OR
@@ -375,16 +927,17 @@ func (qb *QueryBuilder) SearchNodes(ctx context.Context, searchTerm string, node
 					ELSE 6
 				END,
 				n.name
-		`, labelFilter)
+		`, labelFilter, versionFilter)
 	} else {
-		cypher = `
+		cypher = fmt.Sprintf(`
 			MATCH (n)
-			WHERE 
+			WHERE %s AND (
 				toLower(n.name) CONTAINS toLower($searchTerm) OR
 				toLower(n.displayName) CONTAINS toLower($searchTerm) OR
 				toLower(n.signature) CONTAINS toLower($searchTerm) OR
 				toLower(n.symbol) CONTAINS toLower($searchTerm) OR
 				toLower(n.path) CONTAINS toLower($searchTerm)
+			)
 			RETURN n, labels(n) AS nodeLabels
 			ORDER BY 
 				CASE 
@@ -396,16 +949,31 @@ func (qb *QueryBuilder) SearchNodes(ctx context.Context, searchTerm string, node
 					ELSE 6
 				END,
 				n.name
-		`
+		`, versionFilter)
 	}
 	
 	// Only apply limit if it's greater than 0
 	if limit > 0 {
 		cypher += fmt.Sprintf(" LIMIT %d", limit)
+		result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search nodes: %w", err)
+		}
+		return result, nil
 	}
 
-	params := map[string]any{"searchTerm": searchTerm}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	// Without a limit, stream the matches and stop at the safety cap
+	maxResults := qb.searchCap()
+	var result []*neo4j.Record
+	err := qb.client.ExecuteQueryStream(ctx, cypher, params, func(record *neo4j.Record) error {
+		if len(result) == maxResults {
+			slog.Warn("Search results truncated; pass a limit to page through them",
+				"term", searchTerm, "maxResults", maxResults)
+			return ErrStopStream
+		}
+		result = append(result, record)
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search nodes: %w", err)
 	}
@@ -413,26 +981,108 @@ func (qb *QueryBuilder) SearchNodes(ctx context.Context, searchTerm string, node
 	return result, nil
 }
 
+// FindNodesCreatedSince returns declarations of a service version first created
+// at or after the given Unix timestamp, oldest first. An empty serviceName does
+// not restrict the files and an empty version uses the builder's version.
+func (qb *QueryBuilder) FindNodesCreatedSince(ctx context.Context, serviceName, version string, since int64, limit int) ([]*neo4j.Record, error) {
+	if version != "" {
+		qb = qb.WithVersion(version)
+	}
+	params := map[string]any{
+		"since":       since,
+		"serviceName": serviceName,
+	}
+	cypher := fmt.Sprintf(`
+		MATCH (n)
+		WHERE (n:Function OR n:Method OR n:Class OR n:Interface OR n:Variable)
+		  AND n.createdAt >= $since
+		  AND ($serviceName = '' OR EXISTS {
+			MATCH (:Service {name: $serviceName})-[:CONTAINS]->(:File {path: n.filePath})
+		  })
+		  AND %s
+		RETURN n, labels(n) AS nodeLabels
+		ORDER BY n.createdAt, n.name
+	`, qb.versionFilter("n", params))
+
+	if limit > 0 {
+		cypher += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nodes created since %d: %w", since, err)
+	}
+
+	return result, nil
+}
+
+// GetLastIndexRun returns the most recent indexing run for a service
+func (qb *QueryBuilder) GetLastIndexRun(ctx context.Context, service string) (*models.IndexRun, error) {
+	cypher := `
+		MATCH (run:IndexRun {service: $service})
+		RETURN run.service AS service, run.version AS version, run.indexer AS indexer,
+			   run.startedAt AS startedAt, run.finishedAt AS finishedAt, run.fileCount AS fileCount
+		ORDER BY run.startedAt DESC
+		LIMIT 1
+	`
+
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, map[string]any{"service": service})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last index run: %w", err)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no index runs found for service: %s", service)
+	}
+
+	record := result[0].AsMap()
+	return &models.IndexRun{
+		Service:    getString(record, "service"),
+		Version:    getString(record, "version"),
+		Indexer:    getString(record, "indexer"),
+		StartedAt:  int64(getInt(record, "startedAt")),
+		FinishedAt: int64(getInt(record, "finishedAt")),
+		FileCount:  getInt(record, "fileCount"),
+	}, nil
+}
+
+// FunctionSource is the source code of a function or method and its location
+type FunctionSource struct {
+	Function  string `json:"function"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Source    string `json:"source"`
+}
+
 // GetFunctionSourceCode retrieves the exact source code for a function or method
 func (qb *QueryBuilder) GetFunctionSourceCode(ctx context.Context, functionName string) (string, error) {
+	source, err := qb.GetFunctionSource(ctx, functionName)
+	if err != nil {
+		return "", err
+	}
+	return source.Source, nil
+}
+
+// GetFunctionSource retrieves the source code of a function or method along with its location
+func (qb *QueryBuilder) GetFunctionSource(ctx context.Context, functionName string) (*FunctionSource, error) {
 	// Find the function/method node with location metadata
-	cypher := `
+	params := map[string]any{"functionName": functionName}
+	cypher := fmt.Sprintf(`
 		MATCH (f)
-		WHERE (f:Function OR f:Method) AND f.name = $functionName
+		WHERE (f:Function OR f:Method) AND f.name = $functionName AND %s
 		RETURN f.filePath AS filePath, f.startByte AS startByte, f.endByte AS endByte,
 			   f.startLine AS startLine, f.endLine AS endLine,
 			   f.name AS name, f.signature AS signature
 		LIMIT 1
-	`
-	
-	params := map[string]any{"functionName": functionName}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	`, qb.versionFilter("f", params))
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to find function: %w", err)
+		return nil, fmt.Errorf("failed to find function: %w", err)
 	}
 	
 	if len(result) == 0 {
-		return "", fmt.Errorf("function not found: %s", functionName)
+		return nil, fmt.Errorf("%w: %s", ErrFunctionNotFound, functionName)
 	}
 	
 	record := result[0].AsMap()
@@ -443,75 +1093,59 @@ func (qb *QueryBuilder) GetFunctionSourceCode(ctx context.Context, functionName
 	endLine := getInt(record, "endLine")
 	
 	if filePath == "" {
-		return "", fmt.Errorf("no file path found for function: %s", functionName)
+		return nil, fmt.Errorf("no file path found for function: %s", functionName)
 	}
 	
 	// Read the file content - handle both absolute and relative paths
-	content, err := os.ReadFile(filePath)
+	content, err := qb.readSource(ctx, filePath)
 	if err != nil {
-		// If relative path fails, try from project root
-		// This handles the case where tests run from different directories
-		if !filepath.IsAbs(filePath) {
-			// Try from current working directory
-			if pwd, pwdErr := os.Getwd(); pwdErr == nil {
-				// Go up to project root if we're in test directory
-				projectRoot := pwd
-				if strings.HasSuffix(pwd, "/test/integration") {
-					projectRoot = filepath.Dir(filepath.Dir(pwd))
-				}
-				absolutePath := filepath.Join(projectRoot, filePath)
-				if content, err = os.ReadFile(absolutePath); err == nil {
-					// Success with absolute path
-				} else {
-					return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
-				}
-			} else {
-				return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
-			}
-		} else {
-			return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
-		}
+		return nil, err
+	}
+	
+	source := &FunctionSource{
+		Function:  functionName,
+		FilePath:  filePath,
+		StartLine: startLine,
+		EndLine:   endLine,
 	}
 	
 	// If we have byte offsets, use them for precise extraction
 	if startByte >= 0 && endByte >= 0 && startByte < len(content) && endByte <= len(content) {
-		sourceCode := string(content[startByte:endByte])
-		return sourceCode, nil
+		source.Source = string(content[startByte:endByte])
+		return source, nil
 	}
 	
 	// Fallback to line-based extraction
 	if startLine > 0 && endLine > 0 {
 		lines := strings.Split(string(content), "\n")
 		if startLine <= len(lines) && endLine <= len(lines) {
-			functionLines := lines[startLine-1:endLine]
-			sourceCode := strings.Join(functionLines, "\n")
-			return sourceCode, nil
+			source.Source = strings.Join(lines[startLine-1:endLine], "\n")
+			return source, nil
 		}
 	}
 	
-	return "", fmt.Errorf("unable to extract source code for function: %s", functionName)
+	return nil, fmt.Errorf("unable to extract source code for function: %s", functionName)
 }
 
 // GetFunctionSourceCodeBySignature retrieves source code using the function signature for disambiguation
 func (qb *QueryBuilder) GetFunctionSourceCodeBySignature(ctx context.Context, signature string) (string, error) {
 	// Find the function/method node with location metadata using signature
-	cypher := `
+	params := map[string]any{"signature": signature}
+	cypher := fmt.Sprintf(`
 		MATCH (f)
-		WHERE (f:Function OR f:Method) AND f.signature = $signature
+		WHERE (f:Function OR f:Method) AND f.signature = $signature AND %s
 		RETURN f.filePath AS filePath, f.startByte AS startByte, f.endByte AS endByte,
 			   f.startLine AS startLine, f.endLine AS endLine,
 			   f.name AS name, f.signature AS signature
 		LIMIT 1
-	`
-	
-	params := map[string]any{"signature": signature}
-	result, err := qb.client.ExecuteQuery(ctx, cypher, params)
+	`, qb.versionFilter("f", params))
+	result, err := qb.client.ExecuteReadQuery(ctx, cypher, params)
 	if err != nil {
 		return "", fmt.Errorf("failed to find function: %w", err)
 	}
 	
 	if len(result) == 0 {
-		return "", fmt.Errorf("function not found with signature: %s", signature)
+		return "", fmt.Errorf("%w with signature: %s", ErrFunctionNotFound, signature)
 	}
 	
 	record := result[0].AsMap()
@@ -526,30 +1160,9 @@ func (qb *QueryBuilder) GetFunctionSourceCodeBySignature(ctx context.Context, si
 	}
 	
 	// Read the file content - handle both absolute and relative paths
-	content, err := os.ReadFile(filePath)
+	content, err := qb.readSource(ctx, filePath)
 	if err != nil {
-		// If relative path fails, try from project root
-		// This handles the case where tests run from different directories
-		if !filepath.IsAbs(filePath) {
-			// Try from current working directory
-			if pwd, pwdErr := os.Getwd(); pwdErr == nil {
-				// Go up to project root if we're in test directory
-				projectRoot := pwd
-				if strings.HasSuffix(pwd, "/test/integration") {
-					projectRoot = filepath.Dir(filepath.Dir(pwd))
-				}
-				absolutePath := filepath.Join(projectRoot, filePath)
-				if content, err = os.ReadFile(absolutePath); err == nil {
-					// Success with absolute path
-				} else {
-					return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
-				}
-			} else {
-				return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
-			}
-		} else {
-			return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
-		}
+		return "", err
 	}
 	
 	// If we have byte offsets, use them for precise extraction
@@ -0,0 +1,105 @@
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// DefaultMaxRetries is how many times a transaction failing with a transient
+// error is retried when Config.MaxRetries is zero
+const DefaultMaxRetries = 3
+
+// DefaultRetryDelay is the wait before the first retry when Config.RetryDelay
+// is zero; the wait doubles with every further retry up to maxRetryDelay
+const DefaultRetryDelay = 200 * time.Millisecond
+
+const maxRetryDelay = 10 * time.Second
+
+// session is the part of neo4j.SessionWithContext the client runs
+// transactions through
+type session interface {
+	ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error)
+	ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error)
+	Close(ctx context.Context) error
+}
+
+// retryPolicy bounds how transactions failing with transient errors are retried
+type retryPolicy struct {
+	maxRetries int
+	delay      time.Duration
+}
+
+// newRetryPolicy applies the defaults to the retry settings of a Config. A
+// negative MaxRetries disables retries.
+func newRetryPolicy(config Config) retryPolicy {
+	policy := retryPolicy{maxRetries: config.MaxRetries, delay: config.RetryDelay}
+	if policy.maxRetries == 0 {
+		policy.maxRetries = DefaultMaxRetries
+	} else if policy.maxRetries < 0 {
+		policy.maxRetries = 0
+	}
+	if policy.delay <= 0 {
+		policy.delay = DefaultRetryDelay
+	}
+	return policy
+}
+
+// IsRetryable reports whether an error is transient, so the transaction that
+// failed with it may succeed when run again: Neo.TransientError.* errors such
+// as deadlocks, cluster leader switches, lost connections and connection pool
+// timeouts. Other errors, like syntax errors, constraint violations or
+// authentication failures, are fatal.
+func IsRetryable(err error) bool {
+	return neo4j.IsRetryable(lastAttemptError(err))
+}
+
+// lastAttemptError returns the error of the last attempt of a managed
+// transaction the driver gave up on, which it wraps without exposing it to
+// errors.As, or err itself
+func lastAttemptError(err error) error {
+	var limit *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limit) && len(limit.Errors) > 0 {
+		return limit.Errors[len(limit.Errors)-1]
+	}
+	return err
+}
+
+// permanentError wraps an error that must not be retried even when its cause
+// is transient, such as a failure after a stream delivered records
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// run calls attempt until it succeeds, fails with an error that is not
+// retryable, or the retries are used up, waiting with exponential backoff
+// between attempts
+func (p retryPolicy) run(ctx context.Context, attempt func() (any, error)) (any, error) {
+	delay := p.delay
+	for retry := 0; ; retry++ {
+		result, err := attempt()
+		err = lastAttemptError(err)
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return result, permanent.err
+		}
+		if err == nil || retry >= p.maxRetries || !IsRetryable(err) {
+			return result, err
+		}
+
+		slog.Warn("Retrying transaction after transient error",
+			"retry", retry+1, "maxRetries", p.maxRetries, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
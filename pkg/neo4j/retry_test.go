@@ -0,0 +1,144 @@
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession fails its first transactions with the queued errors, then
+// returns result. A blocking session runs each transaction until its context
+// is done.
+type fakeSession struct {
+	errs      []error
+	result    any
+	block     bool
+	attempts  int
+	reads     int // Attempts made in read transactions
+	closed    bool
+	txTimeout time.Duration
+}
+
+func (s *fakeSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	s.reads++
+	return s.ExecuteWrite(ctx, work, configurers...)
+}
+
+func (s *fakeSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	s.attempts++
+	config := neo4j.TransactionConfig{}
+	for _, configurer := range configurers {
+		configurer(&config)
+	}
+	s.txTimeout = config.Timeout
+
+	if s.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	return s.result, nil
+}
+
+func (s *fakeSession) Close(ctx context.Context) error {
+	s.closed = true
+	return nil
+}
+
+func newFakeClient(fake *fakeSession, config Config) *Client {
+	return &Client{
+		retry:        newRetryPolicy(config),
+		queryTimeout: config.QueryTimeout,
+		newSession: func(ctx context.Context, config neo4j.SessionConfig) session {
+			return fake
+		},
+	}
+}
+
+func deadlock() error {
+	return &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected", Msg: "deadlock"}
+}
+
+func TestExecuteQueryRetriesTransientError(t *testing.T) {
+	records := []*neo4j.Record{{Keys: []string{"n"}, Values: []any{int64(1)}}}
+	fake := &fakeSession{errs: []error{deadlock()}, result: records}
+	client := newFakeClient(fake, Config{RetryDelay: time.Millisecond})
+
+	result, err := client.ExecuteQuery(context.Background(), "RETURN 1 AS n", nil)
+	require.NoError(t, err)
+	assert.Equal(t, records, result)
+	assert.Equal(t, 2, fake.attempts)
+	assert.True(t, fake.closed)
+}
+
+func TestExecuteReadQueryUsesReadTransactions(t *testing.T) {
+	records := []*neo4j.Record{{Keys: []string{"n"}, Values: []any{int64(1)}}}
+	fake := &fakeSession{errs: []error{deadlock()}, result: records}
+	client := newFakeClient(fake, Config{RetryDelay: time.Millisecond})
+
+	result, err := client.ExecuteReadQuery(context.Background(), "RETURN 1 AS n", nil)
+	require.NoError(t, err)
+	assert.Equal(t, records, result)
+	assert.Equal(t, 2, fake.attempts)
+	assert.Equal(t, 2, fake.reads, "Retries stay in read transactions")
+
+	fake = &fakeSession{result: records}
+	client = newFakeClient(fake, Config{})
+	_, err = client.ExecuteQuery(context.Background(), "RETURN 1 AS n", nil)
+	require.NoError(t, err)
+	assert.Zero(t, fake.reads)
+}
+
+func TestExecuteQueryDoesNotRetryFatalError(t *testing.T) {
+	syntaxErr := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "invalid input"}
+	fake := &fakeSession{errs: []error{syntaxErr}}
+	client := newFakeClient(fake, Config{RetryDelay: time.Millisecond})
+
+	_, err := client.ExecuteQuery(context.Background(), "RETURN", nil)
+	assert.ErrorIs(t, err, syntaxErr)
+	assert.Equal(t, 1, fake.attempts)
+}
+
+func TestExecuteQueryGivesUpAfterMaxRetries(t *testing.T) {
+	fake := &fakeSession{errs: []error{deadlock(), deadlock(), deadlock()}}
+	client := newFakeClient(fake, Config{MaxRetries: 2, RetryDelay: time.Millisecond})
+
+	_, err := client.ExecuteQuery(context.Background(), "RETURN 1", nil)
+	require.Error(t, err)
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, 3, fake.attempts)
+
+	fake = &fakeSession{errs: []error{deadlock()}}
+	client = newFakeClient(fake, Config{MaxRetries: -1})
+	_, err = client.ExecuteRead(context.Background(), nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, fake.attempts)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(deadlock()))
+	assert.True(t, IsRetryable(&neo4j.Neo4jError{Code: "Neo.ClientError.Cluster.NotALeader"}))
+	assert.True(t, IsRetryable(&neo4j.TransactionExecutionLimit{Errors: []error{deadlock()}}))
+	assert.False(t, IsRetryable(&neo4j.Neo4jError{Code: "Neo.ClientError.Schema.ConstraintValidationFailed"}))
+	assert.False(t, IsRetryable(errors.New("boom")))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestPermanentErrorIsNotRetried(t *testing.T) {
+	fake := &fakeSession{errs: []error{&permanentError{err: deadlock()}}}
+	client := newFakeClient(fake, Config{RetryDelay: time.Millisecond})
+
+	_, err := client.ExecuteRead(context.Background(), nil)
+	require.Error(t, err)
+	assert.Equal(t, deadlock().Error(), err.Error())
+	assert.Equal(t, 1, fake.attempts)
+}
@@ -0,0 +1,157 @@
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/models"
+)
+
+// SourceReadLimits bounds the source files read to extract code. Zero fields
+// use the corresponding DefaultSourceReadLimits value.
+type SourceReadLimits struct {
+	MaxFileSize int64         // Largest file read, in bytes
+	Timeout     time.Duration // Longest a single file read may take
+}
+
+// DefaultSourceReadLimits are applied unless a query builder is configured otherwise
+var DefaultSourceReadLimits = SourceReadLimits{
+	MaxFileSize: 10 << 20, // 10 MiB
+	Timeout:     10 * time.Second,
+}
+
+var (
+	// ErrNotRegularFile is returned when a source path is a directory, device,
+	// pipe or other non-regular file
+	ErrNotRegularFile = errors.New("not a regular file")
+	// ErrSourceFileTooLarge is returned when a source file exceeds the size limit
+	ErrSourceFileTooLarge = errors.New("source file too large")
+)
+
+// withDefaults fills zero limits with the defaults
+func (l SourceReadLimits) withDefaults() SourceReadLimits {
+	if l.MaxFileSize <= 0 {
+		l.MaxFileSize = DefaultSourceReadLimits.MaxFileSize
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultSourceReadLimits.Timeout
+	}
+	return l
+}
+
+// WithSourceReadLimits returns a query builder that reads source files within
+// the given limits
+func (qb *QueryBuilder) WithSourceReadLimits(limits SourceReadLimits) *QueryBuilder {
+	copied := *qb
+	copied.sourceLimits = limits
+	return &copied
+}
+
+// ReadSourceFile reads a source file after checking that it is a regular file
+// within the size limit. The read is abandoned when it exceeds the timeout or
+// ctx is cancelled.
+func ReadSourceFile(ctx context.Context, path string, limits SourceReadLimits) ([]byte, error) {
+	limits = limits.withDefaults()
+
+	// Stat before opening: opening a FIFO blocks until a writer appears
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrNotRegularFile, path, info.Mode().Type())
+	}
+	if info.Size() > limits.MaxFileSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes (limit %d)", ErrSourceFileTooLarge, path, info.Size(), limits.MaxFileSize)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	type readResult struct {
+		data []byte
+		err  error
+	}
+	done := make(chan readResult, 1)
+	go func() {
+		// Read one byte past the limit to detect files that grew since Stat
+		data, err := io.ReadAll(io.LimitReader(file, limits.MaxFileSize+1))
+		done <- readResult{data: data, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			return nil, result.err
+		}
+		if int64(len(result.data)) > limits.MaxFileSize {
+			return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrSourceFileTooLarge, path, limits.MaxFileSize)
+		}
+		return result.data, nil
+	case <-ctx.Done():
+		file.Close() // Unblocks the pending read
+		return nil, fmt.Errorf("reading %s: %w", path, ctx.Err())
+	}
+}
+
+// readSource reads the file a function was indexed from. Relative paths that
+// do not exist from the working directory are retried from the project root,
+// which handles tests running from test/integration.
+func (qb *QueryBuilder) readSource(ctx context.Context, filePath string) ([]byte, error) {
+	content, err := ReadSourceFile(ctx, filePath, qb.sourceLimits)
+	if err == nil || filepath.IsAbs(filePath) || !errors.Is(err, fs.ErrNotExist) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		}
+		return content, nil
+	}
+
+	pwd, pwdErr := os.Getwd()
+	if pwdErr != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	projectRoot := pwd
+	if strings.HasSuffix(pwd, "/test/integration") {
+		projectRoot = filepath.Dir(filepath.Dir(pwd))
+	}
+	content, err = ReadSourceFile(ctx, filepath.Join(projectRoot, filePath), qb.sourceLimits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	return content, nil
+}
+
+// AddReferenceContext sets the context of references indexed without one to
+// their trimmed source line. References whose file cannot be read are left
+// without context.
+func (qb *QueryBuilder) AddReferenceContext(ctx context.Context, references []*models.SymbolReference) {
+	files := make(map[string][]string)
+	for _, ref := range references {
+		if ref.Context != "" {
+			continue
+		}
+		lines, ok := files[ref.FilePath]
+		if !ok {
+			if content, err := qb.readSource(ctx, ref.FilePath); err == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			files[ref.FilePath] = lines
+		}
+		if ref.StartLine > 0 && ref.StartLine <= len(lines) {
+			ref.Context = strings.TrimSpace(lines[ref.StartLine-1])
+		}
+	}
+}
@@ -3,9 +3,14 @@ package query
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/context-maximiser/code-graph/pkg/models"
 	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/similarity"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
 )
 
 // AdvancedQueryService provides complex analysis queries
@@ -20,6 +25,14 @@ func NewAdvancedQueryService(client *neo4j.Client) *AdvancedQueryService {
 	}
 }
 
+// NewAdvancedQueryServiceWithBuilder creates an advanced query service that
+// runs its queries through an existing query builder, e.g. a version-scoped one
+func NewAdvancedQueryServiceWithBuilder(queryBuilder *neo4j.QueryBuilder) *AdvancedQueryService {
+	return &AdvancedQueryService{
+		queryBuilder: queryBuilder,
+	}
+}
+
 // ImpactAnalysisRequest represents an impact analysis request
 type ImpactAnalysisRequest struct {
 	FunctionSymbol string `json:"functionSymbol"`
@@ -95,30 +108,121 @@ type DataFlowStep struct {
 	FilePath   string `json:"filePath"`
 	Line       int    `json:"line"`
 	FlowType   string `json:"flowType"` // direct, indirect, conditional
+	Function   string `json:"function,omitempty"`
 }
 
-// TraceDataFlow traces the flow of data from a parameter
+// DefaultDataFlowSteps is the path length used when none is requested
+const DefaultDataFlowSteps = 15
+
+// TraceDataFlow traces the flow of data from a parameter. Each path starts at
+// the parameter and lists every variable or parameter the value reaches in
+// order; Length counts the hops. Parameters of other functions that receive
+// the value as a call argument are reported as destinations.
 func (aqs *AdvancedQueryService) TraceDataFlow(ctx context.Context, req DataFlowRequest) (*DataFlowResponse, error) {
-	references, err := aqs.queryBuilder.TraceDataFlow(ctx, req.ParameterSymbol)
+	maxSteps := req.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultDataFlowSteps
+	}
+
+	records, err := aqs.queryBuilder.TraceDataFlow(ctx, req.ParameterSymbol, maxSteps)
 	if err != nil {
 		return nil, fmt.Errorf("failed to trace data flow: %w", err)
 	}
 
-	// TODO: Build data flow paths from the references
-	// This requires a more sophisticated analysis of the flow relationships
-	
-	return &DataFlowResponse{
+	response := &DataFlowResponse{
 		ParameterSymbol: req.ParameterSymbol,
-		FlowPaths:       []*DataFlowPath{}, // TODO: Implement
-		Destinations:    references,
-		PathCount:       len(references),
-	}, nil
+		FlowPaths:       []*DataFlowPath{},
+		Destinations:    []*models.SymbolReference{},
+	}
+	seenDestinations := make(map[string]bool)
+
+	for _, record := range records {
+		recordMap := record.AsMap()
+		pathNodes, _ := recordMap["pathNodes"].([]any)
+		pathRels, _ := recordMap["pathRels"].([]any)
+		pathSymbols, _ := recordMap["pathSymbols"].([]any)
+		pathFunctions, _ := recordMap["pathFunctions"].([]any)
+		if len(pathNodes) != len(pathRels)+1 || len(pathSymbols) != len(pathNodes) || len(pathFunctions) != len(pathNodes) {
+			continue
+		}
+
+		flowPath := &DataFlowPath{Steps: []*DataFlowStep{}, Length: len(pathRels)}
+		for i, value := range pathNodes {
+			node, ok := value.(dbtype.Node)
+			if !ok {
+				break
+			}
+			step := dataFlowStep(node, pathSymbols[i], pathFunctions[i])
+			if i > 0 {
+				relationship, ok := pathRels[i-1].(dbtype.Relationship)
+				if !ok {
+					break
+				}
+				step.FlowType, _ = relationship.Props["flowType"].(string)
+				if line, ok := relationship.Props["line"].(int64); ok {
+					step.Line = int(line)
+				}
+
+				kind, _ := relationship.Props["kind"].(string)
+				if kind == "argument" && !seenDestinations[node.ElementId] {
+					seenDestinations[node.ElementId] = true
+					response.Destinations = append(response.Destinations, dataFlowDestination(step, node))
+				}
+			}
+			flowPath.Steps = append(flowPath.Steps, step)
+		}
+		if len(flowPath.Steps) != len(pathNodes) {
+			continue
+		}
+
+		flowPath.Destination = flowPath.Steps[len(flowPath.Steps)-1]
+		response.FlowPaths = append(response.FlowPaths, flowPath)
+	}
+
+	response.PathCount = len(response.FlowPaths)
+	return response, nil
+}
+
+// dataFlowStep converts a node on a data flow path into a step. Nodes without
+// a symbol, such as local variables, are identified by their element ID.
+func dataFlowStep(node dbtype.Node, symbol, function any) *DataFlowStep {
+	step := &DataFlowStep{Symbol: node.ElementId}
+	if s, ok := symbol.(string); ok && s != "" {
+		step.Symbol = s
+	}
+	if len(node.Labels) > 0 {
+		step.Type = node.Labels[0]
+	}
+	step.Name, _ = node.Props["name"].(string)
+	step.FilePath, _ = node.Props["filePath"].(string)
+	step.Function, _ = function.(string)
+	if line, ok := node.Props["startLine"].(int64); ok {
+		step.Line = int(line)
+	}
+	return step
+}
+
+// dataFlowDestination describes a parameter that receives the traced value
+func dataFlowDestination(step *DataFlowStep, node dbtype.Node) *models.SymbolReference {
+	ref := &models.SymbolReference{
+		FilePath: step.FilePath,
+		Context:  step.Function,
+	}
+	if symbol, err := models.ParseSCIPSymbol(step.Symbol); err == nil {
+		ref.Symbol = symbol
+	}
+	if line, ok := node.Props["startLine"].(int64); ok {
+		ref.StartLine = int(line)
+		ref.EndLine = int(line)
+	}
+	return ref
 }
 
 // DependencyAnalysisRequest represents a dependency analysis request
 type DependencyAnalysisRequest struct {
-	ServiceName      string `json:"serviceName"`
-	IncludeInternal  bool   `json:"includeInternal"`
+	ServiceName       string `json:"serviceName"`
+	IncludeInternal   bool   `json:"includeInternal"`
+	IncludeStdlib     bool   `json:"includeStdlib"`
 	IncludeTransitive bool   `json:"includeTransitive"`
 }
 
@@ -127,54 +231,66 @@ type ServiceDependency struct {
 	ServiceName      string   `json:"serviceName"`
 	Version          string   `json:"version,omitempty"`
 	Type             string   `json:"type"` // direct, transitive
+	IsInternal       bool     `json:"isInternal,omitempty"`
+	IsStdlib         bool     `json:"isStdlib,omitempty"`
 	CallingFunctions []string `json:"callingFunctions"`
 	CallCount        int      `json:"callCount"`
+	ImportedBy       []string `json:"importedBy,omitempty"`
 }
 
 // DependencyAnalysisResponse represents dependency analysis results
 type DependencyAnalysisResponse struct {
-	ServiceName  string               `json:"serviceName"`
-	Dependencies []*ServiceDependency `json:"dependencies"`
-	DependencyCount int               `json:"dependencyCount"`
+	ServiceName     string               `json:"serviceName"`
+	Dependencies    []*ServiceDependency `json:"dependencies"`
+	DependencyCount int                  `json:"dependencyCount"`
 }
 
-// AnalyzeDependencies analyzes service dependencies
+// AnalyzeDependencies groups the packages a service calls or imports, ordered
+// by call count. The service's own packages and the standard library are left
+// out unless requested.
 func (aqs *AdvancedQueryService) AnalyzeDependencies(ctx context.Context, req DependencyAnalysisRequest) (*DependencyAnalysisResponse, error) {
-	dependencies, err := aqs.queryBuilder.DiscoverServiceDependencies(ctx, req.ServiceName)
+	uses, err := aqs.queryBuilder.DiscoverServiceDependencies(ctx, req.ServiceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover dependencies: %w", err)
 	}
 
-	// Group dependencies by service
 	depMap := make(map[string]*ServiceDependency)
-	for _, dep := range dependencies {
-		if depData, ok := dep["foreignServiceName"].([]interface{}); ok && len(depData) > 2 {
-			serviceName := fmt.Sprintf("%v", depData[2])
-			
-			if existing, found := depMap[serviceName]; found {
-				if callingFunc, ok := dep["callingFunction"].(string); ok {
-					existing.CallingFunctions = append(existing.CallingFunctions, callingFunc)
-					existing.CallCount++
-				}
-			} else {
-				newDep := &ServiceDependency{
-					ServiceName: serviceName,
-					Type:        "direct",
-					CallCount:   1,
-				}
-				if callingFunc, ok := dep["callingFunction"].(string); ok {
-					newDep.CallingFunctions = []string{callingFunc}
-				}
-				depMap[serviceName] = newDep
+	for _, use := range uses {
+		dep, found := depMap[use.Package]
+		if !found {
+			dep = &ServiceDependency{
+				ServiceName:      use.Package,
+				Type:             "direct",
+				CallingFunctions: []string{},
 			}
+			depMap[use.Package] = dep
+		}
+		dep.IsInternal = dep.IsInternal || !use.IsExternal
+		dep.IsStdlib = dep.IsStdlib || use.IsStdlib
+		dep.CallCount += use.Calls
+		if use.CallingFunction != "" && !slices.Contains(dep.CallingFunctions, use.CallingFunction) {
+			dep.CallingFunctions = append(dep.CallingFunctions, use.CallingFunction)
+		}
+		if use.ImportedBy != "" && !slices.Contains(dep.ImportedBy, use.ImportedBy) {
+			dep.ImportedBy = append(dep.ImportedBy, use.ImportedBy)
 		}
 	}
 
-	// Convert map to slice
-	var serviceDeps []*ServiceDependency
+	serviceDeps := []*ServiceDependency{}
 	for _, dep := range depMap {
+		if (dep.IsInternal && !req.IncludeInternal) || (dep.IsStdlib && !req.IncludeStdlib) {
+			continue
+		}
+		sort.Strings(dep.CallingFunctions)
+		sort.Strings(dep.ImportedBy)
 		serviceDeps = append(serviceDeps, dep)
 	}
+	sort.Slice(serviceDeps, func(i, j int) bool {
+		if serviceDeps[i].CallCount != serviceDeps[j].CallCount {
+			return serviceDeps[i].CallCount > serviceDeps[j].CallCount
+		}
+		return serviceDeps[i].ServiceName < serviceDeps[j].ServiceName
+	})
 
 	return &DependencyAnalysisResponse{
 		ServiceName:     req.ServiceName,
@@ -183,10 +299,15 @@ func (aqs *AdvancedQueryService) AnalyzeDependencies(ctx context.Context, req De
 	}, nil
 }
 
+// DefaultHighComplexityThreshold is the cyclomatic complexity above which a
+// function counts as highly complex when no threshold is requested
+const DefaultHighComplexityThreshold = 10
+
 // ComplexityAnalysisRequest represents a complexity analysis request
 type ComplexityAnalysisRequest struct {
-	ServiceName string `json:"serviceName,omitempty"`
-	FilePath    string `json:"filePath,omitempty"`
+	ServiceName             string `json:"serviceName,omitempty"`
+	FilePath                string `json:"filePath,omitempty"`
+	HighComplexityThreshold int    `json:"highComplexityThreshold,omitempty"`
 }
 
 // ComplexityMetrics represents complexity metrics for a code element
@@ -218,26 +339,220 @@ type ComplexitySummary struct {
 	HighComplexityCount int    `json:"highComplexityCount"`
 }
 
-// AnalyzeComplexity analyzes code complexity metrics
+// AnalyzeComplexity analyzes code complexity metrics of functions and methods.
+// Functions are ordered by descending complexity score.
 func (aqs *AdvancedQueryService) AnalyzeComplexity(ctx context.Context, req ComplexityAnalysisRequest) (*ComplexityAnalysisResponse, error) {
-	// This is a placeholder implementation
-	// In a full implementation, we would query the database for complexity metrics
-	// and calculate various complexity scores
-	
+	threshold := req.HighComplexityThreshold
+	if threshold <= 0 {
+		threshold = DefaultHighComplexityThreshold
+	}
+
+	rows, err := aqs.queryBuilder.GetFunctionMetrics(ctx, req.ServiceName, req.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get function metrics: %w", err)
+	}
+
+	functions := make([]*ComplexityMetrics, 0, len(rows))
+	summary := &ComplexitySummary{}
+	totalComplexity := 0
+
+	for _, row := range rows {
+		metrics := &ComplexityMetrics{
+			Name:                 row["name"].(string),
+			Type:                 row["type"].(string),
+			FilePath:             row["filePath"].(string),
+			CyclomaticComplexity: row["complexity"].(int),
+			LinesOfCode:          row["linesOfCode"].(int),
+			ParameterCount:       row["parameterCount"].(int),
+			CallCount:            row["callCount"].(int),
+		}
+		metrics.ComplexityScore = complexityScore(metrics)
+		functions = append(functions, metrics)
+
+		totalComplexity += metrics.CyclomaticComplexity
+		if metrics.CyclomaticComplexity > summary.MaxComplexity {
+			summary.MaxComplexity = metrics.CyclomaticComplexity
+		}
+		if metrics.CyclomaticComplexity > threshold {
+			summary.HighComplexityCount++
+		}
+	}
+
+	sort.SliceStable(functions, func(i, j int) bool {
+		return functions[i].ComplexityScore > functions[j].ComplexityScore
+	})
+
+	summary.TotalFunctions = len(functions)
+	if len(functions) > 0 {
+		summary.AverageComplexity = float64(totalComplexity) / float64(len(functions))
+	}
+
 	return &ComplexityAnalysisResponse{
 		ServiceName: req.ServiceName,
 		FilePath:    req.FilePath,
-		Functions:   []*ComplexityMetrics{},
+		Functions:   functions,
 		Classes:     []*ComplexityMetrics{},
-		Summary: &ComplexitySummary{
-			TotalFunctions:      0,
-			AverageComplexity:   0.0,
-			MaxComplexity:       0,
-			HighComplexityCount: 0,
-		},
+		Summary:     summary,
+	}, nil
+}
+
+// complexityScore weighs cyclomatic complexity most heavily, with smaller
+// contributions from length, parameter count and fan-out
+func complexityScore(m *ComplexityMetrics) float64 {
+	return float64(m.CyclomaticComplexity) +
+		float64(m.LinesOfCode)/10 +
+		float64(m.ParameterCount)*0.5 +
+		float64(m.CallCount)*0.25
+}
+
+// UncheckedErrorRequest represents an unchecked error analysis request
+type UncheckedErrorRequest struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	FilePath    string `json:"filePath,omitempty"`
+}
+
+// UncheckedErrorFunction is a function that discards the error result of calls
+type UncheckedErrorFunction struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	FilePath string   `json:"filePath"`
+	Line     int      `json:"line"`
+	Calls    []string `json:"calls"` // e.g. "os.Remove at line 12"
+}
+
+// UncheckedErrorResponse represents unchecked error analysis results
+type UncheckedErrorResponse struct {
+	ServiceName string                    `json:"serviceName,omitempty"`
+	Functions   []*UncheckedErrorFunction `json:"functions"`
+	CallCount   int                       `json:"callCount"`
+}
+
+// FindUncheckedErrors lists functions and methods that ignore errors returned
+// by the functions they call. Detection happens during AST indexing and needs
+// the type information of the indexed packages.
+func (aqs *AdvancedQueryService) FindUncheckedErrors(ctx context.Context, req UncheckedErrorRequest) (*UncheckedErrorResponse, error) {
+	rows, err := aqs.queryBuilder.FindUncheckedErrors(ctx, req.ServiceName, req.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unchecked errors: %w", err)
+	}
+
+	response := &UncheckedErrorResponse{
+		ServiceName: req.ServiceName,
+		Functions:   make([]*UncheckedErrorFunction, 0, len(rows)),
+	}
+	for _, row := range rows {
+		fn := &UncheckedErrorFunction{}
+		fn.Name, _ = row["name"].(string)
+		fn.Type, _ = row["type"].(string)
+		fn.FilePath, _ = row["filePath"].(string)
+		fn.Line, _ = row["startLine"].(int)
+		fn.Calls, _ = row["calls"].([]string)
+		response.Functions = append(response.Functions, fn)
+		response.CallCount += len(fn.Calls)
+	}
+
+	return response, nil
+}
+
+// DefaultDuplicateThreshold is the similarity at which two definitions count
+// as duplicates when no threshold is requested
+const DefaultDuplicateThreshold = 0.9
+
+// DuplicateDefinitionRequest represents a duplicate definition request
+type DuplicateDefinitionRequest struct {
+	ServiceName string  `json:"serviceName,omitempty"`
+	FilePath    string  `json:"filePath,omitempty"`
+	Metric      string  `json:"metric,omitempty"`    // exact, jaccard or levenshtein (the default)
+	Threshold   float64 `json:"threshold,omitempty"` // 0-1, DefaultDuplicateThreshold when unset
+}
+
+// Definition is a declared function, method, class or interface
+type Definition struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath"`
+	Line      int    `json:"line"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// DuplicateDefinitionResponse represents duplicate definition results
+type DuplicateDefinitionResponse struct {
+	ServiceName string          `json:"serviceName,omitempty"`
+	Metric      string          `json:"metric"`
+	Threshold   float64         `json:"threshold"`
+	Groups      [][]*Definition `json:"groups"`
+}
+
+// FindDuplicateDefinitions groups the definitions whose name and signature
+// are near-duplicates under the requested similarity metric
+func (aqs *AdvancedQueryService) FindDuplicateDefinitions(ctx context.Context, req DuplicateDefinitionRequest) (*DuplicateDefinitionResponse, error) {
+	metric, err := similarity.ParseMetric(req.Metric)
+	if err != nil {
+		return nil, err
+	}
+	threshold := req.Threshold
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+
+	rows, err := aqs.queryBuilder.GetDefinitions(ctx, req.ServiceName, req.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get definitions: %w", err)
+	}
+
+	definitions := make([]*Definition, 0, len(rows))
+	for _, row := range rows {
+		definition := &Definition{}
+		definition.Name, _ = row["name"].(string)
+		definition.Type, _ = row["type"].(string)
+		definition.FilePath, _ = row["filePath"].(string)
+		definition.Line, _ = row["startLine"].(int)
+		definition.Signature, _ = row["signature"].(string)
+		definitions = append(definitions, definition)
+	}
+
+	return &DuplicateDefinitionResponse{
+		ServiceName: req.ServiceName,
+		Metric:      string(metric),
+		Threshold:   threshold,
+		Groups:      GroupDuplicateDefinitions(definitions, similarity.Matcher{Metric: metric, Threshold: threshold}),
 	}, nil
 }
 
+// GroupDuplicateDefinitions groups definitions of the same type that the
+// matcher considers similar. Each definition joins the first group whose
+// first definition it matches; groups with a single definition are dropped.
+func GroupDuplicateDefinitions(definitions []*Definition, matcher similarity.Matcher) [][]*Definition {
+	var groups [][]*Definition
+	for _, definition := range definitions {
+		key := definitionKey(definition)
+		joined := false
+		for i, group := range groups {
+			if group[0].Type == definition.Type && matcher.Similar(definitionKey(group[0]), key) {
+				groups[i] = append(group, definition)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			groups = append(groups, []*Definition{definition})
+		}
+	}
+
+	duplicates := [][]*Definition{}
+	for _, group := range groups {
+		if len(group) > 1 {
+			duplicates = append(duplicates, group)
+		}
+	}
+	return duplicates
+}
+
+// definitionKey is the content compared when looking for duplicate definitions
+func definitionKey(definition *Definition) string {
+	return similarity.Normalize(definition.Name + " " + definition.Signature)
+}
+
 // CallGraphRequest represents a call graph request
 type CallGraphRequest struct {
 	RootFunction string `json:"rootFunction"`
@@ -274,17 +589,252 @@ type CallGraphEdge struct {
 	Recursive bool   `json:"recursive,omitempty"`
 }
 
-// BuildCallGraph builds a call graph starting from a function
+// DefaultCallGraphDepth is the traversal depth used when none is requested
+const DefaultCallGraphDepth = 5
+
+// BuildCallGraph builds a call graph starting from a function. All CALLS paths
+// up to MaxDepth are fetched in one query and assembled here; each node keeps
+// its shortest distance from the root and edges that lead back to a node
+// already on the path are marked recursive.
 func (aqs *AdvancedQueryService) BuildCallGraph(ctx context.Context, req CallGraphRequest) (*CallGraphResponse, error) {
-	// This is a placeholder implementation
-	// In a full implementation, we would traverse the CALLS relationships
-	// to build a comprehensive call graph
-	
-	return &CallGraphResponse{
+	maxDepth := req.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultCallGraphDepth
+	}
+	direction := req.Direction
+	if direction == "" {
+		direction = "outgoing"
+	}
+
+	records, err := aqs.queryBuilder.FindCallPaths(ctx, req.RootFunction, maxDepth, direction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find call paths: %w", err)
+	}
+
+	graph := &CallGraphResponse{
 		RootFunction: req.RootFunction,
-		Direction:    req.Direction,
+		Direction:    direction,
 		Nodes:        make(map[string]*CallGraphNode),
 		Edges:        []*CallGraphEdge{},
-		MaxDepth:     0,
-	}, nil
-}
\ No newline at end of file
+	}
+	edges := make(map[string]*CallGraphEdge)
+	var rootID string
+
+	addNode := func(node dbtype.Node, depth int) {
+		if existing, ok := graph.Nodes[node.ElementId]; ok {
+			if depth < existing.Depth {
+				existing.Depth = depth
+			}
+			return
+		}
+
+		nodeType := ""
+		if len(node.Labels) > 0 {
+			nodeType = node.Labels[0]
+		}
+		name, _ := node.Props["name"].(string)
+		filePath, _ := node.Props["filePath"].(string)
+		graph.Nodes[node.ElementId] = &CallGraphNode{
+			Symbol:   node.ElementId,
+			Name:     name,
+			Type:     nodeType,
+			FilePath: filePath,
+			Depth:    depth,
+			Children: []string{},
+		}
+	}
+
+	for _, record := range records {
+		recordMap := record.AsMap()
+		root, ok := recordMap["root"].(dbtype.Node)
+		if !ok {
+			continue
+		}
+		if rootID == "" {
+			rootID = root.ElementId
+		} else if rootID != root.ElementId {
+			return nil, fmt.Errorf("function %s is ambiguous, use its signature or symbol", req.RootFunction)
+		}
+		addNode(root, 0)
+
+		pathNodes, _ := recordMap["pathNodes"].([]any)
+		pathRels, _ := recordMap["pathRels"].([]any)
+		if len(pathNodes) != len(pathRels)+1 {
+			continue
+		}
+
+		onPath := map[string]bool{root.ElementId: true}
+		for i, rel := range pathRels {
+			node, ok := pathNodes[i+1].(dbtype.Node)
+			if !ok {
+				break
+			}
+			relationship, ok := rel.(dbtype.Relationship)
+			if !ok {
+				break
+			}
+			addNode(node, i+1)
+
+			edge, ok := edges[relationship.ElementId]
+			if !ok {
+				edge = &CallGraphEdge{
+					From:     relationship.StartElementId,
+					To:       relationship.EndElementId,
+					CallType: "direct",
+				}
+				if line, ok := relationship.Props["line"].(int64); ok {
+					edge.Line = int(line)
+				}
+				edges[relationship.ElementId] = edge
+				graph.Edges = append(graph.Edges, edge)
+			}
+			if onPath[node.ElementId] {
+				edge.Recursive = true
+			}
+			onPath[node.ElementId] = true
+		}
+	}
+
+	if rootID == "" {
+		return nil, fmt.Errorf("%w: %s", neo4j.ErrFunctionNotFound, req.RootFunction)
+	}
+
+	for _, edge := range graph.Edges {
+		if from, ok := graph.Nodes[edge.From]; ok {
+			from.CallCount++
+			from.Children = append(from.Children, edge.To)
+		}
+	}
+	for _, node := range graph.Nodes {
+		if node.Depth > graph.MaxDepth {
+			graph.MaxDepth = node.Depth
+		}
+	}
+
+	return graph, nil
+}
+
+// DOT renders the call graph in Graphviz DOT format. Recursive edges are dashed.
+func (r *CallGraphResponse) DOT() string {
+	ids := make([]string, 0, len(r.Nodes))
+	for id := range r.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := r.Nodes[ids[i]], r.Nodes[ids[j]]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Symbol < b.Symbol
+	})
+
+	// DOT identifiers are assigned by position since element IDs contain colons
+	names := make(map[string]string, len(ids))
+	var b strings.Builder
+	b.WriteString("digraph callgraph {\n")
+	b.WriteString("  node [shape=box];\n")
+	for i, id := range ids {
+		node := r.Nodes[id]
+		names[id] = fmt.Sprintf("n%d", i)
+		label := dotEscape(node.Name)
+		if node.FilePath != "" {
+			label += `\n` + dotEscape(node.FilePath)
+		}
+		fmt.Fprintf(&b, "  %s [label=\"%s\"];\n", names[id], label)
+	}
+	for _, edge := range r.Edges {
+		from, ok := names[edge.From]
+		if !ok {
+			continue
+		}
+		to, ok := names[edge.To]
+		if !ok {
+			continue
+		}
+		if edge.Recursive {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed];\n", from, to)
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", from, to)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Tree renders the call graph as an indented tree from the root function,
+// with -> for calls made and <- for calls received. A function reached again,
+// through a cycle or a shared callee, is listed without its calls and marked.
+func (r *CallGraphResponse) Tree() string {
+	type branch struct {
+		id    string
+		arrow string
+		edge  int
+	}
+	branches := make(map[string][]branch)
+	for i, edge := range r.Edges {
+		if r.Direction != "incoming" {
+			branches[edge.From] = append(branches[edge.From], branch{edge.To, "->", i})
+		}
+		if r.Direction != "outgoing" {
+			branches[edge.To] = append(branches[edge.To], branch{edge.From, "<-", i})
+		}
+	}
+	for _, list := range branches {
+		sort.Slice(list, func(i, j int) bool {
+			a, b := r.Nodes[list[i].id], r.Nodes[list[j].id]
+			if a == nil || b == nil || a.Name == b.Name {
+				return list[i].id < list[j].id
+			}
+			return a.Name < b.Name
+		})
+	}
+
+	label := func(id string) string {
+		node, ok := r.Nodes[id]
+		if !ok {
+			return id
+		}
+		if node.FilePath == "" {
+			return node.Name
+		}
+		return fmt.Sprintf("%s (%s)", node.Name, node.FilePath)
+	}
+
+	var b strings.Builder
+	shown := make(map[string]bool)
+	var walk func(id, arrow string, via, level int)
+	walk = func(id, arrow string, via, level int) {
+		b.WriteString(strings.Repeat("  ", level))
+		if arrow != "" {
+			b.WriteString(arrow + " ")
+		}
+		b.WriteString(label(id))
+		if shown[id] {
+			b.WriteString(" [shown above]\n")
+			return
+		}
+		b.WriteString("\n")
+		shown[id] = true
+		for _, next := range branches[id] {
+			if next.edge != via { // Do not walk straight back along the same call
+				walk(next.id, next.arrow, next.edge, level+1)
+			}
+		}
+	}
+
+	for id, node := range r.Nodes {
+		if node.Depth == 0 {
+			walk(id, "", -1, 0)
+			break
+		}
+	}
+	return b.String()
+}
+
+// dotEscape escapes a string for use inside a quoted DOT attribute
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
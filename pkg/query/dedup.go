@@ -0,0 +1,57 @@
+package query
+
+import (
+	"sort"
+
+	"github.com/context-maximiser/code-graph/pkg/similarity"
+)
+
+// CollapseNearDuplicates folds results whose name and signature are at least
+// threshold similar (0-1) under the default similarity metric into the
+// highest-scored result of the same label.
+func CollapseNearDuplicates(results []*SearchResult, threshold float64) []*SearchResult {
+	return CollapseNearDuplicatesWith(results, similarity.Matcher{Metric: similarity.DefaultMetric, Threshold: threshold})
+}
+
+// CollapseNearDuplicatesWith folds results whose name and signature the
+// matcher considers similar into the highest-scored result of the same label.
+// The kept result records how many results it absorbed. Results are returned
+// in descending score order; a threshold of 0 or less disables collapsing.
+func CollapseNearDuplicatesWith(results []*SearchResult, matcher similarity.Matcher) []*SearchResult {
+	ranked := make([]*SearchResult, len(results))
+	copy(ranked, results)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	if matcher.Threshold <= 0 {
+		return ranked
+	}
+
+	var kept []*SearchResult
+	for _, result := range ranked {
+		key := similarityKey(result)
+		var into *SearchResult
+		for _, candidate := range kept {
+			if candidate.Type == result.Type &&
+				matcher.Similar(similarityKey(candidate), key) {
+				into = candidate
+				break
+			}
+		}
+
+		if into == nil {
+			kept = append(kept, result)
+			continue
+		}
+		into.CollapsedCount++
+		into.CollapsedIDs = append(into.CollapsedIDs, result.ID)
+	}
+
+	return kept
+}
+
+// similarityKey is the content compared when looking for near-duplicates
+func similarityKey(result *SearchResult) string {
+	return result.Name + " " + result.Signature
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/context-maximiser/code-graph/pkg/models"
 	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/similarity"
+	driver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
 )
 
 // LSPService provides Language Server Protocol-like functionality
@@ -20,6 +23,14 @@ func NewLSPService(client *neo4j.Client) *LSPService {
 	}
 }
 
+// NewLSPServiceWithBuilder creates an LSP service that runs its queries
+// through an existing query builder, e.g. a version-scoped one
+func NewLSPServiceWithBuilder(queryBuilder *neo4j.QueryBuilder) *LSPService {
+	return &LSPService{
+		queryBuilder: queryBuilder,
+	}
+}
+
 // GoToDefinitionRequest represents a go-to-definition request
 type GoToDefinitionRequest struct {
 	Symbol   string `json:"symbol"`
@@ -123,6 +134,12 @@ type SearchRequest struct {
 	Query     string   `json:"query"`
 	NodeTypes []string `json:"nodeTypes,omitempty"`
 	Limit     int      `json:"limit,omitempty"`
+	// CollapseThreshold folds results whose name and signature are at least
+	// this similar (0-1) into the best-ranked one; 0 disables collapsing
+	CollapseThreshold float64 `json:"collapseThreshold,omitempty"`
+	// CollapseMetric names the similarity metric used for collapsing:
+	// exact, jaccard or levenshtein (the default)
+	CollapseMetric string `json:"collapseMetric,omitempty"`
 }
 
 // SearchResult represents a search result item
@@ -130,10 +147,16 @@ type SearchResult struct {
 	ID          string            `json:"id"`
 	Name        string            `json:"name"`
 	Type        string            `json:"type"`
+	Labels      []string          `json:"labels,omitempty"`
 	FilePath    string            `json:"filePath,omitempty"`
 	Signature   string            `json:"signature,omitempty"`
 	Description string            `json:"description,omitempty"`
 	Properties  map[string]any    `json:"properties,omitempty"`
+	Score       float64           `json:"score"`
+	Fuzzy       bool              `json:"fuzzy,omitempty"` // Found by a fuzzy full-text fallback
+	// CollapsedCount is the number of near-duplicates folded into this result
+	CollapsedCount int      `json:"collapsedCount,omitempty"`
+	CollapsedIDs   []string `json:"collapsedIds,omitempty"`
 }
 
 // SearchResponse represents the search response
@@ -156,20 +179,55 @@ func (lsp *LSPService) Search(ctx context.Context, req SearchRequest) (*SearchRe
 		nodeTypes = []string{"Function", "Method", "Class", "Interface", "Variable"}
 	}
 
+	collapseMetric, err := similarity.ParseMetric(req.CollapseMetric)
+	if err != nil {
+		return nil, err
+	}
+
 	records, err := lsp.queryBuilder.SearchNodes(ctx, req.Query, nodeTypes, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search nodes: %w", err)
 	}
 
+	results := SearchResultsFromRecords(records)
+
+	if req.CollapseThreshold > 0 {
+		results = CollapseNearDuplicatesWith(results, similarity.Matcher{Metric: collapseMetric, Threshold: req.CollapseThreshold})
+	}
+
+	return &SearchResponse{
+		Query:   req.Query,
+		Results: results,
+		Count:   len(results),
+		Limit:   limit,
+	}, nil
+}
+
+// SearchResultsFromRecords converts ranked SearchNodes records into search results
+func SearchResultsFromRecords(records []*driver.Record) []*SearchResult {
 	var results []*SearchResult
-	for _, record := range records {
+	for i, record := range records {
 		recordMap := record.AsMap()
 		
 		if node, ok := recordMap["n"]; ok {
-			if nodeMap, ok := node.(map[string]any); ok {
+			var nodeMap map[string]any
+			var id string
+			switch n := node.(type) {
+			case dbtype.Node:
+				nodeMap = n.Props
+				id = n.ElementId
+			case map[string]any:
+				nodeMap = n
+			}
+
+			if nodeMap != nil {
+				// Records come back ranked but unscored, so earlier records score higher
 				result := &SearchResult{
+					ID:         id,
 					Properties: nodeMap,
+					Score:      float64(len(records)-i) / float64(len(records)),
 				}
+				result.Fuzzy, _ = recordMap["fuzzy"].(bool)
 
 				// Extract common properties
 				if name, ok := nodeMap["name"].(string); ok {
@@ -186,9 +244,14 @@ func (lsp *LSPService) Search(ctx context.Context, req SearchRequest) (*SearchRe
 				}
 
 				// Extract node type from labels
-				if labels, ok := recordMap["nodeLabels"].([]interface{}); ok && len(labels) > 0 {
-					if label, ok := labels[0].(string); ok {
-						result.Type = label
+				if labels, ok := recordMap["nodeLabels"].([]interface{}); ok {
+					for _, label := range labels {
+						if label, ok := label.(string); ok {
+							result.Labels = append(result.Labels, label)
+						}
+					}
+					if len(result.Labels) > 0 {
+						result.Type = result.Labels[0]
 					}
 				}
 
@@ -196,13 +259,26 @@ func (lsp *LSPService) Search(ctx context.Context, req SearchRequest) (*SearchRe
 			}
 		}
 	}
+	return results
+}
 
-	return &SearchResponse{
-		Query:   req.Query,
-		Results: results,
-		Count:   len(results),
-		Limit:   limit,
-	}, nil
+// RedactSearchResults drops the properties config redacts for each result's
+// labels, clearing the fields extracted from them
+func RedactSearchResults(results []*SearchResult, config *models.RedactionConfig) {
+	for _, result := range results {
+		props := config.Redact(result.Labels, result.Properties)
+		for key, field := range map[string]*string{
+			"name":        &result.Name,
+			"filePath":    &result.FilePath,
+			"signature":   &result.Signature,
+			"description": &result.Description,
+		} {
+			if _, kept := props[key]; !kept {
+				*field = ""
+			}
+		}
+		result.Properties = props
+	}
 }
 
 // CompletionRequest represents a code completion request
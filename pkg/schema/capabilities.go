@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FulltextDDL is the way a server creates full-text indexes
+type FulltextDDL string
+
+const (
+	// FulltextCreateIndex is the CREATE FULLTEXT INDEX command of Neo4j 4.3+
+	FulltextCreateIndex FulltextDDL = "create-index"
+	// FulltextProcedure is the db.index.fulltext.createNodeIndex procedure of
+	// Neo4j 3.5 to 4.x, removed in 5.0
+	FulltextProcedure FulltextDDL = "procedure"
+	// FulltextUnsupported marks servers older than 3.5
+	FulltextUnsupported FulltextDDL = ""
+)
+
+// ErrFulltextUnsupported is returned when creating a full-text index on a
+// server without full-text indexes
+var ErrFulltextUnsupported = errors.New("full-text indexes are not supported by this Neo4j server")
+
+// Capabilities describes the schema features of the connected server
+type Capabilities struct {
+	Version     string      `json:"version"`
+	Edition     string      `json:"edition"`
+	Major       int         `json:"major"`
+	Minor       int         `json:"minor"`
+	FulltextDDL FulltextDDL `json:"fulltextDdl"`
+	TextIndexes bool        `json:"textIndexes"` // CREATE TEXT INDEX, 4.4+
+}
+
+// DetectCapabilities derives the capabilities of a server from the version and
+// edition reported by dbms.components(). Full-text indexes are available on
+// every edition, so only the version decides the DDL. Calendar versions
+// (2025.01 and later) follow 5.x.
+func DetectCapabilities(version, edition string) (*Capabilities, error) {
+	major, minor, err := parseServerVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &Capabilities{Version: version, Edition: edition, Major: major, Minor: minor}
+	switch {
+	case atLeast(major, minor, 4, 3):
+		caps.FulltextDDL = FulltextCreateIndex
+	case atLeast(major, minor, 3, 5):
+		caps.FulltextDDL = FulltextProcedure
+	default:
+		caps.FulltextDDL = FulltextUnsupported
+	}
+	caps.TextIndexes = atLeast(major, minor, 4, 4)
+	return caps, nil
+}
+
+// parseServerVersion reads the major and minor numbers of versions like
+// "5.26.0", "4.4.12-enterprise" or "5.0.0-aura"
+func parseServerVersion(version string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimSpace(version), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("unrecognized Neo4j version %q", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unrecognized Neo4j version %q", version)
+	}
+	minorDigits := strings.IndexFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	if minorDigits < 0 {
+		minorDigits = len(parts[1])
+	}
+	minor, err := strconv.Atoi(parts[1][:minorDigits])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unrecognized Neo4j version %q", version)
+	}
+	return major, minor, nil
+}
+
+func atLeast(major, minor, wantMajor, wantMinor int) bool {
+	return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+}
+
+// capabilitiesFromInfo detects the capabilities from GetDatabaseInfo output
+func capabilitiesFromInfo(info map[string]any) (*Capabilities, error) {
+	var version string
+	if versions, ok := info["versions"].([]any); ok && len(versions) > 0 {
+		version, _ = versions[0].(string)
+	}
+	edition, _ := info["edition"].(string)
+	return DetectCapabilities(version, edition)
+}
+
+// Capabilities probes the server version and edition once and returns the
+// schema features available on it
+func (sm *SchemaManager) Capabilities(ctx context.Context) (*Capabilities, error) {
+	if sm.capabilities != nil {
+		return sm.capabilities, nil
+	}
+	info, err := sm.client.GetDatabaseInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := capabilitiesFromInfo(info)
+	if err != nil {
+		return nil, err
+	}
+	sm.capabilities = caps
+	return caps, nil
+}
+
+// fulltextIndexCypher returns the statement creating a full-text index, or ""
+// when a procedure-created index already exists
+func (sm *SchemaManager) fulltextIndexCypher(ctx context.Context, index Index, labels []string) (string, error) {
+	caps, err := sm.Capabilities(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect server capabilities: %w", err)
+	}
+
+	switch caps.FulltextDDL {
+	case FulltextCreateIndex:
+		properties := make([]string, len(index.Properties))
+		for i, property := range index.Properties {
+			properties[i] = "n." + property
+		}
+		return fmt.Sprintf(
+			"CREATE FULLTEXT INDEX %s IF NOT EXISTS FOR (n:%s) ON EACH [%s]",
+			index.Name, strings.Join(labels, "|"), strings.Join(properties, ", "),
+		), nil
+	case FulltextProcedure:
+		// The procedure has no IF NOT EXISTS; db.indexes() lists the indexes
+		// on the servers that use it
+		result, err := sm.client.ExecuteQuery(ctx,
+			"CALL db.indexes() YIELD name WHERE name = $name RETURN name", map[string]any{"name": index.Name})
+		if err != nil {
+			return "", fmt.Errorf("failed to list indexes: %w", err)
+		}
+		if len(result) > 0 {
+			return "", nil
+		}
+		return fmt.Sprintf(
+			"CALL db.index.fulltext.createNodeIndex('%s', [%s], [%s])",
+			index.Name, quoteProperties(labels), quoteProperties(index.Properties),
+		), nil
+	default:
+		return "", fmt.Errorf("%w (version %s)", ErrFulltextUnsupported, caps.Version)
+	}
+}
@@ -0,0 +1,215 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Migration is one step in the evolution of the graph schema. Steps must be
+// idempotent so that an interrupted migration can simply be run again.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, sm *SchemaManager) error
+	Down        func(ctx context.Context, sm *SchemaManager) error // nil when the step cannot be reverted
+}
+
+// Migrations returns the schema migration steps ordered by version
+func Migrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "Create constraints and indexes",
+			Up: func(ctx context.Context, sm *SchemaManager) error {
+				if err := sm.createConstraints(ctx); err != nil {
+					return err
+				}
+				return sm.createIndexes(ctx)
+			},
+			Down: func(ctx context.Context, sm *SchemaManager) error {
+				for _, index := range GetIndexes() {
+					if err := sm.dropIndex(ctx, index.Name); err != nil {
+						return err
+					}
+				}
+				return sm.dropConstraints(ctx, append(GetConstraints(), versionlessConstraints()...))
+			},
+		},
+		{
+			Version:     2,
+			Description: "Create vector indexes for node embeddings",
+			Up: func(ctx context.Context, sm *SchemaManager) error {
+				return sm.createVectorIndexes(ctx)
+			},
+			Down: func(ctx context.Context, sm *SchemaManager) error {
+				for _, index := range GetVectorIndexes(sm.vectorDimensions) {
+					if err := sm.dropIndex(ctx, index.Name); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version:     3,
+			Description: "Create vector index for document chunk embeddings",
+			Up: func(ctx context.Context, sm *SchemaManager) error {
+				return sm.createVectorIndexes(ctx)
+			},
+			Down: func(ctx context.Context, sm *SchemaManager) error {
+				return sm.dropIndex(ctx, "document_chunk_embedding_idx")
+			},
+		},
+		{
+			Version:     4,
+			Description: "Key files, classes, interfaces and modules by version",
+			Up: func(ctx context.Context, sm *SchemaManager) error {
+				if err := sm.dropConstraints(ctx, versionlessConstraints()); err != nil {
+					return err
+				}
+				return sm.createConstraints(ctx)
+			},
+			// Fails while the graph holds several versions of a node
+			Down: func(ctx context.Context, sm *SchemaManager) error {
+				var versioned []Constraint
+				for _, constraint := range GetConstraints() {
+					for _, property := range constraint.Properties {
+						if property == "version" {
+							versioned = append(versioned, constraint)
+						}
+					}
+				}
+				if err := sm.dropConstraints(ctx, versioned); err != nil {
+					return err
+				}
+				for _, constraint := range versionlessConstraints() {
+					if err := sm.createConstraint(ctx, constraint); err != nil {
+						return fmt.Errorf("failed to create constraint %s: %w", constraint.Name, err)
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// LatestVersion returns the schema version reached by applying every migration
+func LatestVersion() int {
+	migrations := Migrations()
+	return migrations[len(migrations)-1].Version
+}
+
+// CurrentVersion returns the schema version recorded in the database, or 0
+// when no version has been recorded
+func (sm *SchemaManager) CurrentVersion(ctx context.Context) (int, error) {
+	cypher := "MATCH (v:SchemaVersion) RETURN v.version AS version"
+	result, err := sm.client.ExecuteQuery(ctx, cypher, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if len(result) == 0 {
+		return 0, nil
+	}
+	version, _ := result[0].AsMap()["version"].(int64)
+	return int(version), nil
+}
+
+// Migrate applies all pending migrations and returns the steps applied
+func (sm *SchemaManager) Migrate(ctx context.Context) ([]Migration, error) {
+	return sm.MigrateTo(ctx, LatestVersion())
+}
+
+// MigrateTo moves the schema to the target version, applying pending steps
+// in order or reverting newer steps in reverse order. The recorded version is
+// updated after every step, so a failed migration can be resumed. It returns
+// the steps applied or reverted.
+func (sm *SchemaManager) MigrateTo(ctx context.Context, target int) ([]Migration, error) {
+	if target < 0 || target > LatestVersion() {
+		return nil, fmt.Errorf("unknown schema version %d (latest is %d)", target, LatestVersion())
+	}
+
+	current, err := sm.CurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := Migrations()
+	var steps []Migration
+
+	if target >= current {
+		for _, m := range migrations {
+			if m.Version <= current || m.Version > target {
+				continue
+			}
+			if err := m.Up(ctx, sm); err != nil {
+				return steps, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+			}
+			if err := sm.setVersion(ctx, m.Version); err != nil {
+				return steps, err
+			}
+			steps = append(steps, m)
+		}
+		return steps, nil
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		if m.Down == nil {
+			return steps, fmt.Errorf("migration %d (%s) cannot be reverted", m.Version, m.Description)
+		}
+		if err := m.Down(ctx, sm); err != nil {
+			return steps, fmt.Errorf("reverting migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		if err := sm.setVersion(ctx, m.Version-1); err != nil {
+			return steps, err
+		}
+		steps = append(steps, m)
+	}
+	return steps, nil
+}
+
+// setVersion records the schema version in the SchemaVersion node
+func (sm *SchemaManager) setVersion(ctx context.Context, version int) error {
+	cypher := `
+		MERGE (v:SchemaVersion)
+		SET v.version = $version, v.updatedAt = $updatedAt
+	`
+	params := map[string]any{"version": version, "updatedAt": time.Now().UTC().Unix()}
+	if _, err := sm.client.ExecuteQuery(ctx, cypher, params); err != nil {
+		return fmt.Errorf("failed to record schema version %d: %w", version, err)
+	}
+	return nil
+}
+
+// clearVersion removes the recorded schema version
+func (sm *SchemaManager) clearVersion(ctx context.Context) error {
+	if _, err := sm.client.ExecuteQuery(ctx, "MATCH (v:SchemaVersion) DELETE v", nil); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	return nil
+}
+
+// dropConstraints drops the constraints that exist among the given ones
+func (sm *SchemaManager) dropConstraints(ctx context.Context, constraints []Constraint) error {
+	for _, constraint := range constraints {
+		cypher := fmt.Sprintf("DROP CONSTRAINT %s IF EXISTS", constraint.Name)
+		if _, err := sm.client.ExecuteQuery(ctx, cypher, nil); err != nil {
+			return fmt.Errorf("failed to drop constraint %s: %w", constraint.Name, err)
+		}
+	}
+	return nil
+}
+
+// dropIndex drops a single index if it exists
+func (sm *SchemaManager) dropIndex(ctx context.Context, name string) error {
+	cypher := fmt.Sprintf("DROP INDEX %s IF EXISTS", name)
+	if _, err := sm.client.ExecuteQuery(ctx, cypher, nil); err != nil {
+		return fmt.Errorf("failed to drop index %s: %w", name, err)
+	}
+	return nil
+}
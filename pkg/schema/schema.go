@@ -1,29 +1,43 @@
 package schema
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/context-maximiser/code-graph/pkg/neo4j"
 )
 
+// DefaultVectorDimensions is the embedding size vector indexes are created with
+const DefaultVectorDimensions = 768
+
 // SchemaManager handles Neo4j schema creation and management
 type SchemaManager struct {
-	client *neo4j.Client
+	client           *neo4j.Client
+	vectorDimensions int
+	capabilities     *Capabilities // Probed on first use
 }
 
 // NewSchemaManager creates a new schema manager
 func NewSchemaManager(client *neo4j.Client) *SchemaManager {
-	return &SchemaManager{client: client}
+	return &SchemaManager{client: client, vectorDimensions: DefaultVectorDimensions}
+}
+
+// SetVectorDimensions sets the embedding size of the vector indexes created
+// and validated by the manager. It must match the embedding model in use.
+func (sm *SchemaManager) SetVectorDimensions(dimensions int) {
+	sm.vectorDimensions = dimensions
 }
 
 // Constraint represents a Neo4j constraint
 type Constraint struct {
-	Name      string
-	NodeLabel string
-	Property  string
-	Type      string // "UNIQUE", "EXISTENCE", "NODE_KEY"
+	Name       string
+	NodeLabel  string
+	Properties []string // Existence constraints take a single property
+	Type       string   // "UNIQUE", "EXISTENCE", "NODE_KEY"
 }
 
 // Index represents a Neo4j index
@@ -31,7 +45,19 @@ type Index struct {
 	Name       string
 	NodeLabel  string
 	Properties []string
-	Type       string // "BTREE", "TEXT", "POINT", "LOOKUP"
+	Type       string // "BTREE", "TEXT", "FULLTEXT", "LOOKUP"
+}
+
+// searchableLabels are the labels full-text indexes without a label cover
+var searchableLabels = []string{"Service", "File", "Class", "Function", "Method", "Variable", "Symbol", "Document", "Feature"}
+
+// VectorIndex represents a Neo4j vector index over node embeddings
+type VectorIndex struct {
+	Name       string
+	NodeLabel  string
+	Property   string
+	Dimensions int
+	Similarity string // "cosine" or "euclidean"
 }
 
 // GetConstraints returns all constraint definitions for the code graph schema
@@ -39,45 +65,57 @@ func GetConstraints() []Constraint {
 	return []Constraint{
 		// Unique constraints for key identifiers
 		{
-			Name:      "symbol_unique",
-			NodeLabel: "Symbol",
-			Property:  "symbol",
-			Type:      "UNIQUE",
+			Name:       "symbol_unique",
+			NodeLabel:  "Symbol",
+			Properties: []string{"symbol"},
+			Type:       "UNIQUE",
 		},
 		{
-			Name:      "service_name_unique",
-			NodeLabel: "Service",
-			Property:  "name",
-			Type:      "UNIQUE",
+			Name:       "service_name_unique",
+			NodeLabel:  "Service",
+			Properties: []string{"name"},
+			Type:       "UNIQUE",
 		},
+		// Code nodes are unique per indexed version, so versions of a
+		// service are kept apart
 		{
-			Name:      "file_path_unique",
-			NodeLabel: "File", 
-			Property:  "path",
-			Type:      "UNIQUE",
+			Name:       "file_path_version_unique",
+			NodeLabel:  "File",
+			Properties: []string{"path", "version"},
+			Type:       "UNIQUE",
 		},
-		// Node key constraints for composite uniqueness
 		{
-			Name:      "class_fqn_unique",
-			NodeLabel: "Class",
-			Property:  "fqn",
-			Type:      "UNIQUE",
+			Name:       "class_fqn_version_unique",
+			NodeLabel:  "Class",
+			Properties: []string{"fqn", "version"},
+			Type:       "UNIQUE",
 		},
 		{
-			Name:      "interface_fqn_unique",
-			NodeLabel: "Interface",
-			Property:  "fqn",
-			Type:      "UNIQUE",
+			Name:       "interface_fqn_version_unique",
+			NodeLabel:  "Interface",
+			Properties: []string{"fqn", "version"},
+			Type:       "UNIQUE",
 		},
 		{
-			Name:      "module_fqn_unique",
-			NodeLabel: "Module",
-			Property:  "fqn",
-			Type:      "UNIQUE",
+			Name:       "module_fqn_version_unique",
+			NodeLabel:  "Module",
+			Properties: []string{"fqn", "version"},
+			Type:       "UNIQUE",
 		},
 	}
 }
 
+// versionlessConstraints are the constraints that keyed code nodes without
+// their version, replaced by GetConstraints in migration 4
+func versionlessConstraints() []Constraint {
+	return []Constraint{
+		{Name: "file_path_unique", NodeLabel: "File", Properties: []string{"path"}, Type: "UNIQUE"},
+		{Name: "class_fqn_unique", NodeLabel: "Class", Properties: []string{"fqn"}, Type: "UNIQUE"},
+		{Name: "interface_fqn_unique", NodeLabel: "Interface", Properties: []string{"fqn"}, Type: "UNIQUE"},
+		{Name: "module_fqn_unique", NodeLabel: "Module", Properties: []string{"fqn"}, Type: "UNIQUE"},
+	}
+}
+
 // GetIndexes returns all index definitions for the code graph schema
 func GetIndexes() []Index {
 	return []Index{
@@ -202,8 +240,40 @@ func GetIndexes() []Index {
 	}
 }
 
-// CreateSchema creates all constraints and indexes for the code graph
+// GetVectorIndexes returns the vector index definitions for node embeddings
+// of the given size
+func GetVectorIndexes(dimensions int) []VectorIndex {
+	labels := []struct{ name, label string }{
+		{"function_embedding_idx", "Function"},
+		{"method_embedding_idx", "Method"},
+		{"class_embedding_idx", "Class"},
+		{"interface_embedding_idx", "Interface"},
+		{"document_embedding_idx", "Document"},
+		{"document_chunk_embedding_idx", "DocumentChunk"},
+		{"feature_embedding_idx", "Feature"},
+	}
+
+	indexes := make([]VectorIndex, 0, len(labels))
+	for _, l := range labels {
+		indexes = append(indexes, VectorIndex{
+			Name:       l.name,
+			NodeLabel:  l.label,
+			Property:   "embedding",
+			Dimensions: dimensions,
+			Similarity: "cosine",
+		})
+	}
+	return indexes
+}
+
+// CreateSchema creates all constraints and indexes for the code graph and
+// records the latest schema version
 func (sm *SchemaManager) CreateSchema(ctx context.Context) error {
+	// Constraints of older schemas would reject the versions of a node
+	if err := sm.dropConstraints(ctx, versionlessConstraints()); err != nil {
+		return err
+	}
+
 	// Create constraints first
 	if err := sm.createConstraints(ctx); err != nil {
 		return fmt.Errorf("failed to create constraints: %w", err)
@@ -214,7 +284,13 @@ func (sm *SchemaManager) CreateSchema(ctx context.Context) error {
 		return fmt.Errorf("failed to create indexes: %w", err)
 	}
 
-	return nil
+	// Create vector indexes for embedding search
+	if err := sm.createVectorIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create vector indexes: %w", err)
+	}
+
+	// The full schema corresponds to every migration applied
+	return sm.setVersion(ctx, LatestVersion())
 }
 
 // createConstraints creates all constraint definitions
@@ -233,22 +309,29 @@ func (sm *SchemaManager) createConstraints(ctx context.Context) error {
 // createConstraint creates a single constraint
 func (sm *SchemaManager) createConstraint(ctx context.Context, constraint Constraint) error {
 	var cypher string
+	properties := make([]string, len(constraint.Properties))
+	for i, property := range constraint.Properties {
+		properties[i] = "n." + property
+	}
 	
 	switch constraint.Type {
 	case "UNIQUE":
 		cypher = fmt.Sprintf(
-			"CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE n.%s IS UNIQUE",
-			constraint.Name, constraint.NodeLabel, constraint.Property,
+			"CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE (%s) IS UNIQUE",
+			constraint.Name, constraint.NodeLabel, strings.Join(properties, ", "),
 		)
 	case "EXISTENCE":
+		if len(properties) != 1 {
+			return fmt.Errorf("existence constraint %s must have one property", constraint.Name)
+		}
 		cypher = fmt.Sprintf(
-			"CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE n.%s IS NOT NULL",
-			constraint.Name, constraint.NodeLabel, constraint.Property,
+			"CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE %s IS NOT NULL",
+			constraint.Name, constraint.NodeLabel, properties[0],
 		)
 	case "NODE_KEY":
 		cypher = fmt.Sprintf(
-			"CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE (n.%s) IS NODE KEY",
-			constraint.Name, constraint.NodeLabel, constraint.Property,
+			"CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE (%s) IS NODE KEY",
+			constraint.Name, constraint.NodeLabel, strings.Join(properties, ", "),
 		)
 	default:
 		return fmt.Errorf("unsupported constraint type: %s", constraint.Type)
@@ -296,32 +379,30 @@ func (sm *SchemaManager) createIndex(ctx context.Context, index Index) error {
 			)
 		}
 	case "FULLTEXT":
-		if index.NodeLabel == "" {
-			// Full-text index on all nodes using APOC
-			cypher = fmt.Sprintf(
-				"CALL apoc.index.fulltext.nodes.create('%s', ['Service', 'File', 'Class', 'Function', 'Method', 'Variable', 'Symbol', 'Document', 'Feature'], [%s])",
-				index.Name, quoteProperties(index.Properties),
-			)
-		} else {
-			cypher = fmt.Sprintf(
-				"CALL apoc.index.fulltext.nodes.create('%s', ['%s'], [%s])",
-				index.Name, index.NodeLabel, quoteProperties(index.Properties),
-			)
+		// Full-text indexes without a label cover every searchable label
+		labels := searchableLabels
+		if index.NodeLabel != "" {
+			labels = []string{index.NodeLabel}
+		}
+		var err error
+		if cypher, err = sm.fulltextIndexCypher(ctx, index, labels); err != nil {
+			return err
+		}
+		if cypher == "" {
+			return nil
 		}
 	case "TEXT":
-		// Legacy TEXT support - try built-in first, fallback to APOC
-		if index.NodeLabel == "" {
-			// Full-text index on all nodes
-			cypher = fmt.Sprintf(
-				"CALL db.index.fulltext.createNodeIndex('%s', ['Service', 'File', 'Class', 'Function', 'Method', 'Variable', 'Symbol', 'Document', 'Feature'], [%s])",
-				index.Name, quoteProperties(index.Properties),
-			)
-		} else {
-			cypher = fmt.Sprintf(
-				"CALL db.index.fulltext.createNodeIndex('%s', ['%s'], [%s])",
-				index.Name, index.NodeLabel, quoteProperties(index.Properties),
-			)
+		caps, err := sm.Capabilities(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to detect server capabilities: %w", err)
 		}
+		if !caps.TextIndexes || index.NodeLabel == "" || len(index.Properties) != 1 {
+			return fmt.Errorf("TEXT index %s needs Neo4j 4.4+, one label and one property", index.Name)
+		}
+		cypher = fmt.Sprintf(
+			"CREATE TEXT INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.%s)",
+			index.Name, index.NodeLabel, index.Properties[0],
+		)
 	case "LOOKUP":
 		cypher = fmt.Sprintf(
 			"CREATE LOOKUP INDEX %s IF NOT EXISTS FOR (n) ON EACH labels(n)",
@@ -339,71 +420,182 @@ func (sm *SchemaManager) createIndex(ctx context.Context, index Index) error {
 	return nil
 }
 
+// createVectorIndexes creates all vector index definitions
+func (sm *SchemaManager) createVectorIndexes(ctx context.Context) error {
+	if sm.vectorDimensions <= 0 {
+		return fmt.Errorf("vector dimensions must be positive, got %d", sm.vectorDimensions)
+	}
+
+	for _, index := range GetVectorIndexes(sm.vectorDimensions) {
+		cypher := fmt.Sprintf(
+			"CREATE VECTOR INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.%s) "+
+				"OPTIONS {indexConfig: {`vector.dimensions`: %d, `vector.similarity_function`: '%s'}}",
+			index.Name, index.NodeLabel, index.Property, index.Dimensions, index.Similarity,
+		)
+		if _, err := sm.client.ExecuteQuery(ctx, cypher, nil); err != nil {
+			return fmt.Errorf("failed to create vector index %s: %w", index.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// DropSummary lists the schema elements affected by a drop
+type DropSummary struct {
+	Constraints []string
+	Indexes     []string
+}
+
+// Total returns the number of constraints and indexes in the summary
+func (ds *DropSummary) Total() int {
+	return len(ds.Constraints) + len(ds.Indexes)
+}
+
+// ErrDropNotConfirmed is returned when a schema drop was not confirmed
+var ErrDropNotConfirmed = errors.New("schema drop not confirmed")
+
+// ConfirmDrop asks for confirmation before dropping the elements in summary.
+// assumeYes skips the prompt; without it the prompt is only shown when
+// interactive is true, otherwise the drop is refused.
+func ConfirmDrop(summary *DropSummary, in io.Reader, out io.Writer, interactive, assumeYes bool) error {
+	if assumeYes {
+		return nil
+	}
+	if !interactive {
+		return fmt.Errorf("%w: input is not a terminal", ErrDropNotConfirmed)
+	}
+
+	fmt.Fprintf(out, "Drop %d constraints and %d indexes? [y/N]: ", len(summary.Constraints), len(summary.Indexes))
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return ErrDropNotConfirmed
+	}
+}
+
+// PlanDrop returns the constraints and indexes DropSchema would remove
+func (sm *SchemaManager) PlanDrop(ctx context.Context) (*DropSummary, error) {
+	constraints, err := sm.listConstraintNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, err := sm.listIndexNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DropSummary{Constraints: constraints, Indexes: indexes}, nil
+}
+
 // DropSchema drops all constraints and indexes
 func (sm *SchemaManager) DropSchema(ctx context.Context) error {
+	_, err := sm.DropSchemaWithSummary(ctx)
+	return err
+}
+
+// DropSchemaWithSummary drops all constraints and indexes and reports what was dropped
+func (sm *SchemaManager) DropSchemaWithSummary(ctx context.Context) (*DropSummary, error) {
+	summary := &DropSummary{}
+
 	// Drop all indexes first
-	if err := sm.dropAllIndexes(ctx); err != nil {
-		return fmt.Errorf("failed to drop indexes: %w", err)
+	indexes, err := sm.dropAllIndexes(ctx)
+	summary.Indexes = indexes
+	if err != nil {
+		return summary, fmt.Errorf("failed to drop indexes: %w", err)
 	}
 
 	// Drop all constraints
-	if err := sm.dropAllConstraints(ctx); err != nil {
-		return fmt.Errorf("failed to drop constraints: %w", err)
+	constraints, err := sm.dropAllConstraints(ctx)
+	summary.Constraints = constraints
+	if err != nil {
+		return summary, fmt.Errorf("failed to drop constraints: %w", err)
 	}
 
-	return nil
+	return summary, sm.clearVersion(ctx)
 }
 
-// dropAllConstraints drops all constraints in the database
-func (sm *SchemaManager) dropAllConstraints(ctx context.Context) error {
-	// Get all constraint names
+// listConstraintNames returns the names of all constraints in the database
+func (sm *SchemaManager) listConstraintNames(ctx context.Context) ([]string, error) {
 	cypher := "SHOW CONSTRAINTS YIELD name"
 	result, err := sm.client.ExecuteQuery(ctx, cypher, nil)
 	if err != nil {
-		return fmt.Errorf("failed to list constraints: %w", err)
+		return nil, fmt.Errorf("failed to list constraints: %w", err)
 	}
 
-	// Drop each constraint
+	var names []string
 	for _, record := range result {
-		constraintName, ok := record.AsMap()["name"].(string)
-		if !ok {
-			continue
+		if name, ok := record.AsMap()["name"].(string); ok {
+			names = append(names, name)
 		}
+	}
+	return names, nil
+}
+
+// listIndexNames returns the names of all standalone indexes in the database.
+// Indexes backing a constraint are removed together with the constraint.
+func (sm *SchemaManager) listIndexNames(ctx context.Context) ([]string, error) {
+	cypher := "SHOW INDEXES YIELD name, owningConstraint WHERE owningConstraint IS NULL RETURN name"
+	result, err := sm.client.ExecuteQuery(ctx, cypher, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	var names []string
+	for _, record := range result {
+		if name, ok := record.AsMap()["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
 
+// dropAllConstraints drops all constraints in the database
+func (sm *SchemaManager) dropAllConstraints(ctx context.Context) ([]string, error) {
+	names, err := sm.listConstraintNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop each constraint
+	var dropped []string
+	for _, constraintName := range names {
 		dropCypher := fmt.Sprintf("DROP CONSTRAINT %s IF EXISTS", constraintName)
 		_, err := sm.client.ExecuteQuery(ctx, dropCypher, nil)
 		if err != nil {
-			return fmt.Errorf("failed to drop constraint %s: %w", constraintName, err)
+			return dropped, fmt.Errorf("failed to drop constraint %s: %w", constraintName, err)
 		}
+		dropped = append(dropped, constraintName)
 	}
 
-	return nil
+	return dropped, nil
 }
 
 // dropAllIndexes drops all indexes in the database
-func (sm *SchemaManager) dropAllIndexes(ctx context.Context) error {
-	// Get all index names
-	cypher := "SHOW INDEXES YIELD name"
-	result, err := sm.client.ExecuteQuery(ctx, cypher, nil)
+func (sm *SchemaManager) dropAllIndexes(ctx context.Context) ([]string, error) {
+	names, err := sm.listIndexNames(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list indexes: %w", err)
+		return nil, err
 	}
 
 	// Drop each index
-	for _, record := range result {
-		indexName, ok := record.AsMap()["name"].(string)
-		if !ok {
-			continue
-		}
-
+	var dropped []string
+	for _, indexName := range names {
 		dropCypher := fmt.Sprintf("DROP INDEX %s IF EXISTS", indexName)
 		_, err := sm.client.ExecuteQuery(ctx, dropCypher, nil)
 		if err != nil {
-			return fmt.Errorf("failed to drop index %s: %w", indexName, err)
+			return dropped, fmt.Errorf("failed to drop index %s: %w", indexName, err)
 		}
+		dropped = append(dropped, indexName)
 	}
 
-	return nil
+	return dropped, nil
 }
 
 // GetSchemaInfo returns information about current schema
@@ -436,6 +628,12 @@ func (sm *SchemaManager) GetSchemaInfo(ctx context.Context) (map[string]any, err
 	}
 	info["indexes"] = indexes
 
+	version, err := sm.CurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info["version"] = version
+
 	return info, nil
 }
 
@@ -484,10 +682,47 @@ func (sm *SchemaManager) ValidateSchema(ctx context.Context) error {
 		}
 	}
 
+	return sm.validateVectorIndexes(ctx)
+}
+
+// validateVectorIndexes checks that every vector index exists with the
+// configured dimensions
+func (sm *SchemaManager) validateVectorIndexes(ctx context.Context) error {
+	cypher := "SHOW INDEXES YIELD name, type, options WHERE type = 'VECTOR' RETURN name, options"
+	result, err := sm.client.ExecuteQuery(ctx, cypher, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check vector indexes: %w", err)
+	}
+
+	existing := make(map[string]int64)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		name, ok := recordMap["name"].(string)
+		if !ok {
+			continue
+		}
+		existing[name] = -1
+		options, _ := recordMap["options"].(map[string]any)
+		config, _ := options["indexConfig"].(map[string]any)
+		if dimensions, ok := config["vector.dimensions"].(int64); ok {
+			existing[name] = dimensions
+		}
+	}
+
+	for _, index := range GetVectorIndexes(sm.vectorDimensions) {
+		dimensions, ok := existing[index.Name]
+		if !ok {
+			return fmt.Errorf("missing vector index: %s", index.Name)
+		}
+		if dimensions != int64(index.Dimensions) {
+			return fmt.Errorf("vector index %s has %d dimensions, expected %d", index.Name, dimensions, index.Dimensions)
+		}
+	}
+
 	return nil
 }
 
-// quoteProperties wraps property names in quotes for full-text index creation
+// quoteProperties wraps names in quotes for the full-text index procedure
 func quoteProperties(properties []string) string {
 	quoted := make([]string, len(properties))
 	for i, prop := range properties {
@@ -0,0 +1,33 @@
+package schema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmDrop(t *testing.T) {
+	summary := &DropSummary{
+		Constraints: []string{"symbol_unique"},
+		Indexes:     []string{"function_name_index", "class_name_index"},
+	}
+
+	// Without --yes and without a TTY the drop is refused
+	var out bytes.Buffer
+	err := ConfirmDrop(summary, strings.NewReader("y\n"), &out, false, false)
+	assert.ErrorIs(t, err, ErrDropNotConfirmed)
+	assert.Empty(t, out.String(), "No prompt should be shown without a terminal")
+
+	// --yes proceeds without prompting
+	assert.NoError(t, ConfirmDrop(summary, strings.NewReader(""), &out, false, true))
+
+	// Interactive confirmation
+	out.Reset()
+	assert.NoError(t, ConfirmDrop(summary, strings.NewReader("yes\n"), &out, true, false))
+	assert.Contains(t, out.String(), "Drop 1 constraints and 2 indexes?")
+
+	assert.ErrorIs(t, ConfirmDrop(summary, strings.NewReader("n\n"), &out, true, false), ErrDropNotConfirmed)
+	assert.ErrorIs(t, ConfirmDrop(summary, strings.NewReader(""), &out, true, false), ErrDropNotConfirmed)
+}
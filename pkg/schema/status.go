@@ -0,0 +1,144 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+)
+
+// IndexState is the readiness of a search index
+type IndexState struct {
+	Name              string   `json:"name"`
+	State             string   `json:"state"`
+	PopulationPercent float64  `json:"populationPercent"`
+	Labels            []string `json:"labels"`
+	// Queryable is set once the index is online; until then queries
+	// through it fail
+	Queryable bool `json:"queryable"`
+}
+
+// Status compares the schema in the database with the one the code graph needs
+type Status struct {
+	Version            int          `json:"version"`
+	LatestVersion      int          `json:"latestVersion"`
+	MissingConstraints []string     `json:"missingConstraints"`
+	MissingIndexes     []string     `json:"missingIndexes"`
+	VectorIndexes      []IndexState `json:"vectorIndexes"`
+	FulltextIndexes    []IndexState `json:"fulltextIndexes"`
+	Ready              bool         `json:"ready"`
+}
+
+// Diagnostics describes the database, its contents and its schema
+type Diagnostics struct {
+	Server        map[string]any   `json:"server"`
+	Nodes         map[string]int64 `json:"nodes"`
+	Relationships map[string]int64 `json:"relationships"`
+	Schema        *Status          `json:"schema"`
+	Capabilities  *Capabilities    `json:"capabilities,omitempty"`
+}
+
+// Status reports missing constraints and indexes, the state of the vector and
+// full-text indexes and the schema version. The schema is ready when nothing
+// is missing, every migration is applied and every search index is online.
+func (sm *SchemaManager) Status(ctx context.Context) (*Status, error) {
+	info, err := sm.GetSchemaInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		LatestVersion:      LatestVersion(),
+		MissingConstraints: []string{},
+		MissingIndexes:     []string{},
+		VectorIndexes:      []IndexState{},
+		FulltextIndexes:    []IndexState{},
+	}
+	status.Version, _ = info["version"].(int)
+
+	constraints := make(map[string]bool)
+	constraintList, _ := info["constraints"].([]map[string]any)
+	for _, constraint := range constraintList {
+		if name, ok := constraint["name"].(string); ok {
+			constraints[name] = true
+		}
+	}
+	for _, constraint := range GetConstraints() {
+		if !constraints[constraint.Name] {
+			status.MissingConstraints = append(status.MissingConstraints, constraint.Name)
+		}
+	}
+
+	indexes := make(map[string]bool)
+	online := true
+	indexList, _ := info["indexes"].([]map[string]any)
+	for _, index := range indexList {
+		name, _ := index["name"].(string)
+		indexes[name] = true
+
+		state := IndexState{Name: name}
+		state.State, _ = index["state"].(string)
+		state.PopulationPercent, _ = index["populationPercent"].(float64)
+		state.Queryable = state.State == "ONLINE"
+		labels, _ := index["labelsOrTypes"].([]any)
+		for _, label := range labels {
+			if name, ok := label.(string); ok {
+				state.Labels = append(state.Labels, name)
+			}
+		}
+		switch index["type"] {
+		case "VECTOR":
+			status.VectorIndexes = append(status.VectorIndexes, state)
+		case "FULLTEXT":
+			status.FulltextIndexes = append(status.FulltextIndexes, state)
+		default:
+			continue
+		}
+		if state.State != "ONLINE" {
+			online = false
+		}
+	}
+
+	var required []string
+	for _, index := range GetIndexes() {
+		required = append(required, index.Name)
+	}
+	for _, index := range GetVectorIndexes(sm.vectorDimensions) {
+		required = append(required, index.Name)
+	}
+	for _, name := range required {
+		if !indexes[name] {
+			status.MissingIndexes = append(status.MissingIndexes, name)
+		}
+	}
+
+	status.Ready = online && len(status.MissingConstraints) == 0 && len(status.MissingIndexes) == 0 &&
+		status.Version == status.LatestVersion
+	return status, nil
+}
+
+// Diagnose gathers the server information, node counts by label,
+// relationship counts by type and schema status in one call
+func (sm *SchemaManager) Diagnose(ctx context.Context) (*Diagnostics, error) {
+	server, err := sm.client.GetDatabaseInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := sm.client.CountNodesByLabel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := sm.client.CountRelationshipsByType(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := sm.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema: %w", err)
+	}
+
+	diagnostics := &Diagnostics{Server: server, Nodes: nodes, Relationships: relationships, Schema: status}
+	// Servers reporting an unrecognized version are still diagnosed
+	if caps, err := capabilitiesFromInfo(server); err == nil {
+		diagnostics.Capabilities = caps
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,132 @@
+package similarity
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Metric identifies a string similarity metric. Every metric scores a pair of
+// strings between 0 (unrelated) and 1 (identical).
+type Metric string
+
+const (
+	// Exact scores 1 for equal strings and 0 otherwise
+	Exact Metric = "exact"
+	// Jaccard compares the sets of lowercase alphanumeric tokens
+	Jaccard Metric = "jaccard"
+	// Levenshtein is 1 minus the edit distance normalized by the longer length
+	Levenshtein Metric = "levenshtein"
+)
+
+// DefaultMetric is used when no metric is configured
+const DefaultMetric = Levenshtein
+
+// ParseMetric returns the metric with the given name; an empty name selects
+// the default metric
+func ParseMetric(name string) (Metric, error) {
+	switch Metric(strings.ToLower(strings.TrimSpace(name))) {
+	case "":
+		return DefaultMetric, nil
+	case Exact:
+		return Exact, nil
+	case Jaccard:
+		return Jaccard, nil
+	case Levenshtein:
+		return Levenshtein, nil
+	}
+	return "", fmt.Errorf("unknown similarity metric %q (use exact, jaccard or levenshtein)", name)
+}
+
+// Score returns the similarity of a and b under the metric. An unset metric
+// behaves as the default.
+func (m Metric) Score(a, b string) float64 {
+	switch m {
+	case Exact:
+		if a == b {
+			return 1
+		}
+		return 0
+	case Jaccard:
+		return jaccard(a, b)
+	default:
+		return levenshtein(a, b)
+	}
+}
+
+// Matcher decides whether two strings are near-duplicates
+type Matcher struct {
+	Metric    Metric
+	Threshold float64 // Minimum score, inclusive
+}
+
+// Similar reports whether a and b score at least the matcher's threshold
+func (m Matcher) Similar(a, b string) bool {
+	return m.Metric.Score(a, b) >= m.Threshold
+}
+
+// Normalize lowercases s and collapses runs of whitespace, so that formatting
+// differences do not affect comparisons
+func Normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// jaccard returns the size of the intersection of the token sets of a and b
+// divided by the size of their union
+func jaccard(a, b string) float64 {
+	setA, setB := tokenSet(a), tokenSet(b)
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for token := range setA {
+		if setB[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
+
+// tokenSet splits s into lowercase runs of letters and digits
+func tokenSet(s string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		tokens[token] = true
+	}
+	return tokens
+}
+
+// levenshtein returns 1 minus the Levenshtein distance normalized by the
+// length of the longer string
+func levenshtein(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -0,0 +1,56 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimilarityMetrics(t *testing.T) {
+	assert.Equal(t, 1.0, Exact.Score("ProcessOrder", "ProcessOrder"))
+	assert.Equal(t, 0.0, Exact.Score("ProcessOrder", "processOrder"))
+
+	// {process, order, id} vs {process, order, ctx}: 2 shared of 4
+	assert.InDelta(t, 0.5, Jaccard.Score("process_order(id)", "Process order ctx"), 1e-9)
+	assert.Equal(t, 1.0, Jaccard.Score("order process", "Process-Order"), "Token order and case are ignored")
+	assert.Equal(t, 1.0, Jaccard.Score("", "--"), "Strings without tokens are identical")
+
+	// kitten -> sitting takes 3 edits over 7 runes
+	assert.InDelta(t, 1-3.0/7, Levenshtein.Score("kitten", "sitting"), 1e-9)
+	assert.Equal(t, 1.0, Levenshtein.Score("", ""))
+	assert.Equal(t, Levenshtein.Score("kitten", "sitting"), Metric("").Score("kitten", "sitting"),
+		"An unset metric behaves as the default")
+}
+
+func TestSimilarityThresholdBoundary(t *testing.T) {
+	// "abcd" vs "abce" scores exactly 0.75
+	matcher := Matcher{Metric: Levenshtein, Threshold: 0.75}
+	assert.True(t, matcher.Similar("abcd", "abce"), "The threshold is inclusive")
+	matcher.Threshold = 0.76
+	assert.False(t, matcher.Similar("abcd", "abce"))
+
+	matcher = Matcher{Metric: Jaccard, Threshold: 0.5}
+	assert.True(t, matcher.Similar("get user", "get user name"), "2 of 3 tokens shared")
+	assert.False(t, matcher.Similar("get user", "set order"))
+
+	matcher = Matcher{Metric: Exact, Threshold: 1}
+	assert.True(t, matcher.Similar("a", "a"))
+	assert.False(t, matcher.Similar("a", "b"))
+}
+
+func TestParseMetric(t *testing.T) {
+	for name, want := range map[string]Metric{
+		"":            DefaultMetric,
+		"exact":       Exact,
+		" Jaccard ":   Jaccard,
+		"LEVENSHTEIN": Levenshtein,
+	} {
+		metric, err := ParseMetric(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, metric, name)
+	}
+
+	_, err := ParseMetric("cosine")
+	assert.Error(t, err)
+}
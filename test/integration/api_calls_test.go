@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHTTPClientPattern(t *testing.T) {
+	pattern, err := static.ParseHTTPClientPattern("example.com/sdk.(*Client).Fetch:get:1")
+	require.NoError(t, err)
+	assert.Equal(t, static.HTTPClientPattern{Callee: "example.com/sdk.(*Client).Fetch", Method: "GET", URLArg: 1}, pattern)
+
+	pattern, err = static.ParseHTTPClientPattern("example.com/sdk.Send:$0:2")
+	require.NoError(t, err)
+	assert.Equal(t, static.HTTPClientPattern{Callee: "example.com/sdk.Send", MethodArg: 0, URLArg: 2}, pattern)
+
+	for _, spec := range []string{"", "example.com/sdk.Send", "example.com/sdk.Send:GET:x", "example.com/sdk.Send:$x:0"} {
+		_, err := static.ParseHTTPClientPattern(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestIndexOutboundAPICalls(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/consumer\n\ngo 1.21\n"), 0644))
+	source := `package consumer
+
+import (
+	"context"
+	"net/http"
+)
+
+const paymentsURL = "https://payments.internal/v1/charges"
+
+func FetchOrders() (*http.Response, error) {
+	return http.Get("https://orders.internal/v1/orders")
+}
+
+func Charge(ctx context.Context, client *http.Client) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, paymentsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+func Dynamic(url string) (*http.Response, error) {
+	return http.Get(url)
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "client.go"), []byte(source), 0644))
+
+	indexer := static.NewStaticIndexer(client, "consumer-service", "v1.0.0", "")
+	indexer.SetHTTPClientPatterns(static.DefaultHTTPClientPatterns())
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (f:Function)-[r:CALLS_API]->(e:ExternalEndpoint)
+		RETURN f.name AS function, e.method AS method, e.url AS url, e.host AS host, e.path AS path, r.line AS line
+		ORDER BY function`, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2, "Calls with non-constant URLs are not linked")
+
+	charge := result[0].AsMap()
+	assert.Equal(t, "Charge", charge["function"])
+	assert.Equal(t, "POST", charge["method"])
+	assert.Equal(t, "https://payments.internal/v1/charges", charge["url"])
+	assert.Equal(t, int64(15), charge["line"])
+
+	fetch := result[1].AsMap()
+	assert.Equal(t, "FetchOrders", fetch["function"])
+	assert.Equal(t, "GET", fetch["method"])
+	assert.Equal(t, "orders.internal", fetch["host"])
+	assert.Equal(t, "/v1/orders", fetch["path"])
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/api"
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getJSON requests path from the server and decodes the JSON body into v
+func getJSON(t *testing.T, server *httptest.Server, path string, v any) int {
+	t.Helper()
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	return resp.StatusCode
+}
+
+func TestAPIServerRequestValidation(t *testing.T) {
+	// These requests are answered without querying the database
+	server := httptest.NewServer(api.NewServer(neo4j.NewQueryBuilder(nil)).Handler())
+	defer server.Close()
+
+	var health map[string]string
+	assert.Equal(t, http.StatusOK, getJSON(t, server, "/healthz", &health))
+	assert.Equal(t, "ok", health["status"])
+
+	var apiErr api.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server, "/search", &apiErr))
+	assert.Contains(t, apiErr.Error, "'q'")
+
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server, "/search?q=Index&limit=ten", &apiErr))
+	assert.Contains(t, apiErr.Error, "'limit'")
+
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server, "/search?q=Index&types="+url.QueryEscape("Function) DETACH DELETE n //"), &apiErr))
+	assert.Contains(t, apiErr.Error, "invalid identifier")
+
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server, "/references/sym?offset=-1", &apiErr))
+	assert.Contains(t, apiErr.Error, "'offset'")
+
+	resp, err := http.Post(server.URL+"/search?q=Index", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestAPIServerEndpoints(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	source := "package app\n\nfunc SaveUser() {}\n"
+	sourcePath := filepath.Join(t.TempDir(), "app.go")
+	require.NoError(t, os.WriteFile(sourcePath, []byte(source), 0644))
+
+	saveID, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+		"name": "SaveUser", "signature": "SaveUser()", "filePath": sourcePath,
+		"startLine": 3, "endLine": 3, "startByte": 13, "endByte": len(source) - 1,
+	})
+	require.NoError(t, err)
+	handlerID, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+		"name": "CreateUser", "signature": "CreateUser()", "filePath": sourcePath,
+	})
+	require.NoError(t, err)
+	_, err = client.CreateRelationship(ctx, handlerID, saveID, "CALLS", nil)
+	require.NoError(t, err)
+
+	symbol := "scip-go gomod app v1 `app`/SaveUser()."
+	_, err = client.ExecuteQuery(ctx, `
+		MATCH (handler) WHERE elementId(handler) = $handlerId
+		CREATE (s:Symbol {symbol: $symbol})
+		CREATE (:File {path: $path})-[:CONTAINS]->(handler)-[:REFERENCES]->(s)
+	`, map[string]any{"symbol": symbol, "path": sourcePath, "handlerId": handlerID})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(api.NewServer(neo4j.NewQueryBuilder(client)).Handler())
+	defer server.Close()
+
+	var search struct {
+		Results []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"results"`
+	}
+	require.Equal(t, http.StatusOK, getJSON(t, server, "/search?q=SaveUser&types=Function", &search))
+	require.NotEmpty(t, search.Results)
+	assert.Equal(t, "SaveUser", search.Results[0].Name)
+	assert.Equal(t, "Function", search.Results[0].Type)
+
+	var sourceResp api.SourceResponse
+	require.Equal(t, http.StatusOK, getJSON(t, server, "/source/SaveUser", &sourceResp))
+	assert.Equal(t, "func SaveUser() {}", sourceResp.Source)
+
+	var apiErr api.ErrorResponse
+	assert.Equal(t, http.StatusNotFound, getJSON(t, server, "/source/Missing", &apiErr))
+
+	var analyze api.AnalyzeResponse
+	require.Equal(t, http.StatusOK, getJSON(t, server, "/analyze/SaveUser", &analyze))
+	require.NotNil(t, analyze.Function)
+	assert.Equal(t, "SaveUser", analyze.Function.Name)
+	require.Len(t, analyze.Callers, 1)
+	assert.Equal(t, "CreateUser", analyze.Callers[0].Name)
+	assert.Empty(t, analyze.Callees)
+	assert.Equal(t, http.StatusNotFound, getJSON(t, server, "/analyze/Missing", &apiErr))
+
+	var refs api.ReferencesResponse
+	require.Equal(t, http.StatusOK, getJSON(t, server, "/references/"+url.PathEscape(symbol), &refs))
+	assert.Equal(t, symbol, refs.Symbol)
+	assert.Equal(t, 1, refs.Total)
+}
+
+func TestAPIServerRedaction(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+		"name": "SaveUser", "signature": "SaveUser()", "filePath": "app/user.go",
+		"embedding": []float64{0.1, 0.2, 0.3},
+	})
+	require.NoError(t, err)
+
+	type searchBody struct {
+		Results []struct {
+			FilePath   string         `json:"filePath"`
+			Properties map[string]any `json:"properties"`
+		} `json:"results"`
+	}
+
+	// Embeddings are left out by default
+	server := httptest.NewServer(api.NewServer(neo4j.NewQueryBuilder(client)).Handler())
+	defer server.Close()
+
+	var search searchBody
+	require.Equal(t, http.StatusOK, getJSON(t, server, "/search?q=SaveUser&types=Function", &search))
+	require.Len(t, search.Results, 1)
+	assert.NotContains(t, search.Results[0].Properties, "embedding")
+	assert.Equal(t, "app/user.go", search.Results[0].FilePath)
+
+	config, err := models.ParseRedaction("Function.filePath")
+	require.NoError(t, err)
+	redacting := httptest.NewServer(api.NewServer(neo4j.NewQueryBuilder(client)).WithRedaction(config).Handler())
+	defer redacting.Close()
+
+	search = searchBody{}
+	require.Equal(t, http.StatusOK, getJSON(t, redacting, "/search?q=SaveUser&types=Function", &search))
+	require.Len(t, search.Results, 1)
+	assert.Contains(t, search.Results[0].Properties, "embedding")
+	assert.NotContains(t, search.Results[0].Properties, "filePath")
+	assert.Empty(t, search.Results[0].FilePath)
+}
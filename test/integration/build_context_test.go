@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePlatformFixture creates a module with platform-specific files
+func writePlatformFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":              "module example.com/platform\n\ngo 1.21\n",
+		"common.go":           "package platform\n\nfunc Common() string { return Name() }\n",
+		"name_linux.go":       "package platform\n\nfunc Name() string { return \"linux\" }\n",
+		"name_windows.go":     "package platform\n\nfunc Name() string { return \"windows\" }\n",
+		"simd_linux_arm64.go": "package platform\n\nfunc SIMD() bool { return true }\n",
+		"debug.go":            "//go:build debug && !windows\n\npackage platform\n\nfunc Debug() {}\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func collectBaseNames(t *testing.T, indexer *static.StaticIndexer, dir string) []string {
+	t.Helper()
+	files, err := indexer.CollectGoFiles(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCollectGoFilesBuildContext(t *testing.T) {
+	dir := writePlatformFixture(t)
+	indexer := static.NewStaticIndexer(nil, "platform", "v1.0.0", "")
+
+	// Without a build context every file is collected
+	assert.Len(t, collectBaseNames(t, indexer, dir), 5)
+
+	indexer.SetBuildContext("linux", "amd64", nil)
+	assert.Equal(t, []string{"common.go", "name_linux.go"}, collectBaseNames(t, indexer, dir))
+
+	indexer.SetBuildContext("linux", "arm64", []string{"debug"})
+	assert.Equal(t, []string{"common.go", "debug.go", "name_linux.go", "simd_linux_arm64.go"},
+		collectBaseNames(t, indexer, dir))
+
+	indexer.SetBuildContext("windows", "amd64", []string{"debug"})
+	assert.Equal(t, []string{"common.go", "name_windows.go"}, collectBaseNames(t, indexer, dir))
+}
+
+func TestIndexProjectBuildContext(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dir := writePlatformFixture(t)
+	indexer := static.NewStaticIndexer(client, "platform-service", "v1.0.0", "")
+	indexer.SetBuildContext("linux", "arm64", []string{"debug"})
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx,
+		"MATCH (f:Function) RETURN f.name AS name, f.filePath AS path, f.buildConstraint AS constraint", nil)
+	require.NoError(t, err)
+
+	constraints := make(map[string]any)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		path, _ := recordMap["path"].(string)
+		constraints[filepath.Base(path)] = recordMap["constraint"]
+	}
+
+	assert.NotContains(t, constraints, "name_windows.go", "Windows files must not be indexed")
+	assert.Nil(t, constraints["common.go"], "Unconstrained files carry no constraint")
+	assert.Equal(t, "linux", constraints["name_linux.go"])
+	assert.Equal(t, "linux && arm64", constraints["simd_linux_arm64.go"])
+	assert.Equal(t, "debug && !windows", constraints["debug.go"])
+}
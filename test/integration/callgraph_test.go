@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCallGraph(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// main calls isEven and isOdd call each other, isOdd also logs
+	ids := make(map[string]string)
+	for _, name := range []string{"main", "isEven", "isOdd", "logResult"} {
+		id, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+			"name":      name,
+			"signature": name + "(n int) bool",
+			"filePath":  "parity/parity.go",
+		})
+		require.NoError(t, err)
+		ids[name] = id
+	}
+	for _, call := range [][2]string{
+		{"main", "isEven"},
+		{"isEven", "isOdd"},
+		{"isOdd", "isEven"},
+		{"isOdd", "logResult"},
+	} {
+		_, err := client.CreateRelationship(ctx, ids[call[0]], ids[call[1]], "CALLS", nil)
+		require.NoError(t, err)
+	}
+
+	analysis := query.NewAdvancedQueryService(client)
+
+	graph, err := analysis.BuildCallGraph(ctx, query.CallGraphRequest{RootFunction: "main", Direction: "outgoing"})
+	require.NoError(t, err)
+
+	depths := make(map[string]int)
+	for _, node := range graph.Nodes {
+		depths[node.Name] = node.Depth
+	}
+	assert.Equal(t, map[string]int{"main": 0, "isEven": 1, "isOdd": 2, "logResult": 3}, depths)
+	assert.Equal(t, 3, graph.MaxDepth)
+	require.Len(t, graph.Edges, 4)
+
+	recursive := make(map[[2]string]bool)
+	for _, edge := range graph.Edges {
+		recursive[[2]string{graph.Nodes[edge.From].Name, graph.Nodes[edge.To].Name}] = edge.Recursive
+	}
+	assert.Equal(t, map[[2]string]bool{
+		{"main", "isEven"}:     false,
+		{"isEven", "isOdd"}:    false,
+		{"isOdd", "isEven"}:    true,
+		{"isOdd", "logResult"}: false,
+	}, recursive, "Only the call back to an ancestor should be recursive")
+
+	assert.Equal(t, 2, graph.Nodes[ids["isOdd"]].CallCount)
+	assert.ElementsMatch(t, []string{ids["isEven"], ids["logResult"]}, graph.Nodes[ids["isOdd"]].Children)
+
+	// The tree lists isEven once even though isOdd calls back to it
+	assert.Equal(t, "main (parity/parity.go)\n"+
+		"  -> isEven (parity/parity.go)\n"+
+		"    -> isOdd (parity/parity.go)\n"+
+		"      -> isEven (parity/parity.go) [shown above]\n"+
+		"      -> logResult (parity/parity.go)\n", graph.Tree())
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, "digraph callgraph {")
+	assert.Contains(t, dot, `[label="isOdd\nparity/parity.go"]`)
+	assert.Contains(t, dot, "[style=dashed]")
+
+	// Depth bounds the traversal
+	graph, err = analysis.BuildCallGraph(ctx, query.CallGraphRequest{RootFunction: "main", MaxDepth: 1, Direction: "outgoing"})
+	require.NoError(t, err)
+	assert.Len(t, graph.Nodes, 2)
+	assert.Len(t, graph.Edges, 1)
+
+	// Incoming graphs walk from callees to callers
+	graph, err = analysis.BuildCallGraph(ctx, query.CallGraphRequest{RootFunction: "logResult", MaxDepth: 2, Direction: "incoming"})
+	require.NoError(t, err)
+	depths = make(map[string]int)
+	for _, node := range graph.Nodes {
+		depths[node.Name] = node.Depth
+	}
+	assert.Equal(t, map[string]int{"logResult": 0, "isOdd": 1, "isEven": 2}, depths)
+
+	_, err = analysis.BuildCallGraph(ctx, query.CallGraphRequest{RootFunction: "missing"})
+	assert.Error(t, err)
+
+	_, err = analysis.BuildCallGraph(ctx, query.CallGraphRequest{RootFunction: "main", Direction: "sideways"})
+	assert.Error(t, err)
+}
+
+func TestCallGraphTreeDirections(t *testing.T) {
+	// a calls b, b calls c, c calls a
+	graph := &query.CallGraphResponse{
+		RootFunction: "b",
+		Nodes: map[string]*query.CallGraphNode{
+			"a": {Name: "a", Depth: 1},
+			"b": {Name: "b", Depth: 0},
+			"c": {Name: "c", Depth: 1},
+		},
+		Edges: []*query.CallGraphEdge{{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "c", To: "a"}},
+	}
+
+	graph.Direction = "incoming"
+	assert.Equal(t, "b\n  <- a\n    <- c\n      <- b [shown above]\n", graph.Tree())
+
+	graph.Direction = "both"
+	assert.Equal(t, "b\n  <- a\n    <- c\n      <- b [shown above]\n  -> c [shown above]\n", graph.Tree(),
+		"Cycles terminate when a function is reached again")
+}
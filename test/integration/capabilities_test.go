@@ -0,0 +1,40 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		version     string
+		fulltextDDL schema.FulltextDDL
+		textIndexes bool
+	}{
+		{"5.26.0", schema.FulltextCreateIndex, true},
+		{"2025.01.0", schema.FulltextCreateIndex, true},
+		{"5.0-aura", schema.FulltextCreateIndex, true},
+		{"4.4.12", schema.FulltextCreateIndex, true},
+		{"4.3.0", schema.FulltextCreateIndex, false},
+		{"4.2.19", schema.FulltextProcedure, false},
+		{"3.5.35", schema.FulltextProcedure, false},
+		{"3.4.18", schema.FulltextUnsupported, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			caps, err := schema.DetectCapabilities(tt.version, "community")
+			require.NoError(t, err)
+			assert.Equal(t, tt.fulltextDDL, caps.FulltextDDL)
+			assert.Equal(t, tt.textIndexes, caps.TextIndexes)
+			assert.Equal(t, "community", caps.Edition)
+		})
+	}
+
+	for _, version := range []string{"", "dev", "five.1", "5"} {
+		_, err := schema.DetectCapabilities(version, "enterprise")
+		assert.Error(t, err, "version %q", version)
+	}
+}
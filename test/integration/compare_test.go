@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const compareFixture = `package server
+
+type Server struct{ name string }
+
+func (s *Server) Handle(id string) error { return nil }
+
+func Greet(name string) string { return "hello " + name }
+`
+
+// scipDefinition builds the definition scip-go reports for name, locating
+// the identifier in the fixture source
+func scipDefinition(kind, name, ident, signature string) static.IndexedDefinition {
+	start := strings.Index(compareFixture, " "+ident) + 1
+	return static.IndexedDefinition{
+		Kind:      kind,
+		Name:      name,
+		FilePath:  "server.go",
+		Line:      strings.Count(compareFixture[:start], "\n") + 1,
+		StartByte: start,
+		EndByte:   start + len(ident),
+		Signature: signature,
+	}
+}
+
+func TestCompareDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/server\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.go"), []byte(compareFixture), 0644))
+
+	astDefs, err := static.NewStaticIndexer(nil, "server", "v1.0.0", "").CollectDefinitions(dir)
+	require.NoError(t, err)
+	require.Len(t, astDefs, 3)
+
+	// Signatures as scip-go documents them
+	scipDefs := []static.IndexedDefinition{
+		scipDefinition("Type", "Server", "Server", ""),
+		scipDefinition("Method", "Server.Handle", "Handle", "Handle(id string) error"),
+		scipDefinition("Function", "Greet", "Greet", "Greet(name string) string"),
+	}
+
+	comparison := static.CompareDefinitions(astDefs, scipDefs)
+	assert.Equal(t, 3, comparison.Matched)
+	assert.Empty(t, comparison.Discrepancies, "Equivalent definitions should match")
+
+	// Inject a shifted offset, a differing signature and a missing definition
+	scipDefs[1].StartByte++
+	scipDefs[1].EndByte++
+	scipDefs[2].Signature = "Greet(name string) (string, error)"
+	scipDefs = append(scipDefs[:0], scipDefs[1:]...)
+	scipDefs = append(scipDefs, scipDefinition("Function", "Extra", "Extra", ""))
+
+	comparison = static.CompareDefinitions(astDefs, scipDefs)
+	assert.Equal(t, 0, comparison.Matched)
+
+	found := make(map[string]static.IndexDiscrepancy)
+	for _, d := range comparison.Discrepancies {
+		found[d.Type+" "+d.Name] = d
+	}
+	require.Len(t, found, 4)
+	assert.Contains(t, found, "missing-in-scip Server")
+	assert.Contains(t, found, "missing-in-ast Extra")
+	assert.Contains(t, found, "offset Server.Handle")
+	assert.Equal(t, "Greet(name string) string", found["signature Greet"].AST)
+	assert.Equal(t, "Greet(name string) (string, error)", found["signature Greet"].SCIP)
+}
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const complexityFixture = `package sample
+
+func Simple() int {
+	return 1
+}
+
+func Classify(n int, strict bool) string {
+	if n < 0 && strict {
+		return "negative"
+	}
+	for i := 0; i < n; i++ {
+		switch {
+		case i%2 == 0:
+			continue
+		case i%3 == 0 || i%5 == 0:
+			return "divisible"
+		default:
+		}
+	}
+	return "other"
+}
+
+type Counter struct{ n int }
+
+func (c *Counter) Add(delta int) {
+	if delta > 0 {
+		c.n += delta
+	}
+}
+`
+
+func TestAnalyzeComplexity(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	projectDir := t.TempDir()
+	filePath := filepath.Join(projectDir, "sample.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(complexityFixture), 0644))
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	indexer := static.NewStaticIndexer(client, "complexity-service", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, projectDir))
+
+	analysis := query.NewAdvancedQueryService(client)
+	result, err := analysis.AnalyzeComplexity(ctx, query.ComplexityAnalysisRequest{
+		ServiceName:             "complexity-service",
+		HighComplexityThreshold: 3,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Functions, 3)
+
+	// Classify: if, &&, for, two non-default cases and || on top of the base path
+	top := result.Functions[0]
+	assert.Equal(t, "Classify", top.Name)
+	assert.Equal(t, "Function", top.Type)
+	assert.Equal(t, 7, top.CyclomaticComplexity)
+	assert.Equal(t, 2, top.ParameterCount)
+	assert.Equal(t, 15, top.LinesOfCode)
+
+	metrics := make(map[string]*query.ComplexityMetrics)
+	for _, fn := range result.Functions {
+		metrics[fn.Name] = fn
+	}
+	assert.Equal(t, 1, metrics["Simple"].CyclomaticComplexity)
+	assert.Equal(t, 2, metrics["Add"].CyclomaticComplexity)
+	assert.Equal(t, "Method", metrics["Add"].Type)
+	assert.Greater(t, metrics["Add"].ComplexityScore, metrics["Simple"].ComplexityScore)
+
+	assert.Equal(t, 3, result.Summary.TotalFunctions)
+	assert.Equal(t, 7, result.Summary.MaxComplexity)
+	assert.InDelta(t, 10.0/3.0, result.Summary.AverageComplexity, 0.001)
+	assert.Equal(t, 1, result.Summary.HighComplexityCount)
+
+	// Filtering by file or by an unknown service
+	result, err = analysis.AnalyzeComplexity(ctx, query.ComplexityAnalysisRequest{FilePath: filePath})
+	require.NoError(t, err)
+	assert.Len(t, result.Functions, 3)
+	assert.Equal(t, 0, result.Summary.HighComplexityCount, "Nothing exceeds the default threshold")
+
+	result, err = analysis.AnalyzeComplexity(ctx, query.ComplexityAnalysisRequest{ServiceName: "unknown-service"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Functions)
+	assert.Equal(t, 0.0, result.Summary.AverageComplexity)
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeMultiPackageFixture writes a module of three packages with four files each
+func writeMultiPackageFixture(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/shop\n\ngo 1.21\n"), 0644))
+	for _, pkg := range []string{"orders", "users", "billing"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, pkg), 0755))
+		for i := 0; i < 4; i++ {
+			source := fmt.Sprintf(`package %[1]s
+
+type Record%[2]d struct{ ID int }
+
+func (r *Record%[2]d) Save(name string) error {
+	id := r.ID
+	return check%[2]d(id, name)
+}
+
+func check%[2]d(id int, name string) error { return nil }
+`, pkg, i)
+			path := filepath.Join(dir, pkg, fmt.Sprintf("record%d.go", i))
+			require.NoError(t, os.WriteFile(path, []byte(source), 0644))
+		}
+	}
+	return dir
+}
+
+// graphCounts returns the number of nodes per label and relationships per type
+func graphCounts(t *testing.T, ctx context.Context, client *neo4j.Client) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, cypher := range []string{
+		"MATCH (n) UNWIND labels(n) AS key RETURN key, count(*) AS total",
+		"MATCH ()-[r]->() RETURN type(r) AS key, count(*) AS total",
+	} {
+		result, err := client.ExecuteQuery(ctx, cypher, nil)
+		require.NoError(t, err)
+		for _, record := range result {
+			recordMap := record.AsMap()
+			key, _ := recordMap["key"].(string)
+			counts[key], _ = recordMap["total"].(int64)
+		}
+	}
+	return counts
+}
+
+func TestStaticIndexerConcurrentMatchesSequential(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+	dir := writeMultiPackageFixture(t)
+
+	counts := make(map[int]map[string]int64)
+	for _, concurrency := range []int{1, 4} {
+		cleanupDatabase(t, client)
+
+		indexer := static.NewStaticIndexer(client, "shop", "v1.0.0", "")
+		indexer.SetConcurrency(concurrency)
+		require.NoError(t, indexer.IndexProject(ctx, dir))
+		assert.Equal(t, 3, indexer.ModuleMerges(), "Each package should be merged once with concurrency %d", concurrency)
+
+		counts[concurrency] = graphCounts(t, ctx, client)
+	}
+
+	assert.Equal(t, int64(12), counts[1]["File"])
+	assert.Equal(t, int64(3), counts[1]["Module"])
+	assert.Equal(t, counts[1], counts[4], "Concurrent indexing should produce the same graph as sequential indexing")
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dataFlowFixture = `package greet
+
+func Greet(name string) string {
+	msg := "hello " + name
+	return format(msg)
+}
+
+func format(text string) string {
+	return text
+}
+`
+
+func TestTraceDataFlow(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/greet\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greet.go"), []byte(dataFlowFixture), 0644))
+
+	indexer := static.NewStaticIndexer(client, "greet", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	paramSymbol := models.NewGoSCIPSymbol("greet", "v1.0.0", "Greet().(name)").String()
+	analysis := query.NewAdvancedQueryService(client)
+
+	flow, err := analysis.TraceDataFlow(ctx, query.DataFlowRequest{ParameterSymbol: paramSymbol})
+	require.NoError(t, err)
+	require.Len(t, flow.FlowPaths, 1)
+	assert.Equal(t, 1, flow.PathCount)
+
+	path := flow.FlowPaths[0]
+	assert.Equal(t, 2, path.Length)
+	require.Len(t, path.Steps, 3)
+
+	var names, types []string
+	for _, step := range path.Steps {
+		names = append(names, step.Name)
+		types = append(types, step.Type)
+	}
+	assert.Equal(t, []string{"name", "msg", "text"}, names)
+	assert.Equal(t, []string{"Parameter", "LocalVariable", "Parameter"}, types)
+	assert.Equal(t, "indirect", path.Steps[1].FlowType, "msg is computed from name")
+	assert.Equal(t, "direct", path.Steps[2].FlowType, "msg is passed unchanged")
+	assert.Equal(t, 5, path.Steps[2].Line, "Argument flows are located at the call")
+	assert.Equal(t, "format", path.Steps[2].Function)
+	assert.Same(t, path.Steps[2], path.Destination)
+
+	require.Len(t, flow.Destinations, 1)
+	assert.Equal(t, "format", flow.Destinations[0].Context)
+	require.NotNil(t, flow.Destinations[0].Symbol)
+	assert.Equal(t, "format().(text)", flow.Destinations[0].Symbol.Descriptor)
+
+	// The step limit truncates paths
+	flow, err = analysis.TraceDataFlow(ctx, query.DataFlowRequest{ParameterSymbol: paramSymbol, MaxSteps: 1})
+	require.NoError(t, err)
+	require.Len(t, flow.FlowPaths, 1)
+	assert.Equal(t, "msg", flow.FlowPaths[0].Destination.Name)
+	assert.Empty(t, flow.Destinations)
+}
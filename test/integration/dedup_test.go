@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/context-maximiser/code-graph/pkg/similarity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollapseNearDuplicates(t *testing.T) {
+	results := []*query.SearchResult{
+		{ID: "a", Name: "ProcessOrder", Type: "Function", Signature: "ProcessOrder(ctx context.Context, id string) error", Score: 0.9},
+		{ID: "b", Name: "ProcessOrder", Type: "Function", Signature: "ProcessOrder(ctx context.Context, id int) error", Score: 0.95},
+		{ID: "c", Name: "ProcessOrder", Type: "Method", Signature: "ProcessOrder(ctx context.Context, id string) error", Score: 0.5},
+		{ID: "d", Name: "CancelOrder", Type: "Function", Signature: "CancelOrder(reason string)", Score: 0.7},
+	}
+
+	collapsed := query.CollapseNearDuplicates(results, 0.9)
+	require.Len(t, collapsed, 3)
+
+	assert.Equal(t, "b", collapsed[0].ID, "The highest-scored duplicate should be kept")
+	assert.Equal(t, 1, collapsed[0].CollapsedCount)
+	assert.Equal(t, []string{"a"}, collapsed[0].CollapsedIDs)
+	assert.Equal(t, "d", collapsed[1].ID)
+	assert.Equal(t, "c", collapsed[2].ID, "Results of different types never collapse")
+
+	assert.Len(t, query.CollapseNearDuplicates(results, 0), 4, "A zero threshold disables collapsing")
+}
+
+func TestSearchCollapsesNearDuplicates(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// A function and its copy in a forked file
+	for _, filePath := range []string{"orders/process.go", "orders_fork/process.go"} {
+		_, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+			"name":      "ProcessOrder",
+			"signature": "ProcessOrder(ctx context.Context, id string) error",
+			"filePath":  filePath,
+		})
+		require.NoError(t, err)
+	}
+
+	lsp := query.NewLSPService(client)
+
+	response, err := lsp.Search(ctx, query.SearchRequest{Query: "ProcessOrder", NodeTypes: []string{"Function"}})
+	require.NoError(t, err)
+	assert.Len(t, response.Results, 2)
+
+	response, err = lsp.Search(ctx, query.SearchRequest{Query: "ProcessOrder", NodeTypes: []string{"Function"}, CollapseThreshold: 0.9})
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, 1, response.Results[0].CollapsedCount)
+	assert.Equal(t, 1, response.Count)
+}
+
+func TestCollapseNearDuplicatesWithMetric(t *testing.T) {
+	results := []*query.SearchResult{
+		{ID: "a", Name: "LoadUserProfile", Type: "Function", Score: 0.9},
+		{ID: "b", Name: "ProfileUserLoad", Type: "Function", Score: 0.8},
+	}
+
+	// Reordered words are far apart by edit distance but share every token
+	assert.Len(t, query.CollapseNearDuplicatesWith(results, similarity.Matcher{Metric: similarity.Levenshtein, Threshold: 0.9}), 2)
+
+	results = []*query.SearchResult{
+		{ID: "a", Name: "load user profile", Type: "Function", Score: 0.9},
+		{ID: "b", Name: "profile user load", Type: "Function", Score: 0.8},
+	}
+	collapsed := query.CollapseNearDuplicatesWith(results, similarity.Matcher{Metric: similarity.Jaccard, Threshold: 0.9})
+	require.Len(t, collapsed, 1)
+	assert.Equal(t, []string{"b"}, collapsed[0].CollapsedIDs)
+}
+
+func TestGroupDuplicateDefinitions(t *testing.T) {
+	definitions := []*query.Definition{
+		{Name: "ProcessOrder", Type: "Function", FilePath: "orders/process.go", Signature: "ProcessOrder(id string) error"},
+		{Name: "ProcessOrders", Type: "Function", FilePath: "orders/batch.go", Signature: "ProcessOrders(ids []string) error"},
+		{Name: "ProcessOrder", Type: "Class", FilePath: "orders/types.go"},
+		{Name: "processOrder", Type: "Function", FilePath: "orders_fork/process.go", Signature: "processOrder(id string) error"},
+	}
+
+	// Names are compared case-insensitively, and only with definitions of the same type
+	groups := query.GroupDuplicateDefinitions(definitions, similarity.Matcher{Metric: similarity.Exact, Threshold: 1})
+	require.Len(t, groups, 1)
+	assert.Equal(t, []*query.Definition{definitions[0], definitions[3]}, groups[0])
+
+	groups = query.GroupDuplicateDefinitions(definitions, similarity.Matcher{Metric: similarity.Levenshtein, Threshold: 0.85})
+	require.Len(t, groups, 1)
+	assert.Len(t, groups[0], 3)
+
+	assert.Empty(t, query.GroupDuplicateDefinitions(definitions[:1], similarity.Matcher{Metric: similarity.Exact, Threshold: 1}))
+}
+
+func TestFindDuplicateDefinitions(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, filePath := range []string{"orders/process.go", "orders_fork/process.go"} {
+		_, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+			"name":      "ProcessOrder",
+			"signature": "ProcessOrder(ctx context.Context, id string) error",
+			"filePath":  filePath,
+			"startLine": 10,
+		})
+		require.NoError(t, err)
+	}
+	_, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+		"name": "CancelOrder", "signature": "CancelOrder(id string) error", "filePath": "orders/cancel.go",
+	})
+	require.NoError(t, err)
+
+	analysis := query.NewAdvancedQueryService(client)
+
+	response, err := analysis.FindDuplicateDefinitions(ctx, query.DuplicateDefinitionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, string(similarity.DefaultMetric), response.Metric)
+	assert.Equal(t, query.DefaultDuplicateThreshold, response.Threshold)
+	require.Len(t, response.Groups, 1)
+	require.Len(t, response.Groups[0], 2)
+	assert.Equal(t, "orders/process.go", response.Groups[0][0].FilePath)
+	assert.Equal(t, "orders_fork/process.go", response.Groups[0][1].FilePath)
+	assert.Equal(t, 10, response.Groups[0][1].Line)
+
+	response, err = analysis.FindDuplicateDefinitions(ctx, query.DuplicateDefinitionRequest{FilePath: "orders/process.go"})
+	require.NoError(t, err)
+	assert.Empty(t, response.Groups)
+
+	_, err = analysis.FindDuplicateDefinitions(ctx, query.DuplicateDefinitionRequest{Metric: "cosine"})
+	assert.Error(t, err)
+}
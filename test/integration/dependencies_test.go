@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeDependencies(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dir := writeRouteFixture(t, "example.com/app", map[string]string{
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/app/store"
+)
+
+func main() { fmt.Println(store.Save()) }
+`,
+		"store/store.go": `package store
+
+import "github.com/google/uuid"
+
+func Save() string { return uuid.NewString() }
+`,
+	})
+	require.NoError(t, static.NewStaticIndexer(client, "app", "v1.0.0", "").IndexProject(ctx, dir))
+
+	// The AST indexer records no calls, so link them as the SCIP indexer would:
+	// main calls Save within the service, Save calls an external function and
+	// a local closure whose symbol names no package
+	_, err := client.ExecuteQuery(ctx, `
+		MATCH (main:Function {name: 'main'}), (save:Function {name: 'Save'})
+		CREATE (main)-[:CALLS]->(save)
+		CREATE (save)-[:CALLS]->(:Function {name: 'NewString'})-[:DEFINES]->(:Symbol {symbol: $symbol})
+		CREATE (save)-[:CALLS]->(:Function {name: 'closure'})-[:DEFINES]->(:Symbol {symbol: 'local 3'})
+	`, map[string]any{"symbol": "scip-go gomod github.com/google/uuid v1.6.0 `github.com/google/uuid`/NewString()."})
+	require.NoError(t, err)
+
+	analysis := query.NewAdvancedQueryService(client)
+	result, err := analysis.AnalyzeDependencies(ctx, query.DependencyAnalysisRequest{ServiceName: "app"})
+	require.NoError(t, err)
+
+	require.Equal(t, 1, result.DependencyCount)
+	uuid := result.Dependencies[0]
+	assert.Equal(t, "github.com/google/uuid", uuid.ServiceName)
+	assert.Equal(t, []string{"Save"}, uuid.CallingFunctions)
+	assert.Equal(t, 1, uuid.CallCount)
+	assert.Equal(t, []string{"example.com/app/store"}, uuid.ImportedBy)
+
+	result, err = analysis.AnalyzeDependencies(ctx, query.DependencyAnalysisRequest{
+		ServiceName:     "app",
+		IncludeInternal: true,
+		IncludeStdlib:   true,
+	})
+	require.NoError(t, err)
+
+	dependencies := make(map[string]*query.ServiceDependency)
+	for _, dep := range result.Dependencies {
+		dependencies[dep.ServiceName] = dep
+	}
+	require.Contains(t, dependencies, "fmt")
+	assert.True(t, dependencies["fmt"].IsStdlib)
+	assert.Equal(t, 0, dependencies["fmt"].CallCount)
+	require.Contains(t, dependencies, "example.com/app/store")
+	assert.True(t, dependencies["example.com/app/store"].IsInternal)
+	// The call to Save is attributed to the store package by its symbol
+	require.Contains(t, dependencies, "store")
+	assert.True(t, dependencies["store"].IsInternal)
+	assert.Equal(t, []string{"main"}, dependencies["store"].CallingFunctions)
+	assert.NotContains(t, dependencies, "local")
+}
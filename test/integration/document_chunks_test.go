@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/documents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkDocumentSize(t *testing.T) {
+	parser := documents.NewDocumentParser()
+	parser.SetChunkSize(5)
+
+	chunks := parser.ChunkDocument("one two three\n\nfour five\n\nsix seven eight nine ten eleven\n\n\n\ntwelve")
+	assert.Equal(t, []string{
+		"one two three\n\nfour five",
+		"six seven eight nine ten eleven", // Paragraphs are never split
+		"twelve",
+	}, chunks)
+
+	parser.SetChunkSize(0)
+	assert.Len(t, parser.ChunkDocument("one two three\n\nfour five\n\nsix"), 2, "Non-positive sizes are ignored")
+}
+
+func TestIndexDocumentChunks(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	paragraphs := []string{
+		"# Payments",
+		"The payment service charges customers when an order is confirmed.",
+		"Refunds are issued through the same provider within five days.",
+		"Invoices are generated monthly for business accounts.",
+	}
+	docPath := filepath.Join(t.TempDir(), "payments.md")
+	require.NoError(t, os.WriteFile(docPath, []byte(strings.Join(paragraphs, "\n\n")), 0644))
+
+	indexer := documents.NewDocumentIndexer(client)
+	indexer.SetChunkSize(12)
+	require.NoError(t, indexer.IndexDocument(ctx, docPath))
+
+	chunkContents := func() []string {
+		cypher := `
+			MATCH (c:DocumentChunk)-[:PART_OF]->(d:Document {sourceUrl: $sourceUrl})
+			RETURN c.content AS content
+			ORDER BY c.chunkIndex
+		`
+		result, err := client.ExecuteQuery(ctx, cypher, map[string]any{"sourceUrl": docPath})
+		require.NoError(t, err)
+
+		var contents []string
+		for _, record := range result {
+			content, _ := record.AsMap()["content"].(string)
+			contents = append(contents, content)
+		}
+		return contents
+	}
+
+	contents := chunkContents()
+	require.Len(t, contents, 3)
+	assert.Equal(t, "# Payments\n\n"+paragraphs[1], contents[0])
+	assert.Equal(t, paragraphs[3], contents[2])
+
+	stats, err := indexer.GetDocumentStats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, stats["chunkCount"])
+
+	// Re-indexing a shorter document replaces its chunks
+	require.NoError(t, os.WriteFile(docPath, []byte("# Payments\n\nPayments are handled elsewhere now."), 0644))
+	require.NoError(t, indexer.IndexDocument(ctx, docPath))
+
+	contents = chunkContents()
+	require.Len(t, contents, 1)
+	assert.Equal(t, "# Payments\n\nPayments are handled elsewhere now.", contents[0])
+
+	result, err := client.ExecuteQuery(ctx, "MATCH (c:DocumentChunk) RETURN count(c) AS count", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, result[0].AsMap()["count"], "Stale chunks are removed")
+}
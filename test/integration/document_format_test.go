@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/documents"
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func featureNames(features []*models.Feature) []string {
+	var names []string
+	for _, feature := range features {
+		names = append(names, feature.Name)
+	}
+	return names
+}
+
+func TestParseRestructuredText(t *testing.T) {
+	content := `==============
+Billing Design
+==============
+
+Billing charges customers every month.
+
+Invoice Export
+--------------
+
+Invoices are exported as PDF.
+
+Retry Policy
+~~~~~~~~~~~~
+
+Failed charges are retried.
+
+----
+
+Deep Detail
+^^^^^^^^^^^
+
+Fourth level sections are not features.
+`
+	docPath := filepath.Join(t.TempDir(), "billing.rst")
+	require.NoError(t, os.WriteFile(docPath, []byte(content), 0644))
+
+	doc, features, err := documents.NewDocumentParser().ParseDocument(docPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Billing Design", doc.Title)
+	assert.Equal(t, "reStructuredText", doc.Type)
+
+	names := featureNames(features)
+	assert.Contains(t, names, "Invoice Export")
+	assert.Contains(t, names, "Retry Policy")
+	assert.NotContains(t, names, "Deep Detail")
+}
+
+func TestParseAsciiDoc(t *testing.T) {
+	content := `= Search Service
+:toc:
+
+The search service answers queries.
+
+== Query Parsing
+
+Queries are tokenized.
+
+=== Synonym Expansion
+
+Synonyms are expanded before matching.
+
+==== Deep Detail
+
+Fourth level sections are not features.
+`
+	docPath := filepath.Join(t.TempDir(), "search.adoc")
+	require.NoError(t, os.WriteFile(docPath, []byte(content), 0644))
+
+	doc, features, err := documents.NewDocumentParser().ParseDocument(docPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Search Service", doc.Title)
+	assert.Equal(t, "AsciiDoc", doc.Type)
+
+	names := featureNames(features)
+	assert.Contains(t, names, "Query Parsing")
+	assert.Contains(t, names, "Synonym Expansion")
+	assert.NotContains(t, names, "Deep Detail")
+	assert.NotContains(t, names, "= Search Service", "AsciiDoc headings are not read as Markdown")
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/documents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentMentionsCodeNodes(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// IndexProject exists as a method of StaticIndexer and as a package function
+	classID, err := client.CreateNode(ctx, []string{"Class"}, map[string]any{"name": "StaticIndexer"})
+	require.NoError(t, err)
+	methodID, err := client.CreateNode(ctx, []string{"Method"}, map[string]any{"name": "IndexProject"})
+	require.NoError(t, err)
+	_, err = client.CreateRelationship(ctx, classID, methodID, "CONTAINS", nil)
+	require.NoError(t, err)
+
+	moduleID, err := client.CreateNode(ctx, []string{"Module"}, map[string]any{"name": "cli"})
+	require.NoError(t, err)
+	functionID, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{"name": "IndexProject"})
+	require.NoError(t, err)
+	_, err = client.CreateRelationship(ctx, moduleID, functionID, "CONTAINS", nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	ambiguous := filepath.Join(dir, "overview.md")
+	require.NoError(t, os.WriteFile(ambiguous, []byte("# Overview\n\nProjects are indexed by `IndexProject`.\n"), 0644))
+	qualified := filepath.Join(dir, "internals.md")
+	require.NoError(t, os.WriteFile(qualified, []byte("# Internals\n\nSee `StaticIndexer.IndexProject()` for details.\n"), 0644))
+
+	indexer := documents.NewDocumentIndexer(client)
+	require.NoError(t, indexer.IndexDocument(ctx, ambiguous))
+	require.NoError(t, indexer.IndexDocument(ctx, qualified))
+
+	mentions := func(sourceURL string) map[string]float64 {
+		cypher := `
+			MATCH (d:Document {sourceUrl: $sourceUrl})-[r:MENTIONS]->(n)
+			WHERE n:Function OR n:Method OR n:Class
+			RETURN elementId(n) AS id, r.confidence AS confidence
+		`
+		result, err := client.ExecuteQuery(ctx, cypher, map[string]any{"sourceUrl": sourceURL})
+		require.NoError(t, err)
+
+		confidences := make(map[string]float64)
+		for _, record := range result {
+			recordMap := record.AsMap()
+			id, _ := recordMap["id"].(string)
+			confidences[id], _ = recordMap["confidence"].(float64)
+		}
+		return confidences
+	}
+
+	// An unqualified name links every candidate with a shared confidence
+	assert.Equal(t, map[string]float64{methodID: 0.5, functionID: 0.5}, mentions(ambiguous))
+
+	// The qualifier selects the method of StaticIndexer
+	assert.Equal(t, map[string]float64{methodID: 1}, mentions(qualified))
+
+	// Re-indexing does not duplicate the edges
+	require.NoError(t, indexer.IndexDocument(ctx, qualified))
+	result, err := client.ExecuteQuery(ctx, "MATCH (:Document {sourceUrl: $sourceUrl})-[r:MENTIONS]->(:Method) RETURN count(r) AS count",
+		map[string]any{"sourceUrl": qualified})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, result[0].AsMap()["count"])
+}
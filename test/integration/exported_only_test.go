@@ -0,0 +1,100 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportedOnlyFixture = `package api
+
+const Version = "1.0"
+
+const defaultLimit = 10
+
+var Registry = map[string]int{}
+
+var cache = map[string]int{}
+
+type Client struct {
+	BaseURL string
+	token   string
+}
+
+type Doer interface {
+	Do() error
+}
+
+type transport struct {
+	Retries int
+}
+
+func NewClient(baseURL string) *Client {
+	type options struct{ debug bool }
+	return &Client{BaseURL: baseURL}
+}
+
+func (c *Client) Get(path string) error { return c.send(path) }
+
+func (c *Client) send(path string) error { return nil }
+
+func (t *transport) RoundTrip() error { return nil }
+
+func helper() {}
+`
+
+func TestIndexExportedOnly(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/api\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.go"), []byte(exportedOnlyFixture), 0644))
+
+	indexer := static.NewStaticIndexer(client, "api", "v1.0.0", "")
+	indexer.SetExportedOnly(true)
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	names := func(label string) []string {
+		t.Helper()
+		result, err := client.ExecuteQuery(ctx,
+			"MATCH (n:"+label+" {filePath: $path}) RETURN n.name AS name ORDER BY name",
+			map[string]any{"path": filepath.Join(dir, "api.go")})
+		require.NoError(t, err)
+		var found []string
+		for _, record := range result {
+			name, _ := record.Get("name")
+			found = append(found, name.(string))
+		}
+		return found
+	}
+
+	assert.Equal(t, []string{"NewClient"}, names("Function"))
+	assert.Equal(t, []string{"Get"}, names("Method"), "Unexported methods and methods of unexported types are skipped")
+	assert.Equal(t, []string{"Client"}, names("Class"), "Unexported and local types are skipped")
+	assert.Equal(t, []string{"Doer"}, names("Interface"))
+	assert.Equal(t, []string{"BaseURL", "Registry", "Version"}, names("Variable"))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (s:Symbol) WHERE s.displayName IN ['helper', 'send', 'transport', 'token', 'cache', 'defaultLimit', 'RoundTrip']
+		RETURN count(s) AS symbols
+	`, nil)
+	require.NoError(t, err)
+	symbols, _ := result[0].Get("symbols")
+	assert.Equal(t, int64(0), symbols, "Skipped declarations should not get symbols")
+}
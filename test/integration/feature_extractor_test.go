@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/documents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLMFeatureExtractor(t *testing.T) {
+	var gotRequest map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotRequest))
+
+		content := `{"features": [
+			{"name": "Order Checkout", "description": "Customers pay for their cart.", "status": "implemented", "priority": "high"},
+			{"name": "  ", "description": "Nameless features are dropped.", "status": "planned", "priority": "low"}
+		]}`
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"role": "assistant", "content": content}}},
+		})
+	}))
+	defer server.Close()
+
+	extractor := documents.NewLLMExtractor(server.URL, "test-key", "test-model")
+	features, err := extractor.ExtractFeatures(context.Background(), "Checkout lets customers pay.", "docs/spec.md")
+	require.NoError(t, err)
+	require.Len(t, features, 1)
+	assert.Equal(t, "Order Checkout", features[0].Name)
+	assert.Equal(t, "Customers pay for their cart.", features[0].Description)
+	assert.Equal(t, "implemented", features[0].Status)
+	assert.Equal(t, "high", features[0].Priority)
+	assert.Contains(t, features[0].Tags, "llm")
+
+	assert.Equal(t, "test-model", gotRequest["model"])
+	messages, _ := gotRequest["messages"].([]any)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Checkout lets customers pay.", messages[1].(map[string]any)["content"])
+	format, _ := gotRequest["response_format"].(map[string]any)
+	assert.Equal(t, "json_schema", format["type"])
+}
+
+func TestFeatureExtractorFallsBackToRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	docPath := filepath.Join(t.TempDir(), "spec.md")
+	require.NoError(t, os.WriteFile(docPath, []byte("# Billing\n\nFeature: Invoice Export\n"), 0644))
+
+	parser := documents.NewDocumentParser()
+	parser.SetFeatureExtractor(documents.NewLLMExtractor(server.URL, "test-key", ""))
+	_, withFallback, err := parser.ParseDocument(docPath)
+	require.NoError(t, err)
+
+	parser.SetFeatureExtractor(nil)
+	_, withRules, err := parser.ParseDocument(docPath)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, withRules)
+	assert.Equal(t, withRules, withFallback, "Failed chunks use the rule-based extractor")
+}
@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	driver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFulltextIndex = "test_search_fulltext_idx"
+
+// createSearchFixture creates count functions, one in ten of which has a name
+// containing "user", and a full-text index over function and method names
+func createSearchFixture(tb testing.TB, client *neo4j.Client, count int) {
+	tb.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	_, err := client.ExecuteQuery(ctx, `
+		UNWIND range(1, $count) AS i
+		CREATE (:Function {
+			name: CASE WHEN i % 10 = 0 THEN 'SaveUser' + i ELSE 'ProcessOrder' + i END,
+			signature: 'func' + i + '()'
+		})
+	`, map[string]any{"count": count})
+	require.NoError(tb, err)
+
+	_, err = client.ExecuteQuery(ctx, `
+		CREATE FULLTEXT INDEX `+testFulltextIndex+` IF NOT EXISTS
+		FOR (n:Function|Method) ON EACH [n.name, n.signature]
+	`, nil)
+	require.NoError(tb, err)
+	_, err = client.ExecuteQuery(ctx, "CALL db.awaitIndexes(120)", nil)
+	require.NoError(tb, err)
+}
+
+func dropSearchFixture(tb testing.TB, client *neo4j.Client) {
+	tb.Helper()
+	_, err := client.ExecuteQuery(context.Background(), "DROP INDEX "+testFulltextIndex+" IF EXISTS", nil)
+	if err != nil {
+		tb.Logf("Warning: failed to drop full-text index: %v", err)
+	}
+	cleanupDatabase(tb, client)
+}
+
+func searchedNames(records []*driver.Record) []string {
+	var names []string
+	for _, record := range records {
+		node, _ := record.Get("n")
+		names = append(names, node.(dbtype.Node).Props["name"].(string))
+	}
+	return names
+}
+
+func TestSearchNodesFulltext(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		dropSearchFixture(t, client)
+		client.Close(context.Background())
+	}()
+
+	createSearchFixture(t, client, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	queryBuilder := neo4j.NewQueryBuilder(client)
+
+	scanned, err := queryBuilder.SearchNodes(ctx, "User", []string{"Function"}, 0)
+	require.NoError(t, err)
+	indexed, err := queryBuilder.SearchNodes(ctx, "User", []string{"Function"}, 0, neo4j.UseFulltextIndex())
+	require.NoError(t, err)
+
+	assert.Len(t, scanned, 10)
+	assert.ElementsMatch(t, searchedNames(scanned), searchedNames(indexed), "Both paths should find the same nodes")
+
+	limited, err := queryBuilder.SearchNodes(ctx, "User", []string{"Function"}, 3, neo4j.UseFulltextIndex())
+	require.NoError(t, err)
+	assert.Len(t, limited, 3)
+
+	// No index covers Class, so the search falls back to the scan
+	_, err = client.CreateNode(ctx, []string{"Class"}, map[string]any{"name": "UserStore"})
+	require.NoError(t, err)
+	fallback, err := queryBuilder.SearchNodes(ctx, "User", []string{"Function", "Class"}, 0, neo4j.UseFulltextIndex())
+	require.NoError(t, err)
+	assert.Len(t, fallback, 11)
+	assert.Contains(t, searchedNames(fallback), "UserStore")
+}
+
+func TestSearchNodesFulltextFieldBoosts(t *testing.T) {
+	client := createTestClient(t)
+	const index = "test_boost_fulltext_idx"
+	defer func() {
+		if _, err := client.ExecuteQuery(context.Background(), "DROP INDEX "+index+" IF EXISTS", nil); err != nil {
+			t.Logf("Warning: failed to drop full-text index: %v", err)
+		}
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// The content-only match mentions the term more often, so it would
+	// score at least as high without boosts
+	_, err := client.ExecuteQuery(ctx, `
+		CREATE (:Document {name: 'Troubleshooting', content: 'retry retry retry: how to retry a request'})
+		CREATE (:Document {name: 'RetryPolicy', content: 'backoff settings'})
+	`, nil)
+	require.NoError(t, err)
+	_, err = client.ExecuteQuery(ctx, `
+		CREATE FULLTEXT INDEX `+index+` IF NOT EXISTS
+		FOR (n:Document) ON EACH [n.name, n.content]
+	`, nil)
+	require.NoError(t, err)
+	_, err = client.ExecuteQuery(ctx, "CALL db.awaitIndexes(120)", nil)
+	require.NoError(t, err)
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	results, err := queryBuilder.SearchNodes(ctx, "retry", []string{"Document"}, 10, neo4j.UseFulltextIndex())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RetryPolicy", "Troubleshooting"}, searchedNames(results), "A name match should outrank a content-only match")
+
+	// Boosts of the index override the defaults
+	results, err = queryBuilder.SearchNodes(ctx, "retry", []string{"Document"}, 10,
+		neo4j.UseFulltextIndex(), neo4j.BoostFields(index, map[string]float64{"name": 1, "content": 10}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Troubleshooting", "RetryPolicy"}, searchedNames(results))
+}
+
+func TestSearchNodesFuzzyFallback(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		dropSearchFixture(t, client)
+		client.Close(context.Background())
+	}()
+
+	createSearchFixture(t, client, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{"name": "parseConfig", "signature": "func parseConfig(path string) (*Config, error)"})
+	require.NoError(t, err)
+	_, err = client.ExecuteQuery(ctx, "CALL db.awaitIndexes(120)", nil)
+	require.NoError(t, err)
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	exact, err := queryBuilder.SearchNodes(ctx, "parseConfg", []string{"Function"}, 10, neo4j.UseFulltextIndex())
+	require.NoError(t, err)
+	assert.Empty(t, exact, "A typo finds nothing without the fallback")
+
+	fuzzy, err := queryBuilder.SearchNodes(ctx, "parseConfg", []string{"Function"}, 10, neo4j.UseFulltextIndex(), neo4j.FuzzyFallback())
+	require.NoError(t, err)
+	require.NotEmpty(t, fuzzy)
+	assert.Equal(t, "parseConfig", searchedNames(fuzzy)[0])
+	results := query.SearchResultsFromRecords(fuzzy)
+	assert.True(t, results[0].Fuzzy, "Fuzzy matches should be flagged")
+
+	// Searches with exact matches are not retried
+	matched, err := queryBuilder.SearchNodes(ctx, "parseConfig", []string{"Function"}, 10, neo4j.UseFulltextIndex(), neo4j.FuzzyFallback())
+	require.NoError(t, err)
+	require.Len(t, matched, 1)
+	assert.False(t, query.SearchResultsFromRecords(matched)[0].Fuzzy)
+}
+
+func BenchmarkSearchNodes(b *testing.B) {
+	client := createTestClient(b)
+	defer func() {
+		dropSearchFixture(b, client)
+		client.Close(context.Background())
+	}()
+
+	createSearchFixture(b, client, 20000)
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	ctx := context.Background()
+
+	for _, bench := range []struct {
+		name string
+		opts []neo4j.SearchOption
+	}{
+		{"contains", nil},
+		{"fulltext", []neo4j.SearchOption{neo4j.UseFulltextIndex()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := queryBuilder.SearchNodes(ctx, "SaveUser1", []string{"Function", "Method"}, 20, bench.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
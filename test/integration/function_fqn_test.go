@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sameSignatureFixture = `package shapes
+
+type Circle struct{ Radius float64 }
+
+type Square struct{ Side float64 }
+
+func (c Circle) String() string { return "circle" }
+
+func (s *Square) String() string { return "square" }
+
+func (c Circle) Scale(factor float64) Circle { return Circle{c.Radius * factor} }
+
+func (s *Square) Scale(factor float64) Circle { return Circle{s.Side * factor} }
+
+func String() string { return "shapes" }
+`
+
+func TestMethodsWithSameSignature(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/shapes\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shapes.go"), []byte(sameSignatureFixture), 0644))
+
+	indexer := static.NewStaticIndexer(client, "shapes", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx,
+		"MATCH (m:Method {name: 'String'}) RETURN m.fqn AS fqn ORDER BY fqn", nil)
+	require.NoError(t, err)
+	var fqns []any
+	for _, record := range result {
+		fqn, _ := record.Get("fqn")
+		fqns = append(fqns, fqn)
+	}
+	assert.Equal(t, []any{"example.com/shapes.Circle.String", "example.com/shapes.Square.String"}, fqns)
+
+	result, err = client.ExecuteQuery(ctx,
+		"MATCH (f:Function {name: 'String'}) RETURN f.fqn AS fqn", nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	fqn, _ := result[0].Get("fqn")
+	assert.Equal(t, "example.com/shapes.String", fqn)
+
+	// Each Scale method owns its own factor parameter
+	result, err = client.ExecuteQuery(ctx, `
+		MATCH (m:Method {name: 'Scale'})-[:CONTAINS]->(p:Parameter {name: 'factor'})
+		RETURN count(DISTINCT m) AS methods, count(DISTINCT p) AS params
+	`, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	methods, _ := result[0].Get("methods")
+	params, _ := result[0].Get("params")
+	assert.Equal(t, int64(2), methods)
+	assert.Equal(t, int64(2), params)
+}
+
+func TestFunctionsInSameNamedPackages(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.21\n"), 0644))
+	for _, pkg := range []string{"orders", "users"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, pkg, "util"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, pkg, "util", "util.go"), []byte("package util\n\nfunc Helper() {}\n"), 0644))
+	}
+
+	indexer := static.NewStaticIndexer(client, "app", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx,
+		"MATCH (f:Function {name: 'Helper'}) RETURN f.fqn AS fqn ORDER BY fqn", nil)
+	require.NoError(t, err)
+	var fqns []any
+	for _, record := range result {
+		fqn, _ := record.Get("fqn")
+		fqns = append(fqns, fqn)
+	}
+	assert.Equal(t, []any{"example.com/app/orders/util.Helper", "example.com/app/users/util.Helper"}, fqns)
+}
@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const genericsFixture = `package generics
+
+func Map[T, U any](xs []T, f func(T) U) []U {
+	out := make([]U, 0, len(xs))
+	for _, x := range xs {
+		out = append(out, f(x))
+	}
+	return out
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func (p *Pair[K, V]) Swap(key K) K {
+	old := p.Key
+	p.Key = key
+	return old
+}
+`
+
+func writeGenericsFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/generics\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "generics.go"), []byte(genericsFixture), 0644))
+	return dir
+}
+
+func TestGenericSignatures(t *testing.T) {
+	defs, err := static.NewStaticIndexer(nil, "generics", "v1.0.0", "").CollectDefinitions(writeGenericsFixture(t))
+	require.NoError(t, err)
+
+	signatures := make(map[string]string)
+	for _, def := range defs {
+		signatures[def.Name] = def.Signature
+	}
+	assert.Contains(t, signatures["Map"], "Map[T, U any](xs []T, f func(T) U)")
+	assert.Contains(t, signatures, "Pair.Swap", "Methods on generic types should keep their receiver")
+	assert.Contains(t, signatures["Pair.Swap"], "Swap(key K)")
+}
+
+func TestIndexGenerics(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	indexer := static.NewStaticIndexer(client, "generics", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, writeGenericsFixture(t)))
+
+	result, err := client.ExecuteQuery(ctx,
+		"MATCH (f:Function {name: 'Map'}) RETURN f.signature AS signature, f.typeParams AS typeParams", nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	signature, _ := result[0].Get("signature")
+	typeParams, _ := result[0].Get("typeParams")
+	assert.Contains(t, signature, "Map[T, U any](")
+	assert.Equal(t, "[T, U any]", typeParams)
+
+	result, err = client.ExecuteQuery(ctx, "MATCH (c:Class {name: 'Pair'}) RETURN c.typeParams AS typeParams", nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	typeParams, _ = result[0].Get("typeParams")
+	assert.Equal(t, "[K comparable, V any]", typeParams)
+
+	// Non-generic declarations carry no typeParams
+	result, err = client.ExecuteQuery(ctx,
+		"MATCH (m:Method {name: 'Swap'}) RETURN m.typeParams AS typeParams", nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	typeParams, _ = result[0].Get("typeParams")
+	assert.Nil(t, typeParams)
+}
@@ -0,0 +1,283 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initGitRepo creates a git repository in dir and commits the files
+func initGitRepo(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestGitChanges(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{
+		"main.go":           "package main\n\nfunc main() {}\n",
+		"util/util.go":      "package util\n\nfunc Helper() {}\n",
+		"util/old.go":       "package util\n\nfunc Old() {}\n",
+		"vendor/dep/dep.go": "package dep\n",
+	})
+
+	indexer := static.NewStaticIndexer(nil, "git-service", "v1.0.0", "")
+	changes, err := indexer.GitChanges(dir, "HEAD")
+	require.NoError(t, err)
+	assert.Empty(t, changes.Changed)
+	assert.Empty(t, changes.Deleted)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "util/util.go"), []byte("package util\n\nfunc Helper() int { return 1 }\n"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "util/old.go")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "util/new.go"), []byte("package util\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "util/new_test.go"), []byte("package util\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# readme\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor/dep/dep.go"), []byte("package dep\n\nvar X int\n"), 0644))
+
+	changes, err = indexer.GitChanges(dir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "git", changes.Method)
+	assert.Equal(t, []string{filepath.Join(dir, "util/new.go"), filepath.Join(dir, "util/util.go")}, changes.Changed,
+		"Untracked files count as changed; tests, skipped directories and other extensions are ignored")
+	assert.Equal(t, []string{filepath.Join(dir, "util/old.go")}, changes.Deleted)
+
+	// Paths are relative to the indexed root, not the repository root
+	changes, err = indexer.GitChanges(filepath.Join(dir, "util"), "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "util/new.go"), filepath.Join(dir, "util/util.go")}, changes.Changed)
+
+	_, err = indexer.GitChanges(t.TempDir(), "HEAD")
+	assert.True(t, errors.Is(err, static.ErrNotGitRepository), "got %v", err)
+}
+
+func TestIndexChangesSinceRef(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{
+		"go.mod":   "module example.com/app\n\ngo 1.21\n",
+		"main.go":  "package main\n\nfunc main() { Helper() }\n",
+		"util.go":  "package main\n\nfunc Helper() {}\n",
+		"other.go": "package main\n\nfunc Other() {}\n",
+	})
+
+	indexer := static.NewStaticIndexer(client, "git-service", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "util.go"), []byte("package main\n\nfunc Helper() {}\n\nfunc Added() {}\n"), 0644))
+
+	changes, err := indexer.IndexChanges(ctx, dir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "util.go")}, changes.Changed)
+
+	functions := func() []string {
+		t.Helper()
+		result, err := client.ExecuteQuery(ctx,
+			"MATCH (f:Function) WHERE f.filePath STARTS WITH $dir RETURN f.name AS name ORDER BY name",
+			map[string]any{"dir": dir})
+		require.NoError(t, err)
+		var names []string
+		for _, record := range result {
+			name, _ := record.Get("name")
+			names = append(names, name.(string))
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Added", "Helper", "Other", "main"}, functions())
+
+	// Without git, the content hashes stored on File nodes find the edit,
+	// while the untouched other.go is left alone
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, ".git")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "util.go")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+
+	changes, err = indexer.IndexChanges(ctx, dir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", changes.Method)
+	assert.Equal(t, []string{filepath.Join(dir, "main.go")}, changes.Changed)
+	assert.Equal(t, []string{filepath.Join(dir, "util.go")}, changes.Deleted)
+	assert.Equal(t, []string{"Other", "main"}, functions())
+}
+
+func TestIndexChangesKeepsRenamedFiles(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{
+		"go.mod":       "module example.com/app\n\ngo 1.21\n",
+		"util/util.go": "package util\n\n// Helper helps\nfunc Helper() int { return 1 }\n",
+		"main.go":      "package main\n\nfunc main() {}\n",
+	})
+
+	indexer := static.NewStaticIndexer(client, "rename-service", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	oldPath := filepath.Join(dir, "util/util.go")
+	newPath := filepath.Join(dir, "helpers/util.go")
+	nodeIDs := func(path string) (string, string) {
+		t.Helper()
+		result, err := client.ExecuteQuery(ctx, `
+			MATCH (f:File {path: $path}), (fn:Function {name: 'Helper', filePath: $path})
+			RETURN elementId(f) AS file, elementId(fn) AS function
+		`, map[string]any{"path": path})
+		require.NoError(t, err)
+		require.Len(t, result, 1, "Expected the nodes of %s", path)
+		file, _ := result[0].Get("file")
+		function, _ := result[0].Get("function")
+		return file.(string), function.(string)
+	}
+	fileID, functionID := nodeIDs(oldPath)
+
+	// An edge added outside the indexer survives the rename
+	_, err := client.ExecuteQuery(ctx, `
+		MATCH (fn:Function) WHERE elementId(fn) = $id
+		CREATE (:Note {text: 'reviewed'})-[:ANNOTATES]->(fn)
+	`, map[string]any{"id": functionID})
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(newPath), 0755))
+	require.NoError(t, os.Rename(oldPath, newPath))
+
+	// A file moved and edited is a deletion and an addition
+	require.NoError(t, os.Remove(filepath.Join(dir, "main.go")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.go"), []byte("package main\n\nfunc main() { println() }\n"), 0644))
+
+	changes, err := indexer.IndexChanges(ctx, dir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []static.FileRename{{From: oldPath, To: newPath}}, changes.Renamed)
+	assert.Equal(t, []string{filepath.Join(dir, "app.go")}, changes.Changed)
+	assert.Equal(t, []string{filepath.Join(dir, "main.go")}, changes.Deleted)
+
+	newFileID, newFunctionID := nodeIDs(newPath)
+	assert.Equal(t, fileID, newFileID, "The File node should be updated in place")
+	assert.Equal(t, functionID, newFunctionID, "Declarations should keep their identity")
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (:Note)-[:ANNOTATES]->(fn:Function {filePath: $path})
+		MATCH (m:Module)-[:CONTAINS]->(:File {path: $path})
+		RETURN fn.name AS name, m.fqn AS module
+	`, map[string]any{"path": newPath})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	module, _ := result[0].Get("module")
+	assert.Equal(t, "example.com/app/helpers", module, "Moved Go files belong to the module of their new directory")
+
+	result, err = client.ExecuteQuery(ctx,
+		"MATCH (n) WHERE n.filePath = $path OR n.path = $path RETURN count(n) AS count",
+		map[string]any{"path": oldPath})
+	require.NoError(t, err)
+	count, _ := result[0].Get("count")
+	assert.Equal(t, int64(0), count, "No nodes should remain at the old path")
+}
+
+func TestIndexChangesMovesTypeAcrossPackages(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{
+		"go.mod":         "module example.com/app\n\ngo 1.21\n",
+		"util/widget.go": "package util\n\ntype Widget struct{}\n\nfunc (w Widget) Name() string { return \"widget\" }\n",
+	})
+
+	indexer := static.NewStaticIndexer(client, "move-service", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	classIDs := func() map[string]string {
+		t.Helper()
+		result, err := client.ExecuteQuery(ctx,
+			"MATCH (c:Class {name: 'Widget'}) RETURN c.fqn AS fqn, elementId(c) AS id", nil)
+		require.NoError(t, err)
+		ids := make(map[string]string)
+		for _, record := range result {
+			fqn, _ := record.Get("fqn")
+			id, _ := record.Get("id")
+			ids[fqn.(string)] = id.(string)
+		}
+		return ids
+	}
+	before := classIDs()
+	require.Contains(t, before, "example.com/app/util.Widget")
+
+	// The moved type is requalified, so a new Widget in the old package is a
+	// separate node
+	newPath := filepath.Join(dir, "shared/util/widget.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(newPath), 0755))
+	require.NoError(t, os.Rename(filepath.Join(dir, "util/widget.go"), newPath))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "util/other.go"), []byte("package util\n\ntype Widget struct{ ID int }\n"), 0644))
+
+	changes, err := indexer.IndexChanges(ctx, dir, "HEAD")
+	require.NoError(t, err)
+	require.Len(t, changes.Renamed, 1)
+
+	after := classIDs()
+	assert.Len(t, after, 2)
+	assert.Equal(t, before["example.com/app/util.Widget"], after["example.com/app/shared/util.Widget"], "The moved type keeps its identity")
+	assert.NotEqual(t, before["example.com/app/util.Widget"], after["example.com/app/util.Widget"])
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (c:Class {fqn: 'example.com/app/util.Widget'})
+		RETURN c.filePath AS filePath
+	`, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	filePath, _ := result[0].Get("filePath")
+	assert.Equal(t, filepath.Join(dir, "util/other.go"), filePath)
+
+	result, err = client.ExecuteQuery(ctx, "MATCH (m:Method {name: 'Name'}) RETURN m.fqn AS fqn", nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	fqn, _ := result[0].Get("fqn")
+	assert.Equal(t, "example.com/app/shared/util.Widget.Name", fqn)
+}
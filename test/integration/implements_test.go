@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const implementsFixture = `package shapes
+
+import "fmt"
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct{ Side float64 }
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+type Circle struct{ Radius float64 }
+
+func (c *Circle) Area() float64 { return 3.14 * c.Radius * c.Radius }
+
+func (c *Circle) String() string { return fmt.Sprintf("circle(%v)", c.Radius) }
+
+type Point struct{ X, Y int }
+`
+
+// writeImplementsFixture writes a small module with one interface and its implementations
+func writeImplementsFixture(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/shapes\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shapes.go"), []byte(implementsFixture), 0644))
+	return dir
+}
+
+func TestResolveImplementations(t *testing.T) {
+	dir := writeImplementsFixture(t)
+	indexer := static.NewStaticIndexer(nil, "shapes", "v1.0.0", "")
+
+	implementations, err := indexer.ResolveImplementations(dir)
+	require.NoError(t, err)
+
+	found := make(map[string]static.Implementation)
+	for _, impl := range implementations {
+		found[impl.ClassFQN+" -> "+impl.InterfaceFQN] = impl
+	}
+
+	require.Contains(t, found, "example.com/shapes.Square -> example.com/shapes.Shape")
+	require.Contains(t, found, "example.com/shapes.Circle -> example.com/shapes.Shape")
+	assert.False(t, found["example.com/shapes.Square -> example.com/shapes.Shape"].PointerOnly)
+	assert.True(t, found["example.com/shapes.Circle -> example.com/shapes.Shape"].PointerOnly)
+	assert.NotContains(t, found, "example.com/shapes.Point -> example.com/shapes.Shape")
+	assert.NotContains(t, found, "example.com/shapes.Circle -> fmt.Stringer", "Stdlib interfaces are skipped by default")
+
+	indexer.SetIncludeStdlibInterfaces(true)
+	implementations, err = indexer.ResolveImplementations(dir)
+	require.NoError(t, err)
+
+	var stringers []string
+	for _, impl := range implementations {
+		if impl.InterfaceFQN == "fmt.Stringer" {
+			assert.True(t, impl.Stdlib)
+			stringers = append(stringers, impl.ClassFQN)
+		}
+	}
+	assert.Equal(t, []string{"example.com/shapes.Circle"}, stringers)
+}
+
+func TestStaticIndexerImplements(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	indexer := static.NewStaticIndexer(client, "shapes", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, writeImplementsFixture(t)))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (c:Class)-[:IMPLEMENTS]->(i:Interface {name: 'Shape'})
+		RETURN c.name AS name ORDER BY name
+	`, nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, record := range result {
+		name, _ := record.AsMap()["name"].(string)
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"Circle", "Square"}, names)
+}
+
+// writeSameNameFixture writes a module with two packages named model that
+// each declare a Store interface and one implementation
+func writeSameNameFixture(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.21\n"), 0644))
+	for pkg, method := range map[string]string{"users": "User", "orders": "Order"} {
+		source := "package model\n\ntype Store interface {\n\tLoad" + method + "() error\n}\n\n" +
+			"type DB struct{}\n\nfunc (DB) Load" + method + "() error { return nil }\n"
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, pkg, "model"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, pkg, "model", "model.go"), []byte(source), 0644))
+	}
+	return dir
+}
+
+func TestResolveImplementationsSameNameInTwoPackages(t *testing.T) {
+	indexer := static.NewStaticIndexer(nil, "app", "v1.0.0", "")
+
+	implementations, err := indexer.ResolveImplementations(writeSameNameFixture(t))
+	require.NoError(t, err)
+
+	var found []string
+	for _, impl := range implementations {
+		found = append(found, impl.ClassFQN+" -> "+impl.InterfaceFQN)
+	}
+	assert.ElementsMatch(t, []string{
+		"example.com/app/orders/model.DB -> example.com/app/orders/model.Store",
+		"example.com/app/users/model.DB -> example.com/app/users/model.Store",
+	}, found)
+}
+
+func TestStaticIndexerImplementsScopedToService(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := writeSameNameFixture(t)
+	require.NoError(t, static.NewStaticIndexer(client, "app", "v1.0.0", "").IndexProject(ctx, dir))
+	require.NoError(t, static.NewStaticIndexer(client, "shapes", "v1.0.0", "").IndexProject(ctx, writeImplementsFixture(t)))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (c:Class)-[:IMPLEMENTS]->(i:Interface)
+		RETURN c.fqn + ' -> ' + i.fqn AS edge ORDER BY edge
+	`, nil)
+	require.NoError(t, err)
+
+	var edges []string
+	for _, record := range result {
+		edge, _ := record.AsMap()["edge"].(string)
+		edges = append(edges, edge)
+	}
+	assert.Equal(t, []string{
+		"example.com/app/orders/model.DB -> example.com/app/orders/model.Store",
+		"example.com/app/users/model.DB -> example.com/app/users/model.Store",
+		"example.com/shapes.Circle -> example.com/shapes.Shape",
+		"example.com/shapes.Square -> example.com/shapes.Shape",
+	}, edges)
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexImports(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dir := writeRouteFixture(t, "example.com/app", map[string]string{
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/app/store"
+	"github.com/google/uuid"
+)
+
+func main() { fmt.Println(store.Name, uuid.New()) }
+`,
+		"store/store.go": "package store\n\nconst Name = \"store\"\n",
+	})
+	require.NoError(t, static.NewStaticIndexer(client, "app", "v1.0.0", "").IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (:Module {fqn: 'example.com/app'})-[:IMPORTS]->(m:Module)
+		RETURN m.fqn AS fqn, m.name AS name, m.isExternal AS isExternal, m.isStdlib AS isStdlib
+	`, nil)
+	require.NoError(t, err)
+
+	imported := make(map[string]map[string]any)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		imported[recordMap["fqn"].(string)] = recordMap
+	}
+	require.Len(t, imported, 3)
+
+	require.Contains(t, imported, "fmt")
+	assert.Equal(t, "fmt", imported["fmt"]["name"])
+	assert.Equal(t, true, imported["fmt"]["isExternal"])
+	assert.Equal(t, true, imported["fmt"]["isStdlib"])
+
+	require.Contains(t, imported, "github.com/google/uuid")
+	assert.Equal(t, true, imported["github.com/google/uuid"]["isExternal"])
+	assert.Equal(t, false, imported["github.com/google/uuid"]["isStdlib"])
+
+	// The project's own package is the Module its files created
+	require.Contains(t, imported, "example.com/app/store")
+	assert.Equal(t, false, imported["example.com/app/store"]["isExternal"])
+	result, err = client.ExecuteQuery(ctx, `
+		MATCH (m:Module {fqn: 'example.com/app/store'})-[:CONTAINS]->(:File)
+		RETURN count(DISTINCT m) AS modules
+	`, nil)
+	require.NoError(t, err)
+	modules, _ := result[0].AsMap()["modules"].(int64)
+	assert.Equal(t, int64(1), modules)
+}
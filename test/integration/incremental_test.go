@@ -0,0 +1,249 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveFileSweepsOrphanSymbols(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Both files declare shared.Helper, so they define the same symbol
+	projectDir := t.TempDir()
+	fileA := filepath.Join(projectDir, "a", "helper.go")
+	fileB := filepath.Join(projectDir, "b", "helper.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(fileA), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(fileB), 0755))
+	require.NoError(t, os.WriteFile(fileA, []byte("package shared\n\nfunc Helper() int { return 1 }\n\nfunc OnlyInA() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(fileB, []byte("package shared\n\nfunc Helper() int { return 2 }\n"), 0644))
+
+	indexer := static.NewStaticIndexer(client, "incremental-service", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, projectDir))
+
+	countSymbols := func(displayName string) int {
+		result, err := client.ExecuteQuery(ctx,
+			"MATCH (s:Symbol {displayName: $name}) RETURN count(s) AS count",
+			map[string]any{"name": displayName})
+		require.NoError(t, err)
+		count, _ := result[0].AsMap()["count"].(int64)
+		return int(count)
+	}
+	require.Equal(t, 1, countSymbols("Helper"))
+	require.Equal(t, 1, countSymbols("OnlyInA"))
+
+	// Delete file A from disk and reindex it
+	require.NoError(t, os.Remove(fileA))
+	require.NoError(t, indexer.ReindexFile(ctx, fileA))
+
+	assert.Equal(t, 1, countSymbols("Helper"), "Symbol still defined by file B should survive")
+	assert.Equal(t, 0, countSymbols("OnlyInA"), "Symbol only defined by the deleted file should be swept")
+
+	result, err := client.ExecuteQuery(ctx,
+		"MATCH (n) WHERE n.filePath = $path OR n.path = $path RETURN count(n) AS count",
+		map[string]any{"path": fileA})
+	require.NoError(t, err)
+	count, _ := result[0].AsMap()["count"].(int64)
+	assert.Zero(t, count, "No nodes of the deleted file should remain")
+
+	// File B is untouched
+	_, err = neo4j.NewQueryBuilder(client).GetFileContents(ctx, "", fileB)
+	assert.NoError(t, err)
+}
+
+func TestRemoveFileRemovesSCIPReferences(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	scipIndexer := static.NewSCIPIndexer(client, "app", "v1.0.0", "")
+	scipIndexer.SetSCIPBinary(fakeSCIPBinary(t))
+	require.NoError(t, scipIndexer.IndexProject(ctx, t.TempDir()))
+
+	result, err := client.ExecuteQuery(ctx, "MATCH (r:Reference) RETURN DISTINCT r.filePath AS path", nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	path, _ := result[0].AsMap()["path"].(string)
+
+	indexer := static.NewStaticIndexer(client, "app", "v1.0.0", "")
+	require.NoError(t, indexer.RemoveFile(ctx, path))
+
+	result, err = client.ExecuteQuery(ctx, "MATCH (r:Reference) RETURN count(r) AS count", nil)
+	require.NoError(t, err)
+	count, _ := result[0].AsMap()["count"].(int64)
+	assert.Zero(t, count, "References of the removed file should be deleted")
+}
+
+func TestCleanService(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	indexService := func(name, source string) *static.StaticIndexer {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644))
+		indexer := static.NewStaticIndexer(client, name, "v1.0.0", "")
+		require.NoError(t, indexer.IndexProject(ctx, dir))
+		return indexer
+	}
+
+	orders := indexService("orders", "package orders\n\ntype Order struct{ ID string }\n\nfunc Place() {}\n")
+	indexService("billing", "package billing\n\nfunc Charge() {}\n")
+
+	countNodes := func() int {
+		result, err := client.ExecuteQuery(ctx, "MATCH (n) RETURN count(n) AS count", nil)
+		require.NoError(t, err)
+		count, _ := result[0].AsMap()["count"].(int64)
+		return int(count)
+	}
+	before := countNodes()
+
+	// Dry run reports counts without deleting anything
+	planned, err := orders.CleanService(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, planned["Service"])
+	assert.Equal(t, 1, planned["File"])
+	assert.Equal(t, 1, planned["Class"])
+	assert.Equal(t, 1, planned["Function"])
+	assert.Positive(t, planned["Symbol"])
+	assert.Equal(t, before, countNodes())
+
+	deleted, err := orders.CleanService(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, planned, deleted)
+
+	total := 0
+	for _, count := range deleted {
+		total += count
+	}
+	assert.Equal(t, before-total, countNodes())
+
+	// The other service is untouched
+	result, err := client.ExecuteQuery(ctx, "MATCH (f:Function) RETURN collect(f.name) AS names", nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []any{"Charge"}, result[0].AsMap()["names"])
+
+	remaining, err := orders.CleanService(ctx, true)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestCleanServiceSharingModulePath(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Both services check out the same module, so they share its Module node
+	indexService := func(name, source string) (*static.StaticIndexer, string) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/shared\n\ngo 1.21\n"), 0644))
+		path := filepath.Join(dir, "shared.go")
+		require.NoError(t, os.WriteFile(path, []byte(source), 0644))
+		indexer := static.NewStaticIndexer(client, name, "v1.0.0", "")
+		require.NoError(t, indexer.IndexProject(ctx, dir))
+		return indexer, path
+	}
+	alpha, alphaPath := indexService("alpha", "package shared\n\nfunc Helper() {}\n\nfunc OnlyAlpha() {}\n")
+	beta, _ := indexService("beta", "package shared\n\nfunc Helper() {}\n\ntype OnlyBeta struct{}\n")
+
+	result, err := client.ExecuteQuery(ctx, "MATCH (m:Module {fqn: 'example.com/shared'}) RETURN count(m) AS count", nil)
+	require.NoError(t, err)
+	count, _ := result[0].Get("count")
+	require.Equal(t, int64(1), count, "The services should share the module")
+
+	// An orphaned symbol of another service is not swept by alpha
+	_, err = client.CreateNode(ctx, []string{"Symbol"}, map[string]any{"symbol": "other-service orphan", "displayName": "Orphan"})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(alphaPath, []byte("package shared\n\nfunc Helper() {}\n"), 0644))
+	require.NoError(t, alpha.ReindexFile(ctx, alphaPath))
+
+	names := func(query string) []any {
+		t.Helper()
+		result, err := client.ExecuteQuery(ctx, query, nil)
+		require.NoError(t, err)
+		return result[0].AsMap()["names"].([]any)
+	}
+	symbolNames := "MATCH (s:Symbol) RETURN collect(s.displayName) AS names"
+	assert.ElementsMatch(t, []any{"Helper", "OnlyBeta", "Orphan"}, names(symbolNames))
+
+	deleted, err := alpha.CleanService(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted["Function"])
+	assert.Zero(t, deleted["Module"], "The module still holds beta's file")
+
+	assert.ElementsMatch(t, []any{"Helper"}, names("MATCH (f:Function) RETURN collect(f.name) AS names"))
+	assert.ElementsMatch(t, []any{"OnlyBeta"}, names("MATCH (c:Class) RETURN collect(c.name) AS names"))
+	assert.ElementsMatch(t, []any{"Helper", "OnlyBeta", "Orphan"}, names(symbolNames), "Symbols beta defines should survive")
+
+	// Cleaning the last service using the module removes it
+	deleted, err = beta.CleanService(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted["Module"])
+}
+
+func TestWarmCachesAvoidsModuleMerges(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	projectDir := t.TempDir()
+	filePath := filepath.Join(projectDir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte("package main\n\nfunc main() {}\n"), 0644))
+
+	require.NoError(t, static.NewStaticIndexer(client, "warm-service", "v1.0.0", "").IndexProject(ctx, projectDir))
+	require.NoError(t, os.WriteFile(filePath, []byte("package main\n\nfunc main() { helper() }\n\nfunc helper() {}\n"), 0644))
+
+	// A fresh indexer has to merge the module again
+	cold := static.NewStaticIndexer(client, "warm-service", "v1.0.0", "")
+	require.NoError(t, cold.ReindexFile(ctx, filePath))
+	assert.Equal(t, 1, cold.ModuleMerges())
+
+	// A warmed indexer reuses the module node from the graph
+	warm := static.NewStaticIndexer(client, "warm-service", "v1.0.0", "")
+	modules, symbols, err := warm.WarmCaches(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, modules)
+	assert.GreaterOrEqual(t, symbols, 2, "main and helper should be loaded")
+
+	require.NoError(t, warm.ReindexFile(ctx, filePath))
+	assert.Zero(t, warm.ModuleMerges())
+
+	result, err := client.ExecuteQuery(ctx, "MATCH (m:Module) RETURN count(m) AS count", nil)
+	require.NoError(t, err)
+	count, _ := result[0].AsMap()["count"].(int64)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanProject(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                        "module example.com/plan\n\ngo 1.21\n",
+		"main.go":                       "package main\n\nfunc main() {}\n",
+		"main_test.go":                  "package main\n",
+		"internal/store/store.go":       "package store\n",
+		"internal/store/store_test.go":  "package store\n",
+		"internal/store/windows.go":     "//go:build windows\n\npackage store\n",
+		"vendor/example.com/dep/dep.go": "package dep\n",
+		"node_modules/pkg/gen.go":       "package gen\n",
+		"README.md":                     "# plan\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	indexer := static.NewStaticIndexer(nil, "plan", "v1.0.0", "")
+	indexer.SetBuildContext("linux", "amd64", nil)
+	plan, err := indexer.PlanProject(dir)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "main.go"),
+		filepath.Join(dir, "internal/store/store.go"),
+	}, plan.Files)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "vendor"),
+		filepath.Join(dir, "node_modules"),
+	}, plan.SkippedDirs)
+	assert.Len(t, plan.TestFiles, 2)
+	assert.Equal(t, []string{filepath.Join(dir, "internal/store/windows.go")}, plan.Excluded)
+
+	collected, err := indexer.CollectGoFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, plan.Files, collected, "The plan should list exactly the files IndexProject indexes")
+}
+
+func TestPlanProjectSkipDirsAndTests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api/api.go":           "package api\n",
+		"api/api_test.go":      "package api\n",
+		"generated/models.go":  "package generated\n",
+		"vendor/dep/dep.go":    "package dep\n",
+		"internal/gen/keep.go": "package gen\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	indexer := static.NewStaticIndexer(nil, "plan", "v1.0.0", "")
+	indexer.SetSkipDirs(append(static.DefaultSkipDirs(), "generated"))
+	indexer.SetIncludeTests(true)
+	plan, err := indexer.PlanProject(dir)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "api/api.go"),
+		filepath.Join(dir, "api/api_test.go"),
+		filepath.Join(dir, "internal/gen/keep.go"),
+	}, plan.Files)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "generated"),
+		filepath.Join(dir, "vendor"),
+	}, plan.SkippedDirs, "Default skip dirs should still apply")
+	assert.Empty(t, plan.TestFiles)
+
+	// Without the overrides the defaults apply
+	plan, err = static.NewStaticIndexer(nil, "plan", "v1.0.0", "").PlanProject(dir)
+	require.NoError(t, err)
+	assert.Len(t, plan.Files, 3, "generated is not skipped by default")
+	assert.Equal(t, []string{filepath.Join(dir, "api/api_test.go")}, plan.TestFiles)
+}
@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const indexTimingsFixture = `package timings
+
+import "strings"
+
+type Greeter struct {
+	Name string
+}
+
+func (g *Greeter) Greet(prefix string) string {
+	return strings.TrimSpace(prefix + " " + g.Name)
+}
+
+func NewGreeter(name string) *Greeter {
+	return &Greeter{Name: name}
+}
+`
+
+func TestIndexProjectTimings(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/timings\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter.go"), []byte(indexTimingsFixture), 0644))
+	// More packages give concurrent workers files to share
+	for i := 0; i < 8; i++ {
+		pkgDir := filepath.Join(dir, fmt.Sprintf("greeter%d", i))
+		require.NoError(t, os.MkdirAll(pkgDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "greeter.go"), []byte(indexTimingsFixture), 0644))
+	}
+
+	// Phases are wall-clock time, so they cover the run however many workers
+	// index files; only the write times add up every worker
+	for _, concurrency := range []int{1, 4} {
+		indexer := static.NewStaticIndexer(client, "timings", "v1.0.0", "")
+		indexer.SetConcurrency(concurrency)
+		require.NoError(t, indexer.IndexProject(ctx, dir))
+
+		timings := indexer.Timings()
+		assert.Positive(t, timings.Walk)
+		assert.Positive(t, timings.Load)
+		assert.Positive(t, timings.Files)
+		assert.Positive(t, timings.Nodes)
+		assert.Positive(t, timings.Relationships)
+		assert.Positive(t, timings.Link)
+
+		// The phases cover the run except the service node and the index run
+		// record
+		sum := timings.Walk + timings.Load + timings.Files + timings.Link
+		assert.LessOrEqual(t, sum, timings.Total)
+		assert.GreaterOrEqual(t, float64(sum), 0.75*float64(timings.Total),
+			"Phases should account for most of the run with %d workers: %+v", concurrency, timings)
+	}
+}
@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const initialValueFixture = `package sample
+
+type Weekday int
+
+const (
+	Sunday Weekday = iota
+	Monday
+	Tuesday
+)
+
+const (
+	KB = 1 << (10 * (iota + 1))
+	MB
+)
+
+var a, b = pair()
+
+var Greeting string = "hello"
+
+func pair() (int, int) { return 1, 2 }
+`
+
+func TestStaticIndexerInitialValues(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "sample.go"), []byte(initialValueFixture), 0644))
+
+	indexer := static.NewStaticIndexer(client, "initial-value-service", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, projectDir))
+
+	result, err := client.ExecuteQuery(ctx,
+		"MATCH (v:Variable) RETURN v.name as name, v.type as type, v.initialValue as initialValue", nil)
+	require.NoError(t, err)
+
+	values := make(map[string]string)
+	types := make(map[string]string)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		name, _ := recordMap["name"].(string)
+		values[name], _ = recordMap["initialValue"].(string)
+		types[name], _ = recordMap["type"].(string)
+	}
+
+	// Implicit repetition in const blocks carries the iota expression and type
+	assert.Equal(t, "iota", values["Sunday"])
+	assert.Equal(t, "iota", values["Monday"])
+	assert.Equal(t, "iota", values["Tuesday"])
+	assert.Equal(t, "Weekday", types["Tuesday"])
+	assert.Equal(t, "1 << (10 * (iota + 1))", values["KB"])
+	assert.Equal(t, "1 << (10 * (iota + 1))", values["MB"])
+
+	// A single multi-value expression initializes every name
+	assert.Equal(t, "pair()", values["a"])
+	assert.Equal(t, "pair()", values["b"])
+
+	assert.Equal(t, `"hello"`, values["Greeting"])
+}
@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	driver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchResultsJSON(t *testing.T) {
+	records := []*driver.Record{
+		{
+			Keys: []string{"n", "nodeLabels"},
+			Values: []any{
+				dbtype.Node{ElementId: "4:a:1", Props: map[string]any{"name": "SaveUser", "filePath": "app.go"}},
+				[]any{"Function", "Exported"},
+			},
+		},
+		{
+			Keys: []string{"n", "nodeLabels"},
+			Values: []any{
+				dbtype.Node{ElementId: "4:a:2", Props: map[string]any{"name": "UserStore"}},
+				[]any{"Class"},
+			},
+		},
+	}
+
+	results := query.SearchResultsFromRecords(records)
+	output, err := json.Marshal(query.SearchResponse{Query: "User", Results: results, Count: len(results)})
+	require.NoError(t, err)
+	require.True(t, json.Valid(output))
+
+	var decoded struct {
+		Results []struct {
+			Name   string   `json:"name"`
+			Type   string   `json:"type"`
+			Labels []string `json:"labels"`
+			Score  float64  `json:"score"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(output, &decoded))
+	require.Len(t, decoded.Results, 2)
+	assert.Equal(t, "SaveUser", decoded.Results[0].Name)
+	assert.Equal(t, "Function", decoded.Results[0].Type)
+	assert.Equal(t, []string{"Function", "Exported"}, decoded.Results[0].Labels)
+	assert.Greater(t, decoded.Results[0].Score, decoded.Results[1].Score)
+}
+
+func TestFunctionSourceJSON(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	source := "package app\n\nfunc SaveUser() {}\n"
+	sourcePath := filepath.Join(t.TempDir(), "app.go")
+	require.NoError(t, os.WriteFile(sourcePath, []byte(source), 0644))
+
+	_, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+		"name": "SaveUser", "signature": "SaveUser()", "filePath": sourcePath,
+		"startLine": 3, "endLine": 3, "startByte": 13, "endByte": len(source) - 1,
+	})
+	require.NoError(t, err)
+
+	functionSource, err := neo4j.NewQueryBuilder(client).GetFunctionSource(ctx, "SaveUser")
+	require.NoError(t, err)
+
+	output, err := json.Marshal(functionSource)
+	require.NoError(t, err)
+	require.True(t, json.Valid(output))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(output, &decoded))
+	assert.Equal(t, "SaveUser", decoded["function"])
+	assert.Equal(t, sourcePath, decoded["filePath"])
+	assert.EqualValues(t, 3, decoded["startLine"])
+	assert.EqualValues(t, 3, decoded["endLine"])
+	assert.Equal(t, "func SaveUser() {}", decoded["source"])
+}
@@ -0,0 +1,39 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryLabelsAreValidated(t *testing.T) {
+	// Without a client any query that got past validation would panic, so a
+	// returned error shows the label never reached Cypher
+	queryBuilder := neo4j.NewQueryBuilder(nil)
+	ctx := context.Background()
+
+	malicious := []string{
+		"Function) DETACH DELETE n //",
+		"Function OR 1=1",
+		"Function`",
+		"Function:Method",
+		"Function {name: 'x'}",
+		"",
+		"1Function",
+	}
+	for _, label := range malicious {
+		_, err := queryBuilder.SearchNodes(ctx, "x", []string{"Function", label}, 10)
+		assert.ErrorIs(t, err, neo4j.ErrInvalidIdentifier, "SearchNodes label %q", label)
+
+		_, err = queryBuilder.FindNodesByLabel(ctx, label, 10)
+		assert.ErrorIs(t, err, neo4j.ErrInvalidIdentifier, "FindNodesByLabel label %q", label)
+
+		_, err = queryBuilder.FindNodeByProperty(ctx, label, "name", "x")
+		assert.ErrorIs(t, err, neo4j.ErrInvalidIdentifier, "FindNodeByProperty label %q", label)
+
+		_, err = queryBuilder.FindNodeByProperty(ctx, "Function", label, "x")
+		assert.ErrorIs(t, err, neo4j.ErrInvalidIdentifier, "FindNodeByProperty property %q", label)
+	}
+}
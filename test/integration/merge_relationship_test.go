@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/documents"
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeRelationship(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	fromID, err := client.CreateNode(ctx, []string{"Module"}, map[string]any{"name": "from"})
+	require.NoError(t, err)
+	toID, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{"name": "to"})
+	require.NoError(t, err)
+
+	first, err := client.MergeRelationship(ctx, fromID, toID, "CONTAINS", nil)
+	require.NoError(t, err)
+	second, err := client.MergeRelationship(ctx, fromID, toID, "CONTAINS", map[string]any{"weight": 2})
+	require.NoError(t, err)
+	assert.Equal(t, first, second, "Merging again should reuse the relationship")
+
+	result, err := client.ExecuteQuery(ctx,
+		"MATCH (:Module)-[r:CONTAINS]->(:Function) RETURN count(r) AS edges, max(r.weight) AS weight", nil)
+	require.NoError(t, err)
+	edges, _ := result[0].Get("edges")
+	weight, _ := result[0].Get("weight")
+	assert.Equal(t, int64(1), edges)
+	assert.Equal(t, int64(2), weight, "Properties should be set on the existing relationship")
+}
+
+func TestReindexingKeepsSingleEdges(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/twice\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "twice.go"),
+		[]byte("package twice\n\nfunc Add(a, b int) int { return a + b }\n"), 0644))
+
+	for i := 0; i < 2; i++ {
+		indexer := static.NewStaticIndexer(client, "twice", "v1.0.0", "")
+		require.NoError(t, indexer.IndexProject(ctx, dir))
+	}
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (from)-[r]->(to)
+		WHERE type(r) IN ['CONTAINS', 'DEFINES']
+		WITH from, to, type(r) AS relType, count(r) AS edges
+		WHERE edges > 1
+		RETURN relType, labels(from) AS fromLabels, labels(to) AS toLabels, edges
+	`, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result, "Indexing twice should not duplicate structural edges")
+
+	result, err = client.ExecuteQuery(ctx,
+		"MATCH (:Module)-[r:CONTAINS]->(:Function {name: 'Add'}) RETURN count(r) AS edges", nil)
+	require.NoError(t, err)
+	edges, _ := result[0].Get("edges")
+	assert.Equal(t, int64(1), edges)
+}
+
+func TestReindexingSCIPAndDocumentsKeepsSingleEdges(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	binary := fakeSCIPBinary(t)
+	projectDir := t.TempDir()
+	docPath := filepath.Join(t.TempDir(), "greeting.md")
+	require.NoError(t, os.WriteFile(docPath, []byte("# Greeting\n\nVisitors are welcomed by `Greet()`.\n"), 0644))
+
+	index := func() {
+		indexer := static.NewSCIPIndexer(client, "app", "v1.0.0", "")
+		indexer.SetSCIPBinary(binary)
+		require.NoError(t, indexer.IndexProject(ctx, projectDir))
+		require.NoError(t, documents.NewDocumentIndexer(client).IndexDocument(ctx, docPath))
+	}
+
+	countReferences := func() int64 {
+		result, err := client.ExecuteQuery(ctx, "MATCH (r:Reference) RETURN count(r) AS count", nil)
+		require.NoError(t, err)
+		count, _ := result[0].AsMap()["count"].(int64)
+		return count
+	}
+
+	index()
+	references := countReferences()
+	require.NotZero(t, references)
+
+	index()
+	assert.Equal(t, references, countReferences(), "Indexing twice should not duplicate reference nodes")
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (from)-[r]->(to)
+		WHERE type(r) IN ['REFERENCES', 'CONTAINS', 'DESCRIBES', 'MENTIONS']
+		WITH from, to, type(r) AS relType, count(r) AS edges
+		WHERE edges > 1
+		RETURN relType, labels(from) AS fromLabels, labels(to) AS toLabels, edges
+	`, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result, "Indexing twice should not duplicate reference or document edges")
+}
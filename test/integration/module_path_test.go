@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticIndexerModuleFQN(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Two packages named util in different directories of one module
+	projectDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":            "module example.com/mono\n\ngo 1.21\n",
+		"main.go":           "package main\n\nfunc main() {}\n",
+		"api/util/util.go":  "package util\n\nfunc Format() string { return \"api\" }\n",
+		"jobs/util/util.go": "package util\n\nfunc Retry() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(projectDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	indexer := static.NewStaticIndexer(client, "mono", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, projectDir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (m:Module)-[:CONTAINS]->(f:Function)
+		RETURN m.fqn AS fqn, f.name AS function
+		ORDER BY fqn
+	`, nil)
+	require.NoError(t, err)
+
+	modules := make(map[string]string)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		modules[recordMap["fqn"].(string)] = recordMap["function"].(string)
+	}
+
+	assert.Equal(t, map[string]string{
+		"example.com/mono":           "main",
+		"example.com/mono/api/util":  "Format",
+		"example.com/mono/jobs/util": "Retry",
+	}, modules, "Module FQNs should be import paths relative to go.mod")
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -27,7 +28,7 @@ func getEnv(key, defaultValue string) string {
 }
 
 // createTestClient creates a Neo4j client for testing
-func createTestClient(t *testing.T) *neo4j.Client {
+func createTestClient(t testing.TB) *neo4j.Client {
 	t.Helper()
 	
 	config := neo4j.Config{
@@ -46,7 +47,7 @@ func createTestClient(t *testing.T) *neo4j.Client {
 }
 
 // cleanupDatabase removes all test data from the database
-func cleanupDatabase(t *testing.T, client *neo4j.Client) {
+func cleanupDatabase(t testing.TB, client *neo4j.Client) {
 	t.Helper()
 	
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -79,6 +80,36 @@ func TestNeo4jConnection(t *testing.T) {
 	t.Logf("Connected to Neo4j database: %+v", info)
 }
 
+func TestQueryTimeout(t *testing.T) {
+	createTestClient(t).Close(context.Background())
+
+	client, err := neo4j.NewClient(neo4j.Config{
+		URI:          testNeo4jURI,
+		Username:     testNeo4jUser,
+		Password:     testNeo4jPass,
+		Database:     testNeo4jDB,
+		QueryTimeout: 500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close(context.Background())
+
+	// A cartesian product of 10^12 rows runs far longer than the timeout
+	start := time.Now()
+	_, err = client.ExecuteQuery(context.Background(), `
+		UNWIND range(1, 1000000) AS a
+		UNWIND range(1, 1000000) AS b
+		RETURN count(*) AS rows
+	`, nil)
+	if !errors.Is(err, neo4j.ErrQueryTimeout) {
+		t.Fatalf("Expected a query timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("Query ran for %s despite the timeout", elapsed)
+	}
+}
+
 func TestSchemaCreation(t *testing.T) {
 	client := createTestClient(t)
 	defer func() {
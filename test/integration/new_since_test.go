@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindNodesCreatedSince(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cutoff := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	seed := []struct {
+		label     string
+		name      string
+		createdAt int64
+	}{
+		{"Function", "OldFunction", cutoff - 3600},
+		{"Class", "OldType", cutoff - 1},
+		{"Function", "AtCutoff", cutoff},
+		{"Method", "NewMethod", cutoff + 60},
+		{"Interface", "NewInterface", cutoff + 3600},
+	}
+	for _, node := range seed {
+		_, err := client.CreateNode(ctx, []string{node.label}, map[string]any{
+			"name":      node.name,
+			"filePath":  "pkg/sample/sample.go",
+			"createdAt": node.createdAt,
+		})
+		require.NoError(t, err)
+	}
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	newSince := func(serviceName, version string) []string {
+		t.Helper()
+		results, err := queryBuilder.FindNodesCreatedSince(ctx, serviceName, version, cutoff, 0)
+		require.NoError(t, err)
+
+		var names []string
+		for _, record := range results {
+			node, ok := record.AsMap()["n"].(dbtype.Node)
+			require.True(t, ok)
+			names = append(names, node.Props["name"].(string))
+		}
+		return names
+	}
+	assert.Equal(t, []string{"AtCutoff", "NewMethod", "NewInterface"}, newSince("", ""), "Only nodes created at or after the cutoff should be returned, oldest first")
+
+	// Declarations of another service or version are left out when scoped
+	_, err := client.ExecuteQuery(ctx, `
+		CREATE (:Service {name: 'sample-service'})-[:CONTAINS]->(:File {path: 'pkg/sample/sample.go', version: 'v1'})
+		CREATE (:Function {name: 'OtherService', filePath: 'pkg/other/other.go', createdAt: $createdAt, version: 'v1'})
+		CREATE (:Function {name: 'OtherVersion', filePath: 'pkg/sample/sample.go', createdAt: $createdAt, version: 'v2'})
+		CREATE (:Function {name: 'Versioned', filePath: 'pkg/sample/sample.go', createdAt: $createdAt, version: 'v1'})
+	`, map[string]any{"createdAt": cutoff + 7200})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AtCutoff", "NewMethod", "NewInterface", "OtherVersion", "Versioned"}, newSince("sample-service", ""))
+	assert.Equal(t, []string{"OtherService", "Versioned"}, newSince("", "v1"))
+	assert.Equal(t, []string{"Versioned"}, newSince("sample-service", "v1"))
+
+	// The last index run of a service provides the cutoff for --since-last-run
+	for _, startedAt := range []int64{cutoff - 7200, cutoff} {
+		_, err := client.CreateNode(ctx, []string{"IndexRun"}, map[string]any{
+			"service":   "sample-service",
+			"indexer":   "ast",
+			"startedAt": startedAt,
+		})
+		require.NoError(t, err)
+	}
+
+	run, err := queryBuilder.GetLastIndexRun(ctx, "sample-service")
+	require.NoError(t, err)
+	assert.Equal(t, cutoff, run.StartedAt)
+
+	_, err = queryBuilder.GetLastIndexRun(ctx, "unknown-service")
+	assert.Error(t, err)
+}
+
+func TestMergeNodeKeepsCreatedAt(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	merge := map[string]any{"fqn": "sample.Config"}
+	id, err := client.MergeNode(ctx, []string{"Class"}, merge, map[string]any{"name": "Config", "createdAt": int64(100), "updatedAt": int64(100)})
+	require.NoError(t, err)
+
+	sameID, err := client.MergeNode(ctx, []string{"Class"}, merge, map[string]any{"name": "Config", "createdAt": int64(200), "updatedAt": int64(200)})
+	require.NoError(t, err)
+	require.Equal(t, id, sameID)
+
+	result, err := client.ExecuteQuery(ctx, "MATCH (c:Class {fqn: 'sample.Config'}) RETURN c.createdAt AS createdAt, c.updatedAt AS updatedAt", nil)
+	require.NoError(t, err)
+	record := result[0].AsMap()
+	assert.Equal(t, int64(100), record["createdAt"], "Re-merging must keep the original creation time")
+	assert.Equal(t, int64(200), record["updatedAt"])
+}
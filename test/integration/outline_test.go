@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFileContentsOutline(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filePath := "pkg/outline/sample.go"
+
+	// Seed declarations out of source order to verify ordering by line
+	seed := []struct {
+		label string
+		props map[string]any
+	}{
+		{"Function", map[string]any{"name": "Process", "signature": "Process(input string) error", "filePath": filePath, "startLine": 30, "endLine": 40}},
+		{"Class", map[string]any{"name": "Config", "fqn": "outline.Config", "filePath": filePath, "startLine": 5, "endLine": 9}},
+		{"Variable", map[string]any{"name": "Timeout", "filePath": filePath, "startLine": 6, "endLine": 6}},
+		{"Function", map[string]any{"name": "helper", "signature": "helper() int", "filePath": filePath, "startLine": 20, "endLine": 25}},
+		{"Function", map[string]any{"name": "Elsewhere", "signature": "Elsewhere()", "filePath": "pkg/other/other.go", "startLine": 1, "endLine": 3}},
+	}
+
+	ids := make(map[string]string)
+	for _, node := range seed {
+		id, err := client.CreateNode(ctx, []string{node.label}, node.props)
+		require.NoError(t, err)
+		ids[node.props["name"].(string)] = id
+	}
+
+	_, err := client.CreateRelationship(ctx, ids["Config"], ids["Timeout"], "CONTAINS", nil)
+	require.NoError(t, err)
+	_, err = client.CreateRelationship(ctx, ids["Process"], ids["helper"], "CALLS", nil)
+	require.NoError(t, err)
+	_, err = client.CreateRelationship(ctx, ids["Process"], ids["Elsewhere"], "CALLS", nil)
+	require.NoError(t, err)
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	outline, err := queryBuilder.GetFileContents(ctx, "", filePath)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range outline.Entries {
+		names = append(names, entry.Name)
+	}
+	assert.Equal(t, []string{"Config", "Timeout", "helper", "Process"}, names, "Outline should list declarations in source order")
+
+	// Fields nest under their struct
+	children := outline.Children(ids["Config"])
+	require.Len(t, children, 1)
+	assert.Equal(t, "Timeout", children[0].Name)
+
+	// Only relationships between declarations of this file are included
+	relTypes := make(map[string]int)
+	for _, rel := range outline.Relationships {
+		relTypes[rel.Type]++
+	}
+	assert.Equal(t, map[string]int{"CONTAINS": 1, "CALLS": 1}, relTypes)
+
+	_, err = queryBuilder.GetFileContents(ctx, "", "pkg/missing/file.go")
+	assert.Error(t, err, "Unknown files should return an error")
+}
+
+func TestGetFileContentsMatchesOneFile(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Two services each with a main.go
+	for _, service := range []string{"orders", "billing"} {
+		serviceID, err := client.CreateNode(ctx, []string{"Service"}, map[string]any{"name": service})
+		require.NoError(t, err)
+		path := "/src/" + service + "/main.go"
+		fileID, err := client.CreateNode(ctx, []string{"File"}, map[string]any{"path": path})
+		require.NoError(t, err)
+		_, err = client.CreateRelationship(ctx, serviceID, fileID, "CONTAINS", nil)
+		require.NoError(t, err)
+		_, err = client.CreateNode(ctx, []string{"Function"}, map[string]any{"name": "main", "filePath": path, "startLine": 1})
+		require.NoError(t, err)
+	}
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	_, err := queryBuilder.GetFileContents(ctx, "", "main.go")
+	require.Error(t, err, "A suffix matching several files is ambiguous")
+	assert.Contains(t, err.Error(), "/src/billing/main.go")
+	assert.Contains(t, err.Error(), "/src/orders/main.go")
+
+	for _, service := range []string{"orders", "billing"} {
+		outline, err := queryBuilder.GetFileContents(ctx, service, "main.go")
+		require.NoError(t, err)
+		assert.Equal(t, "/src/"+service+"/main.go", outline.FilePath)
+		assert.Len(t, outline.Entries, 1)
+	}
+
+	outline, err := queryBuilder.GetFileContents(ctx, "", "/src/orders/main.go")
+	require.NoError(t, err, "Exact paths are never ambiguous")
+	assert.Len(t, outline.Entries, 1)
+
+	_, err = queryBuilder.GetFileContents(ctx, "billing", "/src/orders/main.go")
+	assert.Error(t, err, "Files of other services are not matched")
+}
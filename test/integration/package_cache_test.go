@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typedFixture = `package store
+
+import "io"
+
+type Store interface {
+	Load(keys []string) (int, error)
+}
+
+type DiskStore struct{ w io.Writer }
+
+func (d *DiskStore) Load(keys []string) (int, error) { return len(keys), nil }
+
+func Open(paths map[string][]byte, opts ...string) *DiskStore { return &DiskStore{} }
+`
+
+// writeTypedFixture writes a module whose signatures need type information to render
+func writeTypedFixture(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/store\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte(typedFixture), 0644))
+	return dir
+}
+
+func TestResolveImplementationsReusesPackages(t *testing.T) {
+	dir := writeTypedFixture(t)
+	indexer := static.NewStaticIndexer(nil, "store", "v1.0.0", "")
+
+	for i := 0; i < 2; i++ {
+		implementations, err := indexer.ResolveImplementations(dir)
+		require.NoError(t, err)
+		require.Len(t, implementations, 1)
+		assert.Equal(t, "example.com/store.DiskStore", implementations[0].ClassFQN)
+	}
+	assert.Equal(t, 1, indexer.PackageLoads())
+}
+
+func TestStaticIndexerTypeInformation(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	indexer := static.NewStaticIndexer(client, "store", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, writeTypedFixture(t)))
+	assert.Equal(t, 1, indexer.PackageLoads(), "The file walk and IMPLEMENTS pass share one load")
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (c:Class {name: 'DiskStore'})-[:IMPLEMENTS]->(i:Interface {name: 'Store'})
+		RETURN count(*) AS links
+	`, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	links, _ := result[0].AsMap()["links"].(int64)
+	assert.Equal(t, int64(1), links)
+
+	result, err = client.ExecuteQuery(ctx, `
+		MATCH (f) WHERE f:Function OR f:Method
+		RETURN f.name AS name, f.returnType AS returnType
+	`, nil)
+	require.NoError(t, err)
+	returnTypes := make(map[string]string)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		name, _ := recordMap["name"].(string)
+		returnTypes[name], _ = recordMap["returnType"].(string)
+	}
+	assert.Equal(t, map[string]string{"Load": "(int, error)", "Open": "*DiskStore"}, returnTypes)
+
+	result, err = client.ExecuteQuery(ctx, `
+		MATCH (p:Parameter) RETURN p.name AS name, p.type AS type
+	`, nil)
+	require.NoError(t, err)
+	paramTypes := make(map[string]string)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		name, _ := recordMap["name"].(string)
+		paramTypes[name], _ = recordMap["type"].(string)
+	}
+	assert.Equal(t, "[]string", paramTypes["keys"])
+	assert.Equal(t, "map[string][]byte", paramTypes["paths"])
+}
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/sourcegraph/scip/bindings/go/scip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	fmtPackageSymbol = "scip-go gomod github.com/golang/go/src go1.22 fmt/"
+	greetSymbol      = "scip-go gomod example.com/app v1.0.0 app/Greet()."
+	nameSymbol       = "scip-go gomod example.com/app v1.0.0 app/name."
+)
+
+// writeReferenceRolesFixture writes a SCIP index with import, definition,
+// read, write and plain call occurrences
+func writeReferenceRolesFixture(t *testing.T, path string) {
+	index := &scip.Index{
+		Metadata: &scip.Metadata{
+			ProjectRoot: "file:///app",
+			ToolInfo:    &scip.ToolInfo{Name: "scip-go", Version: "test"},
+		},
+		Documents: []*scip.Document{{
+			RelativePath: "main.go",
+			Occurrences: []*scip.Occurrence{
+				{Symbol: fmtPackageSymbol, Range: []int32{2, 8, 2, 11}, SymbolRoles: int32(scip.SymbolRole_Import)},
+				{Symbol: greetSymbol, Range: []int32{4, 5, 4, 10}, SymbolRoles: int32(scip.SymbolRole_Definition)},
+				{Symbol: nameSymbol, Range: []int32{8, 1, 8, 5}, SymbolRoles: int32(scip.SymbolRole_WriteAccess)},
+				{Symbol: nameSymbol, Range: []int32{9, 13, 9, 17}, SymbolRoles: int32(scip.SymbolRole_ReadAccess)},
+				{Symbol: greetSymbol, Range: []int32{9, 1, 9, 6}},
+			},
+		}},
+	}
+
+	data, err := proto.Marshal(index)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+// fakeSCIPBinary writes a script standing in for scip-go, which copies the
+// reference roles fixture to --output
+func fakeSCIPBinary(t *testing.T) string {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "fixture.scip")
+	writeReferenceRolesFixture(t, fixture)
+
+	binary := filepath.Join(dir, "fake-scip-go")
+	script := fmt.Sprintf("#!/bin/sh\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"--output\" ]; then cp %q \"$2\"; fi\n  shift\ndone\n", fixture)
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+	return binary
+}
+
+func TestReferenceRoleFilter(t *testing.T) {
+	scipFile := filepath.Join(t.TempDir(), "index.scip")
+	writeReferenceRolesFixture(t, scipFile)
+
+	parser := static.NewSCIPParser()
+	require.NoError(t, parser.ParseFile(scipFile))
+	symbolDefs, err := parser.ExtractSymbols()
+	require.NoError(t, err)
+
+	referencedSymbols := func(filter *static.ReferenceRoleFilter) map[string]int {
+		counts := make(map[string]int)
+		for _, symbolDef := range symbolDefs {
+			for _, ref := range symbolDef.Refs {
+				if !ref.IsDefinition && filter.Allows(ref.Roles) {
+					counts[symbolDef.Symbol.String()]++
+				}
+			}
+		}
+		return counts
+	}
+
+	// The default keeps every occurrence
+	filter, err := static.ParseReferenceRoles("")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{fmtPackageSymbol: 1, greetSymbol: 1, nameSymbol: 2}, referencedSymbols(filter))
+
+	filter, err = static.ParseReferenceRoles("read,write,reference")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{greetSymbol: 1, nameSymbol: 2}, referencedSymbols(filter), "Imports should be excluded")
+
+	filter, err = static.ParseReferenceRoles("all,-import")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{greetSymbol: 1, nameSymbol: 2}, referencedSymbols(filter))
+
+	filter, err = static.ParseReferenceRoles("read")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{nameSymbol: 1}, referencedSymbols(filter))
+
+	_, err = static.ParseReferenceRoles("read,call")
+	assert.Error(t, err)
+	_, err = static.ParseReferenceRoles("-all")
+	assert.Error(t, err)
+}
+
+func TestSCIPIndexerExcludesImportReferences(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	binary := fakeSCIPBinary(t)
+	projectDir := t.TempDir()
+
+	filter, err := static.ParseReferenceRoles("read,write,reference")
+	require.NoError(t, err)
+
+	indexer := static.NewSCIPIndexer(client, "app", "v1.0.0", "")
+	indexer.SetSCIPBinary(binary)
+	indexer.SetReferenceRoles(filter)
+	require.NoError(t, indexer.IndexProject(ctx, projectDir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (:Reference)-[r:REFERENCES]->(s:Symbol)
+		RETURN s.symbol AS symbol, count(r) AS refs
+	`, nil)
+	require.NoError(t, err)
+
+	refs := make(map[string]int64)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		refs[recordMap["symbol"].(string)] = recordMap["refs"].(int64)
+	}
+	assert.Equal(t, map[string]int64{greetSymbol: 1, nameSymbol: 2}, refs, "Import occurrences should not create reference edges")
+}
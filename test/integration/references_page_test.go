@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindReferencesPage(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	symbol := models.NewGoSCIPSymbol("paging", "v1.0.0", "Helper().").String()
+	symbolID, err := client.CreateNode(ctx, []string{"Symbol"}, map[string]any{"symbol": symbol})
+	require.NoError(t, err)
+
+	// Five references in pkg/a, indexed at v1.0.0, and three in pkg/b at v2.0.0
+	for _, file := range []struct {
+		path    string
+		count   int
+		version string
+	}{{"pkg/a/a.go", 5, "v1.0.0"}, {"pkg/b/b.go", 3, "v2.0.0"}} {
+		fileID, err := client.CreateNode(ctx, []string{"File"}, map[string]any{"path": file.path})
+		require.NoError(t, err)
+		for line := 1; line <= file.count; line++ {
+			refID, err := client.CreateNode(ctx, []string{"Reference"},
+				map[string]any{"filePath": file.path, "startLine": line * 10, "startColumn": 2, "version": file.version})
+			require.NoError(t, err)
+			_, err = client.CreateRelationship(ctx, fileID, refID, "CONTAINS", nil)
+			require.NoError(t, err)
+			_, err = client.CreateRelationship(ctx, refID, symbolID, "REFERENCES", nil)
+			require.NoError(t, err)
+		}
+	}
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	location := func(ref *models.SymbolReference) string {
+		return fmt.Sprintf("%s:%d", ref.FilePath, ref.StartLine)
+	}
+
+	// Consecutive pages do not overlap and together cover every reference
+	seen := make(map[string]bool)
+	for offset := 0; offset < 8; offset += 3 {
+		refs, total, err := queryBuilder.FindReferencesPage(ctx, symbol, neo4j.ReferencePage{Limit: 3, Offset: offset})
+		require.NoError(t, err)
+		assert.Equal(t, 8, total)
+		assert.LessOrEqual(t, len(refs), 3)
+		for _, ref := range refs {
+			assert.False(t, seen[location(ref)], "%s returned on more than one page", location(ref))
+			seen[location(ref)] = true
+		}
+	}
+	assert.Len(t, seen, 8)
+
+	// Past the end the page is empty but the total is still reported
+	refs, total, err := queryBuilder.FindReferencesPage(ctx, symbol, neo4j.ReferencePage{Limit: 3, Offset: 9})
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+	assert.Equal(t, 8, total)
+
+	// The file filter applies before paging
+	refs, total, err = queryBuilder.FindReferencesPage(ctx, symbol, neo4j.ReferencePage{Limit: 2, Offset: 2, FilePrefix: "pkg/b/"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, refs, 1)
+	assert.True(t, strings.HasPrefix(refs[0].FilePath, "pkg/b/"))
+	assert.Equal(t, 30, refs[0].StartLine)
+
+	// Only references of the selected version are returned
+	refs, total, err = queryBuilder.WithVersion("v2.0.0").FindReferencesPage(ctx, symbol, neo4j.ReferencePage{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	for _, ref := range refs {
+		assert.True(t, strings.HasPrefix(ref.FilePath, "pkg/b/"))
+	}
+
+	// FindAllReferences still returns everything
+	all, err := queryBuilder.FindAllReferences(ctx, symbol)
+	require.NoError(t, err)
+	assert.Len(t, all, 8)
+}
+
+func TestResolveSymbolAndReferenceContext(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	source := "package app\n\nfunc Run() {\n\tSave()\n}\n"
+	sourcePath := filepath.Join(t.TempDir(), "app.go")
+	require.NoError(t, os.WriteFile(sourcePath, []byte(source), 0644))
+
+	saveSymbol := models.NewGoSCIPSymbol("app", "v1.0.0", "Save().").String()
+	storeSymbol := models.NewGoSCIPSymbol("store", "v1.0.0", "Open().").String()
+	otherSymbol := models.NewGoSCIPSymbol("db", "v1.0.0", "Open().").String()
+	_, err := client.ExecuteQuery(ctx, `
+		CREATE (save:Symbol {symbol: $save, displayName: 'Save'})
+		CREATE (:Symbol {symbol: $store, displayName: 'Open'})
+		CREATE (:Symbol {symbol: $other, displayName: 'Open'})
+		CREATE (file:File {path: $path})
+		CREATE (file)-[:CONTAINS]->(:Reference {filePath: $path, startLine: 4, startColumn: 2})-[:REFERENCES]->(save)
+	`, map[string]any{"save": saveSymbol, "store": storeSymbol, "other": otherSymbol, "path": sourcePath})
+	require.NoError(t, err)
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+
+	// A unique bare name resolves to its symbol, a full symbol to itself
+	symbols, err := queryBuilder.ResolveSymbol(ctx, "Save")
+	require.NoError(t, err)
+	assert.Equal(t, []string{saveSymbol}, symbols)
+
+	symbols, err = queryBuilder.ResolveSymbol(ctx, saveSymbol)
+	require.NoError(t, err)
+	assert.Equal(t, []string{saveSymbol}, symbols)
+
+	// An ambiguous name lists every candidate
+	symbols, err = queryBuilder.ResolveSymbol(ctx, "Open")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{storeSymbol, otherSymbol}, symbols)
+
+	symbols, err = queryBuilder.ResolveSymbol(ctx, "Missing")
+	require.NoError(t, err)
+	assert.Empty(t, symbols)
+
+	// References without an indexed context get their source line
+	refs, err := queryBuilder.FindAllReferences(ctx, saveSymbol)
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	queryBuilder.AddReferenceContext(ctx, refs)
+	assert.Equal(t, sourcePath, refs[0].FilePath)
+	assert.Equal(t, 4, refs[0].StartLine)
+	assert.Equal(t, 2, refs[0].StartColumn)
+	assert.Equal(t, "Save()", refs[0].Context)
+}
@@ -0,0 +1,158 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoutePattern(t *testing.T) {
+	pattern, err := static.ParseRoutePattern("example.com/web.(*Router).Get:get:0")
+	require.NoError(t, err)
+	assert.Equal(t, static.RoutePattern{Callee: "example.com/web.(*Router).Get", Method: "GET"}, pattern)
+
+	pattern, err = static.ParseRoutePattern("example.com/web.(*Router).Route:$0:1")
+	require.NoError(t, err)
+	assert.Equal(t, static.RoutePattern{Callee: "example.com/web.(*Router).Route", MethodArg: 0, PathArg: 1}, pattern)
+
+	pattern, err = static.ParseRoutePattern("example.com/web.Handle:*:0")
+	require.NoError(t, err)
+	assert.Equal(t, static.RoutePattern{Callee: "example.com/web.Handle", MethodArg: -1}, pattern)
+
+	for _, spec := range []string{"", "example.com/web.Handle", "example.com/web.Handle:GET:x", "example.com/web.Handle:$x:0"} {
+		_, err := static.ParseRoutePattern(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// writeRouteFixture writes a module with the given files, relative to its root
+func writeRouteFixture(t *testing.T, module string, files map[string]string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+module+"\n\ngo 1.22\n"), 0644))
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(source), 0644))
+	}
+	return dir
+}
+
+// indexedRoutes indexes a project with the default route patterns and returns
+// the routes as "METHOD path", mapped to the handler exposing them or "" when
+// no handler was linked
+func indexedRoutes(t *testing.T, client *neo4j.Client, dir string) map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	indexer := static.NewStaticIndexer(client, "routes-service", "v1.0.0", "")
+	indexer.SetRoutePatterns(static.DefaultRoutePatterns())
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (r:APIRoute {service: 'routes-service'})
+		OPTIONAL MATCH (handler)-[:EXPOSES_API]->(r)
+		RETURN r.protocol AS protocol, r.method AS method, r.path AS path, handler.name AS handler
+	`, nil)
+	require.NoError(t, err)
+
+	routes := make(map[string]string)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		assert.Equal(t, "http", recordMap["protocol"])
+		handler, _ := recordMap["handler"].(string)
+		routes[recordMap["method"].(string)+" "+recordMap["path"].(string)] = handler
+	}
+	return routes
+}
+
+func TestIndexGinRoutes(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	dir := writeRouteFixture(t, "example.com/ginapp", map[string]string{
+		"routes.go": `package ginapp
+
+import "github.com/gin-gonic/gin"
+
+func Register(r *gin.Engine, users *UserHandler) {
+	r.GET("/users", ListUsers)
+	r.POST("/users", users.Create)
+	r.Handle("DELETE", "/users/:id", DeleteUser)
+	r.GET("/ping", func(c *gin.Context) {})
+}
+`,
+		"handlers.go": `package ginapp
+
+import "github.com/gin-gonic/gin"
+
+type UserHandler struct{}
+
+func ListUsers(c *gin.Context) {}
+
+func DeleteUser(c *gin.Context) {}
+
+func (h *UserHandler) Create(c *gin.Context) {}
+`,
+	})
+
+	assert.Equal(t, map[string]string{
+		"GET /users":        "ListUsers",
+		"POST /users":       "Create",
+		"DELETE /users/:id": "DeleteUser",
+		"GET /ping":         "", // Function literals have no handler node
+	}, indexedRoutes(t, client, dir))
+}
+
+func TestIndexChiRoutes(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	dir := writeRouteFixture(t, "example.com/chiapp", map[string]string{
+		"main.go": `package main
+
+import (
+	"net/http"
+
+	"example.com/chiapp/handlers"
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	r := chi.NewRouter()
+	r.Get("/orders", handlers.ListOrders)
+	r.Method("PUT", "/orders/{id}", http.HandlerFunc(handlers.UpdateOrder))
+	r.HandleFunc("GET /health", health)
+	http.ListenAndServe(":8080", r)
+}
+
+func health(w http.ResponseWriter, r *http.Request) {}
+`,
+		"handlers/orders.go": `package handlers
+
+import "net/http"
+
+func ListOrders(w http.ResponseWriter, r *http.Request) {}
+
+func UpdateOrder(w http.ResponseWriter, r *http.Request) {}
+`,
+	})
+
+	assert.Equal(t, map[string]string{
+		"GET /orders":      "ListOrders",
+		"PUT /orders/{id}": "UpdateOrder",
+		"GET /health":      "health",
+	}, indexedRoutes(t, client, dir))
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDropSchemaWithSummary(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	schemaManager := schema.NewSchemaManager(client)
+	require.NoError(t, schemaManager.CreateSchema(ctx))
+
+	plan, err := schemaManager.PlanDrop(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(plan.Constraints), len(schema.GetConstraints()))
+	assert.NotEmpty(t, plan.Indexes)
+
+	// Proceeds with --yes and reports what was dropped
+	require.NoError(t, schema.ConfirmDrop(plan, strings.NewReader(""), &bytes.Buffer{}, false, true))
+	dropped, err := schemaManager.DropSchemaWithSummary(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(plan.Constraints), len(dropped.Constraints))
+	assert.Equal(t, len(plan.Indexes), len(dropped.Indexes))
+
+	remaining, err := schemaManager.PlanDrop(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, remaining.Total())
+}
+
+func TestGetVectorIndexes(t *testing.T) {
+	indexes := schema.GetVectorIndexes(1536)
+
+	labels := make(map[string]bool)
+	for _, index := range indexes {
+		labels[index.NodeLabel] = true
+		assert.Equal(t, "embedding", index.Property)
+		assert.Equal(t, 1536, index.Dimensions)
+		assert.Equal(t, "cosine", index.Similarity)
+	}
+	for _, label := range []string{"Function", "Method", "Class", "Interface", "Document", "DocumentChunk", "Feature"} {
+		assert.True(t, labels[label], "Expected a vector index on %s", label)
+	}
+}
+
+func TestCreateSchemaVectorIndexes(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	schemaManager := schema.NewSchemaManager(client)
+	require.NoError(t, schemaManager.DropSchema(ctx))
+	schemaManager.SetVectorDimensions(384)
+	require.NoError(t, schemaManager.CreateSchema(ctx))
+	require.NoError(t, schemaManager.ValidateSchema(ctx))
+
+	result, err := client.ExecuteQuery(ctx, "SHOW INDEXES YIELD name, type WHERE type = 'VECTOR' RETURN name", nil)
+	require.NoError(t, err)
+	assert.Len(t, result, len(schema.GetVectorIndexes(384)))
+
+	// Validation catches a dimension mismatch with the embedding model
+	schemaManager.SetVectorDimensions(768)
+	assert.ErrorContains(t, schemaManager.ValidateSchema(ctx), "dimensions")
+}
@@ -0,0 +1,86 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	migrations := schema.Migrations()
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "Versions must be consecutive starting at 1")
+		assert.NotEmpty(t, m.Description)
+		assert.NotNil(t, m.Up)
+	}
+	assert.Equal(t, migrations[len(migrations)-1].Version, schema.LatestVersion())
+}
+
+func TestSchemaMigrate(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	schemaManager := schema.NewSchemaManager(client)
+	require.NoError(t, schemaManager.DropSchema(ctx))
+
+	version, err := schemaManager.CurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, version, "A dropped schema has no version")
+
+	applied, err := schemaManager.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, schema.LatestVersion())
+	require.NoError(t, schemaManager.ValidateSchema(ctx))
+
+	// Running again is a no-op
+	applied, err = schemaManager.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	version, err = schemaManager.CurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, schema.LatestVersion(), version)
+
+	countVectorIndexes := func() int {
+		result, err := client.ExecuteQuery(ctx, "SHOW INDEXES YIELD type WHERE type = 'VECTOR' RETURN count(*) AS count", nil)
+		require.NoError(t, err)
+		count, _ := result[0].AsMap()["count"].(int64)
+		return int(count)
+	}
+	require.NotZero(t, countVectorIndexes())
+
+	// Revert to the initial schema, newest step first
+	reverted, err := schemaManager.MigrateTo(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 3)
+	assert.Equal(t, 4, reverted[0].Version)
+	assert.Equal(t, 3, reverted[1].Version)
+	assert.Equal(t, 2, reverted[2].Version)
+	assert.Zero(t, countVectorIndexes())
+
+	version, err = schemaManager.CurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	// Only the reverted steps are applied again
+	applied, err = schemaManager.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 3)
+	assert.NotZero(t, countVectorIndexes())
+	require.NoError(t, schemaManager.ValidateSchema(ctx))
+
+	_, err = schemaManager.MigrateTo(ctx, schema.LatestVersion()+1)
+	assert.Error(t, err)
+}
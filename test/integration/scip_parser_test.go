@@ -0,0 +1,276 @@
+package integration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/sourcegraph/scip/bindings/go/scip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+const shapesSymbolPrefix = "scip-go gomod example.com/shapes v1.0.0 shapes/"
+
+func TestSCIPParserFixture(t *testing.T) {
+	// testdata/index.scip holds a small scip-go style index of a shapes package
+	// and a command using it
+	parser := static.NewSCIPParser()
+	require.NoError(t, parser.ParseFile("testdata/index.scip"))
+	assert.Equal(t, "scip-go", parser.GetMetadata().ToolInfo.Name)
+
+	files, err := parser.ExtractDocuments()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "shapes.go", files[0].Path)
+	assert.Equal(t, "Go", files[0].Language)
+
+	symbolDefs, err := parser.ExtractSymbols()
+	require.NoError(t, err)
+	bySymbol := make(map[string]*models.SymbolDefinition)
+	for _, def := range symbolDefs {
+		bySymbol[def.Symbol.String()] = def
+	}
+	assert.Len(t, bySymbol, 6, "Local symbols are skipped")
+
+	// Kinds, documentation and signatures come from SymbolInformation
+	newCircle := bySymbol[shapesSymbolPrefix+"NewCircle()."]
+	require.NotNil(t, newCircle)
+	assert.Equal(t, models.FunctionSymbol, newCircle.Info.Kind)
+	assert.Equal(t, "NewCircle", newCircle.Info.DisplayName)
+	assert.Equal(t, "NewCircle creates a circle.", newCircle.Info.Documentation)
+	assert.Equal(t, "func NewCircle(r float64) Circle", newCircle.Info.Signature)
+	assert.Equal(t, "Circle", newCircle.Info.ReturnType)
+
+	// The location is the definition's, with 1-based lines; three-element
+	// ranges end on the line they start
+	assert.Equal(t, "shapes.go", newCircle.Info.FilePath)
+	assert.Equal(t, 12, newCircle.Info.StartLine)
+	assert.Equal(t, 12, newCircle.Info.EndLine)
+	assert.Equal(t, 5, newCircle.Info.StartColumn)
+	assert.Equal(t, 14, newCircle.Info.EndColumn)
+
+	// Occurrence roles separate the definition from references
+	require.NotNil(t, newCircle.GetDefinitionReference())
+	usages := newCircle.GetUsageReferences()
+	require.Len(t, usages, 1)
+	assert.Equal(t, "cmd/main.go", usages[0].FilePath)
+	assert.Equal(t, 6, usages[0].StartLine)
+
+	circle := bySymbol[shapesSymbolPrefix+"Circle#"]
+	require.NotNil(t, circle)
+	assert.Equal(t, models.TypeSymbol, circle.Info.Kind)
+	require.Len(t, circle.Relationships, 1)
+	assert.Equal(t, shapesSymbolPrefix+"Shape#", circle.Relationships[0].Symbol)
+	assert.True(t, circle.Relationships[0].IsImplementation)
+
+	area := bySymbol[shapesSymbolPrefix+"Circle#Area()."]
+	require.NotNil(t, area)
+	assert.Equal(t, models.MethodSymbol, area.Info.Kind)
+	require.Len(t, area.Relationships, 1)
+	assert.True(t, area.Relationships[0].IsImplementation)
+	assert.True(t, area.Relationships[0].IsReference)
+	require.Len(t, area.GetUsageReferences(), 1)
+	assert.NotZero(t, area.GetUsageReferences()[0].Roles&int32(scip.SymbolRole_ReadAccess), "Read access role is kept")
+
+	// External symbols are only referenced, so they have no location
+	println := bySymbol["scip-go gomod github.com/golang/go/src go1.22 fmt/Println()."]
+	require.NotNil(t, println)
+	assert.Equal(t, "Println", println.Info.DisplayName)
+	assert.Empty(t, println.Info.FilePath)
+	assert.Len(t, println.GetUsageReferences(), 1)
+}
+
+func TestSCIPSymbolKinds(t *testing.T) {
+	symbol := func(descriptor string) string { return shapesSymbolPrefix + descriptor }
+	signature := func(text string) *scip.Document { return &scip.Document{Language: "go", Text: text} }
+
+	var occurrences []*scip.Occurrence
+	for i, descriptor := range []string{
+		"", "Circle#", "Shape#", "Circle#Area().", "Circle#scale().", "NewCircle().", "parse().",
+		"Circle#Radius.", "Pi.", "defaultShape.", "NewCircle().(r)",
+	} {
+		occurrences = append(occurrences, &scip.Occurrence{
+			Symbol:      symbol(descriptor),
+			Range:       []int32{int32(i), 0, 4},
+			SymbolRoles: int32(scip.SymbolRole_Definition),
+		})
+	}
+
+	index := &scip.Index{
+		Metadata: &scip.Metadata{ToolInfo: &scip.ToolInfo{Name: "scip-go"}},
+		Documents: []*scip.Document{{
+			RelativePath: "shapes.go",
+			Occurrences:  occurrences,
+			Symbols: []*scip.SymbolInformation{
+				{Symbol: symbol("Shape#"), Kind: scip.SymbolInformation_Interface},
+				{Symbol: symbol("Pi."), Kind: scip.SymbolInformation_Constant},
+				{Symbol: symbol("Circle#Area()."), SignatureDocumentation: signature("func (c Circle) Area() float64")},
+				{Symbol: symbol("Circle#scale()."), SignatureDocumentation: signature("func (c *Circle) scale(f float64)")},
+				{Symbol: symbol("parse()."), SignatureDocumentation: signature("func parse(s string) (c Circle, err error)")},
+				{Symbol: symbol("NewCircle()."), SignatureDocumentation: signature("func NewCircle(r float64) (*Circle, error)")},
+			},
+		}},
+	}
+	data, err := proto.Marshal(index)
+	require.NoError(t, err)
+	scipFile := filepath.Join(t.TempDir(), "index.scip")
+	require.NoError(t, os.WriteFile(scipFile, data, 0644))
+
+	parser := static.NewSCIPParser()
+	require.NoError(t, parser.ParseFile(scipFile))
+	symbolDefs, err := parser.ExtractSymbols()
+	require.NoError(t, err)
+	infos := make(map[string]*models.SymbolInfo)
+	for _, def := range symbolDefs {
+		infos[def.Symbol.String()] = def.Info
+	}
+
+	for _, tt := range []struct {
+		descriptor string
+		kind       models.SymbolKind
+		name       string
+		exported   bool
+		returnType string
+	}{
+		{"", models.PackageSymbol, "shapes", false, ""},
+		{"Circle#", models.TypeSymbol, "Circle", true, ""},
+		{"Shape#", models.InterfaceSymbol, "Shape", true, ""},
+		{"Circle#Area().", models.MethodSymbol, "Area", true, "float64"},
+		{"Circle#scale().", models.MethodSymbol, "scale", false, ""},
+		{"NewCircle().", models.FunctionSymbol, "NewCircle", true, "(*Circle, error)"},
+		{"parse().", models.FunctionSymbol, "parse", false, "(c Circle, err error)"},
+		{"Circle#Radius.", models.FieldSymbol, "Radius", true, ""},
+		{"Pi.", models.ConstantSymbol, "Pi", true, ""},
+		{"defaultShape.", models.VariableSymbol, "defaultShape", false, ""},
+		{"NewCircle().(r)", models.ParameterSymbol, "r", false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			info := infos[symbol(tt.descriptor)]
+			require.NotNil(t, info)
+			assert.Equal(t, tt.kind, info.Kind)
+			assert.Equal(t, tt.name, info.DisplayName)
+			assert.Equal(t, tt.exported, info.IsExported())
+			assert.Equal(t, tt.returnType, info.ReturnType)
+		})
+	}
+}
+
+func TestSCIPIndexLeavesNoArtifacts(t *testing.T) {
+	fixture, err := filepath.Abs("testdata/index.scip")
+	require.NoError(t, err)
+
+	// Stand in for scip-go with a script that copies the fixture to --output
+	// and records where that was
+	dir := t.TempDir()
+	outputLog := filepath.Join(dir, "output-path")
+	binary := filepath.Join(dir, "fake-scip-go")
+	script := fmt.Sprintf("#!/bin/sh\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"--output\" ]; then cp %q \"$2\"; echo \"$2\" > %q; fi\n  shift\ndone\n", fixture, outputLog)
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	projectDir := t.TempDir()
+	indexer := static.NewSCIPIndexer(nil, "shapes", "v1.0.0", "")
+	indexer.SetSCIPBinary(binary)
+	_, err = indexer.CollectDefinitions(projectDir)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(projectDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "Indexing should not write into the project")
+
+	logged, err := os.ReadFile(outputLog)
+	require.NoError(t, err)
+	outputPath := strings.TrimSpace(string(logged))
+	assert.NotEqual(t, projectDir, filepath.Dir(outputPath))
+	assert.NoFileExists(t, outputPath, "The temporary index should be removed")
+	assert.NoDirExists(t, filepath.Dir(outputPath))
+}
+
+func TestSCIPIndexerLanguageCommand(t *testing.T) {
+	fixture, err := filepath.Abs("testdata/index.scip")
+	require.NoError(t, err)
+
+	// Stand in for scip-typescript with a script that records its arguments
+	// and copies the fixture to --output
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "args")
+	binary := filepath.Join(dir, "fake-scip-typescript")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %q\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"--output\" ]; then cp %q \"$2\"; fi\n  shift\ndone\n", argsLog, fixture)
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	indexer := static.NewSCIPIndexer(nil, "web", "v2.0.0", "")
+	require.NoError(t, indexer.SetLanguage("TypeScript"))
+	indexer.SetSCIPBinary(binary)
+	_, err = indexer.CollectDefinitions(t.TempDir())
+	require.NoError(t, err)
+
+	logged, err := os.ReadFile(argsLog)
+	require.NoError(t, err)
+	args := strings.Fields(string(logged))
+	require.Len(t, args, 3)
+	assert.Equal(t, []string{"index", "--output"}, args[:2])
+	assert.Equal(t, "index.scip", filepath.Base(args[2]))
+
+	assert.Error(t, indexer.SetLanguage("cobol"))
+
+	config, err := static.LookupLanguage("python")
+	require.NoError(t, err)
+	assert.Equal(t, "scip-python", config.Binary)
+	assert.Equal(t, "Python", config.Name)
+}
+
+func TestSCIPIndexerFileStats(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	fixture, err := filepath.Abs("testdata/index.scip")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "fake-scip-go")
+	script := fmt.Sprintf("#!/bin/sh\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"--output\" ]; then cp %q \"$2\"; fi\n  shift\ndone\n", fixture)
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	// The documents of the fixture, the second without a trailing newline
+	projectDir := t.TempDir()
+	shapes := "package shapes\n\ntype Circle struct{}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "shapes.go"), []byte(shapes), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "cmd"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "cmd", "main.go"), []byte("package main\n\nfunc main() {}"), 0644))
+
+	indexer := static.NewSCIPIndexer(client, "shapes", "v1.0.0", "")
+	indexer.SetSCIPBinary(binary)
+	require.NoError(t, indexer.IndexProject(ctx, projectDir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (f:File) RETURN f.path AS path, f.hash AS hash, f.lineCount AS lineCount
+	`, nil)
+	require.NoError(t, err)
+
+	stats := make(map[string]map[string]any)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		stats[recordMap["path"].(string)] = recordMap
+	}
+	require.Len(t, stats, 2)
+
+	sum := sha256.Sum256([]byte(shapes))
+	assert.Equal(t, hex.EncodeToString(sum[:]), stats["shapes.go"]["hash"])
+	assert.EqualValues(t, 3, stats["shapes.go"]["lineCount"])
+	assert.NotEmpty(t, stats["cmd/main.go"]["hash"])
+	assert.EqualValues(t, 3, stats["cmd/main.go"]["lineCount"])
+}
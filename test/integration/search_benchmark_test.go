@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/benchmark"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSearchQueries(t *testing.T) {
+	queries, err := benchmark.LoadSearchQueries(strings.NewReader(
+		"# query\texpected\nSaveUser\tSaveUser10\n\nProcessOrder\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []benchmark.SearchQuery{
+		{Query: "SaveUser", Expected: "SaveUser10"},
+		{Query: "ProcessOrder"},
+	}, queries)
+
+	_, err = benchmark.LoadSearchQueries(strings.NewReader("# only comments\n"))
+	assert.Error(t, err)
+}
+
+func TestSearchBenchmark(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		dropSearchFixture(t, client)
+		client.Close(context.Background())
+	}()
+
+	createSearchFixture(t, client, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	queries := []benchmark.SearchQuery{
+		{Query: "SaveUser10", Expected: "SaveUser10"},
+		{Query: "ProcessOrder1", Expected: "ProcessOrder1"},
+		{Query: "DeleteAccount", Expected: "DeleteAccount"},
+		{Query: "SaveUser"},
+	}
+	results, err := benchmark.RunSearch(ctx, neo4j.NewQueryBuilder(client), queries, benchmark.SearchConfig{
+		Modes: []string{"scan", "fulltext"},
+		Types: []string{"Function"},
+		K:     5,
+		Runs:  2,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	for _, result := range results {
+		assert.Equal(t, 4, result.Queries)
+		assert.Equal(t, 3, result.Judged, "Queries without an expected result are not judged")
+		assert.Equal(t, 2, result.Hits, "%s should find the two existing functions", result.Mode)
+		assert.InDelta(t, 2.0/3.0, result.Recall, 0.001)
+		assert.LessOrEqual(t, result.P50, result.P95)
+		assert.LessOrEqual(t, result.P99, result.Max)
+		assert.Positive(t, result.Max)
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, benchmark.PrintComparison(&out, results, 5))
+	assert.Contains(t, out.String(), "RECALL@5")
+	assert.Contains(t, out.String(), "66.7% (2/3)")
+
+	_, err = benchmark.RunSearch(ctx, neo4j.NewQueryBuilder(client), queries, benchmark.SearchConfig{
+		Modes: []string{"vector"},
+		K:     5,
+	})
+	assert.Error(t, err)
+}
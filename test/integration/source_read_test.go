@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSourceFileLimits(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	limits := neo4j.SourceReadLimits{MaxFileSize: 1024, Timeout: 5 * time.Second}
+
+	small := filepath.Join(dir, "small.go")
+	require.NoError(t, os.WriteFile(small, []byte("package small\n"), 0644))
+	content, err := neo4j.ReadSourceFile(ctx, small, limits)
+	require.NoError(t, err)
+	assert.Equal(t, "package small\n", string(content))
+
+	oversized := filepath.Join(dir, "oversized.go")
+	require.NoError(t, os.WriteFile(oversized, bytes.Repeat([]byte("x"), 2048), 0644))
+	_, err = neo4j.ReadSourceFile(ctx, oversized, limits)
+	assert.ErrorIs(t, err, neo4j.ErrSourceFileTooLarge)
+
+	// Directories and devices are refused before they are opened
+	_, err = neo4j.ReadSourceFile(ctx, dir, limits)
+	assert.ErrorIs(t, err, neo4j.ErrNotRegularFile)
+	if info, statErr := os.Stat(os.DevNull); statErr == nil && !info.Mode().IsRegular() {
+		_, err = neo4j.ReadSourceFile(ctx, os.DevNull, limits)
+		assert.ErrorIs(t, err, neo4j.ErrNotRegularFile)
+	}
+
+	// Zero limits fall back to the defaults
+	_, err = neo4j.ReadSourceFile(ctx, small, neo4j.SourceReadLimits{})
+	assert.NoError(t, err)
+}
+
+func TestGetFunctionSourceCodeRejectsUnsafeFiles(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	oversized := filepath.Join(dir, "generated.go")
+	require.NoError(t, os.WriteFile(oversized, bytes.Repeat([]byte("// filler\n"), 1000), 0644))
+
+	for name, path := range map[string]string{"Generated": oversized, "Directory": dir} {
+		_, err := client.CreateNode(ctx, []string{"Function"}, map[string]any{
+			"name": name, "filePath": path, "startByte": 0, "endByte": 10, "startLine": 1, "endLine": 1,
+		})
+		require.NoError(t, err)
+	}
+
+	queryBuilder := neo4j.NewQueryBuilder(client).WithSourceReadLimits(neo4j.SourceReadLimits{MaxFileSize: 4096})
+
+	_, err := queryBuilder.GetFunctionSourceCode(ctx, "Generated")
+	assert.ErrorIs(t, err, neo4j.ErrSourceFileTooLarge)
+
+	_, err = queryBuilder.GetFunctionSourceCode(ctx, "Directory")
+	assert.ErrorIs(t, err, neo4j.ErrNotRegularFile)
+
+	// The limit is kept when the builder is scoped to a version
+	_, err = queryBuilder.WithVersion("").GetFunctionSourceCode(ctx, "Generated")
+	assert.ErrorIs(t, err, neo4j.ErrSourceFileTooLarge)
+}
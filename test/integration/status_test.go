@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaDiagnostics(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	schemaManager := schema.NewSchemaManager(client)
+	require.NoError(t, schemaManager.DropSchema(ctx))
+	require.NoError(t, schemaManager.CreateSchema(ctx))
+	_, err := client.ExecuteQuery(ctx, "CALL db.awaitIndexes(120)", nil)
+	require.NoError(t, err)
+
+	_, err = client.ExecuteQuery(ctx, `
+		CREATE (f:File {path: 'status.go'})-[:CONTAINS]->(:Function {name: 'Ready'})
+		CREATE (f)-[:CONTAINS]->(:Function {name: 'Probe'})
+	`, nil)
+	require.NoError(t, err)
+
+	diagnostics, err := schemaManager.Diagnose(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), diagnostics.Nodes["Function"])
+	assert.Equal(t, int64(1), diagnostics.Nodes["File"])
+	assert.Equal(t, int64(2), diagnostics.Relationships["CONTAINS"])
+	assert.Empty(t, diagnostics.Schema.MissingConstraints)
+	assert.Empty(t, diagnostics.Schema.MissingIndexes)
+	assert.Len(t, diagnostics.Schema.VectorIndexes, len(schema.GetVectorIndexes(schema.DefaultVectorDimensions)))
+	assert.True(t, diagnostics.Schema.Ready)
+
+	// The JSON shape is what readiness probes parse
+	data, err := json.Marshal(diagnostics)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	for _, key := range []string{"server", "nodes", "relationships", "schema"} {
+		assert.Contains(t, decoded, key)
+	}
+	schemaStatus, _ := decoded["schema"].(map[string]any)
+	for _, key := range []string{"version", "latestVersion", "missingConstraints", "missingIndexes", "vectorIndexes", "fulltextIndexes", "ready"} {
+		assert.Contains(t, schemaStatus, key)
+	}
+	assert.Equal(t, true, schemaStatus["ready"])
+
+	require.NoError(t, schemaManager.DropSchema(ctx))
+	status, err := schemaManager.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Ready)
+	assert.Len(t, status.MissingConstraints, len(schema.GetConstraints()))
+	assert.Contains(t, status.MissingIndexes, "function_embedding_idx")
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	driver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteQueryStream(t *testing.T) {
+	client := createTestClient(t)
+	defer client.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	// A million rows are folded into a running total as they arrive
+	var rows, sum int64
+	err := client.ExecuteQueryStream(ctx, "UNWIND range(1, $count) AS i RETURN i", map[string]any{"count": 1000000},
+		func(record *driver.Record) error {
+			value, _ := record.Get("i")
+			rows++
+			sum += value.(int64)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000000), rows)
+	assert.Equal(t, int64(1000000)*1000001/2, sum)
+
+	// Stopping early returns no error and leaves the rest unread
+	rows = 0
+	err = client.ExecuteQueryStream(ctx, "UNWIND range(1, 1000000) AS i RETURN i", nil, func(record *driver.Record) error {
+		rows++
+		if rows == 10 {
+			return neo4j.ErrStopStream
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), rows)
+
+	err = client.ExecuteQueryStream(ctx, "UNWIND range(1, 10) AS i RETURN i", nil, func(record *driver.Record) error {
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestSearchNodesCapsUnlimitedResults(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		dropSearchFixture(t, client)
+		client.Close(context.Background())
+	}()
+
+	createSearchFixture(t, client, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	queryBuilder := neo4j.NewQueryBuilder(client).WithMaxResults(25)
+
+	capped, err := queryBuilder.SearchNodes(ctx, "", []string{"Function"}, 0)
+	require.NoError(t, err)
+	assert.Len(t, capped, 25)
+
+	// An explicit limit is not capped
+	limited, err := queryBuilder.SearchNodes(ctx, "", []string{"Function"}, 50)
+	require.NoError(t, err)
+	assert.Len(t, limited, 50)
+
+	all, err := neo4j.NewQueryBuilder(client).SearchNodes(ctx, "", []string{"Function"}, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 100)
+}
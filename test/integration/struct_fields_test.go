@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const structFieldsFixture = `package orm
+
+import "io"
+
+type User struct {
+	Model
+	*Audit
+	io.Reader
+	ID    int    ` + "`json:\"id\" db:\"user_id\"`" + `
+	Email string ` + "`json:\"email,omitempty\"`" + `
+	Notes string
+}
+
+type Audit struct {
+	CreatedBy string
+}
+
+type Model struct {
+	Version int
+}
+`
+
+func TestStructFieldTagsAndEmbedding(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/orm\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orm.go"), []byte(structFieldsFixture), 0644))
+
+	indexer := static.NewStaticIndexer(client, "orm", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (:Class {name: 'User'})-[:CONTAINS]->(f:Variable)
+		RETURN f.name AS name, f.tag AS tag ORDER BY name
+	`, nil)
+	require.NoError(t, err)
+	tags := make(map[string]any)
+	for _, record := range result {
+		name, _ := record.Get("name")
+		tag, _ := record.Get("tag")
+		tags[name.(string)] = tag
+	}
+	assert.Equal(t, map[string]any{
+		"Email": `json:"email,omitempty"`,
+		"ID":    `json:"id" db:"user_id"`,
+		"Notes": nil,
+	}, tags, "Embedded fields are not field nodes")
+
+	result, err = client.ExecuteQuery(ctx, `
+		MATCH (:Class {name: 'User'})-[r:EMBEDS]->(t)
+		RETURN t.name AS name, r.pointer AS pointer ORDER BY name
+	`, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2, "io.Reader is not in the graph, so it is not linked")
+	name, _ := result[0].Get("name")
+	pointer, _ := result[0].Get("pointer")
+	assert.Equal(t, "Audit", name)
+	assert.Equal(t, true, pointer)
+	name, _ = result[1].Get("name")
+	pointer, _ = result[1].Get("pointer")
+	assert.Equal(t, "Model", name)
+	assert.Equal(t, false, pointer)
+}
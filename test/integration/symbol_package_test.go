@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCIPSymbolPackage(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   string
+	}{
+		{"scip-go gomod github.com/google/uuid v1.6.0 `github.com/google/uuid`/NewString().", "github.com/google/uuid"},
+		{"scip-go gomod github.com/golang/go/src go1.22 fmt/Println().", "fmt"},
+		{"scip-go gomod github.com/golang/go/src go1.22 fmt/", "fmt"},
+		{"scip-go gomod example.com/app v1.0.0 `example.com/app/store`/Store#Save().", "example.com/app/store"},
+		{"scip-java maven com.example 1.0 com/example/Foo#bar().", "com/example"},
+		{"scip-python python requests 2.31 `requests.api`/get().", "requests.api"},
+		{"scip-typescript npm lodash 4.17.21 Foo#", "lodash"},
+	}
+
+	for _, tt := range tests {
+		symbol, err := models.ParseSCIPSymbol(tt.symbol)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, symbol.Package(), tt.symbol)
+	}
+}
+
+func TestSCIPIndexerTagsExternalSymbols(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Stand in for scip-go with a script that copies the fixture to --output;
+	// it references the fmt package of the standard library
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "fixture.scip")
+	writeReferenceRolesFixture(t, fixture)
+
+	binary := filepath.Join(dir, "fake-scip-go")
+	script := fmt.Sprintf("#!/bin/sh\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"--output\" ]; then cp %q \"$2\"; fi\n  shift\ndone\n", fixture)
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	indexer := static.NewSCIPIndexer(client, "app", "v1.0.0", "")
+	indexer.SetSCIPBinary(binary)
+	require.NoError(t, indexer.IndexProject(ctx, t.TempDir()))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (s:Symbol)
+		RETURN s.symbol AS symbol, s.package AS package, s.module AS module, s.isExternal AS isExternal
+	`, nil)
+	require.NoError(t, err)
+
+	type tags struct {
+		Package, Module string
+		IsExternal      bool
+	}
+	symbols := make(map[string]tags)
+	for _, record := range result {
+		recordMap := record.AsMap()
+		symbols[recordMap["symbol"].(string)] = tags{
+			Package:    recordMap["package"].(string),
+			Module:     recordMap["module"].(string),
+			IsExternal: recordMap["isExternal"].(bool),
+		}
+	}
+
+	assert.Equal(t, tags{Package: "fmt", Module: "github.com/golang/go/src", IsExternal: true}, symbols[fmtPackageSymbol],
+		"Standard library symbols are external")
+	assert.Equal(t, tags{Package: "app", Module: "example.com/app", IsExternal: false}, symbols[greetSymbol])
+	assert.Equal(t, tags{Package: "app", Module: "example.com/app", IsExternal: false}, symbols[nameSymbol],
+		"Symbols of a package with definitions in the index are internal even when only referenced")
+}
@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectGoFilesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	external := t.TempDir()
+
+	// project/
+	//   main.go
+	//   loop -> project (cycle)
+	//   lib  -> external/lib
+	// external/lib/
+	//   lib.go
+	//   lib_test.go
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644))
+	libDir := filepath.Join(external, "lib")
+	require.NoError(t, os.MkdirAll(libDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "lib.go"), []byte("package lib\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "lib_test.go"), []byte("package lib\n"), 0644))
+
+	if err := os.Symlink(libDir, filepath.Join(root, "lib")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	require.NoError(t, os.Symlink(root, filepath.Join(root, "loop")))
+
+	indexer := static.NewStaticIndexer(nil, "symlink-service", "v1.0.0", "")
+
+	files, err := indexer.CollectGoFiles(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "main.go")}, files, "Symlinks should be ignored by default")
+
+	indexer.SetFollowSymlinks(true)
+	files, err = indexer.CollectGoFiles(root)
+	require.NoError(t, err)
+	sort.Strings(files)
+	assert.Equal(t, []string{
+		filepath.Join(root, "lib", "lib.go"),
+		filepath.Join(root, "main.go"),
+	}, files, "Each file should be collected once through its symlinked path without looping")
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureTypeStrings(t *testing.T) {
+	tests := []struct {
+		name      string
+		decl      string
+		signature string
+	}{
+		{"Ident", "func Ident(n int) string", "Ident(n int) string"},
+		{"Pointer", "func Pointer(p *Config) *Config", "Pointer(p *Config) *Config"},
+		{"Selector", "func Selector(ctx context.Context) time.Duration", "Selector(ctx context.Context) time.Duration"},
+		{"Slice", "func Slice(xs []string) []*http.Request", "Slice(xs []string) []*http.Request"},
+		{"Array", "func Array(buf [16]byte) [4]int", "Array(buf [16]byte) [4]int"},
+		{"Map", "func Map(m map[string][]int) map[string]*Config", "Map(m map[string][]int) map[string]*Config"},
+		{"Chan", "func Chan(in <-chan int, out chan<- error) chan struct{}", "Chan(in <-chan int, out chan<- error) chan struct{}"},
+		{"Func", "func Func(f func(context.Context) error) func() (int, error)", "Func(f func(context.Context) error) func() (int, error)"},
+		{"Generic", "func Generic(l List[int], m Pair[string, *Config]) List[string]", "Generic(l List[int], m Pair[string, *Config]) List[string]"},
+		{"Variadic", "func Variadic(format string, args ...any)", "Variadic(format string, args ...any)"},
+		{"Interface", "func Interface(v interface{ String() string }) any", "Interface(v interface{ String() string }) any"},
+		{"MultipleResults", "func MultipleResults() (int, error)", "MultipleResults() (int, error)"},
+		{"NamedResults", "func NamedResults() (n, m int, err error)", "NamedResults() (n int, m int, err error)"},
+		{"NamedResult", "func NamedResult() (err error)", "NamedResult() (err error)"},
+		{"GroupedParams", "func GroupedParams(a, b int, s string)", "GroupedParams(a int, b int, s string)"},
+		{"UnnamedParams", "func UnnamedParams(int, *Config) bool", "UnnamedParams(int, *Config) bool"},
+	}
+
+	// Without a go.mod the files are parsed without type information, so
+	// every type is rendered from the syntax
+	dir := t.TempDir()
+	var source strings.Builder
+	source.WriteString("package shapes\n\n")
+	for _, tt := range tests {
+		source.WriteString(tt.decl + " { panic(nil) }\n\n")
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shapes.go"), []byte(source.String()), 0644))
+
+	defs, err := static.NewStaticIndexer(nil, "shapes", "v1.0.0", "").CollectDefinitions(dir)
+	require.NoError(t, err)
+	signatures := make(map[string]string)
+	for _, def := range defs {
+		signatures[def.Name] = def.Signature
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.signature, signatures[tt.name])
+		})
+	}
+}
+
+func TestMultiReturnSignatures(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/multi\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import "strconv"
+
+func AnotherFunction(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+func AnotherFunctionNamed(s string) (n int, err error) {
+	return strconv.Atoi(s)
+}
+
+func main() {}
+`), 0644))
+
+	defs, err := static.NewStaticIndexer(nil, "multi", "v1.0.0", "").CollectDefinitions(dir)
+	require.NoError(t, err)
+	signatures := make(map[string]string)
+	for _, def := range defs {
+		signatures[def.Name] = def.Signature
+	}
+
+	assert.Equal(t, "AnotherFunction(s string) (int, error)", signatures["AnotherFunction"])
+	assert.Equal(t, "AnotherFunctionNamed(s string) (n int, err error)", signatures["AnotherFunctionNamed"])
+}
@@ -0,0 +1,159 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typeScriptFixture = `import { Request } from "./http";
+
+/** Default page size */
+export const PAGE_SIZE: number = 20;
+
+let counter = 0;
+
+/**
+ * Adds two numbers.
+ */
+export function add(a: number, b: number): number {
+  return a + b;
+}
+
+export const greet = (name: string): string => "hello " + name;
+
+export interface Repository {
+  find(id: string): Promise<User>;
+}
+
+export class UserService {
+  constructor(private repo: Repository) {}
+
+  async get(id: string): Promise<User> {
+    return this.repo.find(id);
+  }
+
+  private log(message: string) {}
+}
+
+function helper() {}
+`
+
+func TestPlanProjectLanguages(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "web/app.ts", "web/app.test.ts", "web/legacy.js", "web/node_modules/dep/index.js"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("\n"), 0644))
+	}
+
+	indexer := static.NewStaticIndexer(nil, "web", "v1.0.0", "")
+	plan, err := indexer.PlanProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "main.go")}, plan.Files, "Only Go is indexed by default")
+
+	require.NoError(t, indexer.SetLanguages([]string{"go", "typescript"}))
+	plan, err = indexer.PlanProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "web/app.ts")}, plan.Files)
+	assert.Equal(t, []string{filepath.Join(dir, "web/app.test.ts")}, plan.TestFiles)
+
+	require.NoError(t, indexer.SetLanguages([]string{"typescript", "javascript"}))
+	plan, err = indexer.PlanProject(dir)
+	require.NoError(t, err)
+	assert.Contains(t, plan.Files, filepath.Join(dir, "web/legacy.js"))
+	assert.NotContains(t, plan.Files, filepath.Join(dir, "web/node_modules/dep/index.js"))
+
+	assert.Error(t, indexer.SetLanguages([]string{"cobol"}))
+}
+
+func TestIndexTypeScriptFile(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.ts")
+	require.NoError(t, os.WriteFile(path, []byte(typeScriptFixture), 0644))
+
+	indexer := static.NewStaticIndexer(client, "web", "v1.0.0", "")
+	require.NoError(t, indexer.SetLanguages([]string{"typescript"}))
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (:Service {name: 'web'})-[:CONTAINS]->(f:File {path: $path})
+		RETURN f.language AS language
+	`, map[string]any{"path": path})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	language, _ := result[0].Get("language")
+	assert.Equal(t, "TypeScript", language)
+
+	contained := func(label string) map[string]string {
+		t.Helper()
+		result, err := client.ExecuteQuery(ctx, `
+			MATCH (:File {path: $path})-[:CONTAINS]->(n:`+label+`)
+			RETURN n.name AS name, coalesce(n.signature, n.type, '') AS detail
+		`, map[string]any{"path": path})
+		require.NoError(t, err)
+		found := make(map[string]string)
+		for _, record := range result {
+			name, _ := record.Get("name")
+			detail, _ := record.Get("detail")
+			found[name.(string)] = detail.(string)
+		}
+		return found
+	}
+
+	assert.Equal(t, map[string]string{
+		"add":    "add(a: number, b: number): number",
+		"greet":  "greet(name: string): string",
+		"helper": "helper()",
+	}, contained("Function"))
+	assert.Equal(t, map[string]string{"PAGE_SIZE": "number", "counter": ""}, contained("Variable"))
+	assert.Contains(t, contained("Class"), "UserService")
+	assert.Contains(t, contained("Interface"), "Repository")
+
+	result, err = client.ExecuteQuery(ctx, `
+		MATCH (:Class {name: 'UserService', filePath: $path})-[:CONTAINS]->(m:Method)
+		RETURN m.name AS name, m.fqn AS fqn, m.returnType AS returnType, m.isExported AS exported
+		ORDER BY name
+	`, map[string]any{"path": path})
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	name, _ := result[1].Get("name")
+	fqn, _ := result[1].Get("fqn")
+	returnType, _ := result[1].Get("returnType")
+	assert.Equal(t, "get", name)
+	assert.Equal(t, path+":UserService.get", fqn)
+	assert.Equal(t, "Promise<User>", returnType)
+	exported, _ := result[2].Get("exported")
+	assert.Equal(t, false, exported, "Private methods are not exported")
+
+	result, err = client.ExecuteQuery(ctx, `
+		MATCH (f:Function {name: 'add', filePath: $path})
+		RETURN f.docstring AS docstring, f.isExported AS exported, f.startLine AS startLine
+	`, map[string]any{"path": path})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	docstring, _ := result[0].Get("docstring")
+	exported, _ = result[0].Get("exported")
+	startLine, _ := result[0].Get("startLine")
+	assert.Equal(t, "Adds two numbers.", docstring)
+	assert.Equal(t, true, exported)
+	assert.Equal(t, int64(11), startLine)
+}
@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/query"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uncheckedErrorsFixture = `package files
+
+import (
+	"fmt"
+	"os"
+)
+
+func Checked(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	fmt.Println("removed", path)
+	return nil
+}
+
+func Unchecked(path string) {
+	os.Remove(path)
+	_, _ = os.Stat(path)
+	f, _ := os.Create(path)
+	defer f.Close()
+}
+`
+
+func TestFindUncheckedErrors(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/files\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "files.go"), []byte(uncheckedErrorsFixture), 0644))
+
+	indexer := static.NewStaticIndexer(client, "files", "v1.0.0", "")
+	require.NoError(t, indexer.IndexProject(ctx, dir))
+
+	analysis := query.NewAdvancedQueryService(client)
+	result, err := analysis.FindUncheckedErrors(ctx, query.UncheckedErrorRequest{ServiceName: "files"})
+	require.NoError(t, err)
+
+	require.Len(t, result.Functions, 1, "Only the function ignoring errors should be flagged")
+	fn := result.Functions[0]
+	assert.Equal(t, "Unchecked", fn.Name)
+	assert.Equal(t, []string{
+		"os.Remove at line 17",
+		"os.Stat at line 18",
+		"os.Create at line 19",
+	}, fn.Calls, "Deferred calls are not reported")
+	assert.Equal(t, 3, result.CallCount)
+
+	result, err = analysis.FindUncheckedErrors(ctx, query.UncheckedErrorRequest{ServiceName: "other"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Functions)
+}
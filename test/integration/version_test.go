@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/context-maximiser/code-graph/pkg/indexer/static"
+	"github.com/context-maximiser/code-graph/pkg/neo4j"
+	"github.com/context-maximiser/code-graph/pkg/schema"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionScopedSearch(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, props := range []map[string]any{
+		{"name": "ProcessOrder", "signature": "ProcessOrder() error", "filePath": "v1/order.go", "version": "v1.0.0"},
+		{"name": "ProcessOrder", "signature": "ProcessOrder(ctx context.Context) error", "filePath": "v2/order.go", "version": "v2.0.0"},
+		{"name": "ProcessOrderBatch", "signature": "ProcessOrderBatch() error", "filePath": "v2/batch.go", "version": "v2.0.0"},
+	} {
+		_, err := client.CreateNode(ctx, []string{"Function"}, props)
+		require.NoError(t, err)
+	}
+
+	versionsOf := func(qb *neo4j.QueryBuilder) []string {
+		results, err := qb.SearchNodes(ctx, "ProcessOrder", []string{"Function"}, 0)
+		require.NoError(t, err)
+
+		var versions []string
+		for _, record := range results {
+			node, ok := record.AsMap()["n"].(dbtype.Node)
+			require.True(t, ok)
+			versions = append(versions, node.Props["version"].(string))
+		}
+		return versions
+	}
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	assert.Len(t, versionsOf(queryBuilder), 3, "Unscoped search should return all versions")
+	assert.Equal(t, []string{"v1.0.0"}, versionsOf(queryBuilder.WithVersion("v1.0.0")))
+	assert.Equal(t, []string{"v2.0.0", "v2.0.0"}, versionsOf(queryBuilder.WithVersion("v2.0.0")))
+	assert.Empty(t, versionsOf(queryBuilder.WithVersion("v3.0.0")))
+
+	// Lookups by name honour the version scope too
+	_, err := queryBuilder.WithVersion("v3.0.0").GetFunctionSourceCode(ctx, "ProcessOrder")
+	assert.Error(t, err)
+}
+
+func TestIndexSameProjectAtTwoVersions(t *testing.T) {
+	client := createTestClient(t)
+	defer func() {
+		cleanupDatabase(t, client)
+		client.Close(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	require.NoError(t, schema.NewSchemaManager(client).CreateSchema(ctx))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "order.go")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/orders\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(path, []byte(`package orders
+
+type Processor interface{ Process() error }
+
+type Order struct{ ID string }
+
+func (o *Order) Process() error { return nil }
+
+func ProcessOrder() error { return nil }
+`), 0644))
+
+	v1 := static.NewStaticIndexer(client, "orders", "v1.0.0", "")
+	require.NoError(t, v1.IndexProject(ctx, dir))
+	v2 := static.NewStaticIndexer(client, "orders", "v2.0.0", "")
+	require.NoError(t, v2.IndexProject(ctx, dir))
+
+	versionsOf := func(label string) []string {
+		t.Helper()
+		result, err := client.ExecuteQuery(ctx, `
+			MATCH (n:`+label+`) WHERE n.filePath = $path OR n.path = $path OR n.fqn = 'example.com/orders'
+			RETURN n.version AS version ORDER BY version
+		`, map[string]any{"path": path})
+		require.NoError(t, err)
+		var versions []string
+		for _, record := range result {
+			version, _ := record.Get("version")
+			versions = append(versions, version.(string))
+		}
+		return versions
+	}
+	for _, label := range []string{"File", "Module", "Function", "Method", "Class", "Interface"} {
+		assert.Equal(t, []string{"v1.0.0", "v2.0.0"}, versionsOf(label), "Each version should have its own %s node", label)
+	}
+
+	queryBuilder := neo4j.NewQueryBuilder(client)
+	for _, version := range []string{"v1.0.0", "v2.0.0"} {
+		results, err := queryBuilder.WithVersion(version).SearchNodes(ctx, "ProcessOrder", []string{"Function"}, 0)
+		require.NoError(t, err)
+		assert.Len(t, results, 1, "Search at %s should find that version only", version)
+	}
+
+	result, err := client.ExecuteQuery(ctx, `
+		MATCH (c:Class {name: 'Order'})-[:IMPLEMENTS]->(i:Interface {name: 'Processor'})
+		RETURN c.version = i.version AS sameVersion
+	`, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	for _, record := range result {
+		sameVersion, _ := record.Get("sameVersion")
+		assert.Equal(t, true, sameVersion, "Versions should not be linked to each other")
+	}
+
+	// Reindexing a file of one version leaves the other alone
+	require.NoError(t, v2.ReindexFile(ctx, path))
+	assert.Equal(t, []string{"v1.0.0", "v2.0.0"}, versionsOf("Function"))
+}